@@ -0,0 +1,77 @@
+// Команда allowance разово начисляет норму монет всем юзерам за период.
+// Настройки (сумма, максимум баланса, тип периода) берутся из того же .env, что и у сервера.
+// Повторный запуск за тот же период ничего не начислит.
+//
+// Пример:
+//
+//	go run ./cmd/allowance              # текущий период
+//	go run ./cmd/allowance -period 2026-10
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/config"
+	"github.com/0x0FACED/merch-shop/internal/cache"
+	"github.com/0x0FACED/merch-shop/internal/database"
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/0x0FACED/merch-shop/internal/service"
+	"github.com/0x0FACED/merch-shop/pkg/logger"
+	"go.uber.org/zap"
+)
+
+func main() {
+	period := flag.String("period", "", "period key (2026-10 or 2026-W42), current period by default")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cfg := config.MustLoad()
+	log := logger.New(cfg.Logger)
+
+	if *period == "" {
+		p, err := service.AllowancePeriod(cfg.Allowance.Period, time.Now())
+		if err != nil {
+			log.Fatal("Failed to get allowance period", zap.Error(err))
+		}
+		*period = p
+	}
+
+	db, err := database.New(cfg.Database, log)
+	if err != nil {
+		log.Fatal("Failed to create database", zap.Error(err))
+	}
+	db.MustConnect(ctx)
+	defer db.Close()
+
+	userInfoCache, err := cache.New(cfg.Cache)
+	if err != nil {
+		log.Fatal("Failed to create cache", zap.Error(err))
+	}
+	defer userInfoCache.Close()
+
+	merchService := service.NewUserService(db, log, service.Deps{
+		Cache:    userInfoCache,
+		Transfer: cfg.Transfer,
+		Auth:     cfg.Auth,
+		Shop:     cfg.Shop,
+	})
+
+	result, err := merchService.GrantAllowance(ctx, model.GrantAllowanceParams{
+		Period:     *period,
+		Amount:     cfg.Allowance.Amount,
+		MaxBalance: cfg.Allowance.MaxBalance,
+	})
+	if err != nil {
+		log.Fatal("Failed to grant allowance", zap.Error(err))
+	}
+
+	fmt.Printf("period %s: granted %d coins to %d users\n", result.Period, result.TotalAmount, result.Users)
+}
@@ -5,9 +5,16 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/0x0FACED/merch-shop/config"
+	"github.com/0x0FACED/merch-shop/internal/cache"
+	"github.com/0x0FACED/merch-shop/internal/crypt"
 	"github.com/0x0FACED/merch-shop/internal/database"
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/0x0FACED/merch-shop/internal/oidc"
+	"github.com/0x0FACED/merch-shop/internal/outbox"
+	"github.com/0x0FACED/merch-shop/internal/scheduler"
 	"github.com/0x0FACED/merch-shop/internal/server"
 	"github.com/0x0FACED/merch-shop/internal/server/handler"
 	"github.com/0x0FACED/merch-shop/internal/service"
@@ -29,7 +36,26 @@ func main() {
 	db.MustConnect(ctx)
 	defer db.Close()
 
-	merchService := service.NewUserService(db, log)
+	userInfoCache, err := cache.New(cfg.Cache)
+	if err != nil {
+		log.Fatal("Failed to create cache", zap.Error(err))
+	}
+	defer userInfoCache.Close()
+
+	totpCipher, err := crypt.New(cfg.Auth.TOTPKey)
+	if err != nil {
+		log.Fatal("Failed to create TOTP cipher", zap.Error(err))
+	}
+
+	merchService := service.NewUserService(db, log, service.Deps{
+		Cache:    userInfoCache,
+		Mailer:   outbox.NewFile(cfg.Outbox.Path),
+		IdP:      oidc.New(cfg.OIDC),
+		Secrets:  totpCipher,
+		Transfer: cfg.Transfer,
+		Auth:     cfg.Auth,
+		Shop:     cfg.Shop,
+	})
 
 	h := handler.NewHandler(merchService, log, &cfg.Server)
 
@@ -38,6 +64,15 @@ func main() {
 		log.Fatal("Failed to create server", zap.Error(err))
 	}
 
+	if cfg.Scheduler.Enabled {
+		sched, err := scheduler.New(log, jobs(cfg, merchService)...)
+		if err != nil {
+			log.Fatal("Failed to create scheduler", zap.Error(err))
+		}
+		sched.Start(ctx)
+		defer sched.Wait()
+	}
+
 	go func() {
 		if err := server.Start(ctx); err != nil {
 			log.Error("Server stopped with error", zap.Error(err))
@@ -51,3 +86,79 @@ func main() {
 		log.Fatal("Failed to shutdown server", zap.Error(err))
 	}
 }
+
+// jobs собирает фоновые задачи в зависимости от конфига
+func jobs(cfg *config.ServiceConfig, merchService *service.MerchService) []scheduler.Job {
+	owner := scheduler.InstanceID()
+
+	jobs := []scheduler.Job{
+		{
+			Name:     "scheduled_transfers",
+			Interval: cfg.Scheduler.Interval,
+			Run: func(ctx context.Context) error {
+				_, err := merchService.RunScheduledTransfers(ctx, model.ClaimScheduledTransfersParams{
+					Owner: owner,
+					Lease: cfg.Scheduler.Lease,
+					Limit: cfg.Scheduler.BatchSize,
+				})
+				return err
+			},
+		},
+	}
+
+	if cfg.Allowance.Enabled {
+		jobs = append(jobs, scheduler.Job{
+			Name:     "allowance",
+			Interval: cfg.Allowance.CheckInterval,
+			Run: func(ctx context.Context) error {
+				period, err := service.AllowancePeriod(cfg.Allowance.Period, time.Now())
+				if err != nil {
+					return err
+				}
+				_, err = merchService.GrantAllowance(ctx, model.GrantAllowanceParams{
+					Period:     period,
+					Amount:     cfg.Allowance.Amount,
+					MaxBalance: cfg.Allowance.MaxBalance,
+				})
+				return err
+			},
+		})
+	}
+
+	if cfg.Expiry.Enabled && cfg.Transfer.CoinLifetime > 0 {
+		jobs = append(jobs, scheduler.Job{
+			Name:     "coin_expiry",
+			Interval: cfg.Expiry.CheckInterval,
+			Run: func(ctx context.Context) error {
+				_, err := merchService.ExpireCoins(ctx, model.ExpireCoinsParams{
+					Lifetime: cfg.Transfer.CoinLifetime,
+					Limit:    cfg.Expiry.BatchSize,
+				})
+				return err
+			},
+		})
+	}
+
+	if cfg.Fraud.Enabled {
+		jobs = append(jobs, scheduler.Job{
+			Name:     "fraud_detection",
+			Interval: cfg.Fraud.CheckInterval,
+			Run: func(ctx context.Context) error {
+				_, err := merchService.DetectFraud(ctx, model.FraudDetectionParams{
+					Window:               cfg.Fraud.Window,
+					CycleMaxLength:       cfg.Fraud.CycleMaxLength,
+					CycleMinAmount:       cfg.Fraud.CycleMinAmount,
+					FreshAccountAge:      cfg.Fraud.FreshAccountAge,
+					FanInMinSenders:      cfg.Fraud.FanInMinSenders,
+					VelocityWindow:       cfg.Fraud.VelocityWindow,
+					VelocityMaxTransfers: cfg.Fraud.VelocityMaxTransfers,
+					VelocityMaxAmount:    cfg.Fraud.VelocityMaxAmount,
+					AutoFreeze:           cfg.Fraud.AutoFreeze,
+				})
+				return err
+			},
+		})
+	}
+
+	return jobs
+}
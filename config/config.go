@@ -8,9 +8,19 @@ import (
 )
 
 type ServiceConfig struct {
-	Server   ServerConfig
-	Logger   LoggerConfig
-	Database DatabaseConfig
+	Server    ServerConfig
+	Logger    LoggerConfig
+	Database  DatabaseConfig
+	Cache     CacheConfig
+	Transfer  TransferConfig
+	Scheduler SchedulerConfig
+	Allowance AllowanceConfig
+	Expiry    ExpiryConfig
+	Fraud     FraudConfig
+	Auth      AuthConfig
+	Outbox    OutboxConfig
+	OIDC      OIDCConfig
+	Shop      ShopConfig
 }
 
 type ServerConfig struct {
@@ -37,6 +47,126 @@ type DatabaseConfig struct {
 	PoolTimeout       time.Duration `env:"DATABASE_POOL_TIMEOUT"`
 }
 
+// CacheConfig настройки кэша информации о юзерах.
+// Driver: none (кэш выключен), lru (in-process) или redis
+type CacheConfig struct {
+	Driver  string        `env:"CACHE_DRIVER" envDefault:"none"`
+	TTL     time.Duration `env:"CACHE_TTL" envDefault:"30m"`
+	LRUSize int           `env:"CACHE_LRU_SIZE" envDefault:"10000"`
+
+	RedisAddr     string `env:"REDIS_ADDR" envDefault:"localhost:6379"`
+	RedisPassword string `env:"REDIS_PASSWORD"`
+	RedisDB       int    `env:"REDIS_DB"`
+}
+
+// TransferConfig настройки переводов монет между юзерами
+type TransferConfig struct {
+	// Допустимые категории перевода. Категория у перевода опциональна
+	Categories []string `env:"TRANSFER_CATEGORIES" envSeparator:"," envDefault:"kudos,reimbursement,gift"`
+
+	// Время жизни запроса монет, если при создании не указано другое
+	PaymentRequestTTL time.Duration `env:"TRANSFER_PAYMENT_REQUEST_TTL" envDefault:"168h"`
+
+	// Сколько раз подряд отложенный перевод может упасть, прежде чем остановится
+	ScheduledMaxFailures int `env:"TRANSFER_SCHEDULED_MAX_FAILURES" envDefault:"3"`
+	// Через сколько повторить неудачный разовый перевод
+	ScheduledRetryInterval time.Duration `env:"TRANSFER_SCHEDULED_RETRY_INTERVAL" envDefault:"1h"`
+
+	// Срок жизни монет с момента получения. 0 - монеты не сгорают
+	CoinLifetime time.Duration `env:"TRANSFER_COIN_LIFETIME" envDefault:"8760h"`
+
+	// Лимиты исходящих переводов по умолчанию, 0 - без ограничения.
+	// Для отдельных ролей переопределяются в shop.role_transfer_limits
+	MaxSingle       int `env:"TRANSFER_MAX_SINGLE"`
+	DailyTotal      int `env:"TRANSFER_DAILY_TOTAL"`
+	WeeklyTotal     int `env:"TRANSFER_WEEKLY_TOTAL"`
+	DailyRecipients int `env:"TRANSFER_DAILY_RECIPIENTS"`
+}
+
+// AllowanceConfig настройки регулярного начисления монет всем юзерам
+type AllowanceConfig struct {
+	Enabled    bool   `env:"ALLOWANCE_ENABLED" envDefault:"false"`
+	Period     string `env:"ALLOWANCE_PERIOD" envDefault:"monthly"` // monthly или weekly
+	Amount     int    `env:"ALLOWANCE_AMOUNT" envDefault:"1000"`
+	MaxBalance int    `env:"ALLOWANCE_MAX_BALANCE"` // 0 - без ограничения
+
+	// Как часто фоновая задача проверяет, начислено ли за текущий период
+	CheckInterval time.Duration `env:"ALLOWANCE_CHECK_INTERVAL" envDefault:"1h"`
+}
+
+// ExpiryConfig настройки фоновой задачи, сжигающей монеты старше TransferConfig.CoinLifetime
+type ExpiryConfig struct {
+	Enabled       bool          `env:"COIN_EXPIRY_ENABLED" envDefault:"true"`
+	CheckInterval time.Duration `env:"COIN_EXPIRY_CHECK_INTERVAL" envDefault:"1h"`
+	BatchSize     int           `env:"COIN_EXPIRY_BATCH_SIZE" envDefault:"500"`
+}
+
+// FraudConfig настройки фоновой задачи антифрода. Нулевой порог выключает правило
+type FraudConfig struct {
+	Enabled       bool          `env:"FRAUD_ENABLED" envDefault:"true"`
+	CheckInterval time.Duration `env:"FRAUD_CHECK_INTERVAL" envDefault:"10m"`
+	Window        time.Duration `env:"FRAUD_WINDOW" envDefault:"24h"`
+	AutoFreeze    bool          `env:"FRAUD_AUTO_FREEZE" envDefault:"false"`
+
+	CycleMaxLength int `env:"FRAUD_CYCLE_MAX_LENGTH" envDefault:"4"`
+	CycleMinAmount int `env:"FRAUD_CYCLE_MIN_AMOUNT" envDefault:"50"`
+
+	FreshAccountAge time.Duration `env:"FRAUD_FRESH_ACCOUNT_AGE" envDefault:"72h"`
+	FanInMinSenders int           `env:"FRAUD_FAN_IN_MIN_SENDERS" envDefault:"5"`
+
+	VelocityWindow       time.Duration `env:"FRAUD_VELOCITY_WINDOW" envDefault:"1h"`
+	VelocityMaxTransfers int           `env:"FRAUD_VELOCITY_MAX_TRANSFERS" envDefault:"30"`
+	VelocityMaxAmount    int           `env:"FRAUD_VELOCITY_MAX_AMOUNT" envDefault:"2000"`
+}
+
+// AuthConfig настройки паролей и токенов
+type AuthConfig struct {
+	// Сколько живет одноразовый токен сброса пароля, выданный админом
+	PasswordResetTTL time.Duration `env:"AUTH_PASSWORD_RESET_TTL" envDefault:"1h"`
+	// Сколько ждем возврата юзера от IdP при входе через OIDC
+	OIDCStateTTL time.Duration `env:"AUTH_OIDC_STATE_TTL" envDefault:"10m"`
+
+	// Ключ шифрования секретов TOTP в базе, 32 байта в hex. Пусто - 2FA недоступна
+	TOTPKey string `env:"AUTH_TOTP_KEY"`
+	// Имя сервиса в приложении-аутентификаторе
+	TOTPIssuer string `env:"AUTH_TOTP_ISSUER" envDefault:"Merch Shop"`
+	// Переводы больше этой суммы юзеры с 2FA подтверждают кодом. 0 - не требуется
+	TOTPStepUpAmount int `env:"AUTH_TOTP_STEP_UP_AMOUNT" envDefault:"500"`
+}
+
+// OIDCConfig настройки входа через корпоративный SSO (OpenID Connect, authorization code + PKCE).
+// Пустой Issuer - вход через OIDC выключен
+type OIDCConfig struct {
+	Issuer       string   `env:"OIDC_ISSUER"`
+	ClientID     string   `env:"OIDC_CLIENT_ID"`
+	ClientSecret string   `env:"OIDC_CLIENT_SECRET"` // пусто для public клиента, тогда хватает PKCE
+	RedirectURL  string   `env:"OIDC_REDIRECT_URL" envDefault:"http://localhost:8080/api/auth/oidc/callback"`
+	Scopes       []string `env:"OIDC_SCOPES" envSeparator:"," envDefault:"openid,email,profile"`
+}
+
+// ShopConfig настройки покупок мерча
+type ShopConfig struct {
+	// Сколько штук одного предмета можно купить за раз (строка корзины или одна покупка)
+	MaxItemQuantity int `env:"SHOP_MAX_ITEM_QUANTITY" envDefault:"100"`
+	// Сколько разных предметов может быть в корзине
+	MaxCartLines int `env:"SHOP_MAX_CART_LINES" envDefault:"50"`
+	// Сколько после выдачи заказа можно вернуть предметы из него. 0 - без ограничения
+	ReturnWindow time.Duration `env:"SHOP_RETURN_WINDOW" envDefault:"336h"`
+}
+
+// OutboxConfig - вместо отправки почты письма дописываются в локальный файл (JSON построчно)
+type OutboxConfig struct {
+	Path string `env:"OUTBOX_PATH" envDefault:"outbox/mail.jsonl"`
+}
+
+// SchedulerConfig настройки фоновых задач внутри процесса сервера
+type SchedulerConfig struct {
+	Enabled   bool          `env:"SCHEDULER_ENABLED" envDefault:"true"`
+	Interval  time.Duration `env:"SCHEDULER_INTERVAL" envDefault:"30s"`
+	Lease     time.Duration `env:"SCHEDULER_LEASE" envDefault:"1m"`
+	BatchSize int           `env:"SCHEDULER_BATCH_SIZE" envDefault:"100"`
+}
+
 type LoggerConfig struct {
 	LogLevel string `env:"LOGGER_LEVEL" envDefault:"debug"`
 }
@@ -61,6 +191,46 @@ func MustLoad() *ServiceConfig {
 		panic(err)
 	}
 
+	if err := env.Parse(&cfg.Cache); err != nil {
+		panic(err)
+	}
+
+	if err := env.Parse(&cfg.Transfer); err != nil {
+		panic(err)
+	}
+
+	if err := env.Parse(&cfg.Scheduler); err != nil {
+		panic(err)
+	}
+
+	if err := env.Parse(&cfg.Allowance); err != nil {
+		panic(err)
+	}
+
+	if err := env.Parse(&cfg.Expiry); err != nil {
+		panic(err)
+	}
+
+	if err := env.Parse(&cfg.Fraud); err != nil {
+		panic(err)
+	}
+
+	if err := env.Parse(&cfg.Auth); err != nil {
+		panic(err)
+	}
+
+	if err := env.Parse(&cfg.Outbox); err != nil {
+		panic(err)
+	}
+
+	if err := env.Parse(&cfg.OIDC); err != nil {
+		panic(err)
+	}
+
+	if err := env.Parse(&cfg.Shop); err != nil {
+		panic(err)
+	}
+
 	return cfg
 }
 
@@ -84,5 +254,45 @@ func MustLoadTestConfig() *ServiceConfig {
 		panic(err)
 	}
 
+	if err := env.Parse(&cfg.Cache); err != nil {
+		panic(err)
+	}
+
+	if err := env.Parse(&cfg.Transfer); err != nil {
+		panic(err)
+	}
+
+	if err := env.Parse(&cfg.Scheduler); err != nil {
+		panic(err)
+	}
+
+	if err := env.Parse(&cfg.Allowance); err != nil {
+		panic(err)
+	}
+
+	if err := env.Parse(&cfg.Expiry); err != nil {
+		panic(err)
+	}
+
+	if err := env.Parse(&cfg.Fraud); err != nil {
+		panic(err)
+	}
+
+	if err := env.Parse(&cfg.Auth); err != nil {
+		panic(err)
+	}
+
+	if err := env.Parse(&cfg.Outbox); err != nil {
+		panic(err)
+	}
+
+	if err := env.Parse(&cfg.OIDC); err != nil {
+		panic(err)
+	}
+
+	if err := env.Parse(&cfg.Shop); err != nil {
+		panic(err)
+	}
+
 	return cfg
 }
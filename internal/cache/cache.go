@@ -0,0 +1,78 @@
+// Package cache содержит реализации кэша, которые использует service layer
+// для хранения часто запрашиваемых данных (например, информации о юзере).
+//
+// Доступны реализации: in-process LRU, Redis и Noop (кэш выключен).
+// Счетчики попаданий и промахов публикуются через expvar
+// и доступны на pprof сервере по /debug/vars.
+package cache
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+
+	"github.com/0x0FACED/merch-shop/config"
+)
+
+const (
+	DriverNone  = "none"
+	DriverLRU   = "lru"
+	DriverRedis = "redis"
+)
+
+var ErrUnknownDriver = errors.New("unknown cache driver")
+
+var (
+	hits   = expvar.NewInt("cache_hits")
+	misses = expvar.NewInt("cache_misses")
+)
+
+// Cache - общий интерфейс всех реализаций.
+// Get возвращает false вторым значением, если ключа нет (или он протух).
+// Peek - то же самое, но не попадает в счетчики: для служебных ключей, которые
+// читаются вместе с данными и исказили бы долю попаданий.
+// Close освобождает соединения, вызывается при остановке
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Peek(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte) error
+	Delete(ctx context.Context, keys ...string) error
+	Close() error
+}
+
+// New создает кэш в зависимости от драйвера из конфига
+func New(cfg config.CacheConfig) (Cache, error) {
+	switch cfg.Driver {
+	case DriverNone, "":
+		return Noop{}, nil
+	case DriverLRU:
+		return NewLRU(cfg.LRUSize, cfg.TTL), nil
+	case DriverRedis:
+		return NewRedis(cfg)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownDriver, cfg.Driver)
+	}
+}
+
+// Stats возвращает количество попаданий и промахов с момента старта
+func Stats() (hit, miss int64) {
+	return hits.Value(), misses.Value()
+}
+
+func record(found bool) {
+	if found {
+		hits.Add(1)
+	} else {
+		misses.Add(1)
+	}
+}
+
+// Noop ничего не хранит, используется когда кэш выключен
+type Noop struct{}
+
+func (Noop) Get(context.Context, string) ([]byte, bool, error)  { return nil, false, nil }
+func (Noop) Peek(context.Context, string) ([]byte, bool, error) { return nil, false, nil }
+func (Noop) Set(context.Context, string, []byte) error          { return nil }
+func (Noop) Delete(context.Context, ...string) error            { return nil }
+func (Noop) Close() error                                       { return nil }
@@ -0,0 +1,141 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/config"
+	"github.com/0x0FACED/merch-shop/internal/cache"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// Тест вытеснения самой старой записи при переполнении LRU
+func TestLRU_Eviction(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewLRU(2, time.Minute)
+
+	_ = c.Set(ctx, "a", []byte("1"))
+	_ = c.Set(ctx, "b", []byte("2"))
+
+	// обращаемся к a, теперь самая старая запись - b
+	_, ok, _ := c.Get(ctx, "a")
+	assert.True(t, ok)
+
+	_ = c.Set(ctx, "c", []byte("3"))
+
+	_, ok, _ = c.Get(ctx, "b")
+	assert.False(t, ok)
+
+	value, ok, _ := c.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+	assert.Equal(t, 2, c.Len())
+}
+
+// Тест протухания записи по TTL
+func TestLRU_Expiration(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewLRU(10, 10*time.Millisecond)
+
+	_ = c.Set(ctx, "a", []byte("1"))
+	time.Sleep(20 * time.Millisecond)
+
+	_, ok, _ := c.Get(ctx, "a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestLRU_Delete(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewLRU(10, time.Minute)
+
+	_ = c.Set(ctx, "a", []byte("1"))
+	_ = c.Set(ctx, "b", []byte("2"))
+	_ = c.Delete(ctx, "a", "b", "not-exists")
+
+	assert.Equal(t, 0, c.Len())
+}
+
+// Тест счетчиков попаданий и промахов
+func TestLRU_Stats(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewLRU(10, time.Minute)
+
+	hitsBefore, missesBefore := cache.Stats()
+
+	_ = c.Set(ctx, "a", []byte("1"))
+	_, _, _ = c.Get(ctx, "a")
+	_, _, _ = c.Get(ctx, "b")
+
+	hits, misses := cache.Stats()
+	assert.Equal(t, hitsBefore+1, hits)
+	assert.Equal(t, missesBefore+1, misses)
+}
+
+// Peek не попадает в счетчики ни при попадании, ни при промахе
+func TestLRU_PeekNotCounted(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewLRU(10, time.Minute)
+
+	hitsBefore, missesBefore := cache.Stats()
+
+	_ = c.Set(ctx, "a", []byte("1"))
+	value, ok, _ := c.Peek(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+	_, ok, _ = c.Peek(ctx, "b")
+	assert.False(t, ok)
+
+	hits, misses := cache.Stats()
+	assert.Equal(t, hitsBefore, hits)
+	assert.Equal(t, missesBefore, misses)
+}
+
+func TestRedis_GetSetDelete(t *testing.T) {
+	ctx := context.Background()
+	srv := miniredis.RunT(t)
+
+	c, err := cache.NewRedis(config.CacheConfig{RedisAddr: srv.Addr(), TTL: time.Minute})
+	assert.NoError(t, err)
+	defer c.Close()
+
+	_, ok, err := c.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, c.Set(ctx, "a", []byte("1")))
+
+	value, ok, err := c.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+
+	assert.NoError(t, c.Delete(ctx, "a"))
+
+	_, ok, err = c.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestRedis_Expiration(t *testing.T) {
+	ctx := context.Background()
+	srv := miniredis.RunT(t)
+
+	c, err := cache.NewRedis(config.CacheConfig{RedisAddr: srv.Addr(), TTL: time.Minute})
+	assert.NoError(t, err)
+	defer c.Close()
+
+	assert.NoError(t, c.Set(ctx, "a", []byte("1")))
+	srv.FastForward(2 * time.Minute)
+
+	_, ok, err := c.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestNew_UnknownDriver(t *testing.T) {
+	_, err := cache.New(config.CacheConfig{Driver: "memcached"})
+	assert.ErrorIs(t, err, cache.ErrUnknownDriver)
+}
@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// LRU - in-process кэш с ограничением по количеству записей и TTL.
+// При переполнении вытесняется запись, к которой дольше всего не обращались
+type LRU struct {
+	mu sync.Mutex
+
+	size  int
+	ttl   time.Duration
+	ll    *list.List
+	items map[string]*list.Element
+
+	now func() time.Time
+}
+
+type lruEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+func NewLRU(size int, ttl time.Duration) *LRU {
+	if size <= 0 {
+		size = 1
+	}
+
+	return &LRU{
+		size:  size,
+		ttl:   ttl,
+		ll:    list.New(),
+		items: make(map[string]*list.Element, size),
+		now:   time.Now,
+	}
+}
+
+func (c *LRU) Get(_ context.Context, key string) ([]byte, bool, error) {
+	value, ok := c.get(key)
+	record(ok)
+	return value, ok, nil
+}
+
+func (c *LRU) Peek(_ context.Context, key string) ([]byte, bool, error) {
+	value, ok := c.get(key)
+	return value, ok, nil
+}
+
+func (c *LRU) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := el.Value.(*lruEntry)
+	if c.ttl > 0 && c.now().After(entry.expiresAt) {
+		c.removeElement(el)
+		return nil, false
+	}
+
+	c.ll.MoveToFront(el)
+	return entry.value, true
+}
+
+func (c *LRU) Set(_ context.Context, key string, value []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := c.now().Add(c.ttl)
+
+	if el, ok := c.items[key]; ok {
+		entry := el.Value.(*lruEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.ll.MoveToFront(el)
+		return nil
+	}
+
+	el := c.ll.PushFront(&lruEntry{key: key, value: value, expiresAt: expiresAt})
+	c.items[key] = el
+
+	if c.ll.Len() > c.size {
+		c.removeElement(c.ll.Back())
+	}
+
+	return nil
+}
+
+func (c *LRU) Delete(_ context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if el, ok := c.items[key]; ok {
+			c.removeElement(el)
+		}
+	}
+
+	return nil
+}
+
+// Len возвращает текущее количество записей
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// Close ничего не делает, LRU живет в памяти процесса
+func (c *LRU) Close() error {
+	return nil
+}
+
+func (c *LRU) removeElement(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*lruEntry).key)
+}
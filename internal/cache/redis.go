@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/config"
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis - кэш поверх redis, общий для всех инстансов сервиса
+type Redis struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+func NewRedis(cfg config.CacheConfig) (*Redis, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.RedisAddr,
+		Password: cfg.RedisPassword,
+		DB:       cfg.RedisDB,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("failed to ping redis: %w", err)
+	}
+
+	return &Redis{
+		client: client,
+		ttl:    cfg.TTL,
+	}, nil
+}
+
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, ok, err := r.Peek(ctx, key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	record(ok)
+	return value, ok, nil
+}
+
+func (r *Redis) Peek(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := r.client.Get(ctx, key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	return value, true, nil
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value []byte) error {
+	return r.client.Set(ctx, key, value, r.ttl).Err()
+}
+
+func (r *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return r.client.Del(ctx, keys...).Err()
+}
+
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
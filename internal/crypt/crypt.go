@@ -0,0 +1,100 @@
+// Package crypt шифрует секреты, которые нужно хранить в базе и читать обратно
+// (например, секреты TOTP): AES-256-GCM с ключом из конфига
+package crypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrDisabled   = errors.New("encryption key is not configured")
+	ErrInvalidKey = errors.New("encryption key must be 32 bytes in hex")
+	ErrDecrypt    = errors.New("failed to decrypt")
+)
+
+// Cipher - общий интерфейс AESGCM и заглушки Disabled. associated не шифруется, но
+// проверяется при расшифровке: так шифротекст нельзя переложить в чужую строку таблицы
+type Cipher interface {
+	Encrypt(plaintext, associated string) (string, error)
+	Decrypt(ciphertext, associated string) (string, error)
+}
+
+// New создает шифр по ключу в hex. Пустой ключ - шифрование выключено, возвращается Disabled
+func New(hexKey string) (Cipher, error) {
+	if hexKey == "" {
+		return Disabled{}, nil
+	}
+
+	key, err := hex.DecodeString(hexKey)
+	if err != nil || len(key) != 32 {
+		return nil, ErrInvalidKey
+	}
+
+	return NewAESGCM(key)
+}
+
+// Disabled - ключа нет, все методы возвращают ErrDisabled
+type Disabled struct{}
+
+func (Disabled) Encrypt(string, string) (string, error) {
+	return "", ErrDisabled
+}
+
+func (Disabled) Decrypt(string, string) (string, error) {
+	return "", ErrDisabled
+}
+
+type AESGCM struct {
+	aead cipher.AEAD
+}
+
+func NewAESGCM(key []byte) (*AESGCM, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidKey, err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AESGCM{aead: aead}, nil
+}
+
+// Encrypt возвращает base64(nonce + шифротекст), nonce случайный для каждого вызова
+func (c *AESGCM) Encrypt(plaintext, associated string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), []byte(associated))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (c *AESGCM) Decrypt(ciphertext, associated string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrDecrypt, err)
+	}
+
+	if len(data) < c.aead.NonceSize() {
+		return "", fmt.Errorf("%w: ciphertext is too short", ErrDecrypt)
+	}
+
+	nonce, sealed := data[:c.aead.NonceSize()], data[c.aead.NonceSize():]
+
+	plaintext, err := c.aead.Open(nil, nonce, sealed, []byte(associated))
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrDecrypt, err)
+	}
+
+	return string(plaintext), nil
+}
@@ -0,0 +1,53 @@
+package crypt_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/0x0FACED/merch-shop/internal/crypt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKey = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+
+func TestAESGCM(t *testing.T) {
+	c, err := crypt.New(testKey)
+	require.NoError(t, err)
+
+	ciphertext, err := c.Encrypt("JBSWY3DPEHPK3PXP", "totp:1")
+	require.NoError(t, err)
+	assert.NotContains(t, ciphertext, "JBSWY3DPEHPK3PXP")
+
+	// nonce каждый раз новый
+	other, err := c.Encrypt("JBSWY3DPEHPK3PXP", "totp:1")
+	require.NoError(t, err)
+	assert.NotEqual(t, ciphertext, other)
+
+	plaintext, err := c.Decrypt(ciphertext, "totp:1")
+	require.NoError(t, err)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", plaintext)
+
+	// шифротекст привязан к associated
+	_, err = c.Decrypt(ciphertext, "totp:2")
+	assert.ErrorIs(t, err, crypt.ErrDecrypt)
+
+	// и к ключу
+	otherKey, err := crypt.New(strings.Repeat("ff", 32))
+	require.NoError(t, err)
+	_, err = otherKey.Decrypt(ciphertext, "totp:1")
+	assert.ErrorIs(t, err, crypt.ErrDecrypt)
+}
+
+func TestNew(t *testing.T) {
+	c, err := crypt.New("")
+	require.NoError(t, err)
+	_, err = c.Encrypt("secret", "")
+	assert.ErrorIs(t, err, crypt.ErrDisabled)
+
+	_, err = crypt.New("abcd")
+	assert.ErrorIs(t, err, crypt.ErrInvalidKey)
+
+	_, err = crypt.New(strings.Repeat("zz", 32))
+	assert.ErrorIs(t, err, crypt.ErrInvalidKey)
+}
@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+)
+
+// GrantAllowance начисляет норму монет всем юзерам за период одним запросом.
+// Юзеры, которым за этот период уже начисляли, пропускаются (ON CONFLICT DO NOTHING),
+// поэтому запрос можно безопасно запускать сколько угодно раз и с нескольких инстансов.
+// Удаленным юзерам и сервисным аккаунтам норма не начисляется.
+// Если задан MaxBalance, то начисляется не больше, чем нужно до него. Баланс при этом
+// читается без блокировки, так что при одновременных переводах максимум может быть
+// немного превышен - для нормы монет это допустимо
+func (p *Postgres) GrantAllowance(ctx context.Context, params model.GrantAllowanceParams) (*model.AllowanceResult, error) {
+	query := `
+		WITH granted AS (
+			INSERT INTO shop.allowance_grants (user_id, period, amount)
+			SELECT w.user_id, $1,
+				CASE WHEN $3 > 0 THEN LEAST($2, GREATEST($3 - w.balance, 0)) ELSE $2 END
+			FROM shop.wallets w
+			JOIN shop.users u ON u.id = w.user_id
+			WHERE u.status <> 'deleted' AND u.role <> $6
+			ON CONFLICT (user_id, period) DO NOTHING
+			RETURNING user_id, amount
+		),
+		credited AS (
+			UPDATE shop.wallets w
+			SET balance = w.balance + g.amount
+			FROM granted g
+			WHERE w.user_id = g.user_id AND g.amount > 0
+		),
+		lots AS (
+			INSERT INTO shop.coin_lots (user_id, remaining)
+			SELECT user_id, amount
+			FROM granted
+			WHERE amount > 0
+		),
+		logged AS (
+			INSERT INTO shop.transactions (from_user_id, to_user_id, amount, memo, kind)
+			SELECT NULL, user_id, amount, $4, $5
+			FROM granted
+			WHERE amount > 0
+		)
+		SELECT COUNT(*), COALESCE(SUM(amount), 0), COALESCE(array_agg(user_id), '{}')
+		FROM granted
+		WHERE amount > 0
+	`
+
+	memo := fmt.Sprintf("allowance %s", params.Period)
+
+	result := &model.AllowanceResult{Period: params.Period}
+
+	var userIDs []int64
+	err := p.pgx.QueryRow(ctx, query,
+		params.Period,
+		params.Amount,
+		params.MaxBalance,
+		memo,
+		model.TransactionKindAllowance,
+		model.RoleService,
+	).Scan(&result.Users, &result.TotalAmount, &userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
+	}
+
+	result.UserIDs = make([]uint, 0, len(userIDs))
+	for _, id := range userIDs {
+		result.UserIDs = append(result.UserIDs, uint(id))
+	}
+
+	return result, nil
+}
@@ -0,0 +1,193 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+const apiKeyColumns = `
+	id, user_id, name, prefix, scopes, expires_at, last_used_at, revoked_at, created_at
+`
+
+func scanAPIKey(row pgx.Row, k *model.APIKey) error {
+	return row.Scan(
+		&k.ID,
+		&k.UserID,
+		&k.Name,
+		&k.Prefix,
+		&k.Scopes,
+		&k.ExpiresAt,
+		&k.LastUsedAt,
+		&k.RevokedAt,
+		&k.CreatedAt,
+	)
+}
+
+// CreateServiceAccount создает юзера с ролью service, пустым паролем и пустым кошельком.
+// Стартовые монеты, как у обычных юзеров, сервисному аккаунту не начисляются
+func (p *Postgres) CreateServiceAccount(ctx context.Context, params model.CreateServiceAccountParams) (*model.ServiceAccount, error) {
+	query := `
+		WITH account AS (
+			INSERT INTO shop.users (username, password_hash, role)
+			VALUES ($1, '', $2)
+			ON CONFLICT (username) DO NOTHING
+			RETURNING id, username, status, created_at
+		),
+		wallet AS (
+			INSERT INTO shop.wallets (user_id, balance)
+			SELECT id, 0 FROM account
+		)
+		SELECT id, username, status, created_at FROM account
+	`
+
+	account := &model.ServiceAccount{}
+	err := p.pgx.QueryRow(ctx, query, params.Name, model.RoleService).Scan(
+		&account.ID,
+		&account.Name,
+		&account.Status,
+		&account.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUsernameTaken
+		}
+		return nil, fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
+	}
+
+	return account, nil
+}
+
+func (p *Postgres) GetServiceAccounts(ctx context.Context) ([]model.ServiceAccount, error) {
+	rows, err := p.pgx.Query(ctx, `
+		SELECT id, username, status, created_at
+		FROM shop.users
+		WHERE role = $1
+		ORDER BY id
+	`, model.RoleService)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	accounts, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.ServiceAccount, error) {
+		var a model.ServiceAccount
+		err := row.Scan(&a.ID, &a.Name, &a.Status, &a.CreatedAt)
+		return a, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrScanFailed, err)
+	}
+
+	return accounts, nil
+}
+
+// CreateAPIKey сохраняет ключ для сервисного аккаунта. Ключи выдаются только сервисным
+// аккаунтам, для остальных юзеров аккаунт считается не найденным
+func (p *Postgres) CreateAPIKey(ctx context.Context, params model.CreateAPIKeyParams) (*model.APIKey, error) {
+	query := `
+		INSERT INTO shop.api_keys (user_id, name, prefix, key_hash, scopes, created_by, expires_at)
+		SELECT u.id, $2, $3, $4, $5, $6, CASE WHEN $7::float8 > 0 THEN NOW() + make_interval(secs => $7::float8) END
+		FROM shop.users u
+		WHERE u.id = $1 AND u.role = $8 AND u.status <> 'deleted'
+		RETURNING ` + apiKeyColumns
+
+	key := &model.APIKey{}
+	err := scanAPIKey(p.pgx.QueryRow(ctx, query,
+		params.UserID,
+		params.Name,
+		params.Prefix,
+		params.KeyHash,
+		params.Scopes,
+		params.AdminID,
+		params.TTL.Seconds(),
+		model.RoleService,
+	), key)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("service account %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
+	}
+
+	return key, nil
+}
+
+// GetAPIKeys возвращает все ключи аккаунта, включая отозванные и протухшие
+func (p *Postgres) GetAPIKeys(ctx context.Context, userID uint) ([]model.APIKey, error) {
+	rows, err := p.pgx.Query(ctx, `
+		SELECT `+apiKeyColumns+`
+		FROM shop.api_keys
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	keys, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.APIKey, error) {
+		var k model.APIKey
+		err := scanAPIKey(row, &k)
+		return k, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrScanFailed, err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey отзывает ключ. Повторный отзыв ничего не меняет
+func (p *Postgres) RevokeAPIKey(ctx context.Context, id uint) (*model.APIKey, error) {
+	query := `
+		UPDATE shop.api_keys
+		SET revoked_at = COALESCE(revoked_at, NOW())
+		WHERE id = $1
+		RETURNING ` + apiKeyColumns
+
+	key := &model.APIKey{}
+	if err := scanAPIKey(p.pgx.QueryRow(ctx, query, id), key); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("api key %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
+	}
+
+	return key, nil
+}
+
+// AuthAPIKey ищет действующий ключ по хэшу. last_used_at обновляется не чаще раза в минуту,
+// чтобы частые запросы бота не превращались в запись на каждый запрос
+func (p *Postgres) AuthAPIKey(ctx context.Context, keyHash string) (*model.APIKeyAuth, error) {
+	query := `
+		WITH key AS (
+			SELECT k.id, k.user_id, k.scopes, u.status
+			FROM shop.api_keys k
+			JOIN shop.users u ON u.id = k.user_id
+			WHERE k.key_hash = $1
+				AND k.revoked_at IS NULL
+				AND (k.expires_at IS NULL OR k.expires_at > NOW())
+		),
+		touched AS (
+			UPDATE shop.api_keys k
+			SET last_used_at = NOW()
+			FROM key
+			WHERE k.id = key.id
+				AND (k.last_used_at IS NULL OR k.last_used_at < NOW() - INTERVAL '1 minute')
+		)
+		SELECT id, user_id, scopes, status FROM key
+	`
+
+	auth := &model.APIKeyAuth{}
+	err := p.pgx.QueryRow(ctx, query, keyHash).Scan(&auth.KeyID, &auth.UserID, &auth.Scopes, &auth.Status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
+	}
+
+	return auth, nil
+}
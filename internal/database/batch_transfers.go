@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+// SendCoinBatch выполняет все переводы пачки в одной транзакции: либо проходят все, либо ни один.
+// Возвращает ID получателей в порядке переводов. Ошибка конкретного перевода
+// оборачивается в *BatchTransferError с его индексом
+func (p *Postgres) SendCoinBatch(ctx context.Context, params model.SendCoinBatchParams) ([]uint, error) {
+	tx, err := p.pgx.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToBeginTx, err)
+	}
+	defer tx.Rollback(ctx)
+
+	usernames := make([]string, 0, len(params.Transfers))
+	for _, t := range params.Transfers {
+		usernames = append(usernames, t.ToUser)
+	}
+
+	getUserIDsQuery := `
+		SELECT username, id FROM shop.users WHERE username = ANY($1)
+	`
+	rows, err := tx.Query(ctx, getUserIDsQuery, usernames)
+	if err != nil {
+		return nil, fmt.Errorf("%w query %q: %w", ErrFailedToFindRecipient, getUserIDsQuery, err)
+	}
+
+	ids := make(map[string]uint, len(usernames))
+	var (
+		username string
+		id       uint
+	)
+	_, err = pgx.ForEachRow(rows, []any{&username, &id}, func() error {
+		ids[username] = id
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrScanFailed, err)
+	}
+
+	toUserIDs := make([]uint, 0, len(params.Transfers))
+	for i, t := range params.Transfers {
+		id, ok := ids[t.ToUser]
+		if !ok {
+			return nil, &BatchTransferError{Index: i, Err: fmt.Errorf("recipient %w", ErrNotFound)}
+		}
+		toUserIDs = append(toUserIDs, id)
+	}
+
+	// блокируем все кошельки сразу и по возрастанию id, иначе две пачки
+	// с пересекающимися получателями могут заблокировать друг друга
+	walletIDs := append(slices.Clone(toUserIDs), params.FromUser)
+	slices.Sort(walletIDs)
+	walletIDs = slices.Compact(walletIDs)
+
+	_, err = tx.Exec(ctx, `
+		SELECT user_id FROM shop.wallets WHERE user_id = ANY($1) ORDER BY user_id FOR UPDATE
+	`, walletIDs)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	for i, t := range params.Transfers {
+		err = transferTx(ctx, tx, transfer{
+			fromUserID: params.FromUser,
+			toUserID:   toUserIDs[i],
+			amount:     t.Amount,
+			memo:       t.Memo,
+			category:   t.Category,
+			limits:     params.Limits,
+		})
+		if err != nil {
+			return nil, &BatchTransferError{Index: i, Err: err}
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToCommitTx, err)
+	}
+
+	return toUserIDs, nil
+}
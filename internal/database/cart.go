@@ -0,0 +1,194 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+// AddCartItem добавляет вариант предмета в корзину. Если он там уже есть, количество увеличивается
+func (p *Postgres) AddCartItem(ctx context.Context, params model.CartItemParams) error {
+	return p.setCartItem(ctx, params, true)
+}
+
+// UpdateCartItem меняет количество варианта предмета, который уже лежит в корзине
+func (p *Postgres) UpdateCartItem(ctx context.Context, params model.CartItemParams) error {
+	return p.setCartItem(ctx, params, false)
+}
+
+// setCartItem сохраняет строку корзины с проверкой лимитов. add - прибавить Quantity
+// к текущему количеству, иначе заменить
+func (p *Postgres) setCartItem(ctx context.Context, params model.CartItemParams, add bool) error {
+	tx, err := p.pgx.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToBeginTx, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockCart(ctx, tx, params.UserID); err != nil {
+		return err
+	}
+
+	line, err := findVariant(ctx, tx, params.Item, params.Variant)
+	if err != nil {
+		return err
+	}
+
+	var current, lines int
+	err = tx.QueryRow(ctx, `
+		SELECT COALESCE(SUM(quantity) FILTER (WHERE variant_id = $2), 0), COUNT(*)
+		FROM shop.cart_items
+		WHERE user_id = $1
+	`, params.UserID, line.variantID).Scan(&current, &lines)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	quantity := params.Quantity
+	switch {
+	case current == 0 && !add:
+		return fmt.Errorf("cart item %w", ErrNotFound)
+	case current == 0 && params.MaxLines > 0 && lines >= params.MaxLines:
+		return fmt.Errorf("%w: max %d", ErrCartFull, params.MaxLines)
+	case add:
+		quantity += current
+	}
+
+	if params.MaxQuantity > 0 && quantity > params.MaxQuantity {
+		return fmt.Errorf("%w: max %d", ErrQuantityLimit, params.MaxQuantity)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO shop.cart_items (user_id, item_id, variant_id, quantity)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, variant_id) DO UPDATE
+		SET quantity = EXCLUDED.quantity
+	`, params.UserID, line.itemID, line.variantID, quantity)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToCommitTx, err)
+	}
+
+	return nil
+}
+
+// lockCart блокирует корзину юзера до конца транзакции. Строки корзины тут не подходят:
+// для лимита на их количество нужно блокировать и отсутствующие строки
+func lockCart(ctx context.Context, q execer, userID uint) error {
+	_, err := q.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('shop.cart_items'), $1)`, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+	return nil
+}
+
+// RemoveCartItem убирает вариант предмета из корзины, пустой variant - вариант по умолчанию
+func (p *Postgres) RemoveCartItem(ctx context.Context, userID uint, item, variant string) error {
+	query := `
+		DELETE FROM shop.cart_items c
+		USING shop.items i, shop.item_variants v
+		WHERE c.item_id = i.id AND c.variant_id = v.id AND c.user_id = $1 AND i.name = $2
+			AND (CASE WHEN $3::text = '' THEN v.is_default ELSE v.sku = $3 END)
+	`
+
+	tag, err := p.pgx.Exec(ctx, query, userID, item, variant)
+	if err != nil {
+		return fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("cart item %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+// GetCart возвращает корзину по текущим ценам
+func (p *Postgres) GetCart(ctx context.Context, userID uint) (*model.Cart, error) {
+	rows, err := p.pgx.Query(ctx, `
+		SELECT i.name, `+variantSKUColumn+`, c.quantity, i.price + v.price_delta
+		FROM shop.cart_items c
+		JOIN shop.items i ON i.id = c.item_id
+		JOIN shop.item_variants v ON v.id = c.variant_id
+		WHERE c.user_id = $1
+		ORDER BY c.added_at, i.name, v.id
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	lines, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.CartLine, error) {
+		var line model.CartLine
+		err := row.Scan(&line.Item, &line.Variant, &line.Quantity, &line.Price)
+		line.Total = line.Quantity * line.Price
+		return line, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrScanFailed, err)
+	}
+
+	cart := &model.Cart{Lines: lines}
+	for _, line := range lines {
+		cart.Total += line.Total
+	}
+
+	return cart, nil
+}
+
+// Checkout оформляет корзину одним заказом (см. placeOrder) и очищает ее
+func (p *Postgres) Checkout(ctx context.Context, params model.CheckoutParams) (*model.Order, error) {
+	tx, err := p.pgx.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToBeginTx, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockCart(ctx, tx, params.UserID); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT c.item_id, c.variant_id, i.name, `+variantSKUColumn+`, c.quantity, i.price + v.price_delta
+		FROM shop.cart_items c
+		JOIN shop.items i ON i.id = c.item_id
+		JOIN shop.item_variants v ON v.id = c.variant_id
+		WHERE c.user_id = $1
+		ORDER BY c.added_at, i.name, v.id
+	`, params.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	lines, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (orderLine, error) {
+		var line orderLine
+		err := row.Scan(&line.itemID, &line.variantID, &line.item, &line.sku, &line.quantity, &line.price)
+		return line, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrScanFailed, err)
+	}
+
+	if len(lines) == 0 {
+		return nil, ErrCartEmpty
+	}
+
+	order, err := placeOrder(ctx, tx, params.UserID, 0, lines, params.MaxQuantity)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, `DELETE FROM shop.cart_items WHERE user_id = $1`, params.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToCommitTx, err)
+	}
+
+	return order, nil
+}
@@ -0,0 +1,140 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// execer - общее у pgx.Tx и pgxpool.Pool, чтобы хелперы работали и внутри транзакции, и без нее
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+// addCoinLot добавляет юзеру новую партию монет. Вызывать после UPDATE кошелька
+func addCoinLot(ctx context.Context, q execer, userID uint, amount int) error {
+	_, err := q.Exec(ctx, `
+		INSERT INTO shop.coin_lots (user_id, remaining)
+		VALUES ($1, $2)
+	`, userID, amount)
+	if err != nil {
+		return fmt.Errorf("%w: add coin lot: %w", ErrQueryFailed, err)
+	}
+	return nil
+}
+
+// consumeCoinLots списывает amount монет с партий юзера, начиная с самых старых (FIFO).
+// Из партии берется min(остаток партии, сколько еще осталось списать), где
+// "сколько осталось" = amount минус сумма всех более старых партий.
+// Пустые партии сразу удаляются. Вызывать после UPDATE кошелька, он держит блокировку
+func consumeCoinLots(ctx context.Context, q execer, userID uint, amount int) error {
+	_, err := q.Exec(ctx, `
+		UPDATE shop.coin_lots l
+		SET remaining = l.remaining - LEAST(o.remaining, $2 - (o.running - o.remaining))
+		FROM (
+			SELECT id, remaining, SUM(remaining) OVER (ORDER BY acquired_at, id) AS running
+			FROM shop.coin_lots
+			WHERE user_id = $1 AND remaining > 0
+		) o
+		WHERE l.id = o.id AND o.running - o.remaining < $2
+	`, userID, amount)
+	if err != nil {
+		return fmt.Errorf("%w: consume coin lots: %w", ErrQueryFailed, err)
+	}
+
+	_, err = q.Exec(ctx, `DELETE FROM shop.coin_lots WHERE user_id = $1 AND remaining = 0`, userID)
+	if err != nil {
+		return fmt.Errorf("%w: delete empty coin lots: %w", ErrQueryFailed, err)
+	}
+
+	return nil
+}
+
+// ExpireCoins сжигает партии старше Lifetime у не более чем Limit юзеров.
+// Кошельки блокируются в порядке user_id, сгоревшие монеты списываются с баланса
+// и пишутся в историю как отправленные @system. Если обработаны не все, то
+// оставшиеся сгорят при следующем вызове
+func (p *Postgres) ExpireCoins(ctx context.Context, params model.ExpireCoinsParams) (*model.ExpiryResult, error) {
+	tx, err := p.pgx.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToBeginTx, err)
+	}
+	defer tx.Rollback(ctx)
+
+	lifetime := params.Lifetime.Seconds()
+
+	rows, err := tx.Query(ctx, `
+		SELECT user_id
+		FROM shop.wallets
+		WHERE user_id IN (
+			SELECT DISTINCT user_id
+			FROM shop.coin_lots
+			WHERE remaining > 0 AND acquired_at <= NOW() - make_interval(secs => $1)
+		)
+		ORDER BY user_id
+		LIMIT $2
+		FOR UPDATE
+	`, lifetime, params.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	userIDs, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrScanFailed, err)
+	}
+
+	result := &model.ExpiryResult{}
+	if len(userIDs) == 0 {
+		return result, nil
+	}
+
+	query := `
+		WITH expired AS (
+			DELETE FROM shop.coin_lots
+			WHERE user_id = ANY($1)
+				AND remaining > 0
+				AND acquired_at <= NOW() - make_interval(secs => $2)
+			RETURNING user_id, remaining
+		),
+		per_user AS (
+			SELECT user_id, SUM(remaining)::int AS amount
+			FROM expired
+			GROUP BY user_id
+		),
+		debited AS (
+			UPDATE shop.wallets w
+			SET balance = w.balance - p.amount
+			FROM per_user p
+			WHERE w.user_id = p.user_id
+		),
+		logged AS (
+			INSERT INTO shop.transactions (from_user_id, to_user_id, amount, memo, kind)
+			SELECT user_id, NULL, amount, 'coins expired', $3
+			FROM per_user
+		)
+		SELECT COUNT(*), COALESCE(SUM(amount), 0), COALESCE(array_agg(user_id), '{}')
+		FROM per_user
+	`
+
+	var expiredIDs []int64
+	err = tx.QueryRow(ctx, query, userIDs, lifetime, model.TransactionKindExpiry).
+		Scan(&result.Users, &result.TotalAmount, &expiredIDs)
+	if err != nil {
+		return nil, fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToCommitTx, err)
+	}
+
+	result.UserIDs = make([]uint, 0, len(expiredIDs))
+	for _, id := range expiredIDs {
+		result.UserIDs = append(result.UserIDs, uint(id))
+	}
+
+	return result, nil
+}
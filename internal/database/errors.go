@@ -1,6 +1,9 @@
 package database
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrInvalidLoginOrPassword = errors.New("invalid login or password")
@@ -18,3 +21,97 @@ var (
 	ErrFailedToSaveTransaction = errors.New("failed to save transaction")
 	ErrFailedToCommitTx        = errors.New("failed to commit tx")
 )
+
+var (
+	ErrPaymentRequestNotPending = errors.New("payment request is not pending")
+	ErrPaymentRequestExpired    = errors.New("payment request is expired")
+	ErrSelfPaymentRequest       = errors.New("cannot request coins from yourself")
+	ErrSelfScheduledTransfer    = errors.New("cannot schedule a transfer to yourself")
+)
+
+var (
+	ErrSingleTransferLimit  = errors.New("transfer exceeds max single transfer amount")
+	ErrDailyTransferLimit   = errors.New("daily transfer limit exceeded")
+	ErrWeeklyTransferLimit  = errors.New("weekly transfer limit exceeded")
+	ErrDailyRecipientsLimit = errors.New("daily recipients limit exceeded")
+)
+
+var (
+	ErrAccountFrozen      = errors.New("account is frozen")
+	ErrAccountSuspended   = errors.New("account is suspended")
+	ErrRecipientNotActive = errors.New("recipient account is not active")
+	ErrFraudAlertReviewed = errors.New("fraud alert is already reviewed")
+)
+
+var (
+	ErrInvalidResetToken = errors.New("invalid or expired reset token")
+	ErrInvalidAPIKey     = errors.New("invalid api key")
+	ErrUsernameTaken     = errors.New("username is already taken")
+	ErrInvalidOIDCState  = errors.New("invalid or expired oidc login state")
+
+	ErrTOTPAlreadyEnabled  = errors.New("two-factor authentication is already enabled")
+	ErrTOTPCodeUsed        = errors.New("two-factor code is already used")
+	ErrInvalidRecoveryCode = errors.New("invalid recovery code")
+)
+
+var (
+	ErrCartEmpty     = errors.New("cart is empty")
+	ErrCartFull      = errors.New("too many different items in cart")
+	ErrQuantityLimit = errors.New("item quantity exceeds limit")
+
+	ErrSoldOut        = errors.New("item is sold out")
+	ErrPurchaseLimit  = errors.New("per-user purchase limit reached")
+	ErrSaleNotStarted = errors.New("item sale has not started")
+	ErrSaleEnded      = errors.New("item sale has ended")
+
+	ErrVariantRequired = errors.New("item has variants, specify one")
+	ErrSKUTaken        = errors.New("sku is already taken")
+	ErrNegativePrice   = errors.New("variant price must not be negative")
+
+	ErrOrderStatusTransition = errors.New("order status cannot be changed")
+	ErrNotEnoughItems        = errors.New("not enough items in inventory")
+
+	ErrNotReturnable    = errors.New("not enough units to return within the return window")
+	ErrReturnNotPending = errors.New("return request is not pending")
+	ErrInvalidRefund    = errors.New("invalid refund amount")
+
+	ErrSelfGift = errors.New("cannot gift to yourself")
+)
+
+var (
+	ErrLeaseLost       = errors.New("scheduled transfer lease lost")
+	ErrAlreadyExecuted = errors.New("scheduled transfer run already executed")
+	ErrNotCancellable  = errors.New("scheduled transfer is not active")
+)
+
+// BatchTransferError - ошибка в конкретном переводе из пачки, Index - его номер в запросе
+type BatchTransferError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchTransferError) Error() string {
+	return fmt.Sprintf("transfer %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchTransferError) Unwrap() error {
+	return e.Err
+}
+
+// OrderLineError - ошибка в конкретной строке заказа, Item и Variant - предмет и SKU варианта строки
+type OrderLineError struct {
+	Item    string
+	Variant string
+	Err     error
+}
+
+func (e *OrderLineError) Error() string {
+	if e.Variant != "" {
+		return fmt.Sprintf("item %q variant %q: %v", e.Item, e.Variant, e.Err)
+	}
+	return fmt.Sprintf("item %q: %v", e.Item, e.Err)
+}
+
+func (e *OrderLineError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,336 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+// FindTransferCycles ищет кольца переводов длиной до CycleMaxLength за окно.
+// Ребро A->B - сумма всех переводов A->B за окно, слабые ребра (меньше CycleMinAmount) отбрасываются.
+// Путь строится только от юзера с минимальным id в кольце, поэтому каждое кольцо находится
+// ровно один раз, а не по разу от каждого участника
+func (p *Postgres) FindTransferCycles(ctx context.Context, params model.FraudDetectionParams) ([]model.FraudFinding, error) {
+	query := `
+		WITH RECURSIVE edges AS (
+			SELECT from_user_id AS src, to_user_id AS dst, SUM(amount)::int AS amount
+			FROM shop.transactions
+			WHERE kind = 'transfer'
+				AND from_user_id IS NOT NULL
+				AND to_user_id IS NOT NULL
+				AND from_user_id <> to_user_id
+				AND created_at > NOW() - make_interval(secs => $1)
+			GROUP BY 1, 2
+			HAVING SUM(amount) >= $3
+		),
+		paths AS (
+			SELECT src AS start, dst, ARRAY[src, dst] AS path, amount AS min_amount, 1 AS depth
+			FROM edges
+			WHERE dst > src
+			UNION ALL
+			SELECT p.start, e.dst, p.path || e.dst, LEAST(p.min_amount, e.amount), p.depth + 1
+			FROM paths p
+			JOIN edges e ON e.src = p.dst
+			WHERE p.depth < $2
+				AND p.dst <> p.start
+				AND e.dst >= p.start
+				AND NOT (e.dst = ANY(p.path[2:]))
+		)
+		SELECT path[1:array_length(path, 1) - 1], min_amount
+		FROM paths
+		WHERE dst = start
+	`
+
+	rows, err := p.pgx.Query(ctx, query, params.Window.Seconds(), params.CycleMaxLength, params.CycleMinAmount)
+	if err != nil {
+		return nil, fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
+	}
+
+	var findings []model.FraudFinding
+	var (
+		path   []uint
+		amount int
+	)
+	_, err = pgx.ForEachRow(rows, []any{&path, &amount}, func() error {
+		members := slices.Clone(path)
+		slices.Sort(members)
+
+		findings = append(findings, model.FraudFinding{
+			Rule:        model.FraudRuleCycle,
+			Fingerprint: fraudFingerprint(model.FraudRuleCycle, members...),
+			UserIDs:     members,
+			Details: map[string]any{
+				"path":      slices.Clone(path),
+				"minAmount": amount,
+			},
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrScanFailed, err)
+	}
+
+	return findings, nil
+}
+
+// FindFreshFanIn ищет получателей, которым за окно перевели монеты как минимум FanInMinSenders
+// свежих аккаунтов - типичная картина, когда фермы аккаунтов сливают стартовые монеты на основной
+func (p *Postgres) FindFreshFanIn(ctx context.Context, params model.FraudDetectionParams) ([]model.FraudFinding, error) {
+	query := `
+		SELECT t.to_user_id, array_agg(DISTINCT t.from_user_id), SUM(t.amount)::int
+		FROM shop.transactions t
+		JOIN shop.users s ON s.id = t.from_user_id
+		WHERE t.kind = 'transfer'
+			AND t.to_user_id IS NOT NULL
+			AND t.created_at > NOW() - make_interval(secs => $1)
+			AND t.created_at - s.created_at < make_interval(secs => $2)
+		GROUP BY t.to_user_id
+		HAVING COUNT(DISTINCT t.from_user_id) >= $3
+	`
+
+	rows, err := p.pgx.Query(ctx, query, params.Window.Seconds(), params.FreshAccountAge.Seconds(), params.FanInMinSenders)
+	if err != nil {
+		return nil, fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
+	}
+
+	var findings []model.FraudFinding
+	var (
+		recipient uint
+		senders   []uint
+		amount    int
+	)
+	_, err = pgx.ForEachRow(rows, []any{&recipient, &senders, &amount}, func() error {
+		findings = append(findings, model.FraudFinding{
+			Rule:        model.FraudRuleFreshFanIn,
+			Fingerprint: fraudFingerprint(model.FraudRuleFreshFanIn, recipient),
+			UserIDs:     []uint{recipient},
+			Details: map[string]any{
+				"senders": slices.Clone(senders),
+				"amount":  amount,
+			},
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrScanFailed, err)
+	}
+
+	return findings, nil
+}
+
+// FindVelocitySpikes ищет отправителей, которые за VelocityWindow сделали слишком много
+// переводов или перевели слишком много монет
+func (p *Postgres) FindVelocitySpikes(ctx context.Context, params model.FraudDetectionParams) ([]model.FraudFinding, error) {
+	query := `
+		SELECT from_user_id, COUNT(*)::int, SUM(amount)::int
+		FROM shop.transactions
+		WHERE kind = 'transfer'
+			AND from_user_id IS NOT NULL
+			AND created_at > NOW() - make_interval(secs => $1)
+		GROUP BY from_user_id
+		HAVING ($2 > 0 AND COUNT(*) > $2) OR ($3 > 0 AND SUM(amount) > $3)
+	`
+
+	rows, err := p.pgx.Query(ctx, query, params.VelocityWindow.Seconds(), params.VelocityMaxTransfers, params.VelocityMaxAmount)
+	if err != nil {
+		return nil, fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
+	}
+
+	var findings []model.FraudFinding
+	var (
+		sender    uint
+		transfers int
+		amount    int
+	)
+	_, err = pgx.ForEachRow(rows, []any{&sender, &transfers, &amount}, func() error {
+		findings = append(findings, model.FraudFinding{
+			Rule:        model.FraudRuleVelocity,
+			Fingerprint: fraudFingerprint(model.FraudRuleVelocity, sender),
+			UserIDs:     []uint{sender},
+			Details: map[string]any{
+				"transfers": transfers,
+				"amount":    amount,
+			},
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrScanFailed, err)
+	}
+
+	return findings, nil
+}
+
+// fraudFingerprint - ключ находки: правило и участники, например cycle:3,7,12
+func fraudFingerprint(rule string, userIDs ...uint) string {
+	ids := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		ids = append(ids, strconv.FormatUint(uint64(id), 10))
+	}
+	return rule + ":" + strings.Join(ids, ",")
+}
+
+// SaveFraudAlerts сохраняет находки как алерты и, если включено, замораживает юзеров из новых алертов.
+// Находка пропускается, если по ней уже есть открытый алерт или алерт разобрали меньше Cooldown назад,
+// иначе каждый проход антифрода заново поднимал бы те же самые переводы
+func (p *Postgres) SaveFraudAlerts(ctx context.Context, params model.SaveFraudAlertsParams) (*model.FraudDetectionResult, error) {
+	tx, err := p.pgx.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToBeginTx, err)
+	}
+	defer tx.Rollback(ctx)
+
+	insertQuery := `
+		INSERT INTO shop.fraud_alerts (rule, fingerprint, user_ids, details)
+		SELECT $1, $2, $3, $4
+		WHERE NOT EXISTS (
+			SELECT 1 FROM shop.fraud_alerts
+			WHERE fingerprint = $2
+				AND (status = 'open' OR reviewed_at > NOW() - make_interval(secs => $5))
+		)
+		ON CONFLICT (fingerprint) WHERE status = 'open' DO NOTHING
+		RETURNING id
+	`
+
+	result := &model.FraudDetectionResult{Findings: len(params.Findings)}
+
+	for _, f := range params.Findings {
+		var id uint
+		err := tx.QueryRow(ctx, insertQuery, f.Rule, f.Fingerprint, f.UserIDs, f.Details, params.Cooldown.Seconds()).Scan(&id)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				continue
+			}
+			return nil, fmt.Errorf("%w query %q: %w", ErrQueryFailed, insertQuery, err)
+		}
+
+		result.Alerts++
+
+		if !params.AutoFreeze {
+			continue
+		}
+
+		// морозим только активных: suspended и deleted и так ничего не могут
+		frozen, err := changeUserStatus(ctx, tx, statusChange{
+			userIDs: f.UserIDs,
+			from:    []string{model.UserStatusActive},
+			to:      model.UserStatusFrozen,
+			reason:  fmt.Sprintf("fraud alert #%d (%s)", id, f.Rule),
+		})
+		if err != nil {
+			return nil, err
+		}
+		result.Frozen = append(result.Frozen, frozen...)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToCommitTx, err)
+	}
+
+	return result, nil
+}
+
+const fraudAlertColumns = `id, rule, user_ids, details, status, created_at, reviewed_by, reviewed_at, COALESCE(review_note, '')`
+
+func scanFraudAlert(row pgx.Row) (model.FraudAlert, error) {
+	var a model.FraudAlert
+	err := row.Scan(&a.ID, &a.Rule, &a.UserIDs, &a.Details, &a.Status, &a.CreatedAt, &a.ReviewedBy, &a.ReviewedAt, &a.ReviewNote)
+	return a, err
+}
+
+// GetFraudAlerts возвращает алерты, самые новые первыми
+func (p *Postgres) GetFraudAlerts(ctx context.Context, params model.GetFraudAlertsParams) ([]model.FraudAlert, error) {
+	query := `
+		SELECT ` + fraudAlertColumns + `
+		FROM shop.fraud_alerts
+		WHERE ($1::text = '' OR status = $1)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`
+
+	rows, err := p.pgx.Query(ctx, query, params.Status, params.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
+	}
+
+	alerts, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.FraudAlert, error) {
+		return scanFraudAlert(row)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrScanFailed, err)
+	}
+
+	return alerts, nil
+}
+
+// ReviewFraudAlert закрывает открытый алерт решением админа и при необходимости
+// размораживает юзеров из него
+func (p *Postgres) ReviewFraudAlert(ctx context.Context, params model.ReviewFraudAlertParams) (*model.FraudAlert, error) {
+	tx, err := p.pgx.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToBeginTx, err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE shop.fraud_alerts
+		SET status = $2, reviewed_by = $3, reviewed_at = NOW(), review_note = NULLIF($4, '')
+		WHERE id = $1 AND status = 'open'
+		RETURNING ` + fraudAlertColumns
+
+	alert, err := scanFraudAlert(tx.QueryRow(ctx, query, params.ID, params.Status, params.AdminID, params.Note))
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
+		}
+
+		var exists bool
+		err = tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM shop.fraud_alerts WHERE id = $1)`, params.ID).Scan(&exists)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+		}
+		if !exists {
+			return nil, ErrNotFound
+		}
+		return nil, ErrFraudAlertReviewed
+	}
+
+	if params.Unfreeze {
+		_, err = changeUserStatus(ctx, tx, statusChange{
+			userIDs: alert.UserIDs,
+			from:    []string{model.UserStatusFrozen},
+			to:      model.UserStatusActive,
+			reason:  fmt.Sprintf("fraud alert #%d %s", alert.ID, alert.Status),
+			by:      &params.AdminID,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToCommitTx, err)
+	}
+
+	return &alert, nil
+}
+
+// GetUserRole возвращает роль юзера, нужна для проверки доступа к админским ручкам
+func (p *Postgres) GetUserRole(ctx context.Context, userID uint) (string, error) {
+	var role string
+	err := p.pgx.QueryRow(ctx, `SELECT role FROM shop.users WHERE id = $1`, userID).Scan(&role)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	return role, nil
+}
@@ -0,0 +1,244 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+// receivedGiftsQuery и sentGiftsQuery - история подарков юзера $1 для GetUserInfo и выгрузки.
+// $2 - имя удаленного контрагента (model.DeletedUser)
+const (
+	receivedGiftsQuery = `
+		SELECT COALESCE(shop.display_username(u.username, u.status), $2),
+			COALESCE(i.name, ''), COALESCE(` + variantSKUColumn + `, ''),
+			g.quantity, g.order_id, COALESCE(g.message, ''), g.created_at
+		FROM shop.gifts g
+		LEFT JOIN shop.users u ON u.id = g.from_user_id
+		LEFT JOIN shop.items i ON i.id = g.item_id
+		LEFT JOIN shop.item_variants v ON v.id = g.variant_id
+		WHERE g.to_user_id = $1
+		ORDER BY g.created_at, g.id
+	`
+	sentGiftsQuery = `
+		SELECT COALESCE(g.to_user_id, 0), COALESCE(shop.display_username(u.username, u.status), $2),
+			COALESCE(i.name, ''), COALESCE(` + variantSKUColumn + `, ''),
+			g.quantity, g.order_id, COALESCE(g.message, ''), g.created_at
+		FROM shop.gifts g
+		LEFT JOIN shop.users u ON u.id = g.to_user_id
+		LEFT JOIN shop.items i ON i.id = g.item_id
+		LEFT JOIN shop.item_variants v ON v.id = g.variant_id
+		WHERE g.from_user_id = $1
+		ORDER BY g.created_at, g.id
+	`
+)
+
+func scanReceivedGift(rows pgx.Rows) (model.ReceivedGift, error) {
+	var gift model.ReceivedGift
+	err := rows.Scan(&gift.User, &gift.Item, &gift.Variant, &gift.Quantity, &gift.OrderID, &gift.Message, &gift.CreatedAt)
+	return gift, err
+}
+
+func scanSentGift(rows pgx.Rows) (model.SentGift, error) {
+	var gift model.SentGift
+	err := rows.Scan(&gift.UserID, &gift.User, &gift.Item, &gift.Variant, &gift.Quantity, &gift.OrderID, &gift.Message, &gift.CreatedAt)
+	return gift, err
+}
+
+// queueGiftHistory добавляет в батч запросы истории подарков, читать их через collectGiftHistory
+func queueGiftHistory(batch *pgx.Batch, userID uint) {
+	batch.Queue(receivedGiftsQuery, userID, model.DeletedUser)
+	batch.Queue(sentGiftsQuery, userID, model.DeletedUser)
+}
+
+func collectGiftHistory(br pgx.BatchResults) (model.GiftHistory, error) {
+	received, err := collectBatchRows(br, scanReceivedGift)
+	if err != nil {
+		return model.GiftHistory{}, err
+	}
+
+	sent, err := collectBatchRows(br, scanSentGift)
+	if err != nil {
+		return model.GiftHistory{}, err
+	}
+
+	return model.GiftHistory{Received: received, Sent: sent}, nil
+}
+
+// findGiftRecipient находит получателя подарка по имени. Дарить можно только активным юзерам и не себе
+func findGiftRecipient(ctx context.Context, tx pgx.Tx, fromUserID uint, username string) (uint, error) {
+	var (
+		id     uint
+		status string
+	)
+	err := tx.QueryRow(ctx, `SELECT id, status FROM shop.users WHERE username = $1`, username).Scan(&id, &status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, fmt.Errorf("recipient %w", ErrNotFound)
+		}
+		return 0, fmt.Errorf("%w: %w", ErrFailedToFindRecipient, err)
+	}
+
+	if id == fromUserID {
+		return 0, ErrSelfGift
+	}
+
+	if status != model.UserStatusActive {
+		return 0, ErrRecipientNotActive
+	}
+
+	return id, nil
+}
+
+// recordGift сохраняет подарок в историю обеих сторон. orderID 0 - подарок из инвентаря
+func recordGift(ctx context.Context, tx pgx.Tx, params model.GiftItemParams, toUserID uint, line orderLine, orderID uint) (*model.SentGift, error) {
+	gift := &model.SentGift{
+		UserID:   toUserID,
+		User:     params.ToUser,
+		Item:     line.item,
+		Variant:  line.sku,
+		Quantity: params.Quantity,
+		Message:  params.Message,
+	}
+	if orderID != 0 {
+		gift.OrderID = &orderID
+	}
+
+	err := tx.QueryRow(ctx, `
+		INSERT INTO shop.gifts (from_user_id, to_user_id, item_id, variant_id, quantity, order_id, message)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''))
+		RETURNING created_at
+	`, params.FromUser, toUserID, line.itemID, line.variantID, params.Quantity, gift.OrderID, params.Message).Scan(&gift.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	return gift, nil
+}
+
+// GiftItem передает Quantity штук варианта из инвентаря отправителя получателю.
+// Штуки, которые ждут возврата или выдачи заказа, подарить нельзя (см. freeItems)
+func (p *Postgres) GiftItem(ctx context.Context, params model.GiftItemParams) (*model.SentGift, error) {
+	tx, err := p.pgx.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToBeginTx, err)
+	}
+	defer tx.Rollback(ctx)
+
+	toUserID, err := findGiftRecipient(ctx, tx, params.FromUser, params.ToUser)
+	if err != nil {
+		return nil, err
+	}
+
+	line, err := findVariant(ctx, tx, params.Item, params.Variant)
+	if err != nil {
+		return nil, err
+	}
+
+	var status string
+	err = tx.QueryRow(ctx, `SELECT status FROM shop.users WHERE id = $1`, params.FromUser).Scan(&status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	// иначе замороженный юзер выведет ценности через предметы
+	if err := checkSpenderStatus(status); err != nil {
+		return nil, err
+	}
+
+	// инвентарь юзера уменьшается только под блокировкой его кошелька.
+	// Инвентарь получателя только растет, его кошелек не блокируем
+	_, err = tx.Exec(ctx, `SELECT 1 FROM shop.wallets WHERE user_id = $1 FOR UPDATE`, params.FromUser)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToFetchBalance, err)
+	}
+
+	free, err := freeItems(ctx, tx, params.FromUser, line.variantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if free < params.Quantity {
+		return nil, fmt.Errorf("%w: %d left", ErrNotEnoughItems, free)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE shop.inventory
+		SET quantity = quantity - $3
+		WHERE user_id = $1 AND variant_id = $2
+	`, params.FromUser, line.variantID, params.Quantity)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	_, err = tx.Exec(ctx, `DELETE FROM shop.inventory WHERE user_id = $1 AND quantity = 0`, params.FromUser)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO shop.inventory (user_id, item_id, variant_id, quantity)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, variant_id) DO UPDATE
+		SET quantity = shop.inventory.quantity + EXCLUDED.quantity
+	`, toUserID, line.itemID, line.variantID, params.Quantity)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	gift, err := recordGift(ctx, tx, params, toUserID, line, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToCommitTx, err)
+	}
+
+	return gift, nil
+}
+
+// BuyGift покупает Quantity штук варианта одним заказом отправителя (см. placeOrder),
+// предметы сразу попадают в инвентарь получателя. Лимит на юзера считается по отправителю
+func (p *Postgres) BuyGift(ctx context.Context, params model.GiftItemParams) (*model.Order, error) {
+	tx, err := p.pgx.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToBeginTx, err)
+	}
+	defer tx.Rollback(ctx)
+
+	toUserID, err := findGiftRecipient(ctx, tx, params.FromUser, params.ToUser)
+	if err != nil {
+		return nil, err
+	}
+
+	line, err := findVariant(ctx, tx, params.Item, params.Variant)
+	if err != nil {
+		return nil, err
+	}
+	line.quantity = params.Quantity
+
+	order, err := placeOrder(ctx, tx, params.FromUser, toUserID, []orderLine{line}, params.MaxQuantity)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := recordGift(ctx, tx, params, toUserID, line, order.ID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToCommitTx, err)
+	}
+
+	order.RecipientID = toUserID
+	order.Recipient = params.ToUser
+
+	return order, nil
+}
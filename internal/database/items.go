@@ -0,0 +1,134 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+// catalogColumns - колонки для scanCatalogItem
+const catalogColumns = `
+	name, price, stock, per_user_limit, sale_starts_at, sale_ends_at,
+	COALESCE(stock > 0, TRUE) AND COALESCE(sale_starts_at <= NOW(), TRUE) AND COALESCE(sale_ends_at > NOW(), TRUE)
+`
+
+func scanCatalogItem(row pgx.Row, item *model.CatalogItem) error {
+	return row.Scan(&item.Name, &item.Price, &item.Stock, &item.PerUserLimit, &item.SaleStartsAt, &item.SaleEndsAt, &item.Available)
+}
+
+// GetCatalog возвращает все предметы магазина с вариантами, остатками на складе
+// и ограничениями продажи. Предмет, у которого распроданы все варианты, недоступен
+func (p *Postgres) GetCatalog(ctx context.Context) ([]model.CatalogItem, error) {
+	batch := &pgx.Batch{}
+	batch.Queue(`SELECT ` + catalogColumns + ` FROM shop.items ORDER BY id`)
+	batch.Queue(`
+		SELECT i.name, v.sku, v.size, v.color, i.price + v.price_delta, v.stock, COALESCE(v.stock > 0, TRUE)
+		FROM shop.item_variants v
+		JOIN shop.items i ON i.id = v.item_id
+		WHERE NOT v.is_default
+		ORDER BY v.item_id, v.id
+	`)
+
+	br := p.pgx.SendBatch(ctx, batch)
+	defer br.Close()
+
+	items, err := collectBatchRows(br, func(rows pgx.Rows) (model.CatalogItem, error) {
+		var item model.CatalogItem
+		err := scanCatalogItem(rows, &item)
+		return item, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// вариант приходит вместе с именем своего предмета, раскладываем их по предметам
+	itemVariants, err := collectBatchRows(br, func(rows pgx.Rows) (model.CatalogItem, error) {
+		var item model.CatalogItem
+		var v model.ItemVariant
+		err := rows.Scan(&item.Name, &v.SKU, &v.Size, &v.Color, &v.Price, &v.Stock, &v.Available)
+		item.Variants = []model.ItemVariant{v}
+		return item, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	variants := make(map[string][]model.ItemVariant)
+	for _, iv := range itemVariants {
+		variants[iv.Name] = append(variants[iv.Name], iv.Variants...)
+	}
+
+	for i := range items {
+		items[i].Variants = variants[items[i].Name]
+		if len(items[i].Variants) > 0 && !slices.ContainsFunc(items[i].Variants, func(v model.ItemVariant) bool { return v.Available }) {
+			items[i].Available = false
+		}
+	}
+
+	return items, nil
+}
+
+// SetItemRules заменяет склад, лимит на юзера и окно продажи предмета
+func (p *Postgres) SetItemRules(ctx context.Context, params model.SetItemRulesParams) (*model.CatalogItem, error) {
+	item := &model.CatalogItem{}
+
+	err := scanCatalogItem(p.pgx.QueryRow(ctx, `
+		UPDATE shop.items
+		SET stock = $2, per_user_limit = $3, sale_starts_at = $4, sale_ends_at = $5
+		WHERE name = $1
+		RETURNING `+catalogColumns,
+		params.Item, params.Stock, params.PerUserLimit, params.SaleStartsAt, params.SaleEndsAt,
+	), item)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("item %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	return item, nil
+}
+
+// SetItemVariant создает вариант предмета или заменяет его размер, цвет, надбавку и склад.
+// SKU уникален в пределах предмета, вариант по умолчанию так поменять нельзя
+func (p *Postgres) SetItemVariant(ctx context.Context, params model.SetItemVariantParams) (*model.ItemVariant, error) {
+	var itemID uint
+	var price int
+	err := p.pgx.QueryRow(ctx, `SELECT id, price FROM shop.items WHERE name = $1`, params.Item).Scan(&itemID, &price)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("item %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	if price+params.PriceDelta < 0 {
+		return nil, fmt.Errorf("%w: item price %d, delta %d", ErrNegativePrice, price, params.PriceDelta)
+	}
+
+	variant := &model.ItemVariant{}
+
+	// SKU варианта по умолчанию не перезаписываем, тогда строки не будет
+	err = p.pgx.QueryRow(ctx, `
+		INSERT INTO shop.item_variants (item_id, sku, size, color, price_delta, stock)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (item_id, sku) DO UPDATE
+		SET size = EXCLUDED.size, color = EXCLUDED.color, price_delta = EXCLUDED.price_delta, stock = EXCLUDED.stock
+		WHERE NOT shop.item_variants.is_default
+		RETURNING sku, size, color, $7::int + price_delta, stock, COALESCE(stock > 0, TRUE)
+	`, itemID, params.SKU, params.Size, params.Color, params.PriceDelta, params.Stock, price).Scan(
+		&variant.SKU, &variant.Size, &variant.Color, &variant.Price, &variant.Stock, &variant.Available,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrSKUTaken
+		}
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	return variant, nil
+}
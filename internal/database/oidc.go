@@ -0,0 +1,146 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+// maxUsernameAttempts - сколько имен перебираем (name, name2, name3...),
+// прежде чем сдаться при создании юзера из OIDC
+const maxUsernameAttempts = 100
+
+// CreateOIDCState сохраняет начатый вход через OIDC, заодно удаляя протухшие
+func (p *Postgres) CreateOIDCState(ctx context.Context, params model.CreateOIDCStateParams) error {
+	query := `
+		WITH expired AS (
+			DELETE FROM shop.oidc_login_states WHERE expires_at < NOW()
+		)
+		INSERT INTO shop.oidc_login_states (state_hash, code_verifier, nonce, expires_at)
+		VALUES ($1, $2, $3, NOW() + make_interval(secs => $4))
+	`
+
+	_, err := p.pgx.Exec(ctx, query, params.StateHash, params.CodeVerifier, params.Nonce, params.TTL.Seconds())
+	if err != nil {
+		return fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
+	}
+
+	return nil
+}
+
+// ConsumeOIDCState забирает state, он одноразовый: удаляется даже протухший
+func (p *Postgres) ConsumeOIDCState(ctx context.Context, stateHash string) (*model.OIDCState, error) {
+	query := `
+		DELETE FROM shop.oidc_login_states
+		WHERE state_hash = $1
+		RETURNING code_verifier, nonce, expires_at > NOW()
+	`
+
+	state := &model.OIDCState{}
+	var valid bool
+
+	err := p.pgx.QueryRow(ctx, query, stateHash).Scan(&state.CodeVerifier, &state.Nonce, &valid)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrInvalidOIDCState
+		}
+		return nil, fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
+	}
+	if !valid {
+		return nil, ErrInvalidOIDCState
+	}
+
+	return state, nil
+}
+
+// LoginOIDCUser находит юзера по issuer + subject или создает его с кошельком, как CreateUser.
+// Если params.Username занят, пробуются имена с номером. Пароля у нового юзера нет.
+// Статус юзера не проверяется, это делает сервис
+func (p *Postgres) LoginOIDCUser(ctx context.Context, params model.OIDCLoginParams) (*model.User, error) {
+	identity := params.Identity
+
+	tx, err := p.pgx.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToBeginTx, err)
+	}
+	defer tx.Rollback(ctx)
+
+	// два первых входа одного юзера одновременно не должны создать двух юзеров
+	_, err = tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1 || ' ' || $2))`, identity.Issuer, identity.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	user := &model.User{}
+
+	err = tx.QueryRow(ctx, `
+		UPDATE shop.user_identities i
+		SET email = $3, last_login_at = NOW()
+		FROM shop.users u
+		WHERE u.id = i.user_id AND i.issuer = $1 AND i.subject = $2
+		RETURNING u.id, u.username, u.status, u.token_version
+	`, identity.Issuer, identity.Subject, identity.Email).Scan(&user.ID, &user.Username, &user.Status, &user.TokenVersion)
+	switch {
+	case err == nil:
+		if err := tx.Commit(ctx); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrFailedToCommitTx, err)
+		}
+		return user, nil
+	case !errors.Is(err, pgx.ErrNoRows):
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	user, err = createOIDCUser(ctx, tx, params.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := createWallet(ctx, tx, user.ID); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO shop.user_identities (user_id, issuer, subject, email)
+		VALUES ($1, $2, $3, $4)
+	`, user.ID, identity.Issuer, identity.Subject, identity.Email)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToCommitTx, err)
+	}
+
+	return user, nil
+}
+
+// createOIDCUser создает юзера с пустым паролем под первым свободным именем из username, username2, ...
+func createOIDCUser(ctx context.Context, tx pgx.Tx, username string) (*model.User, error) {
+	query := `
+		INSERT INTO shop.users (username, password_hash)
+		VALUES ($1, '')
+		ON CONFLICT (username) DO NOTHING
+		RETURNING id, username, status, token_version
+	`
+
+	for i := 1; i <= maxUsernameAttempts; i++ {
+		candidate := username
+		if i > 1 {
+			candidate = fmt.Sprintf("%s%d", username, i)
+		}
+
+		user := &model.User{}
+		err := tx.QueryRow(ctx, query, candidate).Scan(&user.ID, &user.Username, &user.Status, &user.TokenVersion)
+		if err == nil {
+			return user, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
+		}
+	}
+
+	return nil, ErrUsernameTaken
+}
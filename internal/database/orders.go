@@ -0,0 +1,729 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+// variantSKUColumn - SKU варианта v для ответов API, у варианта по умолчанию пустой
+const variantSKUColumn = `CASE WHEN v.is_default THEN '' ELSE v.sku END`
+
+// orderLine - строка будущего заказа, price - текущая цена одной штуки с надбавкой варианта.
+// sku пустой для варианта по умолчанию
+type orderLine struct {
+	itemID    uint
+	variantID uint
+	item      string
+	sku       string
+	quantity  int
+	price     int
+}
+
+func (l orderLine) error(err error) error {
+	return &OrderLineError{Item: l.item, Variant: l.sku, Err: err}
+}
+
+// findVariant находит вариант предмета для покупки или корзины. Пустой sku - вариант
+// по умолчанию, он доступен, только пока у предмета нет других вариантов
+func findVariant(ctx context.Context, tx pgx.Tx, item, sku string) (orderLine, error) {
+	line := orderLine{item: item, sku: sku}
+
+	var hasVariants bool
+	err := tx.QueryRow(ctx, `
+		SELECT i.id, i.price, EXISTS (
+			SELECT 1 FROM shop.item_variants v WHERE v.item_id = i.id AND NOT v.is_default
+		)
+		FROM shop.items i
+		WHERE i.name = $1
+	`, item).Scan(&line.itemID, &line.price, &hasVariants)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return line, fmt.Errorf("item %w", ErrNotFound)
+		}
+		return line, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	if sku == "" {
+		if hasVariants {
+			return line, ErrVariantRequired
+		}
+
+		err = tx.QueryRow(ctx, `SELECT id FROM shop.item_variants WHERE item_id = $1 AND is_default`, line.itemID).Scan(&line.variantID)
+		if err != nil {
+			return line, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+		}
+		return line, nil
+	}
+
+	var delta int
+	err = tx.QueryRow(ctx, `
+		SELECT id, price_delta
+		FROM shop.item_variants
+		WHERE item_id = $1 AND sku = $2 AND NOT is_default
+	`, line.itemID, sku).Scan(&line.variantID, &delta)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return line, fmt.Errorf("variant %w", ErrNotFound)
+		}
+		return line, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+	line.price += delta
+
+	return line, nil
+}
+
+// PurchaseItem покупает несколько штук одного варианта предмета одним заказом из одной строки
+func (p *Postgres) PurchaseItem(ctx context.Context, params model.PurchaseItemParams) (*model.Order, error) {
+	tx, err := p.pgx.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToBeginTx, err)
+	}
+	defer tx.Rollback(ctx)
+
+	line, err := findVariant(ctx, tx, params.Item, params.Variant)
+	if err != nil {
+		return nil, err
+	}
+	line.quantity = params.Quantity
+
+	order, err := placeOrder(ctx, tx, params.UserID, 0, []orderLine{line}, params.MaxQuantity)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToCommitTx, err)
+	}
+
+	return order, nil
+}
+
+// placeOrder проверяет строки и ограничения продажи (см. reserveItems), один раз проверяет
+// баланс, списывает сумму, создает заказ со строками и добавляет предметы в инвентарь.
+// recipientID - кому достаются предметы, если заказ - подарок, 0 - самому покупателю.
+// Вызывать внутри транзакции, варианты в строках не должны повторяться.
+// Ошибка конкретной строки оборачивается в *OrderLineError
+func placeOrder(ctx context.Context, tx pgx.Tx, userID, recipientID uint, lines []orderLine, maxQuantity int) (*model.Order, error) {
+	var status string
+	err := tx.QueryRow(ctx, `SELECT status FROM shop.users WHERE id = $1`, userID).Scan(&status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	if err := checkSpenderStatus(status); err != nil {
+		return nil, err
+	}
+
+	var balance int
+	err = tx.QueryRow(ctx, `SELECT balance FROM shop.wallets WHERE user_id = $1 FOR UPDATE`, userID).Scan(&balance)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToFetchBalance, err)
+	}
+
+	order := &model.Order{Lines: make([]model.OrderLine, 0, len(lines))}
+	itemIDs := make([]uint, 0, len(lines))
+	variantIDs := make([]uint, 0, len(lines))
+	quantities := make([]int, 0, len(lines))
+	prices := make([]int, 0, len(lines))
+
+	for _, line := range lines {
+		// для корзины лимит могли уменьшить в конфиге, пока предмет в ней лежал
+		if maxQuantity > 0 && line.quantity > maxQuantity {
+			return nil, line.error(fmt.Errorf("%w: max %d", ErrQuantityLimit, maxQuantity))
+		}
+
+		order.Total += line.quantity * line.price
+		order.Lines = append(order.Lines, model.OrderLine{
+			Item:     line.item,
+			Variant:  line.sku,
+			Quantity: line.quantity,
+			Price:    line.price,
+		})
+
+		itemIDs = append(itemIDs, line.itemID)
+		variantIDs = append(variantIDs, line.variantID)
+		quantities = append(quantities, line.quantity)
+		prices = append(prices, line.price)
+	}
+
+	if err := reserveItems(ctx, tx, userID, lines); err != nil {
+		return nil, err
+	}
+
+	if balance < order.Total {
+		return nil, fmt.Errorf("%w: order total %d, balance %d", ErrInsufficientFunds, order.Total, balance)
+	}
+
+	if order.Total > 0 {
+		_, err = tx.Exec(ctx, `UPDATE shop.wallets SET balance = balance - $1 WHERE user_id = $2`, order.Total, userID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrFailedToDebitSender, err)
+		}
+
+		if err := consumeCoinLots(ctx, tx, userID, order.Total); err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO shop.orders (user_id, total, recipient_id)
+		VALUES ($1, $2, NULLIF($3::int, 0))
+		RETURNING id, status, created_at
+	`, userID, order.Total, recipientID).Scan(&order.ID, &order.Status, &order.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO shop.order_items (order_id, item_id, variant_id, quantity, price)
+		SELECT $1, unnest($2::int[]), unnest($3::int[]), unnest($4::int[]), unnest($5::int[])
+	`, order.ID, itemIDs, variantIDs, quantities, prices)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	holderID := userID
+	if recipientID != 0 {
+		holderID = recipientID
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO shop.inventory (user_id, item_id, variant_id, quantity)
+		SELECT $1, unnest($2::int[]), unnest($3::int[]), unnest($4::int[])
+		ON CONFLICT (user_id, variant_id) DO UPDATE
+		SET quantity = shop.inventory.quantity + EXCLUDED.quantity
+	`, holderID, itemIDs, variantIDs, quantities)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	return order, nil
+}
+
+// itemRules - ограничения продажи предмета на момент заказа. bought - сколько штук юзер
+// уже купил, считается только при заданном лимите на юзера
+type itemRules struct {
+	stock        *int
+	perUserLimit *int
+	notStarted   bool
+	ended        bool
+	hasVariants  bool
+	bought       int
+}
+
+// reserveItems проверяет окно продажи, лимит на юзера и склады предмета и варианта для каждой
+// строки и списывает штуки со складов. Предметы, а за ними варианты с ограниченным складом
+// блокируются по возрастанию ID, так параллельные заказы не уводят склад в минус и не ждут
+// друг друга крест-накрест. Кошелек юзера уже должен быть заблокирован: иначе две параллельные
+// покупки обойдут лимит на юзера. Ошибка конкретной строки оборачивается в *OrderLineError
+func reserveItems(ctx context.Context, tx pgx.Tx, userID uint, lines []orderLine) error {
+	itemIDs := make([]uint, 0, len(lines))
+	variantIDs := make([]uint, 0, len(lines))
+	variantQuantities := make([]int, 0, len(lines))
+	for _, line := range lines {
+		itemIDs = append(itemIDs, line.itemID)
+		variantIDs = append(variantIDs, line.variantID)
+		variantQuantities = append(variantQuantities, line.quantity)
+	}
+
+	// остальные предметы не блокируем, чтобы покупки без склада не шли по очереди
+	_, err := tx.Exec(ctx, `
+		SELECT id
+		FROM shop.items
+		WHERE id = ANY($1) AND stock IS NOT NULL
+		ORDER BY id
+		FOR UPDATE
+	`, itemIDs)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT id, stock
+		FROM shop.item_variants
+		WHERE id = ANY($1) AND stock IS NOT NULL
+		ORDER BY id
+		FOR UPDATE
+	`, variantIDs)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	variantStocks := make(map[uint]int, len(lines))
+	var (
+		id    uint
+		stock int
+	)
+	_, err = pgx.ForEachRow(rows, []any{&id, &stock}, func() error {
+		variantStocks[id] = stock
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrScanFailed, err)
+	}
+
+	// покупки по одной штуке из старой shop.purchases тоже идут в лимит, отмененные заказы - нет
+	rows, err = tx.Query(ctx, `
+		SELECT
+			i.id, i.stock, i.per_user_limit,
+			COALESCE(i.sale_starts_at > NOW(), FALSE),
+			COALESCE(i.sale_ends_at <= NOW(), FALSE),
+			EXISTS (SELECT 1 FROM shop.item_variants v WHERE v.item_id = i.id AND NOT v.is_default),
+			CASE WHEN i.per_user_limit IS NULL THEN 0 ELSE (
+				SELECT COALESCE(SUM(oi.quantity), 0)
+				FROM shop.order_items oi
+				JOIN shop.orders o ON o.id = oi.order_id
+				WHERE o.user_id = $2 AND oi.item_id = i.id AND o.status <> 'cancelled'
+			) + (
+				SELECT COUNT(*)
+				FROM shop.purchases p
+				WHERE p.user_id = $2 AND p.item_id = i.id
+			) END
+		FROM shop.items i
+		WHERE i.id = ANY($1)
+	`, itemIDs, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	rules := make(map[uint]itemRules, len(lines))
+	var r itemRules
+	_, err = pgx.ForEachRow(rows, []any{&id, &r.stock, &r.perUserLimit, &r.notStarted, &r.ended, &r.hasVariants, &r.bought}, func() error {
+		rules[id] = r
+		r = itemRules{} // иначе pgx запишет следующий stock в тот же указатель
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrScanFailed, err)
+	}
+
+	// разные варианты одного предмета делят его склад и лимит на юзера
+	itemQuantities := make(map[uint]int, len(lines))
+	for _, line := range lines {
+		r := rules[line.itemID]
+		itemQuantities[line.itemID] += line.quantity
+		quantity := itemQuantities[line.itemID]
+
+		var lineErr error
+		switch {
+		case r.notStarted:
+			lineErr = ErrSaleNotStarted
+		case r.ended:
+			lineErr = ErrSaleEnded
+		case line.sku == "" && r.hasVariants:
+			// вариант по умолчанию лежал в корзине до того, как у предмета появились варианты
+			lineErr = ErrVariantRequired
+		case r.perUserLimit != nil && r.bought+quantity > *r.perUserLimit:
+			lineErr = fmt.Errorf("%w: max %d, already bought %d", ErrPurchaseLimit, *r.perUserLimit, r.bought)
+		case r.stock != nil && quantity > *r.stock:
+			lineErr = fmt.Errorf("%w: %d left", ErrSoldOut, *r.stock-(quantity-line.quantity))
+		}
+		if stock, ok := variantStocks[line.variantID]; ok && lineErr == nil && line.quantity > stock {
+			lineErr = fmt.Errorf("%w: %d left", ErrSoldOut, stock)
+		}
+		if lineErr != nil {
+			return line.error(lineErr)
+		}
+	}
+
+	stockItemIDs := make([]uint, 0, len(itemQuantities))
+	stockQuantities := make([]int, 0, len(itemQuantities))
+	for id, quantity := range itemQuantities {
+		stockItemIDs = append(stockItemIDs, id)
+		stockQuantities = append(stockQuantities, quantity)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE shop.items i
+		SET stock = i.stock - l.quantity
+		FROM unnest($1::int[], $2::int[]) AS l(item_id, quantity)
+		WHERE i.id = l.item_id AND i.stock IS NOT NULL
+	`, stockItemIDs, stockQuantities)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE shop.item_variants v
+		SET stock = v.stock - l.quantity
+		FROM unnest($1::int[], $2::int[]) AS l(variant_id, quantity)
+		WHERE v.id = l.variant_id AND v.stock IS NOT NULL
+	`, variantIDs, variantQuantities)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	return nil
+}
+
+// querier - общее у pgx.Tx и pgxpool.Pool для чтения, как execer для Exec
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// orderFilter - какие заказы читает queryOrders. Нулевые поля не фильтруют, limit 0 - без ограничения
+type orderFilter struct {
+	orderID uint
+	userID  uint
+	status  string
+	limit   int
+}
+
+// queryOrders читает заказы со строками, новые заказы первыми
+func queryOrders(ctx context.Context, q querier, f orderFilter) ([]model.Order, error) {
+	query := `
+		WITH o AS (
+			SELECT id, user_id, recipient_id, total, status, status_changed_at, created_at
+			FROM shop.orders
+			WHERE ($1::int = 0 OR id = $1)
+				AND ($2::int = 0 OR user_id = $2)
+				AND ($3::text = '' OR status = $3)
+			ORDER BY created_at DESC, id DESC
+			LIMIT NULLIF($4::int, 0)
+		)
+		SELECT
+			o.id, COALESCE(o.user_id, 0), COALESCE(shop.display_username(u.username, u.status), $5),
+			COALESCE(o.recipient_id, 0), COALESCE(shop.display_username(r.username, r.status), ''),
+			o.total, o.status, o.status_changed_at, o.created_at,
+			COALESCE(i.name, ''), COALESCE(` + variantSKUColumn + `, ''), oi.quantity, oi.price
+		FROM o
+		LEFT JOIN shop.users u ON u.id = o.user_id
+		LEFT JOIN shop.users r ON r.id = o.recipient_id
+		JOIN shop.order_items oi ON oi.order_id = o.id
+		LEFT JOIN shop.items i ON oi.item_id = i.id
+		LEFT JOIN shop.item_variants v ON oi.variant_id = v.id
+		ORDER BY o.created_at DESC, o.id DESC, oi.id
+	`
+
+	rows, err := q.Query(ctx, query, f.orderID, f.userID, f.status, f.limit, model.DeletedUser)
+	if err != nil {
+		return nil, fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
+	}
+
+	orderLines, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.Order, error) {
+		var order model.Order
+		var line model.OrderLine
+		err := row.Scan(
+			&order.ID, &order.UserID, &order.Username,
+			&order.RecipientID, &order.Recipient,
+			&order.Total, &order.Status, &order.StatusChangedAt, &order.CreatedAt,
+			&line.Item, &line.Variant, &line.Quantity, &line.Price,
+		)
+		order.Lines = []model.OrderLine{line}
+		return order, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrScanFailed, err)
+	}
+
+	return groupOrderLines(orderLines), nil
+}
+
+// groupOrderLines собирает в заказы строки, прочитанные по одной вместе с заказом.
+// Строки одного заказа должны идти подряд
+func groupOrderLines(orderLines []model.Order) []model.Order {
+	var orders []model.Order
+	for _, o := range orderLines {
+		if n := len(orders); n > 0 && orders[n-1].ID == o.ID {
+			orders[n-1].Lines = append(orders[n-1].Lines, o.Lines...)
+			continue
+		}
+		orders = append(orders, o)
+	}
+	return orders
+}
+
+// GetOrders возвращает заказы юзера или, при UserID 0, всех юзеров
+func (p *Postgres) GetOrders(ctx context.Context, params model.GetOrdersParams) ([]model.Order, error) {
+	return queryOrders(ctx, p.pgx, orderFilter{
+		userID: params.UserID,
+		status: params.Status,
+		limit:  params.Limit,
+	})
+}
+
+// SetOrderStatus переводит заказ в новый статус, если это разрешено model.OrderTransitions,
+// и пишет смену в shop.order_status_audit. Отмена в той же транзакции возвращает монеты
+// и забирает предметы (см. cancelOrder). Возвращает заказ уже в новом статусе
+func (p *Postgres) SetOrderStatus(ctx context.Context, params model.SetOrderStatusParams) (*model.Order, error) {
+	tx, err := p.pgx.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToBeginTx, err)
+	}
+	defer tx.Rollback(ctx)
+
+	// holderID - у кого предметы заказа: получатель подарка или сам покупатель
+	var (
+		userID, holderID *uint
+		status           string
+		total            int
+	)
+	err = tx.QueryRow(ctx, `
+		SELECT user_id, COALESCE(recipient_id, user_id), status, total
+		FROM shop.orders
+		WHERE id = $1
+		FOR UPDATE
+	`, params.OrderID).Scan(&userID, &holderID, &status, &total)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("order %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	if !model.CanChangeOrderStatus(status, params.Status) {
+		return nil, fmt.Errorf("%w: from %q to %q", ErrOrderStatusTransition, status, params.Status)
+	}
+
+	if params.Status == model.OrderStatusCancelled {
+		// юзеров удаляют мягко, так что без владельца заказ остается только после ручной чистки базы
+		if userID == nil || holderID == nil {
+			return nil, fmt.Errorf("%w: order owner is deleted", ErrOrderStatusTransition)
+		}
+		if err := cancelOrder(ctx, tx, params.OrderID, *userID, *holderID, total); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+		WITH changed AS (
+			UPDATE shop.orders
+			SET status = $2, status_changed_at = NOW()
+			WHERE id = $1
+		)
+		INSERT INTO shop.order_status_audit (order_id, old_status, new_status, reason, changed_by)
+		VALUES ($1, $3, $2, NULLIF($4, ''), $5)
+	`, params.OrderID, params.Status, status, params.Reason, params.AdminID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	orders, err := queryOrders(ctx, tx, orderFilter{orderID: params.OrderID})
+	if err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return nil, fmt.Errorf("order %w", ErrNotFound)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToCommitTx, err)
+	}
+
+	return &orders[0], nil
+}
+
+// freeItems возвращает, сколько штук варианта из инвентаря юзера можно подарить или вернуть.
+// Штуки из pending возвратов заберет одобрение возврата, а штуки из еще не выданных заказов,
+// в том числе подарков юзеру, - отмена заказа, поэтому они не считаются.
+// Вызывается под блокировкой кошелька юзера
+func freeItems(ctx context.Context, tx pgx.Tx, userID, variantID uint) (int, error) {
+	var free int
+	err := tx.QueryRow(ctx, `
+		SELECT
+			COALESCE((SELECT quantity FROM shop.inventory WHERE user_id = $1 AND variant_id = $2), 0)
+			- COALESCE((
+				SELECT SUM(quantity)
+				FROM shop.return_requests
+				WHERE user_id = $1 AND variant_id = $2 AND status = 'pending'
+			), 0)
+			- COALESCE((
+				SELECT SUM(oi.quantity)
+				FROM shop.order_items oi
+				JOIN shop.orders o ON o.id = oi.order_id
+				WHERE COALESCE(o.recipient_id, o.user_id) = $1 AND oi.variant_id = $2
+					AND o.status IN ('placed', 'ready_for_pickup')
+			), 0)
+	`, userID, variantID).Scan(&free)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	return max(free, 0), nil
+}
+
+// cancelOrder возвращает покупателю userID стоимость заказа новой партией монет, забирает
+// предметы заказа из инвентаря holderID (получателя подарка или самого покупателя) и возвращает
+// их на склады предметов и вариантов. Если каких-то штук в инвентаре уже нет, ничего
+// не меняется, ошибка строки оборачивается в *OrderLineError.
+// Блокировки в том же порядке, что и в placeOrder: кошельки, предметы, варианты
+func cancelOrder(ctx context.Context, tx pgx.Tx, orderID, userID, holderID uint, total int) error {
+	// инвентарь юзера меняется только под блокировкой его кошелька. Кошельки блокируем
+	// по возрастанию ID, иначе отмены встречных подарков будут ждать друг друга
+	_, err := tx.Exec(ctx, `
+		SELECT 1
+		FROM shop.wallets
+		WHERE user_id IN ($1, $2)
+		ORDER BY user_id
+		FOR UPDATE
+	`, userID, holderID)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToFetchBalance, err)
+	}
+
+	// удаленные предметы и варианты пропускаем: из инвентаря они уже пропали вместе со складом
+	rows, err := tx.Query(ctx, `
+		SELECT oi.item_id, oi.variant_id, i.name, `+variantSKUColumn+`, SUM(oi.quantity)::int, COALESCE(MIN(inv.quantity), 0)
+		FROM shop.order_items oi
+		JOIN shop.items i ON i.id = oi.item_id
+		JOIN shop.item_variants v ON v.id = oi.variant_id
+		LEFT JOIN shop.inventory inv ON inv.user_id = $2 AND inv.variant_id = oi.variant_id
+		WHERE oi.order_id = $1
+		GROUP BY oi.item_id, oi.variant_id, i.name, v.is_default, v.sku
+		ORDER BY oi.variant_id
+	`, orderID, holderID)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	var (
+		lines []orderLine
+		line  orderLine
+		have  int
+	)
+	_, err = pgx.ForEachRow(rows, []any{&line.itemID, &line.variantID, &line.item, &line.sku, &line.quantity, &have}, func() error {
+		if have < line.quantity {
+			return line.error(fmt.Errorf("%w: %d of %d left", ErrNotEnoughItems, have, line.quantity))
+		}
+		lines = append(lines, line)
+		return nil
+	})
+	if err != nil {
+		var lineErr *OrderLineError
+		if errors.As(err, &lineErr) {
+			return err
+		}
+		return fmt.Errorf("%w: %w", ErrScanFailed, err)
+	}
+
+	if total > 0 {
+		_, err = tx.Exec(ctx, `UPDATE shop.wallets SET balance = balance + $1 WHERE user_id = $2`, total, userID)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrFailedToCreditRecipient, err)
+		}
+
+		if err := addCoinLot(ctx, tx, userID, total); err != nil {
+			return err
+		}
+	}
+
+	itemIDs := make([]uint, 0, len(lines))
+	variantIDs := make([]uint, 0, len(lines))
+	quantities := make([]int, 0, len(lines))
+	for _, line := range lines {
+		itemIDs = append(itemIDs, line.itemID)
+		variantIDs = append(variantIDs, line.variantID)
+		quantities = append(quantities, line.quantity)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE shop.inventory inv
+		SET quantity = inv.quantity - l.quantity
+		FROM unnest($2::int[], $3::int[]) AS l(variant_id, quantity)
+		WHERE inv.user_id = $1 AND inv.variant_id = l.variant_id
+	`, holderID, variantIDs, quantities)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	_, err = tx.Exec(ctx, `DELETE FROM shop.inventory WHERE user_id = $1 AND quantity = 0`, holderID)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	return restockItems(ctx, tx, itemIDs, variantIDs, quantities)
+}
+
+// restockItems возвращает штуки на склады предметов и вариантов, у которых склад ограничен.
+// Строки складов блокируются по возрастанию ID, как в reserveItems
+func restockItems(ctx context.Context, tx pgx.Tx, itemIDs, variantIDs []uint, quantities []int) error {
+	_, err := tx.Exec(ctx, `
+		SELECT id
+		FROM shop.items
+		WHERE id = ANY($1) AND stock IS NOT NULL
+		ORDER BY id
+		FOR UPDATE
+	`, itemIDs)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		SELECT id
+		FROM shop.item_variants
+		WHERE id = ANY($1) AND stock IS NOT NULL
+		ORDER BY id
+		FOR UPDATE
+	`, variantIDs)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE shop.items i
+		SET stock = i.stock + l.quantity
+		FROM (
+			SELECT item_id, SUM(quantity)::int AS quantity
+			FROM unnest($1::int[], $2::int[]) AS l(item_id, quantity)
+			GROUP BY item_id
+		) l
+		WHERE i.id = l.item_id AND i.stock IS NOT NULL
+	`, itemIDs, quantities)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE shop.item_variants v
+		SET stock = v.stock + l.quantity
+		FROM unnest($1::int[], $2::int[]) AS l(variant_id, quantity)
+		WHERE v.id = l.variant_id AND v.stock IS NOT NULL
+	`, variantIDs, quantities)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	return nil
+}
+
+// GetOrderStatusHistory возвращает журнал смены статусов заказа, новые записи первыми
+func (p *Postgres) GetOrderStatusHistory(ctx context.Context, orderID uint) ([]model.OrderStatusChange, error) {
+	var exists bool
+	err := p.pgx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM shop.orders WHERE id = $1)`, orderID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("order %w", ErrNotFound)
+	}
+
+	query := `
+		SELECT id, old_status, new_status, COALESCE(reason, ''), changed_by, created_at
+		FROM shop.order_status_audit
+		WHERE order_id = $1
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := p.pgx.Query(ctx, query, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
+	}
+
+	history, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.OrderStatusChange, error) {
+		var c model.OrderStatusChange
+		err := row.Scan(&c.ID, &c.OldStatus, &c.NewStatus, &c.Reason, &c.ChangedBy, &c.CreatedAt)
+		return c, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrScanFailed, err)
+	}
+
+	return history, nil
+}
@@ -0,0 +1,134 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+// SetPassword меняет хэш пароля и увеличивает версию токенов, отзывая все выданные JWT.
+// Неиспользованные токены сброса пароля удаляются, сессии помечаются отозванными. Возвращает новую версию токенов
+func (p *Postgres) SetPassword(ctx context.Context, params model.SetPasswordParams) (int, error) {
+	query := `
+		WITH updated AS (
+			UPDATE shop.users
+			SET password_hash = $2, token_version = token_version + 1
+			WHERE id = $1 AND status <> 'deleted'
+			RETURNING id, token_version
+		),
+		revoked AS (
+			DELETE FROM shop.password_reset_tokens t
+			USING updated
+			WHERE t.user_id = updated.id AND t.used_at IS NULL
+		),
+		sessions AS (
+			UPDATE shop.sessions s
+			SET revoked_at = NOW()
+			FROM updated
+			WHERE s.user_id = updated.id AND s.revoked_at IS NULL
+		)
+		SELECT token_version FROM updated
+	`
+
+	var version int
+	err := p.pgx.QueryRow(ctx, query, params.UserID, params.PasswordHash).Scan(&version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrNotFound
+		}
+		return 0, fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
+	}
+
+	return version, nil
+}
+
+// CreatePasswordReset сохраняет хэш нового токена сброса пароля.
+// Предыдущие неиспользованные токены юзера удаляются, действует только последний
+func (p *Postgres) CreatePasswordReset(ctx context.Context, params model.CreatePasswordResetParams) (*model.PasswordReset, error) {
+	tx, err := p.pgx.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToBeginTx, err)
+	}
+	defer tx.Rollback(ctx)
+
+	reset := &model.PasswordReset{UserID: params.UserID}
+
+	var status string
+	err = tx.QueryRow(ctx, `
+		SELECT username, status FROM shop.users WHERE id = $1 FOR UPDATE
+	`, params.UserID).Scan(&reset.Username, &status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+	// у удаленного юзера восстанавливать нечего
+	if status == model.UserStatusDeleted {
+		return nil, ErrNotFound
+	}
+
+	_, err = tx.Exec(ctx, `
+		DELETE FROM shop.password_reset_tokens WHERE user_id = $1 AND used_at IS NULL
+	`, params.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO shop.password_reset_tokens (user_id, token_hash, created_by, expires_at)
+		VALUES ($1, $2, $3, NOW() + make_interval(secs => $4))
+		RETURNING expires_at
+	`, params.UserID, params.TokenHash, params.AdminID, params.TTL.Seconds()).Scan(&reset.ExpiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToCommitTx, err)
+	}
+
+	return reset, nil
+}
+
+// ResetPassword гасит токен сброса и меняет пароль одним запросом, так что токен
+// нельзя использовать дважды даже при одновременных запросах. Как и SetPassword,
+// отзывает все выданные JWT. Возвращает ID юзера
+func (p *Postgres) ResetPassword(ctx context.Context, params model.ResetPasswordParams) (uint, error) {
+	query := `
+		WITH token AS (
+			UPDATE shop.password_reset_tokens
+			SET used_at = NOW()
+			WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+			RETURNING user_id
+		),
+		updated AS (
+			UPDATE shop.users u
+			SET password_hash = $2, token_version = u.token_version + 1
+			FROM token
+			WHERE u.id = token.user_id AND u.status <> 'deleted'
+			RETURNING u.id
+		),
+		sessions AS (
+			UPDATE shop.sessions s
+			SET revoked_at = NOW()
+			FROM updated
+			WHERE s.user_id = updated.id AND s.revoked_at IS NULL
+		)
+		SELECT id FROM updated
+	`
+
+	var userID uint
+	err := p.pgx.QueryRow(ctx, query, params.TokenHash, params.PasswordHash).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrInvalidResetToken
+		}
+		return 0, fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
+	}
+
+	return userID, nil
+}
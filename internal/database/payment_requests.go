@@ -0,0 +1,271 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+// paymentRequestColumns - общий список колонок для выборки запросов монет.
+// Протухшие pending запросы сразу отдаем со статусом expired
+const paymentRequestColumns = `
+	pr.id, pr.from_user_id, pr.to_user_id,
+	shop.display_username(fu.username, fu.status), shop.display_username(tu.username, tu.status),
+	pr.amount, COALESCE(pr.memo, ''),
+	CASE WHEN pr.status = 'pending' AND pr.expires_at <= NOW() THEN 'expired' ELSE pr.status END,
+	pr.expires_at, pr.created_at, pr.resolved_at
+`
+
+func scanPaymentRequest(row pgx.Row, pr *model.PaymentRequest) error {
+	return row.Scan(
+		&pr.ID,
+		&pr.FromUserID,
+		&pr.ToUserID,
+		&pr.FromUser,
+		&pr.ToUser,
+		&pr.Amount,
+		&pr.Memo,
+		&pr.Status,
+		&pr.ExpiresAt,
+		&pr.CreatedAt,
+		&pr.ResolvedAt,
+	)
+}
+
+func (p *Postgres) CreatePaymentRequest(ctx context.Context, params model.CreatePaymentRequestParams) (*model.PaymentRequest, error) {
+	var toUserID uint
+	err := p.pgx.QueryRow(ctx, `SELECT id FROM shop.users WHERE username = $1`, params.ToUser).Scan(&toUserID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("recipient %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("%w: %w", ErrFailedToFindRecipient, err)
+	}
+
+	if toUserID == params.FromUser {
+		return nil, ErrSelfPaymentRequest
+	}
+
+	query := `
+		WITH pr AS (
+			INSERT INTO shop.payment_requests (from_user_id, to_user_id, amount, memo, expires_at)
+			VALUES ($1, $2, $3, NULLIF($4, ''), NOW() + make_interval(secs => $5))
+			RETURNING *
+		)
+		SELECT ` + paymentRequestColumns + `
+		FROM pr
+		JOIN shop.users fu ON pr.from_user_id = fu.id
+		JOIN shop.users tu ON pr.to_user_id = tu.id
+	`
+
+	pr := &model.PaymentRequest{}
+	row := p.pgx.QueryRow(ctx, query, params.FromUser, toUserID, params.Amount, params.Memo, params.TTL.Seconds())
+	if err := scanPaymentRequest(row, pr); err != nil {
+		return nil, fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
+	}
+
+	return pr, nil
+}
+
+func (p *Postgres) GetPaymentRequests(ctx context.Context, params model.GetPaymentRequestsParams) ([]model.PaymentRequest, error) {
+	column := "pr.to_user_id"
+	if params.Direction == model.PaymentRequestsOutgoing {
+		column = "pr.from_user_id"
+	}
+
+	query := `
+		SELECT ` + paymentRequestColumns + `
+		FROM shop.payment_requests pr
+		JOIN shop.users fu ON pr.from_user_id = fu.id
+		JOIN shop.users tu ON pr.to_user_id = tu.id
+		WHERE ` + column + ` = $1
+		ORDER BY pr.created_at DESC
+	`
+
+	rows, err := p.pgx.Query(ctx, query, params.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+	defer rows.Close()
+
+	var requests []model.PaymentRequest
+	for rows.Next() {
+		var pr model.PaymentRequest
+		if err := scanPaymentRequest(rows, &pr); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrScanFailed, err)
+		}
+		requests = append(requests, pr)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRowsFailed, err)
+	}
+
+	return requests, nil
+}
+
+// GetPaymentRequest возвращает запрос по ID без блокировки, сервису нужна сумма до перевода
+func (p *Postgres) GetPaymentRequest(ctx context.Context, id uint) (*model.PaymentRequest, error) {
+	query := `
+		SELECT ` + paymentRequestColumns + `
+		FROM shop.payment_requests pr
+		JOIN shop.users fu ON pr.from_user_id = fu.id
+		JOIN shop.users tu ON pr.to_user_id = tu.id
+		WHERE pr.id = $1
+	`
+
+	pr := &model.PaymentRequest{}
+	if err := scanPaymentRequest(p.pgx.QueryRow(ctx, query, id), pr); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("payment request %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
+	}
+
+	return pr, nil
+}
+
+// AcceptPaymentRequest принимает запрос: переводит монеты от ToUser к FromUser
+// через transferTx и закрывает запрос в одной транзакции
+func (p *Postgres) AcceptPaymentRequest(ctx context.Context, params model.ResolvePaymentRequestParams) (*model.PaymentRequest, error) {
+	tx, err := p.pgx.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToBeginTx, err)
+	}
+	defer tx.Rollback(ctx)
+
+	pr, err := lockPendingPaymentRequest(ctx, tx, params.ID, func(pr *model.PaymentRequest) bool {
+		return pr.ToUserID == params.UserID
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = transferTx(ctx, tx, transfer{
+		fromUserID: pr.ToUserID,
+		toUserID:   pr.FromUserID,
+		amount:     pr.Amount,
+		memo:       pr.Memo,
+		limits:     params.Limits,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := setPaymentRequestStatus(ctx, tx, pr, model.PaymentRequestAccepted); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToCommitTx, err)
+	}
+
+	return pr, nil
+}
+
+// DeclinePaymentRequest отклоняет запрос, может только тот, у кого просят монеты
+func (p *Postgres) DeclinePaymentRequest(ctx context.Context, params model.ResolvePaymentRequestParams) (*model.PaymentRequest, error) {
+	return p.closePaymentRequest(ctx, params, model.PaymentRequestDeclined, func(pr *model.PaymentRequest) bool {
+		return pr.ToUserID == params.UserID
+	})
+}
+
+// CancelPaymentRequest отменяет запрос, может только его автор
+func (p *Postgres) CancelPaymentRequest(ctx context.Context, params model.ResolvePaymentRequestParams) (*model.PaymentRequest, error) {
+	return p.closePaymentRequest(ctx, params, model.PaymentRequestCancelled, func(pr *model.PaymentRequest) bool {
+		return pr.FromUserID == params.UserID
+	})
+}
+
+func (p *Postgres) closePaymentRequest(
+	ctx context.Context,
+	params model.ResolvePaymentRequestParams,
+	status string,
+	allowed func(pr *model.PaymentRequest) bool,
+) (*model.PaymentRequest, error) {
+	tx, err := p.pgx.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToBeginTx, err)
+	}
+	defer tx.Rollback(ctx)
+
+	pr, err := lockPendingPaymentRequest(ctx, tx, params.ID, allowed)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := setPaymentRequestStatus(ctx, tx, pr, status); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToCommitTx, err)
+	}
+
+	return pr, nil
+}
+
+// lockPendingPaymentRequest блокирует запрос (FOR UPDATE) и проверяет, что его можно закрыть.
+// Чужие запросы для юзера не существуют, поэтому на них возвращается ErrNotFound.
+// Протухший запрос помечается expired и транзакция сразу коммитится, чтобы статус
+// сохранился, даже несмотря на то что вызывающая сторона вернет ошибку
+func lockPendingPaymentRequest(
+	ctx context.Context,
+	tx pgx.Tx,
+	id uint,
+	allowed func(pr *model.PaymentRequest) bool,
+) (*model.PaymentRequest, error) {
+	query := `
+		SELECT ` + paymentRequestColumns + `
+		FROM shop.payment_requests pr
+		JOIN shop.users fu ON pr.from_user_id = fu.id
+		JOIN shop.users tu ON pr.to_user_id = tu.id
+		WHERE pr.id = $1
+		FOR UPDATE OF pr
+	`
+
+	pr := &model.PaymentRequest{}
+	if err := scanPaymentRequest(tx.QueryRow(ctx, query, id), pr); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("payment request %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
+	}
+
+	if !allowed(pr) {
+		return nil, fmt.Errorf("payment request %w", ErrNotFound)
+	}
+
+	switch pr.Status {
+	case model.PaymentRequestPending:
+		return pr, nil
+	case model.PaymentRequestExpired:
+		if err := setPaymentRequestStatus(ctx, tx, pr, model.PaymentRequestExpired); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrFailedToCommitTx, err)
+		}
+		return nil, ErrPaymentRequestExpired
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrPaymentRequestNotPending, pr.Status)
+	}
+}
+
+func setPaymentRequestStatus(ctx context.Context, tx pgx.Tx, pr *model.PaymentRequest, status string) error {
+	query := `
+		UPDATE shop.payment_requests
+		SET status = $2, resolved_at = NOW()
+		WHERE id = $1
+		RETURNING resolved_at
+	`
+	if err := tx.QueryRow(ctx, query, pr.ID, status).Scan(&pr.ResolvedAt); err != nil {
+		return fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
+	}
+
+	pr.Status = status
+	return nil
+}
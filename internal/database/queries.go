@@ -11,14 +11,21 @@ import (
 
 func (p *Postgres) AuthUser(ctx context.Context, params model.AuthUserParams) (*model.User, error) {
 	query := `
-		SELECT id, password_hash
-		FROM shop.users
-		WHERE username = $1
+		SELECT u.id, u.password_hash, u.status, u.token_version,
+			EXISTS (SELECT 1 FROM shop.user_totp t WHERE t.user_id = u.id AND t.confirmed_at IS NOT NULL)
+		FROM shop.users u
+		WHERE u.username = $1
 	`
 
 	user := &model.User{}
 
-	err := p.pgx.QueryRow(ctx, query, params.Username).Scan(&user.ID, &user.Password)
+	err := p.pgx.QueryRow(ctx, query, params.Username).Scan(
+		&user.ID,
+		&user.Password,
+		&user.Status,
+		&user.TokenVersion,
+		&user.TOTPEnabled,
+	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrNotFound
@@ -33,7 +40,7 @@ func (p *Postgres) CreateUser(ctx context.Context, params model.CreateUserParams
 	query := `
 		INSERT INTO shop.users (username, password_hash)
 		VALUES ($1, $2)
-		RETURNING id, username
+		RETURNING id, username, status, token_version
 	`
 
 	user := &model.User{}
@@ -41,95 +48,168 @@ func (p *Postgres) CreateUser(ctx context.Context, params model.CreateUserParams
 	err := p.pgx.QueryRow(ctx, query, params.Username, params.Password).Scan(
 		&user.ID,
 		&user.Username,
+		&user.Status,
+		&user.TokenVersion,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
 	}
 
-	createWalletQuery := `
-		INSERT INTO shop.wallets (user_id)
-		VALUES ($1)
-	`
-
-	_, err = p.pgx.Exec(ctx, createWalletQuery, user.ID)
-	if err != nil {
-		return nil, fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
+	if err := createWallet(ctx, p.pgx, user.ID); err != nil {
+		return nil, err
 	}
 
 	return user, nil
 }
 
-func (p *Postgres) GetUserInfo(ctx context.Context, params model.GetUserInfoParams) (*model.UserInfo, error) {
-	var balance uint
-	err := p.pgx.QueryRow(ctx, `SELECT balance FROM shop.wallets WHERE user_id = $1`, params.ID).Scan(&balance)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, ErrNotFound
-		}
-		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+// createWallet создает кошелек нового юзера со стартовыми монетами.
+// Стартовые монеты тоже партия, чтобы сумма партий совпадала с балансом
+func createWallet(ctx context.Context, q execer, userID uint) error {
+	query := `
+		WITH wallet AS (
+			INSERT INTO shop.wallets (user_id)
+			VALUES ($1)
+			RETURNING user_id, balance
+		)
+		INSERT INTO shop.coin_lots (user_id, remaining)
+		SELECT user_id, balance FROM wallet WHERE balance > 0
+	`
+
+	if _, err := q.Exec(ctx, query, userID); err != nil {
+		return fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
 	}
+	return nil
+}
+
+// counterpartyColumn - имя контрагента в истории переводов, u - контрагент (LEFT JOIN).
+// Ожидает имя для системных операций в $3 и имя удаленного юзера в $4
+const counterpartyColumn = `CASE WHEN t.kind <> 'transfer' THEN $3 ELSE COALESCE(shop.display_username(u.username, u.status), $4) END`
+
+// maxExpiringEntries - сколько ближайших дат сгорания отдавать в /api/info
+const maxExpiringEntries = 10
 
+// GetUserInfo отправляет все запросы (баланс, инвентарь, полученные и отправленные монеты,
+// ближайшие сгорания монет) одним pgx.Batch и читает их результаты по порядку
+func (p *Postgres) GetUserInfo(ctx context.Context, params model.GetUserInfoParams) (*model.UserInfo, error) {
 	inventoryQuery := `
-		SELECT i.name, inv.quantity
+		SELECT i.name, ` + variantSKUColumn + `, v.size, v.color, inv.quantity
 		FROM shop.inventory inv
 		JOIN shop.items i ON inv.item_id = i.id
+		JOIN shop.item_variants v ON inv.variant_id = v.id
 		WHERE inv.user_id = $1
 	`
-	rows, err := p.pgx.Query(ctx, inventoryQuery, params.ID)
-	if err != nil {
-		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
-	}
-	defer rows.Close()
-
-	var items []model.Item
-	for rows.Next() {
-		var item model.Item
-		if err := rows.Scan(&item.Type, &item.Quantity); err != nil {
-			return nil, fmt.Errorf("%w: %w", ErrScanFailed, err)
-		}
-		items = append(items, item)
-	}
 
+	// $2 - фильтр по категории, пустая строка значит без фильтра.
+	// $3 - имя для системных операций (у них контрагента нет, см. model.SystemUser).
+	// $4 - имя удаленного контрагента (model.DeletedUser). Переводы удаленных юзеров остаются
+	// в истории, чтобы сходился баланс, но без имени: и для удаленных через DELETE /api/me,
+	// и для строк, удаленных из базы (контрагент NULL, но kind = transfer)
 	receivedQuery := `
-		SELECT u.username, t.amount
+		SELECT ` + counterpartyColumn + `, t.amount, 0, COALESCE(t.memo, ''), COALESCE(t.category, '')
 		FROM shop.transactions t
-		JOIN shop.users u ON t.from_user_id = u.id
+		LEFT JOIN shop.users u ON t.from_user_id = u.id
 		WHERE t.to_user_id = $1
+			AND ($2::text = '' OR t.category = $2)
 	`
-	rows, err = p.pgx.Query(ctx, receivedQuery, params.ID)
-	if err != nil {
-		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
-	}
-	defer rows.Close()
-
-	var received []model.ReceivedTransaction
-	for rows.Next() {
-		var trans model.ReceivedTransaction
-		if err := rows.Scan(&trans.User, &trans.Amount); err != nil {
-			return nil, fmt.Errorf("%w: %w", ErrScanFailed, err)
-		}
-		received = append(received, trans)
-	}
 
 	sentQuery := `
-		SELECT u.username, t.amount
+		SELECT ` + counterpartyColumn + `, t.amount, 0, COALESCE(t.memo, ''), COALESCE(t.category, '')
 		FROM shop.transactions t
-		JOIN shop.users u ON t.to_user_id = u.id
+		LEFT JOIN shop.users u ON t.to_user_id = u.id
 		WHERE t.from_user_id = $1
+			AND ($2::text = '' OR t.category = $2)
 	`
-	rows, err = p.pgx.Query(ctx, sentQuery, params.ID)
+
+	if params.HistoryMode == model.CoinHistoryModeAggregated {
+		// группируем по контрагенту прямо в базе, чтобы не гонять тысячи строк
+		receivedQuery = `
+			SELECT ` + counterpartyColumn + `, SUM(t.amount), COUNT(*), '', ''
+			FROM shop.transactions t
+			LEFT JOIN shop.users u ON t.from_user_id = u.id
+			WHERE t.to_user_id = $1
+				AND ($2::text = '' OR t.category = $2)
+			GROUP BY 1
+			ORDER BY 1
+		`
+		sentQuery = `
+			SELECT ` + counterpartyColumn + `, SUM(t.amount), COUNT(*), '', ''
+			FROM shop.transactions t
+			LEFT JOIN shop.users u ON t.to_user_id = u.id
+			WHERE t.from_user_id = $1
+				AND ($2::text = '' OR t.category = $2)
+			GROUP BY 1
+			ORDER BY 1
+		`
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue(`SELECT balance FROM shop.wallets WHERE user_id = $1`, params.ID)
+	batch.Queue(inventoryQuery, params.ID)
+	batch.Queue(receivedQuery, params.ID, params.Category, model.SystemUser, model.DeletedUser)
+	batch.Queue(sentQuery, params.ID, params.Category, model.SystemUser, model.DeletedUser)
+	queueGiftHistory(batch, params.ID)
+	if params.CoinLifetime > 0 {
+		// ближайшие сгорания, сгруппированные по дню
+		batch.Queue(`
+			SELECT SUM(remaining)::int, date_trunc('day', acquired_at + make_interval(secs => $2)) AS expires_at
+			FROM shop.coin_lots
+			WHERE user_id = $1 AND remaining > 0
+			GROUP BY expires_at
+			ORDER BY expires_at
+			LIMIT $3
+		`, params.ID, params.CoinLifetime.Seconds(), maxExpiringEntries)
+	}
+
+	br := p.pgx.SendBatch(ctx, batch)
+	defer br.Close()
+
+	var balance uint
+	err := br.QueryRow().Scan(&balance)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
 		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
 	}
-	defer rows.Close()
 
-	var sent []model.SentTransaction
-	for rows.Next() {
+	items, err := collectBatchRows(br, scanInventoryItem)
+	if err != nil {
+		return nil, err
+	}
+
+	received, err := collectBatchRows(br, func(rows pgx.Rows) (model.ReceivedTransaction, error) {
+		var trans model.ReceivedTransaction
+		err := rows.Scan(&trans.User, &trans.Amount, &trans.Count, &trans.Memo, &trans.Category)
+		return trans, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sent, err := collectBatchRows(br, func(rows pgx.Rows) (model.SentTransaction, error) {
 		var trans model.SentTransaction
-		if err := rows.Scan(&trans.User, &trans.Amount); err != nil {
-			return nil, fmt.Errorf("%w: %w", ErrScanFailed, err)
+		err := rows.Scan(&trans.User, &trans.Amount, &trans.Count, &trans.Memo, &trans.Category)
+		return trans, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	gifts, err := collectGiftHistory(br)
+	if err != nil {
+		return nil, err
+	}
+
+	var expiring []model.ExpiringCoins
+	if params.CoinLifetime > 0 {
+		expiring, err = collectBatchRows(br, func(rows pgx.Rows) (model.ExpiringCoins, error) {
+			var coins model.ExpiringCoins
+			err := rows.Scan(&coins.Amount, &coins.ExpiresAt)
+			return coins, err
+		})
+		if err != nil {
+			return nil, err
 		}
-		sent = append(sent, trans)
 	}
 
 	return &model.UserInfo{
@@ -139,22 +219,50 @@ func (p *Postgres) GetUserInfo(ctx context.Context, params model.GetUserInfoPara
 			Received: received,
 			Sent:     sent,
 		},
+		GiftHistory: gifts,
+		Expiring:    expiring,
 	}, nil
 }
 
-func (p *Postgres) SendCoin(ctx context.Context, params model.SendCoinParams) error {
-	tx, err := p.pgx.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable})
+func scanInventoryItem(rows pgx.Rows) (model.Item, error) {
+	var item model.Item
+	err := rows.Scan(&item.Type, &item.Variant, &item.Size, &item.Color, &item.Quantity)
+	return item, err
+}
+
+// collectBatchRows читает результат очередного запроса из батча и сразу закрывает rows,
+// иначе следующий результат батча прочитать не получится
+func collectBatchRows[T any](br pgx.BatchResults, scan func(rows pgx.Rows) (T, error)) ([]T, error) {
+	rows, err := br.Query()
 	if err != nil {
-		return fmt.Errorf("%w: %w", ErrFailedToBeginTx, err)
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
 	}
+	defer rows.Close()
 
-	defer func() {
+	var result []T
+	for rows.Next() {
+		v, err := scan(rows)
 		if err != nil {
-			tx.Rollback(ctx)
-		} else {
-			tx.Commit(ctx)
+			return nil, fmt.Errorf("%w: %w", ErrScanFailed, err)
 		}
-	}()
+		result = append(result, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRowsFailed, err)
+	}
+
+	return result, nil
+}
+
+// SendCoin переводит монеты и возвращает ID получателя
+func (p *Postgres) SendCoin(ctx context.Context, params model.SendCoinParams) (uint, error) {
+	tx, err := p.pgx.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable})
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrFailedToBeginTx, err)
+	}
+
+	defer tx.Rollback(ctx)
 
 	getUserIDQuery := `
 		SELECT id FROM shop.users WHERE username = $1
@@ -163,69 +271,128 @@ func (p *Postgres) SendCoin(ctx context.Context, params model.SendCoinParams) er
 	err = tx.QueryRow(ctx, getUserIDQuery, params.ToUser).Scan(&toUserID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return fmt.Errorf("recipient %w", ErrNotFound)
+			return 0, fmt.Errorf("recipient %w", ErrNotFound)
 		}
-		return fmt.Errorf("%w query %q: %w", ErrFailedToFindRecipient, getUserIDQuery, err)
+		return 0, fmt.Errorf("%w query %q: %w", ErrFailedToFindRecipient, getUserIDQuery, err)
 	}
 
+	err = transferTx(ctx, tx, transfer{
+		fromUserID: params.FromUser,
+		toUserID:   toUserID,
+		amount:     params.Amount,
+		memo:       params.Memo,
+		category:   params.Category,
+		limits:     params.Limits,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrFailedToCommitTx, err)
+	}
+
+	return toUserID, nil
+}
+
+// checkSpenderStatus проверяет, что юзер со статусом status может тратить монеты
+func checkSpenderStatus(status string) error {
+	switch status {
+	case model.UserStatusFrozen:
+		return ErrAccountFrozen
+	case model.UserStatusSuspended, model.UserStatusDeleted:
+		return ErrAccountSuspended
+	}
+	return nil
+}
+
+// transfer - параметры перевода внутри уже открытой транзакции
+type transfer struct {
+	fromUserID uint
+	toUserID   uint
+	amount     int
+	memo       string
+	category   string
+	limits     model.TransferLimits
+}
+
+// transferTx проверяет статус и баланс отправителя, списывает и начисляет монеты и сохраняет запись
+// в shop.transactions. Коммит остается на вызывающей стороне, поэтому функцию можно
+// переиспользовать везде, где перевод - часть более крупной операции
+func transferTx(ctx context.Context, tx pgx.Tx, t transfer) error {
 	lockBalanceQuery := `
-		SELECT balance FROM shop.wallets WHERE user_id = $1
+		SELECT w.balance, u.status
+		FROM shop.wallets w
+		JOIN shop.users u ON u.id = w.user_id
+		WHERE w.user_id = $1
 	`
-	var fromBalance int
-	err = tx.QueryRow(ctx, lockBalanceQuery, params.FromUser).Scan(&fromBalance)
+	var (
+		fromBalance int
+		fromStatus  string
+	)
+	err := tx.QueryRow(ctx, lockBalanceQuery, t.fromUserID).Scan(&fromBalance, &fromStatus)
 	if err != nil {
 		return fmt.Errorf("%w query %q: %w", ErrFailedToFetchBalance, lockBalanceQuery, err)
 	}
 
-	if fromBalance < params.Amount {
+	if err := checkSpenderStatus(fromStatus); err != nil {
+		return err
+	}
+
+	var toStatus string
+	err = tx.QueryRow(ctx, `SELECT status FROM shop.users WHERE id = $1`, t.toUserID).Scan(&toStatus)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToFindRecipient, err)
+	}
+
+	if toStatus != model.UserStatusActive {
+		return ErrRecipientNotActive
+	}
+
+	if fromBalance < t.amount {
 		return ErrInsufficientFunds
 	}
 
+	if err := checkTransferLimits(ctx, tx, t); err != nil {
+		return err
+	}
+
 	decreaseBalanceQuery := `
 		UPDATE shop.wallets SET balance = balance - $1 WHERE user_id = $2
 	`
-	_, err = tx.Exec(ctx, decreaseBalanceQuery, params.Amount, params.FromUser)
+	_, err = tx.Exec(ctx, decreaseBalanceQuery, t.amount, t.fromUserID)
 	if err != nil {
 		return fmt.Errorf("%w: %w", ErrFailedToDebitSender, err)
 	}
 
+	if err := consumeCoinLots(ctx, tx, t.fromUserID, t.amount); err != nil {
+		return err
+	}
+
 	increaseBalanceQuery := `
 		UPDATE shop.wallets SET balance = balance + $1 WHERE user_id = $2
 	`
-	_, err = tx.Exec(ctx, increaseBalanceQuery, params.Amount, toUserID)
+	_, err = tx.Exec(ctx, increaseBalanceQuery, t.amount, t.toUserID)
 	if err != nil {
 		return fmt.Errorf("%w: %w", ErrFailedToCreditRecipient, err)
 	}
 
+	if err := addCoinLot(ctx, tx, t.toUserID, t.amount); err != nil {
+		return err
+	}
+
 	insertTransactionQuery := `
-		INSERT INTO shop.transactions (from_user_id, to_user_id, amount)
-		VALUES ($1, $2, $3)
+		INSERT INTO shop.transactions (from_user_id, to_user_id, amount, memo, category)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''))
 	`
-	_, err = tx.Exec(ctx, insertTransactionQuery, params.FromUser, toUserID, params.Amount)
+	_, err = tx.Exec(ctx, insertTransactionQuery, t.fromUserID, t.toUserID, t.amount, t.memo, t.category)
 	if err != nil {
 		return fmt.Errorf("%w: %w", ErrFailedToSaveTransaction, err)
 	}
 
-	if err = tx.Commit(ctx); err != nil {
-		return fmt.Errorf("%w: %w", ErrFailedToCommitTx, err)
-	}
-
 	return nil
 }
 
-func (p *Postgres) GetUserBalance(ctx context.Context, userID uint) (uint, error) {
-	var balance uint
-	err := p.pgx.QueryRow(ctx, `SELECT balance FROM shop.wallets WHERE user_id = $1`, userID).Scan(&balance)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return 0, ErrNotFound
-		}
-		return 0, fmt.Errorf("%w: %w", ErrQueryFailed, err)
-	}
-
-	return balance, nil
-}
-
 func (p *Postgres) BuyItem(ctx context.Context, params model.BuyItemParams) error {
 	tx, err := p.pgx.Begin(ctx)
 	if err != nil {
@@ -233,32 +400,16 @@ func (p *Postgres) BuyItem(ctx context.Context, params model.BuyItemParams) erro
 	}
 	defer tx.Rollback(ctx)
 
-	var itemID, price uint
-	err = tx.QueryRow(ctx, `SELECT id, price FROM shop.items WHERE name = $1`, params.Item).Scan(&itemID, &price)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return ErrNotFound
-		}
-		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
-	}
-
-	if params.Balance < price {
-		return ErrInsufficientFunds
-	}
-
-	_, err = tx.Exec(ctx, `UPDATE shop.wallets SET balance = balance - $1 WHERE user_id = $2`, price, params.UserID)
+	line, err := findVariant(ctx, tx, params.Item, params.Variant)
 	if err != nil {
-		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+		return err
 	}
+	line.quantity = 1
 
-	_, err = tx.Exec(ctx, `
-		INSERT INTO shop.inventory (user_id, item_id, quantity)
-		VALUES ($1, $2, 1)
-		ON CONFLICT (user_id, item_id) DO UPDATE
-		SET quantity = shop.inventory.quantity + 1
-	`, params.UserID, itemID)
-	if err != nil {
-		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	// покупка по одной штуке - тоже заказ, чтобы офис-менеджер мог его выдать или отменить.
+	// Баланс проверяет placeOrder под блокировкой кошелька
+	if _, err := placeOrder(ctx, tx, params.UserID, 0, []orderLine{line}, 0); err != nil {
+		return err
 	}
 
 	if err := tx.Commit(ctx); err != nil {
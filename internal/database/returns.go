@@ -0,0 +1,319 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+// returnFilter - какие возвраты читает queryReturns. Нулевые поля не фильтруют, limit 0 - без ограничения
+type returnFilter struct {
+	id     uint
+	userID uint
+	status string
+	limit  int
+}
+
+// queryReturns читает возвраты, новые первыми
+func queryReturns(ctx context.Context, q querier, f returnFilter) ([]model.ReturnRequest, error) {
+	query := `
+		SELECT
+			r.id, r.user_id, shop.display_username(u.username, u.status),
+			COALESCE(i.name, ''), COALESCE(` + variantSKUColumn + `, ''),
+			r.quantity, r.amount, r.refund, r.reason, r.status,
+			COALESCE(r.review_note, ''), r.reviewed_by, r.reviewed_at, r.created_at
+		FROM shop.return_requests r
+		JOIN shop.users u ON u.id = r.user_id
+		LEFT JOIN shop.items i ON i.id = r.item_id
+		LEFT JOIN shop.item_variants v ON v.id = r.variant_id
+		WHERE ($1::int = 0 OR r.id = $1)
+			AND ($2::int = 0 OR r.user_id = $2)
+			AND ($3::text = '' OR r.status = $3)
+		ORDER BY r.created_at DESC, r.id DESC
+		LIMIT NULLIF($4::int, 0)
+	`
+
+	rows, err := q.Query(ctx, query, f.id, f.userID, f.status, f.limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
+	}
+
+	returns, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.ReturnRequest, error) {
+		var r model.ReturnRequest
+		err := row.Scan(
+			&r.ID, &r.UserID, &r.Username,
+			&r.Item, &r.Variant,
+			&r.Quantity, &r.Amount, &r.Refund, &r.Reason, &r.Status,
+			&r.ReviewNote, &r.ReviewedBy, &r.ReviewedAt, &r.CreatedAt,
+		)
+		return r, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrScanFailed, err)
+	}
+
+	return returns, nil
+}
+
+// CreateReturn создает возврат в статусе pending. Штуки берутся из строк выданных заказов,
+// начиная с последних, сумма возврата считается по их ценам. Заказы в подарок не возвращаются:
+// предметы у получателя, а платил отправитель. Штуки, которые уже
+// ждут возврата или возвращены, второй раз не берутся. Кошелек блокируется, чтобы
+// параллельные возвраты одного юзера не взяли одни и те же штуки
+func (p *Postgres) CreateReturn(ctx context.Context, params model.CreateReturnParams) (*model.ReturnRequest, error) {
+	tx, err := p.pgx.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToBeginTx, err)
+	}
+	defer tx.Rollback(ctx)
+
+	line, err := findVariant(ctx, tx, params.Item, params.Variant)
+	if err != nil {
+		return nil, err
+	}
+
+	var status string
+	err = tx.QueryRow(ctx, `SELECT status FROM shop.users WHERE id = $1`, params.UserID).Scan(&status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	// замороженному юзеру монеты не вернуть, как и не перевести
+	if err := checkSpenderStatus(status); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, `SELECT 1 FROM shop.wallets WHERE user_id = $1 FOR UPDATE`, params.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToFetchBalance, err)
+	}
+
+	// штуки из других pending возвратов и невыданных заказов тоже еще лежат в инвентаре
+	free, err := freeItems(ctx, tx, params.UserID, line.variantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if free < params.Quantity {
+		return nil, fmt.Errorf("%w: %d left", ErrNotEnoughItems, free)
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT oi.id, oi.quantity - COALESCE(SUM(ri.quantity) FILTER (WHERE r.status <> 'rejected'), 0)::int, oi.price
+		FROM shop.order_items oi
+		JOIN shop.orders o ON o.id = oi.order_id
+		LEFT JOIN shop.return_request_items ri ON ri.order_item_id = oi.id
+		LEFT JOIN shop.return_requests r ON r.id = ri.return_id
+		WHERE o.user_id = $1 AND o.recipient_id IS NULL AND oi.variant_id = $2 AND o.status = 'delivered'
+			AND ($3::float8 = 0 OR o.status_changed_at > NOW() - make_interval(secs => $3))
+		GROUP BY oi.id, o.status_changed_at
+		HAVING oi.quantity - COALESCE(SUM(ri.quantity) FILTER (WHERE r.status <> 'rejected'), 0) > 0
+		ORDER BY o.status_changed_at DESC, oi.id DESC
+	`, params.UserID, line.variantID, params.Window.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	var (
+		orderItemIDs []uint
+		quantities   []int
+		prices       []int
+		amount       int
+		left         = params.Quantity
+		id           uint
+		returnable   int
+		price        int
+	)
+	_, err = pgx.ForEachRow(rows, []any{&id, &returnable, &price}, func() error {
+		if left == 0 {
+			return nil
+		}
+		quantity := min(returnable, left)
+		left -= quantity
+		amount += quantity * price
+
+		orderItemIDs = append(orderItemIDs, id)
+		quantities = append(quantities, quantity)
+		prices = append(prices, price)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrScanFailed, err)
+	}
+
+	if left > 0 {
+		return nil, fmt.Errorf("%w: %d returnable", ErrNotReturnable, params.Quantity-left)
+	}
+
+	var returnID uint
+	err = tx.QueryRow(ctx, `
+		INSERT INTO shop.return_requests (user_id, item_id, variant_id, quantity, amount, reason)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, params.UserID, line.itemID, line.variantID, params.Quantity, amount, params.Reason).Scan(&returnID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO shop.return_request_items (return_id, order_item_id, quantity, price)
+		SELECT $1, unnest($2::int[]), unnest($3::int[]), unnest($4::int[])
+	`, returnID, orderItemIDs, quantities, prices)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	returns, err := queryReturns(ctx, tx, returnFilter{id: returnID})
+	if err != nil {
+		return nil, err
+	}
+	if len(returns) == 0 {
+		return nil, fmt.Errorf("return request %w", ErrNotFound)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToCommitTx, err)
+	}
+
+	return &returns[0], nil
+}
+
+// GetReturns возвращает возвраты юзера или, при UserID 0, всех юзеров
+func (p *Postgres) GetReturns(ctx context.Context, params model.GetReturnsParams) ([]model.ReturnRequest, error) {
+	return queryReturns(ctx, p.pgx, returnFilter{
+		userID: params.UserID,
+		status: params.Status,
+		limit:  params.Limit,
+	})
+}
+
+// ReviewReturn закрывает pending возврат решением админа. Одобрение в одной транзакции
+// забирает штуки из инвентаря, возвращает их на склады и зачисляет Refund новой партией монет
+func (p *Postgres) ReviewReturn(ctx context.Context, params model.ReviewReturnParams) (*model.ReturnRequest, error) {
+	tx, err := p.pgx.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToBeginTx, err)
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		userID            uint
+		itemID, variantID *uint
+		quantity, amount  int
+		status            string
+	)
+	err = tx.QueryRow(ctx, `
+		SELECT user_id, item_id, variant_id, quantity, amount, status
+		FROM shop.return_requests
+		WHERE id = $1
+		FOR UPDATE
+	`, params.ID).Scan(&userID, &itemID, &variantID, &quantity, &amount, &status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("return request %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	if status != model.ReturnPending {
+		return nil, ErrReturnNotPending
+	}
+
+	var refund *int
+	if params.Status == model.ReturnApproved {
+		r := amount
+		if params.Refund != nil {
+			if *params.Refund > amount {
+				return nil, fmt.Errorf("%w: max %d", ErrInvalidRefund, amount)
+			}
+			r = *params.Refund
+		}
+		refund = &r
+
+		if err := returnItems(ctx, tx, userID, itemID, variantID, quantity, r); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE shop.return_requests
+		SET status = $2, refund = $3, review_note = NULLIF($4, ''), reviewed_by = $5, reviewed_at = NOW()
+		WHERE id = $1
+	`, params.ID, params.Status, refund, params.Note, params.AdminID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	returns, err := queryReturns(ctx, tx, returnFilter{id: params.ID})
+	if err != nil {
+		return nil, err
+	}
+	if len(returns) == 0 {
+		return nil, fmt.Errorf("return request %w", ErrNotFound)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToCommitTx, err)
+	}
+
+	return &returns[0], nil
+}
+
+// returnItems забирает quantity штук варианта из инвентаря юзера, возвращает их на склады
+// и зачисляет refund монет. Если штук в инвентаре уже нет, ничего не меняется
+func returnItems(ctx context.Context, tx pgx.Tx, userID uint, itemID, variantID *uint, quantity, refund int) error {
+	// инвентарь юзера меняется только под блокировкой его кошелька
+	_, err := tx.Exec(ctx, `SELECT 1 FROM shop.wallets WHERE user_id = $1 FOR UPDATE`, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToFetchBalance, err)
+	}
+
+	// удаленный предмет или вариант пропал из инвентаря вместе со строкой
+	if itemID == nil || variantID == nil {
+		return fmt.Errorf("%w: item is deleted", ErrNotEnoughItems)
+	}
+
+	var have int
+	err = tx.QueryRow(ctx, `
+		SELECT COALESCE((SELECT quantity FROM shop.inventory WHERE user_id = $1 AND variant_id = $2), 0)
+	`, userID, *variantID).Scan(&have)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+	if have < quantity {
+		return fmt.Errorf("%w: %d of %d left", ErrNotEnoughItems, have, quantity)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE shop.inventory
+		SET quantity = quantity - $3
+		WHERE user_id = $1 AND variant_id = $2
+	`, userID, *variantID, quantity)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	_, err = tx.Exec(ctx, `DELETE FROM shop.inventory WHERE user_id = $1 AND quantity = 0`, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	if refund > 0 {
+		_, err = tx.Exec(ctx, `UPDATE shop.wallets SET balance = balance + $1 WHERE user_id = $2`, refund, userID)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrFailedToCreditRecipient, err)
+		}
+
+		if err := addCoinLot(ctx, tx, userID, refund); err != nil {
+			return err
+		}
+	}
+
+	return restockItems(ctx, tx, []uint{*itemID}, []uint{*variantID}, []int{quantity})
+}
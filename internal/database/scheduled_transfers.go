@@ -0,0 +1,336 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+const scheduledTransferColumns = `
+	st.id, st.from_user_id, st.to_user_id, shop.display_username(u.username, u.status), st.amount,
+	COALESCE(st.memo, ''), COALESCE(st.category, ''), COALESCE(st.schedule, ''),
+	st.next_run_at, st.status, st.consecutive_failures, st.created_at
+`
+
+func scanScheduledTransfer(row pgx.Row, st *model.ScheduledTransfer) error {
+	return row.Scan(
+		&st.ID,
+		&st.FromUserID,
+		&st.ToUserID,
+		&st.ToUser,
+		&st.Amount,
+		&st.Memo,
+		&st.Category,
+		&st.Schedule,
+		&st.NextRunAt,
+		&st.Status,
+		&st.ConsecutiveFailures,
+		&st.CreatedAt,
+	)
+}
+
+func (p *Postgres) CreateScheduledTransfer(ctx context.Context, params model.CreateScheduledTransferParams) (*model.ScheduledTransfer, error) {
+	var toUserID uint
+	err := p.pgx.QueryRow(ctx, `SELECT id FROM shop.users WHERE username = $1`, params.ToUser).Scan(&toUserID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("recipient %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("%w: %w", ErrFailedToFindRecipient, err)
+	}
+
+	// перевод самому себе по расписанию ничего не меняет, только расходует лимиты переводов
+	if toUserID == params.FromUser {
+		return nil, ErrSelfScheduledTransfer
+	}
+
+	query := `
+		WITH st AS (
+			INSERT INTO shop.scheduled_transfers (from_user_id, to_user_id, amount, memo, category, schedule, next_run_at)
+			VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), $7)
+			RETURNING *
+		)
+		SELECT ` + scheduledTransferColumns + `
+		FROM st
+		JOIN shop.users u ON st.to_user_id = u.id
+	`
+
+	st := &model.ScheduledTransfer{}
+	row := p.pgx.QueryRow(ctx, query,
+		params.FromUser,
+		toUserID,
+		params.Amount,
+		params.Memo,
+		params.Category,
+		params.Schedule,
+		params.RunAt,
+	)
+	if err := scanScheduledTransfer(row, st); err != nil {
+		return nil, fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
+	}
+
+	return st, nil
+}
+
+func (p *Postgres) GetScheduledTransfers(ctx context.Context, params model.GetScheduledTransfersParams) ([]model.ScheduledTransfer, error) {
+	query := `
+		SELECT ` + scheduledTransferColumns + `
+		FROM shop.scheduled_transfers st
+		JOIN shop.users u ON st.to_user_id = u.id
+		WHERE st.from_user_id = $1
+		ORDER BY st.created_at DESC
+	`
+
+	rows, err := p.pgx.Query(ctx, query, params.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+	defer rows.Close()
+
+	var transfers []model.ScheduledTransfer
+	for rows.Next() {
+		var st model.ScheduledTransfer
+		if err := scanScheduledTransfer(rows, &st); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrScanFailed, err)
+		}
+		transfers = append(transfers, st)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRowsFailed, err)
+	}
+
+	return transfers, nil
+}
+
+func (p *Postgres) GetScheduledTransferRuns(ctx context.Context, params model.ScheduledTransferIDParams) ([]model.ScheduledTransferRun, error) {
+	var exists bool
+	err := p.pgx.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM shop.scheduled_transfers WHERE id = $1 AND from_user_id = $2)
+	`, params.ID, params.UserID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("scheduled transfer %w", ErrNotFound)
+	}
+
+	rows, err := p.pgx.Query(ctx, `
+		SELECT scheduled_for, executed_at, status, COALESCE(error, '')
+		FROM shop.scheduled_transfer_runs
+		WHERE scheduled_transfer_id = $1
+		ORDER BY scheduled_for DESC
+	`, params.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+	defer rows.Close()
+
+	var runs []model.ScheduledTransferRun
+	for rows.Next() {
+		var run model.ScheduledTransferRun
+		if err := rows.Scan(&run.ScheduledFor, &run.ExecutedAt, &run.Status, &run.Error); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrScanFailed, err)
+		}
+		runs = append(runs, run)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRowsFailed, err)
+	}
+
+	return runs, nil
+}
+
+func (p *Postgres) CancelScheduledTransfer(ctx context.Context, params model.ScheduledTransferIDParams) error {
+	var status string
+	err := p.pgx.QueryRow(ctx, `
+		SELECT status FROM shop.scheduled_transfers WHERE id = $1 AND from_user_id = $2
+	`, params.ID, params.UserID).Scan(&status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("scheduled transfer %w", ErrNotFound)
+		}
+		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	// Если прямо сейчас перевод выполняется, то он завершится, а следующие запуски уже не начнутся
+	tag, err := p.pgx.Exec(ctx, `
+		UPDATE shop.scheduled_transfers
+		SET status = 'cancelled', next_run_at = NULL
+		WHERE id = $1 AND status = 'active'
+	`, params.ID)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: %s", ErrNotCancellable, status)
+	}
+
+	return nil
+}
+
+// ClaimScheduledTransfers берет в аренду переводы, время которых пришло.
+// FOR UPDATE SKIP LOCKED позволяет нескольким инстансам забирать разные переводы,
+// не блокируя друг друга
+func (p *Postgres) ClaimScheduledTransfers(ctx context.Context, params model.ClaimScheduledTransfersParams) ([]model.ScheduledTransfer, error) {
+	query := `
+		WITH st AS (
+			UPDATE shop.scheduled_transfers
+			SET lease_owner = $1, lease_until = NOW() + make_interval(secs => $2)
+			WHERE id IN (
+				SELECT id FROM shop.scheduled_transfers
+				WHERE status = 'active'
+					AND next_run_at <= NOW()
+					AND (lease_until IS NULL OR lease_until < NOW())
+				ORDER BY next_run_at
+				LIMIT $3
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING *
+		)
+		SELECT ` + scheduledTransferColumns + `
+		FROM st
+		JOIN shop.users u ON st.to_user_id = u.id
+	`
+
+	rows, err := p.pgx.Query(ctx, query, params.Owner, params.Lease.Seconds(), params.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+	defer rows.Close()
+
+	var transfers []model.ScheduledTransfer
+	for rows.Next() {
+		var st model.ScheduledTransfer
+		if err := scanScheduledTransfer(rows, &st); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrScanFailed, err)
+		}
+		transfers = append(transfers, st)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRowsFailed, err)
+	}
+
+	return transfers, nil
+}
+
+// ExecuteScheduledTransfer выполняет один запуск: проверяет аренду, переводит монеты
+// через transferTx, пишет запуск в историю и сдвигает next_run_at - все в одной транзакции.
+// Возвращает ID получателя
+func (p *Postgres) ExecuteScheduledTransfer(ctx context.Context, params model.ExecuteScheduledTransferParams) (uint, error) {
+	tx, err := p.pgx.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable})
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrFailedToBeginTx, err)
+	}
+	defer tx.Rollback(ctx)
+
+	t, err := lockLeasedScheduledTransfer(ctx, tx, params.ID, params.Owner)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := insertScheduledRun(ctx, tx, params.ID, params.ScheduledFor, model.ScheduledRunSuccess, ""); err != nil {
+		return 0, err
+	}
+
+	t.limits = params.Limits
+	if err := transferTx(ctx, tx, t); err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE shop.scheduled_transfers
+		SET next_run_at = $2,
+			status = CASE WHEN $2::timestamptz IS NULL THEN 'completed' ELSE status END,
+			consecutive_failures = 0,
+			lease_owner = NULL,
+			lease_until = NULL
+		WHERE id = $1
+	`, params.ID, params.NextRunAt)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrFailedToCommitTx, err)
+	}
+
+	return t.toUserID, nil
+}
+
+// RecordScheduledTransferFailure пишет неудачный запуск и освобождает аренду
+func (p *Postgres) RecordScheduledTransferFailure(ctx context.Context, params model.RecordScheduledTransferFailureParams) error {
+	tx, err := p.pgx.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToBeginTx, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := lockLeasedScheduledTransfer(ctx, tx, params.ID, params.Owner); err != nil {
+		return err
+	}
+
+	if err := insertScheduledRun(ctx, tx, params.ID, params.ScheduledFor, model.ScheduledRunFailed, params.Error); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE shop.scheduled_transfers
+		SET next_run_at = $2,
+			status = $3,
+			consecutive_failures = $4,
+			lease_owner = NULL,
+			lease_until = NULL
+		WHERE id = $1
+	`, params.ID, params.NextRunAt, params.Status, params.Failures)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToCommitTx, err)
+	}
+
+	return nil
+}
+
+// lockLeasedScheduledTransfer блокирует перевод, только если аренда все еще у нас
+func lockLeasedScheduledTransfer(ctx context.Context, tx pgx.Tx, id uint, owner string) (transfer, error) {
+	var t transfer
+	err := tx.QueryRow(ctx, `
+		SELECT from_user_id, to_user_id, amount, COALESCE(memo, ''), COALESCE(category, '')
+		FROM shop.scheduled_transfers
+		WHERE id = $1 AND status = 'active' AND lease_owner = $2 AND lease_until > NOW()
+		FOR UPDATE
+	`, id, owner).Scan(&t.fromUserID, &t.toUserID, &t.amount, &t.memo, &t.category)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return t, ErrLeaseLost
+		}
+		return t, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	return t, nil
+}
+
+func insertScheduledRun(ctx context.Context, tx pgx.Tx, id uint, scheduledFor time.Time, status, runErr string) error {
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO shop.scheduled_transfer_runs (scheduled_transfer_id, scheduled_for, status, error)
+		VALUES ($1, $2, $3, NULLIF($4, ''))
+		ON CONFLICT (scheduled_transfer_id, scheduled_for) DO NOTHING
+	`, id, scheduledFor, status, runErr)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrAlreadyExecuted
+	}
+
+	return nil
+}
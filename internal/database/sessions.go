@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+const sessionColumns = `id, user_agent, ip, created_at, last_seen_at, expires_at`
+
+func scanSession(row pgx.Row, s *model.Session) error {
+	return row.Scan(&s.ID, &s.UserAgent, &s.IP, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt)
+}
+
+// CreateSession сохраняет сессию под новый токен. Заодно удаляются истекшие сессии юзера:
+// их токены уже не пройдут проверку exp, и хранить их незачем
+func (p *Postgres) CreateSession(ctx context.Context, params model.CreateSessionParams) (*model.Session, error) {
+	query := `
+		WITH expired AS (
+			DELETE FROM shop.sessions
+			WHERE user_id = $1 AND expires_at < NOW()
+		)
+		INSERT INTO shop.sessions (user_id, user_agent, ip, expires_at)
+		VALUES ($1, $2, $3, NOW() + make_interval(secs => $4))
+		RETURNING ` + sessionColumns
+
+	session := &model.Session{}
+	row := p.pgx.QueryRow(ctx, query, params.UserID, params.UserAgent, params.IP, params.TTL.Seconds())
+	if err := scanSession(row, session); err != nil {
+		return nil, fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
+	}
+
+	return session, nil
+}
+
+// GetSessions возвращает действующие сессии юзера, новые первыми
+func (p *Postgres) GetSessions(ctx context.Context, userID uint) ([]model.Session, error) {
+	rows, err := p.pgx.Query(ctx, `
+		SELECT `+sessionColumns+`
+		FROM shop.sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY created_at DESC, id DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	sessions, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.Session, error) {
+		var s model.Session
+		err := scanSession(row, &s)
+		return s, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrScanFailed, err)
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession отзывает сессию юзера, ее токен перестает приниматься сразу.
+// Чужая, отозванная или истекшая сессия - ErrNotFound
+func (p *Postgres) RevokeSession(ctx context.Context, params model.SessionIDParams) error {
+	query := `
+		UPDATE shop.sessions
+		SET revoked_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > NOW()
+	`
+
+	tag, err := p.pgx.Exec(ctx, query, params.ID, params.UserID)
+	if err != nil {
+		return fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("session %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+// deleteSessions удаляет все сессии юзера, их токены после этого не принимаются
+func deleteSessions(ctx context.Context, q execer, userID uint) error {
+	_, err := q.Exec(ctx, `DELETE FROM shop.sessions WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+	return nil
+}
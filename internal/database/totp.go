@@ -0,0 +1,186 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateTOTP сохраняет новый секрет, пока 2FA не подтверждена. Неподтвержденный секрет
+// перезаписывается (юзер начал подключение заново), подтвержденный - нет
+func (p *Postgres) CreateTOTP(ctx context.Context, params model.CreateTOTPParams) error {
+	query := `
+		INSERT INTO shop.user_totp (user_id, secret_encrypted)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret_encrypted = EXCLUDED.secret_encrypted,
+			last_step = 0,
+			failed_attempts = 0,
+			locked_until = NULL,
+			created_at = NOW()
+		WHERE shop.user_totp.confirmed_at IS NULL
+	`
+
+	tag, err := p.pgx.Exec(ctx, query, params.UserID, params.SecretEncrypted)
+	if err != nil {
+		return fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrTOTPAlreadyEnabled
+	}
+
+	return nil
+}
+
+func (p *Postgres) GetTOTP(ctx context.Context, userID uint) (*model.TOTP, error) {
+	totp := &model.TOTP{UserID: userID}
+
+	err := p.pgx.QueryRow(ctx, `
+		SELECT secret_encrypted, confirmed_at IS NOT NULL, last_step,
+			CASE WHEN locked_until > NOW() THEN locked_until END
+		FROM shop.user_totp
+		WHERE user_id = $1
+	`, userID).Scan(&totp.SecretEncrypted, &totp.Confirmed, &totp.LastStep, &totp.LockedUntil)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	return totp, nil
+}
+
+// ConfirmTOTP включает 2FA и заменяет коды восстановления на новые
+func (p *Postgres) ConfirmTOTP(ctx context.Context, params model.ConfirmTOTPParams) error {
+	tx, err := p.pgx.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToBeginTx, err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE shop.user_totp
+		SET confirmed_at = NOW(), last_step = $2, failed_attempts = 0, locked_until = NULL
+		WHERE user_id = $1 AND confirmed_at IS NULL
+	`, params.UserID, params.Step)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	_, err = tx.Exec(ctx, `DELETE FROM shop.totp_recovery_codes WHERE user_id = $1`, params.UserID)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO shop.totp_recovery_codes (user_id, code_hash)
+		SELECT $1, unnest($2::text[])
+	`, params.UserID, params.RecoveryCodeHashes)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToCommitTx, err)
+	}
+
+	return nil
+}
+
+// UseTOTPStep запоминает шаг принятого кода и сбрасывает счетчик неудач.
+// Код того же или более раннего шага уже не примется
+func (p *Postgres) UseTOTPStep(ctx context.Context, userID uint, step int64) error {
+	tag, err := p.pgx.Exec(ctx, `
+		UPDATE shop.user_totp
+		SET last_step = $2, failed_attempts = 0, locked_until = NULL
+		WHERE user_id = $1 AND confirmed_at IS NOT NULL AND last_step < $2
+	`, userID, step)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrTOTPCodeUsed
+	}
+
+	return nil
+}
+
+// UseRecoveryCode гасит код восстановления и сбрасывает счетчик неудач
+func (p *Postgres) UseRecoveryCode(ctx context.Context, userID uint, codeHash string) error {
+	tag, err := p.pgx.Exec(ctx, `
+		WITH used AS (
+			UPDATE shop.totp_recovery_codes
+			SET used_at = NOW()
+			WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+			RETURNING user_id
+		)
+		UPDATE shop.user_totp t
+		SET failed_attempts = 0, locked_until = NULL
+		FROM used
+		WHERE t.user_id = used.user_id AND t.confirmed_at IS NOT NULL
+	`, userID, codeHash)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrInvalidRecoveryCode
+	}
+
+	return nil
+}
+
+// RecordTOTPFailure считает неверный код. На MaxFailures подряд проверка блокируется
+// на LockFor, а счетчик начинается заново
+func (p *Postgres) RecordTOTPFailure(ctx context.Context, params model.TOTPFailureParams) error {
+	_, err := p.pgx.Exec(ctx, `
+		UPDATE shop.user_totp
+		SET failed_attempts = CASE WHEN failed_attempts + 1 >= $2 THEN 0 ELSE failed_attempts + 1 END,
+			locked_until = CASE WHEN failed_attempts + 1 >= $2 THEN NOW() + make_interval(secs => $3) ELSE locked_until END
+		WHERE user_id = $1
+	`, params.UserID, params.MaxFailures, params.LockFor.Seconds())
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	return nil
+}
+
+// DeleteTOTP выключает 2FA вместе с кодами восстановления
+func (p *Postgres) DeleteTOTP(ctx context.Context, userID uint) error {
+	tx, err := p.pgx.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToBeginTx, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := deleteTOTP(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToCommitTx, err)
+	}
+
+	return nil
+}
+
+func deleteTOTP(ctx context.Context, q execer, userID uint) error {
+	_, err := q.Exec(ctx, `DELETE FROM shop.totp_recovery_codes WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	_, err = q.Exec(ctx, `DELETE FROM shop.user_totp WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+// checkTransferLimits проверяет лимиты отправителя с учетом переопределения для его роли.
+// Считаются только переводы между юзерами, в том числе уже сделанные в этой транзакции
+// (важно для пачки переводов). Транзакции переводов serializable, поэтому два параллельных
+// перевода одного отправителя не смогут вдвоем проскочить лимит
+func checkTransferLimits(ctx context.Context, tx pgx.Tx, t transfer) error {
+	query := `
+		WITH limits AS (
+			SELECT
+				COALESCE(o.max_single_transfer, $3) AS max_single,
+				COALESCE(o.daily_total, $4) AS daily_total,
+				COALESCE(o.weekly_total, $5) AS weekly_total,
+				COALESCE(o.daily_recipients, $6) AS daily_recipients
+			FROM shop.users u
+			LEFT JOIN shop.role_transfer_limits o ON o.role = u.role
+			WHERE u.id = $1
+		),
+		sent AS (
+			SELECT
+				COALESCE(SUM(amount) FILTER (WHERE created_at > NOW() - INTERVAL '1 day'), 0) AS daily_total,
+				COALESCE(SUM(amount), 0) AS weekly_total,
+				COUNT(DISTINCT to_user_id) FILTER (WHERE created_at > NOW() - INTERVAL '1 day') AS daily_recipients,
+				COALESCE(BOOL_OR(to_user_id = $2) FILTER (WHERE created_at > NOW() - INTERVAL '1 day'), false) AS known_recipient
+			FROM shop.transactions
+			WHERE from_user_id = $1
+				AND kind = 'transfer'
+				AND created_at > NOW() - INTERVAL '7 days'
+		)
+		SELECT l.max_single, l.daily_total, l.weekly_total, l.daily_recipients,
+			s.daily_total, s.weekly_total, s.daily_recipients, s.known_recipient
+		FROM limits l, sent s
+	`
+
+	var (
+		limits         model.TransferLimits
+		dailySent      int
+		weeklySent     int
+		recipients     int
+		knownRecipient bool
+	)
+	err := tx.QueryRow(ctx, query,
+		t.fromUserID,
+		t.toUserID,
+		t.limits.MaxSingle,
+		t.limits.DailyTotal,
+		t.limits.WeeklyTotal,
+		t.limits.DailyRecipients,
+	).Scan(
+		&limits.MaxSingle,
+		&limits.DailyTotal,
+		&limits.WeeklyTotal,
+		&limits.DailyRecipients,
+		&dailySent,
+		&weeklySent,
+		&recipients,
+		&knownRecipient,
+	)
+	if err != nil {
+		return fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
+	}
+
+	switch {
+	case limits.MaxSingle > 0 && t.amount > limits.MaxSingle:
+		return fmt.Errorf("%w (%d)", ErrSingleTransferLimit, limits.MaxSingle)
+	case limits.DailyTotal > 0 && dailySent+t.amount > limits.DailyTotal:
+		return fmt.Errorf("%w (%d)", ErrDailyTransferLimit, limits.DailyTotal)
+	case limits.WeeklyTotal > 0 && weeklySent+t.amount > limits.WeeklyTotal:
+		return fmt.Errorf("%w (%d)", ErrWeeklyTransferLimit, limits.WeeklyTotal)
+	case limits.DailyRecipients > 0 && !knownRecipient && recipients >= limits.DailyRecipients:
+		return fmt.Errorf("%w (%d)", ErrDailyRecipientsLimit, limits.DailyRecipients)
+	}
+
+	return nil
+}
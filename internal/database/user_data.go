@@ -0,0 +1,300 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+// deletedUsernamePrefix - префикс имени удаленного юзера, к нему дописывается ID.
+// С @ имя не пройдет валидацию логина, поэтому под ним никто не зарегистрируется
+const deletedUsernamePrefix = "@deleted:"
+
+// GetUser возвращает юзера по ID вместе с хэшем пароля
+func (p *Postgres) GetUser(ctx context.Context, userID uint) (*model.User, error) {
+	user := &model.User{}
+
+	err := p.pgx.QueryRow(ctx, `
+		SELECT id, username, password_hash, status, token_version
+		FROM shop.users
+		WHERE id = $1
+	`, userID).Scan(&user.ID, &user.Username, &user.Password, &user.Status, &user.TokenVersion)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	return user, nil
+}
+
+// ExportUserData собирает данные юзера для выгрузки одним батчем, как GetUserInfo.
+// Запросы монет и отложенные переводы сервис добавляет сам через существующие методы
+func (p *Postgres) ExportUserData(ctx context.Context, userID uint) (*model.UserExport, error) {
+	// контрагент - другая сторона перевода, для системных операций его нет.
+	// $3 и $4 нужны для counterpartyColumn
+	transfersQuery := `
+		SELECT
+			CASE WHEN t.to_user_id = $1 THEN $2 ELSE $5 END,
+			` + counterpartyColumn + `,
+			t.amount, COALESCE(t.memo, ''), COALESCE(t.category, ''), t.kind, t.created_at
+		FROM shop.transactions t
+		LEFT JOIN shop.users u ON u.id = CASE WHEN t.to_user_id = $1 THEN t.from_user_id ELSE t.to_user_id END
+		WHERE t.from_user_id = $1 OR t.to_user_id = $1
+		ORDER BY t.created_at, t.id
+	`
+
+	batch := &pgx.Batch{}
+	batch.Queue(`
+		SELECT u.id, u.username, u.role, u.status, u.created_at, w.balance
+		FROM shop.users u
+		JOIN shop.wallets w ON w.user_id = u.id
+		WHERE u.id = $1
+	`, userID)
+	batch.Queue(`
+		SELECT remaining, acquired_at
+		FROM shop.coin_lots
+		WHERE user_id = $1 AND remaining > 0
+		ORDER BY acquired_at, id
+	`, userID)
+	batch.Queue(`
+		SELECT i.name, `+variantSKUColumn+`, v.size, v.color, inv.quantity
+		FROM shop.inventory inv
+		JOIN shop.items i ON inv.item_id = i.id
+		JOIN shop.item_variants v ON inv.variant_id = v.id
+		WHERE inv.user_id = $1
+	`, userID)
+	batch.Queue(transfersQuery,
+		userID,
+		model.TransferDirectionIn,
+		model.SystemUser,
+		model.DeletedUser,
+		model.TransferDirectionOut,
+	)
+	batch.Queue(`
+		SELECT COALESCE(i.name, ''), COALESCE(`+variantSKUColumn+`, ''), p.price, p.created_at
+		FROM shop.purchases p
+		LEFT JOIN shop.items i ON p.item_id = i.id
+		LEFT JOIN shop.item_variants v ON p.variant_id = v.id
+		WHERE p.user_id = $1
+		ORDER BY p.created_at, p.id
+	`, userID)
+	batch.Queue(`
+		SELECT o.id, o.total, o.status, o.status_changed_at, o.created_at, COALESCE(i.name, ''), COALESCE(`+variantSKUColumn+`, ''), oi.quantity, oi.price
+		FROM shop.orders o
+		JOIN shop.order_items oi ON oi.order_id = o.id
+		LEFT JOIN shop.items i ON oi.item_id = i.id
+		LEFT JOIN shop.item_variants v ON oi.variant_id = v.id
+		WHERE o.user_id = $1
+		ORDER BY o.created_at, o.id, oi.id
+	`, userID)
+	queueGiftHistory(batch, userID)
+	batch.Queue(`
+		SELECT issuer, subject, email, created_at, last_login_at
+		FROM shop.user_identities
+		WHERE user_id = $1
+		ORDER BY id
+	`, userID)
+	batch.Queue(`
+		SELECT `+sessionColumns+`
+		FROM shop.sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY created_at, id
+	`, userID)
+
+	br := p.pgx.SendBatch(ctx, batch)
+	defer br.Close()
+
+	export := &model.UserExport{}
+
+	profile := &export.Profile
+	err := br.QueryRow().Scan(
+		&profile.ID,
+		&profile.Username,
+		&profile.Role,
+		&profile.Status,
+		&profile.CreatedAt,
+		&export.Wallet.Balance,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	export.Wallet.Lots, err = collectBatchRows(br, func(rows pgx.Rows) (model.CoinLot, error) {
+		var lot model.CoinLot
+		err := rows.Scan(&lot.Remaining, &lot.AcquiredAt)
+		return lot, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	export.Inventory, err = collectBatchRows(br, scanInventoryItem)
+	if err != nil {
+		return nil, err
+	}
+
+	export.Transfers, err = collectBatchRows(br, func(rows pgx.Rows) (model.TransferRecord, error) {
+		var t model.TransferRecord
+		err := rows.Scan(&t.Direction, &t.Counterparty, &t.Amount, &t.Memo, &t.Category, &t.Kind, &t.CreatedAt)
+		return t, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	export.Purchases, err = collectBatchRows(br, func(rows pgx.Rows) (model.Purchase, error) {
+		var purchase model.Purchase
+		err := rows.Scan(&purchase.Item, &purchase.Variant, &purchase.Price, &purchase.CreatedAt)
+		return purchase, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	orderLines, err := collectBatchRows(br, func(rows pgx.Rows) (model.Order, error) {
+		var order model.Order
+		var line model.OrderLine
+		err := rows.Scan(&order.ID, &order.Total, &order.Status, &order.StatusChangedAt, &order.CreatedAt, &line.Item, &line.Variant, &line.Quantity, &line.Price)
+		order.Lines = []model.OrderLine{line}
+		return order, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	export.Orders = groupOrderLines(orderLines)
+
+	export.Gifts, err = collectGiftHistory(br)
+	if err != nil {
+		return nil, err
+	}
+
+	export.Identities, err = collectBatchRows(br, func(rows pgx.Rows) (model.LinkedIdentity, error) {
+		var identity model.LinkedIdentity
+		err := rows.Scan(&identity.Issuer, &identity.Subject, &identity.Email, &identity.CreatedAt, &identity.LastLoginAt)
+		return identity, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	export.Sessions, err = collectBatchRows(br, func(rows pgx.Rows) (model.Session, error) {
+		var session model.Session
+		err := scanSession(rows, &session)
+		return session, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// DeleteUser анонимизирует юзера вместо удаления строки, чтобы не ломать историю переводов:
+// статус становится deleted (с записью в журнал), имя заменяется на deletedUsernamePrefix + ID,
+// хэш пароля, привязки к SSO, 2FA и сессии стираются. Незакрытые запросы монет и отложенные переводы в обе стороны отменяются.
+// Кошелек, партии монет, инвентарь и покупки остаются за анонимным ID, чтобы сходились балансы.
+// Возвращает ID всех, с кем у юзера были переводы и подарки: у них в истории поменялось имя контрагента
+func (p *Postgres) DeleteUser(ctx context.Context, userID uint) ([]uint, error) {
+	tx, err := p.pgx.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToBeginTx, err)
+	}
+	defer tx.Rollback(ctx)
+
+	var status string
+	err = tx.QueryRow(ctx, `SELECT status FROM shop.users WHERE id = $1`, userID).Scan(&status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+	if status == model.UserStatusDeleted {
+		return nil, ErrNotFound
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE shop.payment_requests
+		SET status = 'cancelled', resolved_at = NOW()
+		WHERE (from_user_id = $1 OR to_user_id = $1) AND status = 'pending'
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE shop.scheduled_transfers
+		SET status = 'cancelled', next_run_at = NULL
+		WHERE (from_user_id = $1 OR to_user_id = $1) AND status = 'active'
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	_, err = changeUserStatus(ctx, tx, statusChange{
+		userIDs: []uint{userID},
+		to:      model.UserStatusDeleted,
+		reason:  "deleted by user",
+		by:      &userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// email из SSO - тоже персональные данные. Без привязки юзер сможет
+	// снова войти через SSO, но уже новым аккаунтом
+	_, err = tx.Exec(ctx, `DELETE FROM shop.user_identities WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	if err := deleteTOTP(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+
+	// user agent и IP - тоже персональные данные
+	if err := deleteSessions(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE shop.users
+		SET username = $2::text || id, password_hash = ''
+		WHERE id = $1
+	`, userID, deletedUsernamePrefix)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT to_user_id FROM shop.transactions WHERE from_user_id = $1 AND to_user_id IS NOT NULL
+		UNION
+		SELECT from_user_id FROM shop.transactions WHERE to_user_id = $1 AND from_user_id IS NOT NULL
+		UNION
+		SELECT to_user_id FROM shop.gifts WHERE from_user_id = $1 AND to_user_id IS NOT NULL
+		UNION
+		SELECT from_user_id FROM shop.gifts WHERE to_user_id = $1 AND from_user_id IS NOT NULL
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+
+	counterparties, err := pgx.CollectRows(rows, pgx.RowTo[uint])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrScanFailed, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToCommitTx, err)
+	}
+
+	return counterparties, nil
+}
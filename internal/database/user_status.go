@@ -0,0 +1,162 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/jackc/pgx/v5"
+)
+
+// statusChange - смена статуса нескольких юзеров с записью в журнал
+type statusChange struct {
+	userIDs []uint
+	from    []string // меняем только юзеров в этих статусах, пусто - в любом
+	to      string
+	reason  string
+	by      *uint // nil - система
+}
+
+// changeUserStatus меняет статус и пишет каждую смену в shop.user_status_audit.
+// Юзеры, у которых статус уже такой, пропускаются. Возвращает ID тех, кому статус сменили
+func changeUserStatus(ctx context.Context, tx pgx.Tx, c statusChange) ([]uint, error) {
+	query := `
+		WITH old AS (
+			SELECT id, status
+			FROM shop.users
+			WHERE id = ANY($1)
+				AND status <> $2
+				AND (cardinality($5::text[]) = 0 OR status = ANY($5))
+			FOR UPDATE
+		),
+		changed AS (
+			UPDATE shop.users u
+			SET status = $2, status_reason = NULLIF($3, ''), status_changed_at = NOW()
+			FROM old
+			WHERE u.id = old.id
+			RETURNING u.id, old.status AS old_status
+		),
+		audit AS (
+			INSERT INTO shop.user_status_audit (user_id, old_status, new_status, reason, changed_by)
+			SELECT id, old_status, $2, NULLIF($3, ''), $4
+			FROM changed
+		)
+		SELECT id FROM changed
+	`
+
+	from := c.from
+	if from == nil {
+		from = []string{}
+	}
+
+	rows, err := tx.Query(ctx, query, c.userIDs, c.to, c.reason, c.by, from)
+	if err != nil {
+		return nil, fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[uint])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrScanFailed, err)
+	}
+
+	return ids, nil
+}
+
+// SetUserStatus меняет статус юзера от имени админа
+func (p *Postgres) SetUserStatus(ctx context.Context, params model.SetUserStatusParams) error {
+	tx, err := p.pgx.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToBeginTx, err)
+	}
+	defer tx.Rollback(ctx)
+
+	var status string
+	err = tx.QueryRow(ctx, `SELECT status FROM shop.users WHERE id = $1`, params.UserID).Scan(&status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("%w: %w", ErrQueryFailed, err)
+	}
+	// удаленный юзер анонимизирован, вернуть его нельзя
+	if status == model.UserStatusDeleted {
+		return ErrNotFound
+	}
+
+	_, err = changeUserStatus(ctx, tx, statusChange{
+		userIDs: []uint{params.UserID},
+		to:      params.Status,
+		reason:  params.Reason,
+		by:      &params.AdminID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToCommitTx, err)
+	}
+
+	return nil
+}
+
+// GetUserAccess возвращает текущий статус юзера, версию его токенов и отозвана ли сессия токена,
+// по ним AuthMiddleware решает, пускать ли запрос. Все одним запросом по первичным ключам,
+// last_seen_at сессии обновляется в нем же, но не чаще раза в минуту.
+// sessionID 0 - токен выдан до появления сессий, сессия не проверяется
+func (p *Postgres) GetUserAccess(ctx context.Context, userID, sessionID uint) (*model.UserAccess, error) {
+	query := `
+		WITH session AS (
+			SELECT id, last_seen_at
+			FROM shop.sessions
+			WHERE id = $2 AND user_id = $1 AND revoked_at IS NULL
+		),
+		touched AS (
+			UPDATE shop.sessions s
+			SET last_seen_at = NOW()
+			FROM session
+			WHERE s.id = session.id AND session.last_seen_at < NOW() - INTERVAL '1 minute'
+		)
+		SELECT u.status, u.token_version, $2::int <> 0 AND NOT EXISTS (SELECT 1 FROM session)
+		FROM shop.users u
+		WHERE u.id = $1
+	`
+
+	access := &model.UserAccess{}
+	err := p.pgx.QueryRow(ctx, query, userID, sessionID).Scan(&access.Status, &access.TokenVersion, &access.SessionRevoked)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
+	}
+
+	return access, nil
+}
+
+// GetUserStatusHistory возвращает журнал смены статусов юзера, новые записи первыми
+func (p *Postgres) GetUserStatusHistory(ctx context.Context, userID uint) ([]model.UserStatusChange, error) {
+	query := `
+		SELECT id, old_status, new_status, COALESCE(reason, ''), changed_by, created_at
+		FROM shop.user_status_audit
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := p.pgx.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w query %q: %w", ErrQueryFailed, query, err)
+	}
+
+	history, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.UserStatusChange, error) {
+		var c model.UserStatusChange
+		err := row.Scan(&c.ID, &c.OldStatus, &c.NewStatus, &c.Reason, &c.ChangedBy, &c.CreatedAt)
+		return c, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrScanFailed, err)
+	}
+
+	return history, nil
+}
@@ -0,0 +1,30 @@
+package model
+
+// SystemUser - имя, от которого в истории показываются системные операции
+// (начисления, сгорание монет). Юзернеймы только alphanum, поэтому совпасть с реальным юзером оно не может
+const SystemUser = "@system"
+
+// DeletedUser - так в истории и запросах показывается удаленный контрагент.
+// Должно совпадать с shop.display_username в миграциях
+const DeletedUser = "@deleted"
+
+// Типы записей в shop.transactions
+const (
+	TransactionKindTransfer  = "transfer"
+	TransactionKindAllowance = "allowance"
+	TransactionKindExpiry    = "expiry"
+)
+
+// Периоды начисления нормы монет
+const (
+	AllowancePeriodMonthly = "monthly"
+	AllowancePeriodWeekly  = "weekly"
+)
+
+// AllowanceResult - итог начисления за период
+type AllowanceResult struct {
+	Period      string `json:"period"`
+	Users       int    `json:"users"`       // сколько юзеров получили монеты
+	TotalAmount int    `json:"totalAmount"` // сколько монет начислено всего
+	UserIDs     []uint `json:"-"`
+}
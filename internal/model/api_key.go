@@ -0,0 +1,50 @@
+package model
+
+import "time"
+
+// APIKeyPrefix - с него начинаются все API ключи, по нему AuthMiddleware отличает ключ от JWT
+const APIKeyPrefix = "msk_"
+
+// Скоупы API ключей. У юзеров с JWT скоупов нет, им доступно все
+const (
+	ScopeUsersRead      = "users:read"      // информация о себе, списки запросов монет и отложенных переводов
+	ScopeTransfersWrite = "transfers:write" // переводы, запросы монет, отложенные переводы
+	ScopePurchasesWrite = "purchases:write" // покупка мерча
+)
+
+var APIKeyScopes = []string{ScopeUsersRead, ScopeTransfersWrite, ScopePurchasesWrite}
+
+// ServiceAccount - юзер с ролью service
+type ServiceAccount struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// APIKey - ключ без секрета, Prefix - начало ключа, чтобы отличать ключи в списке
+type APIKey struct {
+	ID         uint       `json:"id"`
+	UserID     uint       `json:"userId"`
+	Name       string     `json:"name,omitempty"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// CreatedAPIKey - только что созданный ключ. Key показывается один раз, в базе его нет
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// APIKeyAuth - результат проверки ключа в AuthMiddleware
+type APIKeyAuth struct {
+	KeyID  uint
+	UserID uint
+	Scopes []string
+	Status string // статус владельца ключа
+}
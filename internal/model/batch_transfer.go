@@ -0,0 +1,15 @@
+package model
+
+const (
+	BatchTransferStatusSent       = "sent"
+	BatchTransferStatusFailed     = "failed"
+	BatchTransferStatusRolledBack = "rolled_back" // перевод откатился из-за ошибки в другом
+)
+
+// BatchTransferResult - результат одного перевода из пачки, в порядке запроса
+type BatchTransferResult struct {
+	ToUser string `json:"toUser"`
+	Amount int    `json:"amount"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
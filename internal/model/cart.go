@@ -0,0 +1,76 @@
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+// CartLine - строка корзины. Variant - SKU варианта, пусто для варианта по умолчанию.
+// Price - текущая цена одной штуки с надбавкой варианта, Total - цена всей строки
+type CartLine struct {
+	Item     string `json:"item"`
+	Variant  string `json:"variant,omitempty"`
+	Quantity int    `json:"quantity"`
+	Price    int    `json:"price"`
+	Total    int    `json:"total"`
+}
+
+// Cart - корзина юзера по текущим ценам, строки в порядке добавления
+type Cart struct {
+	Lines []CartLine `json:"lines"`
+	Total int        `json:"total"`
+}
+
+// Статусы заказа, см. OrderTransitions
+const (
+	OrderStatusPlaced         = "placed"
+	OrderStatusReadyForPickup = "ready_for_pickup"
+	OrderStatusDelivered      = "delivered"
+	OrderStatusCancelled      = "cancelled" // монеты возвращены, предметы забраны из инвентаря
+)
+
+// OrderTransitions - в какие статусы можно перевести заказ из текущего.
+// delivered и cancelled окончательные
+var OrderTransitions = map[string][]string{
+	OrderStatusPlaced:         {OrderStatusReadyForPickup, OrderStatusCancelled},
+	OrderStatusReadyForPickup: {OrderStatusDelivered, OrderStatusCancelled},
+}
+
+// CanChangeOrderStatus - можно ли перевести заказ из статуса from в to
+func CanChangeOrderStatus(from, to string) bool {
+	return slices.Contains(OrderTransitions[from], to)
+}
+
+// Order - оформленная корзина, оплаченная одним списанием.
+// UserID и Username - покупатель, в выгрузке данных юзера не заполняются.
+// Recipient - кому куплен подарок, пусто - покупатель сам себе
+type Order struct {
+	ID              uint        `json:"id"`
+	UserID          uint        `json:"userId,omitempty"`
+	Username        string      `json:"username,omitempty"`
+	RecipientID     uint        `json:"-"`
+	Recipient       string      `json:"recipient,omitempty"`
+	Total           int         `json:"total"`
+	Status          string      `json:"status"`
+	StatusChangedAt *time.Time  `json:"statusChangedAt,omitempty"`
+	CreatedAt       time.Time   `json:"createdAt"`
+	Lines           []OrderLine `json:"lines"`
+}
+
+// OrderStatusChange - запись журнала смены статуса заказа
+type OrderStatusChange struct {
+	ID        uint      `json:"id"`
+	OldStatus string    `json:"oldStatus"`
+	NewStatus string    `json:"newStatus"`
+	Reason    string    `json:"reason,omitempty"`
+	ChangedBy *uint     `json:"changedBy,omitempty"` // nil - юзер удален
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// OrderLine - строка заказа, Price - цена одной штуки на момент заказа
+type OrderLine struct {
+	Item     string `json:"item"`
+	Variant  string `json:"variant,omitempty"`
+	Quantity int    `json:"quantity"`
+	Price    int    `json:"price"`
+}
@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// CatalogItem - предмет магазина с ограничениями продажи. nil - ограничения нет.
+// Available - продажа идет и склад не пуст
+type CatalogItem struct {
+	Name         string     `json:"name"`
+	Price        int        `json:"price"`
+	Stock        *int       `json:"stock"`
+	PerUserLimit *int       `json:"perUserLimit"`
+	SaleStartsAt *time.Time `json:"saleStartsAt"`
+	SaleEndsAt   *time.Time `json:"saleEndsAt"`
+	Available    bool       `json:"available"`
+
+	// варианты предмета, без варианта по умолчанию. Если они есть, покупка без варианта не пройдет
+	Variants []ItemVariant `json:"variants,omitempty"`
+}
+
+// ItemVariant - вариант предмета со своим складом. Price - цена предмета с надбавкой варианта,
+// Available - склад варианта не пуст (окно продажи и склад предмета - в CatalogItem)
+type ItemVariant struct {
+	SKU       string `json:"sku"`
+	Size      string `json:"size,omitempty"`
+	Color     string `json:"color,omitempty"`
+	Price     int    `json:"price"`
+	Stock     *int   `json:"stock"`
+	Available bool   `json:"available"`
+}
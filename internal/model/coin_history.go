@@ -1,16 +1,31 @@
 package model
 
+// Режимы отображения истории монет в /api/info
+const (
+	CoinHistoryModeRaw        = "raw"        // одна запись на каждую транзакцию
+	CoinHistoryModeAggregated = "aggregated" // одна запись на каждого контрагента
+)
+
 type CoinHistory struct {
 	Received []ReceivedTransaction `json:"received"`
 	Sent     []SentTransaction     `json:"sent"`
 }
 
+// Count заполняется только в агрегированном режиме,
+// тогда Amount - это сумма всех переводов от/к контрагенту.
+// Memo и Category, наоборот, только в raw режиме
 type ReceivedTransaction struct {
-	User   string `json:"fromUser"`
-	Amount int    `json:"amount"`
+	User     string `json:"fromUser"`
+	Amount   int    `json:"amount"`
+	Count    int    `json:"count,omitempty"`
+	Memo     string `json:"memo,omitempty"`
+	Category string `json:"category,omitempty"`
 }
 
 type SentTransaction struct {
-	User   string `json:"toUser"`
-	Amount int    `json:"amount"`
+	User     string `json:"toUser"`
+	Amount   int    `json:"amount"`
+	Count    int    `json:"count,omitempty"`
+	Memo     string `json:"memo,omitempty"`
+	Category string `json:"category,omitempty"`
 }
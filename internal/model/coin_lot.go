@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// ExpiringCoins - сколько монет сгорит в указанный день
+type ExpiringCoins struct {
+	Amount    int       `json:"amount"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// ExpiryResult - итог одного прохода задачи сгорания монет
+type ExpiryResult struct {
+	Users       int    `json:"users"`
+	TotalAmount int    `json:"totalAmount"`
+	UserIDs     []uint `json:"-"`
+}
@@ -0,0 +1,76 @@
+package model
+
+import "time"
+
+// Направления перевода в выгрузке данных юзера
+const (
+	TransferDirectionIn  = "in"
+	TransferDirectionOut = "out"
+)
+
+// UserExport - все данные юзера для выгрузки по GET /api/me/export
+type UserExport struct {
+	ExportedAt time.Time `json:"exportedAt"`
+
+	Profile   UserProfile      `json:"profile"`
+	Wallet    WalletExport     `json:"wallet"`
+	Inventory []Item           `json:"inventory"`
+	Transfers []TransferRecord `json:"transfers"`
+	Purchases []Purchase       `json:"purchases"`
+	Orders    []Order          `json:"orders"`
+	Gifts     GiftHistory      `json:"gifts"`
+
+	Identities []LinkedIdentity `json:"identities"` // привязки к SSO
+	Sessions   []Session        `json:"sessions"`   // действующие сессии
+
+	PaymentRequests    []PaymentRequest    `json:"paymentRequests"`
+	ScheduledTransfers []ScheduledTransfer `json:"scheduledTransfers"`
+}
+
+type UserProfile struct {
+	ID        uint      `json:"id"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type WalletExport struct {
+	Balance uint      `json:"balance"`
+	Lots    []CoinLot `json:"lots"`
+}
+
+// CoinLot - партия монет, полученных юзером в AcquiredAt
+type CoinLot struct {
+	Remaining  int       `json:"remaining"`
+	AcquiredAt time.Time `json:"acquiredAt"`
+}
+
+// TransferRecord - запись из shop.transactions с точки зрения юзера.
+// Counterparty - SystemUser для начислений и сгораний, DeletedUser для удаленных юзеров
+type TransferRecord struct {
+	Direction    string    `json:"direction"` // in или out
+	Counterparty string    `json:"counterparty"`
+	Amount       int       `json:"amount"`
+	Memo         string    `json:"memo,omitempty"`
+	Category     string    `json:"category,omitempty"`
+	Kind         string    `json:"kind"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// Purchase - покупка предмета, Price - цена на момент покупки
+type Purchase struct {
+	Item      string    `json:"item"`
+	Variant   string    `json:"variant,omitempty"`
+	Price     int       `json:"price"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// LinkedIdentity - привязка юзера к аккаунту в IdP, через который он входит по OIDC
+type LinkedIdentity struct {
+	Issuer      string    `json:"issuer"`
+	Subject     string    `json:"subject"`
+	Email       string    `json:"email,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	LastLoginAt time.Time `json:"lastLoginAt"`
+}
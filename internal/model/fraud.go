@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+// Правила антифрода
+const (
+	FraudRuleCycle      = "cycle"        // монеты ходят по кругу между несколькими юзерами
+	FraudRuleFreshFanIn = "fresh_fan_in" // много свежих аккаунтов переводят одному юзеру
+	FraudRuleVelocity   = "velocity"     // слишком много переводов за короткое время
+)
+
+// Статусы алерта антифрода
+const (
+	FraudAlertOpen      = "open"
+	FraudAlertConfirmed = "confirmed"
+	FraudAlertDismissed = "dismissed"
+)
+
+// FraudFinding - срабатывание правила до сохранения в алерт
+type FraudFinding struct {
+	Rule        string
+	Fingerprint string
+	UserIDs     []uint
+	Details     map[string]any
+}
+
+type FraudAlert struct {
+	ID         uint           `json:"id" db:"id"`
+	Rule       string         `json:"rule" db:"rule"`
+	UserIDs    []uint         `json:"userIds" db:"user_ids"`
+	Details    map[string]any `json:"details" db:"details"`
+	Status     string         `json:"status" db:"status"`
+	CreatedAt  time.Time      `json:"createdAt" db:"created_at"`
+	ReviewedBy *uint          `json:"reviewedBy,omitempty" db:"reviewed_by"`
+	ReviewedAt *time.Time     `json:"reviewedAt,omitempty" db:"reviewed_at"`
+	ReviewNote string         `json:"reviewNote,omitempty" db:"review_note"`
+}
+
+// FraudDetectionResult - итог одного прохода антифрода
+type FraudDetectionResult struct {
+	Findings int    `json:"findings"` // сколько всего сработало правил
+	Alerts   int    `json:"alerts"`   // сколько из них стали новыми алертами
+	Frozen   []uint `json:"frozen"`   // кого заморозили автоматически
+}
@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// GiftHistory - подарки предметов в /api/info, как CoinHistory для монет
+type GiftHistory struct {
+	Received []ReceivedGift `json:"received"`
+	Sent     []SentGift     `json:"sent"`
+}
+
+// ReceivedGift - полученный подарок. OrderID - заказ, которым подарок куплен,
+// nil - подарок отдан из инвентаря отправителя
+type ReceivedGift struct {
+	User      string    `json:"fromUser"`
+	Item      string    `json:"item"`
+	Variant   string    `json:"variant,omitempty"`
+	Quantity  int       `json:"quantity"`
+	OrderID   *uint     `json:"orderId,omitempty"`
+	Message   string    `json:"message,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// SentGift - отправленный подарок. UserID - ID получателя, нужен сервису для сброса кэша
+type SentGift struct {
+	UserID    uint      `json:"-"`
+	User      string    `json:"toUser"`
+	Item      string    `json:"item"`
+	Variant   string    `json:"variant,omitempty"`
+	Quantity  int       `json:"quantity"`
+	OrderID   *uint     `json:"orderId,omitempty"`
+	Message   string    `json:"message,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -1,6 +1,11 @@
 package model
 
+// Item - сколько штук предмета есть у юзера. Для вариантов (размер, цвет) - отдельная
+// строка на каждый вариант, у варианта по умолчанию Variant, Size и Color пустые
 type Item struct {
 	Type     string `json:"type" db:"type"`
+	Variant  string `json:"variant,omitempty" db:"variant"` // SKU варианта
+	Size     string `json:"size,omitempty" db:"size"`
+	Color    string `json:"color,omitempty" db:"color"`
 	Quantity uint   `json:"quantity" db:"quantity"`
 }
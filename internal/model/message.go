@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// Message - письмо юзеру. Почты у юзеров нет, поэтому адресат - имя юзера,
+// а письма пока складываются в outbox (см. internal/outbox)
+type Message struct {
+	To        string    `json:"to"`
+	Subject   string    `json:"subject"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"createdAt"`
+}
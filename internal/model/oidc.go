@@ -0,0 +1,15 @@
+package model
+
+// OIDCIdentity - юзер по версии IdP. Юзер магазина ищется по паре Issuer + Subject,
+// Email нужен только чтобы придумать имя при первом входе
+type OIDCIdentity struct {
+	Issuer  string
+	Subject string
+	Email   string
+}
+
+// OIDCState - то, что сохраняется между редиректом на IdP и возвратом юзера обратно
+type OIDCState struct {
+	CodeVerifier string
+	Nonce        string
+}
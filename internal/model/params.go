@@ -1,8 +1,11 @@
 package model
 
+import "time"
+
 type AuthUserParams struct {
 	Username string
 	Password string
+	OTP      string `json:"-"` // код TOTP или восстановления, нужен, если у юзера включена 2FA
 }
 
 type CreateUserParams struct {
@@ -14,14 +17,379 @@ type SendCoinParams struct {
 	FromUser uint
 	ToUser   string
 	Amount   int
+	Memo     string // опционально
+	Category string // опционально, одна из TransferConfig.Categories
+	OTP      string `json:"-"` // код 2FA, нужен для крупных переводов, см. AuthConfig.TOTPStepUpAmount
+
+	Limits TransferLimits // заполняет сервис из конфига
+}
+
+// SendCoinBatchParams - переводы от одного отправителя нескольким получателям,
+// выполняются все вместе или ни одного
+type SendCoinBatchParams struct {
+	FromUser  uint
+	Transfers []BatchTransfer
+	OTP       string `json:"-"` // код 2FA, если сумма пачки крупная
+
+	Limits TransferLimits // заполняет сервис из конфига
+}
+
+type BatchTransfer struct {
+	ToUser   string
+	Amount   int
+	Memo     string // опционально
+	Category string // опционально
 }
 
 type GetUserInfoParams struct {
-	ID uint
+	ID          uint
+	HistoryMode string // raw (по умолчанию) или aggregated
+	Category    string // если задана, то в истории только переводы этой категории
+
+	// Срок жизни монет, заполняет сервис из конфига. 0 - монеты не сгорают
+	CoinLifetime time.Duration
 }
 
 type BuyItemParams struct {
 	UserID  uint
 	Item    string
-	Balance uint
+	Variant string // SKU, пусто - вариант по умолчанию
+}
+
+// CartItemParams - добавление предмета в корзину или смена его количества.
+// Лимиты заполняет сервис из конфига
+type CartItemParams struct {
+	UserID   uint
+	Item     string
+	Variant  string // SKU, пусто - вариант по умолчанию
+	Quantity int
+
+	MaxQuantity int // сколько штук предмета может быть в строке
+	MaxLines    int // сколько разных предметов может быть в корзине
+}
+
+// PurchaseItemParams - покупка нескольких штук одного предмета одним заказом
+type PurchaseItemParams struct {
+	UserID   uint
+	Item     string
+	Variant  string // SKU, пусто - вариант по умолчанию
+	Quantity int
+
+	MaxQuantity int // сколько штук можно купить за раз, заполняет сервис из конфига
+}
+
+// GiftItemParams - подарок Quantity штук варианта предмета юзеру ToUser:
+// из своего инвентаря или покупкой за свои монеты
+type GiftItemParams struct {
+	FromUser uint
+	ToUser   string
+	Item     string
+	Variant  string // SKU, пусто - вариант по умолчанию
+	Quantity int
+	Message  string // опционально
+
+	MaxQuantity int // только для покупки, заполняет сервис из конфига
+}
+
+// SetItemRulesParams - ограничения продажи предмета от админа, заменяются целиком.
+// nil - ограничения нет
+type SetItemRulesParams struct {
+	Item         string
+	Stock        *int
+	PerUserLimit *int
+	SaleStartsAt *time.Time
+	SaleEndsAt   *time.Time
+}
+
+// SetItemVariantParams - создание или замена варианта предмета по SKU. Stock nil - без ограничения
+type SetItemVariantParams struct {
+	Item       string
+	SKU        string
+	Size       string
+	Color      string
+	PriceDelta int
+	Stock      *int
+}
+
+type CheckoutParams struct {
+	UserID uint
+
+	MaxQuantity int // заполняет сервис из конфига
+}
+
+// GetOrdersParams - список заказов. UserID 0 - заказы всех юзеров (для админа),
+// пустой Status - в любом статусе
+type GetOrdersParams struct {
+	UserID uint
+	Status string
+	Limit  int
+}
+
+// SetOrderStatusParams - смена статуса заказа админом, пишется в журнал вместе с причиной
+type SetOrderStatusParams struct {
+	OrderID uint
+	AdminID uint
+	Status  string
+	Reason  string // обязательна для отмены
+}
+
+// CreateReturnParams - возврат Quantity штук варианта предмета. Штуки берутся из последних
+// выданных заказов, выданных не раньше Window назад
+type CreateReturnParams struct {
+	UserID   uint
+	Item     string
+	Variant  string // SKU, пусто - вариант по умолчанию
+	Quantity int
+	Reason   string
+
+	Window time.Duration // заполняет сервис из конфига, 0 - без ограничения
+}
+
+type GetReturnsParams struct {
+	UserID uint   // 0 - возвраты всех юзеров (для админа)
+	Status string // пусто - в любом статусе
+	Limit  int
+}
+
+// ReviewReturnParams - решение админа по возврату. Refund - сумма возврата
+// при одобрении, nil - полная сумма по ценам покупки
+type ReviewReturnParams struct {
+	ID      uint
+	AdminID uint
+	Status  string // approved или rejected
+	Refund  *int
+	Note    string
+}
+
+type CreatePaymentRequestParams struct {
+	FromUser uint   // кто просит монеты
+	ToUser   string // у кого просят
+	Amount   int
+	Memo     string
+	TTL      time.Duration // срок жизни, конец срока считает база от NOW()
+}
+
+type GetPaymentRequestsParams struct {
+	UserID    uint
+	Direction string // incoming или outgoing
+}
+
+// ResolvePaymentRequestParams используется для accept/decline/cancel
+type ResolvePaymentRequestParams struct {
+	ID     uint
+	UserID uint
+
+	Limits TransferLimits // нужно только для accept, заполняет сервис из конфига
+	OTP    string         `json:"-"` // только для accept, код 2FA для крупного запроса
+}
+
+type CreateScheduledTransferParams struct {
+	FromUser uint
+	ToUser   string
+	Amount   int
+	Memo     string
+	Category string
+	Schedule string // cron выражение, пусто для разового перевода
+	RunAt    time.Time
+	OTP      string `json:"-"` // код 2FA, если сумма одного запуска больше порога step-up
+}
+
+type GetScheduledTransfersParams struct {
+	UserID uint
+}
+
+// ScheduledTransferIDParams используется для отмены перевода и получения истории запусков
+type ScheduledTransferIDParams struct {
+	ID     uint
+	UserID uint
+}
+
+type ClaimScheduledTransfersParams struct {
+	Owner string        // идентификатор инстанса
+	Lease time.Duration // на сколько берем аренду
+	Limit int
+}
+
+type ExecuteScheduledTransferParams struct {
+	ID           uint
+	Owner        string
+	ScheduledFor time.Time
+	NextRunAt    *time.Time // nil - перевод завершен
+
+	Limits TransferLimits
+}
+
+type RecordScheduledTransferFailureParams struct {
+	ID           uint
+	Owner        string
+	ScheduledFor time.Time
+	Error        string
+	Failures     int    // новое количество неудач подряд
+	Status       string // active или stopped
+	NextRunAt    *time.Time
+}
+
+type GrantAllowanceParams struct {
+	Period     string // ключ периода, например 2026-10 или 2026-W42
+	Amount     int
+	MaxBalance int // 0 - без ограничения
+}
+
+type ExpireCoinsParams struct {
+	Lifetime time.Duration
+	Limit    int // сколько юзеров обрабатываем за один вызов
+}
+
+// FraudDetectionParams - пороги правил антифрода. Нулевой порог выключает правило
+type FraudDetectionParams struct {
+	Window time.Duration // за какой период смотрим переводы
+
+	CycleMaxLength int // максимальная длина кольца (2 - перевод туда и обратно)
+	CycleMinAmount int // кольцо учитывается, только если по каждому ребру прошло не меньше
+
+	FreshAccountAge time.Duration // аккаунт свежий, если перевел монеты раньше чем через столько после регистрации
+	FanInMinSenders int           // сколько свежих отправителей у одного получателя уже подозрительно
+
+	VelocityWindow       time.Duration
+	VelocityMaxTransfers int // больше стольких переводов за VelocityWindow - подозрительно
+	VelocityMaxAmount    int // больше стольких монет за VelocityWindow - подозрительно
+
+	AutoFreeze bool // замораживать юзеров из новых алертов до разбора админом
+}
+
+type SaveFraudAlertsParams struct {
+	Findings []FraudFinding
+	// если по той же находке алерт уже разобрали за это время, новый не создаем
+	Cooldown   time.Duration
+	AutoFreeze bool
+}
+
+type GetFraudAlertsParams struct {
+	Status string // пусто - все
+	Limit  int
+}
+
+type ReviewFraudAlertParams struct {
+	ID       uint
+	AdminID  uint
+	Status   string // confirmed или dismissed
+	Note     string
+	Unfreeze bool // разморозить юзеров из алерта
+}
+
+// DeleteUserParams - удаление аккаунта самим юзером, Password - подтверждение паролем
+type DeleteUserParams struct {
+	UserID   uint
+	Password string
+}
+
+type ChangePasswordParams struct {
+	UserID      uint
+	OldPassword string
+	NewPassword string
+}
+
+// SetPasswordParams - новый хэш пароля, заодно отзываются все токены юзера
+type SetPasswordParams struct {
+	UserID       uint
+	PasswordHash string
+}
+
+type CreatePasswordResetParams struct {
+	UserID  uint
+	AdminID uint
+
+	// заполняет сервис
+	TokenHash string
+	TTL       time.Duration // конец срока считает база от NOW()
+}
+
+type ResetPasswordParams struct {
+	Token       string
+	NewPassword string
+
+	// заполняет сервис
+	TokenHash    string
+	PasswordHash string
+}
+
+type CreateServiceAccountParams struct {
+	Name    string
+	AdminID uint
+}
+
+type CreateAPIKeyParams struct {
+	UserID  uint // сервисный аккаунт
+	AdminID uint
+	Name    string
+	Scopes  []string
+	TTL     time.Duration // 0 - бессрочный, конец срока считает база от NOW()
+
+	// заполняет сервис
+	Prefix  string
+	KeyHash string
+}
+
+// CreateOIDCStateParams - начало входа через OIDC, state хранится только хэшем
+type CreateOIDCStateParams struct {
+	StateHash    string
+	CodeVerifier string
+	Nonce        string
+	TTL          time.Duration // конец срока считает база от NOW()
+}
+
+// OIDCLoginParams - вход через OIDC. Username используется, только если юзера еще нет,
+// занятое имя база дополнит номером
+type OIDCLoginParams struct {
+	Identity OIDCIdentity
+	Username string
+}
+
+// FinishOIDCLoginParams - параметры редиректа от IdP. Error - ошибка от IdP вместо кода
+type FinishOIDCLoginParams struct {
+	State string
+	Code  string
+	Error string
+}
+
+type CreateTOTPParams struct {
+	UserID          uint
+	SecretEncrypted string
+}
+
+// ConfirmTOTPParams - первый верный код включает 2FA и заменяет коды восстановления
+type ConfirmTOTPParams struct {
+	UserID             uint
+	Step               int64
+	RecoveryCodeHashes []string
+}
+
+// TOTPFailureParams - неверный код. После MaxFailures подряд проверка блокируется на LockFor
+type TOTPFailureParams struct {
+	UserID      uint
+	MaxFailures int
+	LockFor     time.Duration
+}
+
+// CreateSessionParams - сессия под новый токен, TTL совпадает со сроком жизни токена.
+// Конец срока считает база от NOW()
+type CreateSessionParams struct {
+	UserID    uint
+	UserAgent string
+	IP        string
+	TTL       time.Duration
+}
+
+// SessionIDParams используется для отзыва сессии, юзер может отозвать только свою
+type SessionIDParams struct {
+	ID     uint
+	UserID uint
+}
+
+// SetUserStatusParams - смена статуса юзера админом, причина обязательна и пишется в журнал
+type SetUserStatusParams struct {
+	UserID  uint
+	AdminID uint
+	Status  string
+	Reason  string
 }
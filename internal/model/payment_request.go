@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// Статусы запроса монет
+const (
+	PaymentRequestPending   = "pending"
+	PaymentRequestAccepted  = "accepted"
+	PaymentRequestDeclined  = "declined"
+	PaymentRequestCancelled = "cancelled"
+	PaymentRequestExpired   = "expired"
+)
+
+// Направления при получении списка запросов
+const (
+	PaymentRequestsIncoming = "incoming" // запросы, адресованные юзеру (он платит)
+	PaymentRequestsOutgoing = "outgoing" // запросы, созданные юзером (ему платят)
+)
+
+// PaymentRequest - запрос монет. FromUser просит монеты, ToUser платит
+type PaymentRequest struct {
+	ID         uint       `json:"id" db:"id"`
+	FromUserID uint       `json:"-" db:"from_user_id"`
+	ToUserID   uint       `json:"-" db:"to_user_id"`
+	FromUser   string     `json:"fromUser"`
+	ToUser     string     `json:"toUser"`
+	Amount     int        `json:"amount" db:"amount"`
+	Memo       string     `json:"memo,omitempty" db:"memo"`
+	Status     string     `json:"status" db:"status"`
+	ExpiresAt  time.Time  `json:"expiresAt" db:"expires_at"`
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+	ResolvedAt *time.Time `json:"resolvedAt,omitempty" db:"resolved_at"`
+}
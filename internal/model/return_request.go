@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// Статусы возврата
+const (
+	ReturnPending  = "pending"
+	ReturnApproved = "approved"
+	ReturnRejected = "rejected"
+)
+
+// ReturnRequest - просьба вернуть штуки варианта предмета из выданных заказов.
+// Amount - сколько монет вернется по ценам покупки, Refund - сколько вернули при одобрении
+type ReturnRequest struct {
+	ID         uint       `json:"id"`
+	UserID     uint       `json:"-"`
+	Username   string     `json:"username"`
+	Item       string     `json:"item"`
+	Variant    string     `json:"variant,omitempty"`
+	Quantity   int        `json:"quantity"`
+	Amount     int        `json:"amount"`
+	Refund     *int       `json:"refund,omitempty"`
+	Reason     string     `json:"reason"`
+	Status     string     `json:"status"`
+	ReviewNote string     `json:"reviewNote,omitempty"`
+	ReviewedBy *uint      `json:"reviewedBy,omitempty"`
+	ReviewedAt *time.Time `json:"reviewedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// Статусы отложенного перевода
+const (
+	ScheduledTransferActive    = "active"
+	ScheduledTransferCompleted = "completed" // разовый перевод выполнен
+	ScheduledTransferCancelled = "cancelled" // отменен юзером
+	ScheduledTransferStopped   = "stopped"   // остановлен после нескольких неудач подряд
+)
+
+// Статусы запуска отложенного перевода
+const (
+	ScheduledRunSuccess = "success"
+	ScheduledRunFailed  = "failed"
+)
+
+// ScheduledTransfer - разовый (Schedule пустой) или регулярный (Schedule - cron выражение) перевод
+type ScheduledTransfer struct {
+	ID                  uint       `json:"id"`
+	FromUserID          uint       `json:"-"`
+	ToUserID            uint       `json:"-"`
+	ToUser              string     `json:"toUser"`
+	Amount              int        `json:"amount"`
+	Memo                string     `json:"memo,omitempty"`
+	Category            string     `json:"category,omitempty"`
+	Schedule            string     `json:"schedule,omitempty"`
+	NextRunAt           *time.Time `json:"nextRunAt,omitempty"`
+	Status              string     `json:"status"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	CreatedAt           time.Time  `json:"createdAt"`
+}
+
+type ScheduledTransferRun struct {
+	ScheduledFor time.Time `json:"scheduledFor"`
+	ExecutedAt   time.Time `json:"executedAt"`
+	Status       string    `json:"status"`
+	Error        string    `json:"error,omitempty"`
+}
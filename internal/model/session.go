@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// Session - место, где юзер залогинен. Каждый JWT привязан к своей сессии,
+// Current - сессия, с токеном которой пришел запрос
+type Session struct {
+	ID         uint      `json:"id"`
+	UserAgent  string    `json:"userAgent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	Current    bool      `json:"current"`
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// TOTP - настройки 2FA юзера. Секрет зашифрован, расшифровывает его сервис
+type TOTP struct {
+	UserID          uint
+	SecretEncrypted string
+	Confirmed       bool // false - юзер начал подключение, но еще не ввел первый код
+	LastStep        int64
+	LockedUntil     *time.Time
+}
+
+// TOTPEnrollment - то, что нужно добавить в приложение-аутентификатор.
+// URI обычно показывают QR кодом, Secret - для ручного ввода
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
@@ -0,0 +1,10 @@
+package model
+
+// TransferLimits - лимиты исходящих переводов. 0 - без ограничения.
+// Сутки и неделя скользящие: считаются от момента перевода назад
+type TransferLimits struct {
+	MaxSingle       int // максимальная сумма одного перевода
+	DailyTotal      int // сколько всего можно отправить за сутки
+	WeeklyTotal     int // сколько всего можно отправить за 7 дней
+	DailyRecipients int // скольким разным юзерам можно отправить за сутки
+}
@@ -1,13 +1,70 @@
 package model
 
+import "time"
+
+// Роли юзеров. Роль по умолчанию - user, админа назначают вручную в базе
+const (
+	RoleUser    = "user"
+	RoleAdmin   = "admin"
+	RoleService = "service" // сервисный аккаунт, ходит в API только по ключам
+)
+
+// Статусы юзера
+const (
+	UserStatusActive    = "active"
+	UserStatusFrozen    = "frozen"    // не может тратить и получать монеты
+	UserStatusSuspended = "suspended" // не может войти, токены не принимаются
+	UserStatusDeleted   = "deleted"   // мягко удален, для входа как suspended
+)
+
 type User struct {
 	ID       uint   `db:"id"`
 	Username string `db:"username"`
 	Password string `db:"password_hash"`
+	Status   string `db:"status"`
+
+	// TokenVersion зашивается в JWT, смена пароля ее увеличивает и так отзывает старые токены
+	TokenVersion int `db:"token_version"`
+
+	// TOTPEnabled - включена 2FA, при входе по паролю нужен еще код. Заполняет только AuthUser
+	TOTPEnabled bool
+}
+
+// UserAccess - то, что AuthMiddleware проверяет на каждый запрос
+type UserAccess struct {
+	Status       string
+	TokenVersion int
+
+	// SessionRevoked - сессию токена отозвали или ее нет у этого юзера
+	SessionRevoked bool
+}
+
+// PasswordReset - выданный админом токен сброса пароля. Сам токен уходит юзеру письмом и в ответ не попадает
+type PasswordReset struct {
+	UserID    uint      `json:"userId"`
+	Username  string    `json:"-"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// CanLogin - suspended и deleted юзеры не могут ни войти, ни пользоваться уже выданным токеном
+func CanLogin(status string) bool {
+	return status != UserStatusSuspended && status != UserStatusDeleted
+}
+
+// UserStatusChange - запись журнала смены статуса юзера
+type UserStatusChange struct {
+	ID        uint      `json:"id" db:"id"`
+	OldStatus string    `json:"oldStatus" db:"old_status"`
+	NewStatus string    `json:"newStatus" db:"new_status"`
+	Reason    string    `json:"reason,omitempty" db:"reason"`
+	ChangedBy *uint     `json:"changedBy,omitempty" db:"changed_by"` // nil - система
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
 }
 
 type UserInfo struct {
 	Coins       uint `db:"balance"`
 	Inventory   []Item
 	CoinHistory CoinHistory
+	GiftHistory GiftHistory
+	Expiring    []ExpiringCoins
 }
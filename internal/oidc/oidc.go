@@ -0,0 +1,327 @@
+// Package oidc - вход через корпоративный SSO по OpenID Connect:
+// authorization code flow с PKCE (S256). Провайдер находится через discovery
+// (/.well-known/openid-configuration), ID токен проверяется по ключам из jwks_uri.
+// Поддерживаются только ID токены с подписью RS256
+package oidc
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/config"
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/golang-jwt/jwt"
+)
+
+var (
+	ErrDisabled       = errors.New("oidc login is disabled")
+	ErrDiscovery      = errors.New("oidc discovery failed")
+	ErrExchange       = errors.New("oidc code exchange failed")
+	ErrInvalidIDToken = errors.New("invalid id token")
+)
+
+// maxResponseSize - больше этого от IdP не читаем
+const maxResponseSize = 1 << 20
+
+// Provider - общий интерфейс клиента и заглушки Disabled
+type Provider interface {
+	// AuthCodeURL - куда отправить юзера логиниться. В ссылку уходит только хэш codeVerifier
+	AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error)
+	// Exchange меняет код из редиректа на ID токен и возвращает проверенного юзера
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*model.OIDCIdentity, error)
+}
+
+// New создает клиента по конфигу. Если Issuer не задан, возвращается Disabled
+func New(cfg config.OIDCConfig) Provider {
+	if cfg.Issuer == "" {
+		return Disabled{}
+	}
+	return NewClient(cfg, &http.Client{Timeout: 10 * time.Second})
+}
+
+// Disabled - OIDC выключен, все методы возвращают ErrDisabled
+type Disabled struct{}
+
+func (Disabled) AuthCodeURL(context.Context, string, string, string) (string, error) {
+	return "", ErrDisabled
+}
+
+func (Disabled) Exchange(context.Context, string, string, string) (*model.OIDCIdentity, error) {
+	return nil, ErrDisabled
+}
+
+// CodeChallenge - PKCE challenge для метода S256
+func CodeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Client ходит к IdP. Discovery и ключи запрашиваются при первом входе и кэшируются,
+// ключи перечитываются, если пришел токен с незнакомым kid (IdP сменил ключ)
+type Client struct {
+	cfg  config.OIDCConfig
+	http *http.Client
+
+	mu        sync.Mutex
+	discovery *discovery
+	keys      map[string]*rsa.PublicKey
+}
+
+func NewClient(cfg config.OIDCConfig, httpClient *http.Client) *Client {
+	return &Client{
+		cfg:  cfg,
+		http: httpClient,
+	}
+}
+
+func (c *Client) AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	d, err := c.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(d.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrDiscovery, err)
+	}
+
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", c.cfg.ClientID)
+	q.Set("redirect_uri", c.cfg.RedirectURL)
+	q.Set("scope", strings.Join(c.cfg.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", CodeChallenge(codeVerifier))
+	q.Set("code_challenge_method", "S256")
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+func (c *Client) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*model.OIDCIdentity, error) {
+	d, err := c.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.cfg.RedirectURL},
+		"client_id":     {c.cfg.ClientID},
+		"code_verifier": {codeVerifier},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrExchange, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	// client_secret_basic, у public клиента секрета нет
+	if c.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(c.cfg.ClientID), url.QueryEscape(c.cfg.ClientSecret))
+	}
+
+	var token struct {
+		IDToken string `json:"id_token"`
+		Error   string `json:"error"`
+	}
+	status, err := c.doJSON(req, &token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrExchange, err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("%w: status %d: %s", ErrExchange, status, token.Error)
+	}
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("%w: no id_token in response", ErrExchange)
+	}
+
+	return c.verify(ctx, d, token.IDToken, nonce)
+}
+
+// verify проверяет подпись и claims ID токена: iss, aud, exp и nonce из нашего запроса
+func (c *Client) verify(ctx context.Context, d *discovery, rawToken, nonce string) (*model.OIDCIdentity, error) {
+	claims := jwt.MapClaims{}
+
+	_, err := jwt.ParseWithClaims(rawToken, claims, func(token *jwt.Token) (any, error) {
+		if token.Method != jwt.SigningMethodRS256 {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		return c.key(ctx, d, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidIDToken, err)
+	}
+
+	subject, _ := claims["sub"].(string)
+	tokenNonce, _ := claims["nonce"].(string)
+	email, _ := claims["email"].(string)
+
+	switch {
+	case !claims.VerifyIssuer(d.Issuer, true):
+		return nil, fmt.Errorf("%w: issuer mismatch", ErrInvalidIDToken)
+	case !claims.VerifyAudience(c.cfg.ClientID, true):
+		return nil, fmt.Errorf("%w: audience mismatch", ErrInvalidIDToken)
+	case !claims.VerifyExpiresAt(time.Now().Unix(), true):
+		return nil, fmt.Errorf("%w: token is expired", ErrInvalidIDToken)
+	case tokenNonce != nonce:
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	case subject == "":
+		return nil, fmt.Errorf("%w: no subject", ErrInvalidIDToken)
+	}
+
+	return &model.OIDCIdentity{
+		Issuer:  d.Issuer,
+		Subject: subject,
+		Email:   email,
+	}, nil
+}
+
+func (c *Client) discover(ctx context.Context) (*discovery, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.discovery != nil {
+		return c.discovery, nil
+	}
+
+	wellKnown := strings.TrimSuffix(c.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wellKnown, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDiscovery, err)
+	}
+
+	d := &discovery{}
+	status, err := c.doJSON(req, d)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDiscovery, err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("%w: status %d", ErrDiscovery, status)
+	}
+	// по спеке issuer в документе должен совпадать с тем, у кого его запросили
+	if d.Issuer != c.cfg.Issuer {
+		return nil, fmt.Errorf("%w: issuer %q does not match %q", ErrDiscovery, d.Issuer, c.cfg.Issuer)
+	}
+
+	c.discovery = d
+	return d, nil
+}
+
+// key возвращает ключ подписи по kid. Пустой kid допустим, если у IdP ровно один ключ
+func (c *Client) key(ctx context.Context, d *discovery, kid string) (*rsa.PublicKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if key, ok := c.findKey(kid); ok {
+		return key, nil
+	}
+
+	keys, err := c.fetchKeys(ctx, d.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+	c.keys = keys
+
+	if key, ok := c.findKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (c *Client) findKey(kid string) (*rsa.PublicKey, bool) {
+	if kid == "" && len(c.keys) == 1 {
+		for _, key := range c.keys {
+			return key, true
+		}
+	}
+	key, ok := c.keys[kid]
+	return key, ok
+}
+
+func (c *Client) fetchKeys(ctx context.Context, jwksURI string) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURI, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	status, err := c.doJSON(req, &jwks)
+	if err != nil {
+		return nil, fmt.Errorf("fetch jwks: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("fetch jwks: status %d", status)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(jwks.Keys))
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("jwks key %q: %w", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("jwks key %q: %w", k.Kid, err)
+		}
+
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	return keys, nil
+}
+
+// doJSON выполняет запрос и разбирает JSON ответ. Тело читается и при ошибочном статусе,
+// чтобы достать из него error
+func (c *Client) doJSON(req *http.Request, v any) (int, error) {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(v); err != nil {
+		return resp.StatusCode, fmt.Errorf("decode response: %w", err)
+	}
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,173 @@
+package oidc_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"testing"
+
+	"github.com/0x0FACED/merch-shop/config"
+	"github.com/0x0FACED/merch-shop/internal/oidc"
+	"github.com/0x0FACED/merch-shop/internal/oidc/oidctest"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testClientID    = "merch-shop"
+	testRedirectURL = "http://shop.local/api/auth/oidc/callback"
+	testVerifier    = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+)
+
+func newTestClient(idp *oidctest.Server) *oidc.Client {
+	return oidc.NewClient(config.OIDCConfig{
+		Issuer:      idp.URL,
+		ClientID:    testClientID,
+		RedirectURL: testRedirectURL,
+		Scopes:      []string{"openid", "email"},
+	}, http.DefaultClient)
+}
+
+// login проходит вход до кода в редиректе
+func login(t *testing.T, idp *oidctest.Server, client *oidc.Client) string {
+	authURL, err := client.AuthCodeURL(context.Background(), "state", "nonce", testVerifier)
+	require.NoError(t, err)
+
+	callback, err := idp.Login(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "state", callback.Query().Get("state"))
+
+	code := callback.Query().Get("code")
+	require.NotEmpty(t, code, callback.Query().Get("error"))
+	return code
+}
+
+func TestExchange_Success(t *testing.T) {
+	idp := oidctest.NewServer(testClientID)
+	defer idp.Close()
+	idp.SetUser("user-1", "alice@example.com")
+
+	client := newTestClient(idp)
+
+	authURL, err := client.AuthCodeURL(context.Background(), "state", "nonce", testVerifier)
+	require.NoError(t, err)
+	assert.Contains(t, authURL, "code_challenge="+oidc.CodeChallenge(testVerifier))
+	assert.NotContains(t, authURL, testVerifier)
+
+	code := login(t, idp, client)
+
+	identity, err := client.Exchange(context.Background(), code, testVerifier, "nonce")
+	require.NoError(t, err)
+	assert.Equal(t, idp.URL, identity.Issuer)
+	assert.Equal(t, "user-1", identity.Subject)
+	assert.Equal(t, "alice@example.com", identity.Email)
+
+	// код одноразовый
+	_, err = client.Exchange(context.Background(), code, testVerifier, "nonce")
+	assert.ErrorIs(t, err, oidc.ErrExchange)
+}
+
+func TestExchange_WrongVerifier(t *testing.T) {
+	idp := oidctest.NewServer(testClientID)
+	defer idp.Close()
+	idp.SetUser("user-1", "alice@example.com")
+
+	client := newTestClient(idp)
+	code := login(t, idp, client)
+
+	_, err := client.Exchange(context.Background(), code, testVerifier+"0", "nonce")
+	assert.ErrorIs(t, err, oidc.ErrExchange)
+}
+
+func TestExchange_InvalidIDToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims func(jwt.MapClaims)
+		nonce  string
+	}{
+		{name: "nonce mismatch", nonce: "other"},
+		{name: "wrong audience", claims: func(c jwt.MapClaims) { c["aud"] = "other-client" }},
+		{name: "wrong issuer", claims: func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }},
+		{name: "expired", claims: func(c jwt.MapClaims) { c["exp"] = 1 }},
+		{name: "no subject", claims: func(c jwt.MapClaims) { delete(c, "sub") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := oidctest.NewServer(testClientID)
+			defer idp.Close()
+			idp.SetUser("user-1", "alice@example.com")
+			idp.Claims = tt.claims
+
+			client := newTestClient(idp)
+			code := login(t, idp, client)
+
+			nonce := "nonce"
+			if tt.nonce != "" {
+				nonce = tt.nonce
+			}
+
+			_, err := client.Exchange(context.Background(), code, testVerifier, nonce)
+			assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+		})
+	}
+}
+
+// Токен, подписанный не опубликованным ключом, не проходит, даже если claims правильные
+func TestExchange_ForeignSignature(t *testing.T) {
+	idp := oidctest.NewServer(testClientID)
+	defer idp.Close()
+	idp.SetUser("user-1", "alice@example.com")
+
+	foreignKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	idp.SigningKey = foreignKey
+
+	client := newTestClient(idp)
+	code := login(t, idp, client)
+
+	_, err = client.Exchange(context.Background(), code, testVerifier, "nonce")
+	assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+}
+
+// После смены ключа у IdP клиент перечитывает jwks по незнакомому kid
+func TestExchange_KeyRotation(t *testing.T) {
+	idp := oidctest.NewServer(testClientID)
+	defer idp.Close()
+	idp.SetUser("user-1", "alice@example.com")
+
+	client := newTestClient(idp)
+
+	_, err := client.Exchange(context.Background(), login(t, idp, client), testVerifier, "nonce")
+	require.NoError(t, err)
+
+	idp.RotateKey()
+
+	_, err = client.Exchange(context.Background(), login(t, idp, client), testVerifier, "nonce")
+	assert.NoError(t, err)
+}
+
+func TestAuthorize_AccessDenied(t *testing.T) {
+	idp := oidctest.NewServer(testClientID)
+	defer idp.Close()
+
+	authURL, err := newTestClient(idp).AuthCodeURL(context.Background(), "state", "nonce", testVerifier)
+	require.NoError(t, err)
+
+	callback, err := idp.Login(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "access_denied", callback.Query().Get("error"))
+	assert.Empty(t, callback.Query().Get("code"))
+}
+
+func TestDisabled(t *testing.T) {
+	provider := oidc.New(config.OIDCConfig{})
+
+	_, err := provider.AuthCodeURL(context.Background(), "state", "nonce", testVerifier)
+	assert.ErrorIs(t, err, oidc.ErrDisabled)
+
+	_, err = provider.Exchange(context.Background(), "code", testVerifier, "nonce")
+	assert.ErrorIs(t, err, oidc.ErrDisabled)
+}
@@ -0,0 +1,272 @@
+// Package oidctest - OIDC провайдер для тестов. Вместо страницы входа /authorize сразу
+// "логинит" юзера, заданного через SetUser, и редиректит обратно с кодом.
+// ID токены подписываются RSA ключом, который генерируется при старте
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/internal/oidc"
+	"github.com/golang-jwt/jwt"
+)
+
+type authRequest struct {
+	user        User
+	clientID    string
+	redirectURI string
+	challenge   string
+	nonce       string
+}
+
+// User - кого IdP "залогинит" на следующем /authorize
+type User struct {
+	Subject string
+	Email   string
+}
+
+type Server struct {
+	URL      string
+	ClientID string
+
+	// Claims, если задан, может поменять claims ID токена перед подписью
+	Claims func(claims jwt.MapClaims)
+	// SigningKey, если задан, подписывает ID токены вместо ключа, опубликованного в jwks
+	SigningKey *rsa.PrivateKey
+
+	srv *httptest.Server
+
+	mu    sync.Mutex
+	key   *rsa.PrivateKey
+	keyID int
+	user  *User
+	codes map[string]authRequest
+}
+
+// NewServer запускает IdP, который принимает только клиента clientID
+func NewServer(clientID string) *Server {
+	s := &Server{
+		ClientID: clientID,
+		codes:    make(map[string]authRequest),
+	}
+	s.RotateKey()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("GET /authorize", s.authorize)
+	mux.HandleFunc("POST /token", s.token)
+	mux.HandleFunc("GET /jwks", s.jwks)
+
+	s.srv = httptest.NewServer(mux)
+	s.URL = s.srv.URL
+
+	return s
+}
+
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// RotateKey заменяет ключ подписи на новый с новым kid, старый из jwks пропадает
+func (s *Server) RotateKey() {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(fmt.Sprintf("oidctest: generate key: %v", err))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.key = key
+	s.keyID++
+}
+
+// SetUser задает юзера для следующих входов. Без юзера /authorize отвечает access_denied
+func (s *Server) SetUser(subject, email string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.user = &User{Subject: subject, Email: email}
+}
+
+// Login проходит по ссылке на /authorize, как это сделал бы браузер,
+// и возвращает адрес, на который IdP редиректит обратно в приложение
+func (s *Server) Login(authURL string) (*url.URL, error) {
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	resp, err := client.Get(authURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound {
+		return nil, fmt.Errorf("oidctest: authorize returned %d", resp.StatusCode)
+	}
+
+	return resp.Location()
+}
+
+func (s *Server) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                s.URL,
+		"authorization_endpoint":                s.URL + "/authorize",
+		"token_endpoint":                        s.URL + "/token",
+		"jwks_uri":                              s.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil || q.Get("redirect_uri") == "" {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	params := redirect.Query()
+	params.Set("state", q.Get("state"))
+
+	s.mu.Lock()
+	switch {
+	case q.Get("client_id") != s.ClientID:
+		params.Set("error", "unauthorized_client")
+	case q.Get("response_type") != "code":
+		params.Set("error", "unsupported_response_type")
+	case q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "":
+		params.Set("error", "invalid_request")
+	case s.user == nil:
+		params.Set("error", "access_denied")
+	default:
+		code := randomString()
+		s.codes[code] = authRequest{
+			user:        *s.user,
+			clientID:    q.Get("client_id"),
+			redirectURI: q.Get("redirect_uri"),
+			challenge:   q.Get("code_challenge"),
+			nonce:       q.Get("nonce"),
+		}
+		params.Set("code", code)
+	}
+	s.mu.Unlock()
+
+	redirect.RawQuery = params.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	clientID := r.PostForm.Get("client_id")
+	if user, _, ok := r.BasicAuth(); ok {
+		clientID, _ = url.QueryUnescape(user)
+	}
+
+	// код одноразовый, удаляем сразу
+	s.mu.Lock()
+	req, ok := s.codes[r.PostForm.Get("code")]
+	delete(s.codes, r.PostForm.Get("code"))
+	s.mu.Unlock()
+
+	switch {
+	case r.PostForm.Get("grant_type") != "authorization_code":
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	case !ok, clientID != req.clientID, r.PostForm.Get("redirect_uri") != req.redirectURI:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	case oidc.CodeChallenge(r.PostForm.Get("code_verifier")) != req.challenge:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":   s.URL,
+		"aud":   req.clientID,
+		"sub":   req.user.Subject,
+		"email": req.user.Email,
+		"nonce": req.nonce,
+		"iat":   now.Unix(),
+		"exp":   now.Add(5 * time.Minute).Unix(),
+	}
+	if s.Claims != nil {
+		s.Claims(claims)
+	}
+
+	s.mu.Lock()
+	key, kid := s.key, s.kid()
+	s.mu.Unlock()
+	if s.SigningKey != nil {
+		key = s.SigningKey
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+
+	idToken, err := token.SignedString(key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+func (s *Server) jwks(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	pub, kid := s.key.PublicKey, s.kid()
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": kid,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func (s *Server) kid() string {
+	return fmt.Sprintf("oidctest-%d", s.keyID)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func randomString() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,59 @@
+// Package outbox - заглушка отправки почты. Письма не уходят наружу,
+// а дописываются в локальный файл по одному JSON на строку, откуда их можно
+// забрать вручную или подключить настоящую отправку позже
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+)
+
+// File пишет письма в файл. Безопасен для использования из нескольких горутин,
+// но не из нескольких процессов
+type File struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFile(path string) *File {
+	return &File{path: path}
+}
+
+func (f *File) Send(_ context.Context, msg model.Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal message: %w", err)
+	}
+	data = append(data, '\n')
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o700); err != nil {
+		return fmt.Errorf("create outbox dir: %w", err)
+	}
+
+	// в письмах секреты (токены сброса пароля), поэтому файл доступен только владельцу
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("open outbox: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("write outbox: %w", err)
+	}
+
+	return nil
+}
+
+// Noop выбрасывает письма, используется когда outbox не нужен
+type Noop struct{}
+
+func (Noop) Send(context.Context, model.Message) error { return nil }
@@ -0,0 +1,44 @@
+package outbox_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/0x0FACED/merch-shop/internal/outbox"
+	"github.com/stretchr/testify/assert"
+)
+
+// Письма дописываются в конец файла по одному на строку, директория создается сама
+func TestFile_Send(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox", "mail.jsonl")
+	f := outbox.NewFile(path)
+
+	assert.NoError(t, f.Send(context.Background(), model.Message{To: "user1", Subject: "first"}))
+	assert.NoError(t, f.Send(context.Background(), model.Message{To: "user2", Subject: "second"}))
+
+	file, err := os.Open(path)
+	assert.NoError(t, err)
+	defer file.Close()
+
+	var messages []model.Message
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var msg model.Message
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &msg))
+		messages = append(messages, msg)
+	}
+
+	if assert.Len(t, messages, 2) {
+		assert.Equal(t, "user1", messages[0].To)
+		assert.Equal(t, "second", messages[1].Subject)
+	}
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
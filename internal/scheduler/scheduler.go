@@ -0,0 +1,93 @@
+// Package scheduler запускает фоновые задачи внутри процесса сервера.
+//
+// Каждая задача выполняется в своей горутине с заданным интервалом.
+// Сам планировщик ничего не знает о конкурентности между инстансами:
+// задачи сами защищаются арендой в базе (см. отложенные переводы).
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/pkg/logger"
+	"go.uber.org/zap"
+)
+
+var ErrInvalidInterval = errors.New("job interval must be positive")
+
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+type Scheduler struct {
+	jobs []Job
+
+	logger *logger.ZapLogger
+	wg     sync.WaitGroup
+}
+
+// New проверяет интервалы задач: нулевой интервал из конфига иначе уронил бы процесс при старте
+func New(l *logger.ZapLogger, jobs ...Job) (*Scheduler, error) {
+	for _, job := range jobs {
+		if job.Interval <= 0 {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidInterval, job.Name)
+		}
+	}
+
+	return &Scheduler{
+		jobs:   jobs,
+		logger: l,
+	}, nil
+}
+
+// Start запускает все задачи и сразу возвращает управление.
+// Задачи останавливаются, когда ctx отменен
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.loop(ctx, job)
+		}()
+	}
+}
+
+// Wait ждет завершения всех задач после отмены контекста
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	s.logger.Info("Starting scheduler job", zap.String("job", job.Name), zap.Duration("interval", job.Interval))
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := job.Run(ctx); err != nil {
+			s.logger.Error("Scheduler job failed", zap.String("job", job.Name), zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Scheduler job stopped", zap.String("job", job.Name))
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// InstanceID возвращает идентификатор текущего инстанса для аренды задач в базе
+func InstanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d-%d", host, os.Getpid(), time.Now().UnixNano())
+}
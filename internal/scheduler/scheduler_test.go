@@ -0,0 +1,25 @@
+package scheduler_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/config"
+	"github.com/0x0FACED/merch-shop/internal/scheduler"
+	"github.com/0x0FACED/merch-shop/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_RejectsNonPositiveInterval(t *testing.T) {
+	l := logger.NewTestLogger(config.LoggerConfig{LogLevel: "debug"})
+	run := func(context.Context) error { return nil }
+
+	_, err := scheduler.New(l, scheduler.Job{Name: "ok", Interval: time.Second, Run: run}, scheduler.Job{Name: "broken", Run: run})
+	assert.ErrorIs(t, err, scheduler.ErrInvalidInterval)
+	assert.ErrorContains(t, err, "broken")
+
+	_, err = scheduler.New(l, scheduler.Job{Name: "ok", Interval: time.Second, Run: run})
+	require.NoError(t, err)
+}
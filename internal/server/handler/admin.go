@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/labstack/echo/v4"
+)
+
+// AdminMiddleware пускает дальше только админов. Ставится после AuthMiddleware
+func (h *Handler) AdminMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userID := c.Get("user_id").(uint)
+
+		if err := h.userService.CheckAdmin(c.Request().Context(), userID); err != nil {
+			return serviceError(err)
+		}
+
+		return next(c)
+	}
+}
+
+func (h *Handler) GetFraudAlerts(c echo.Context) error {
+	var req FraudAlertsRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	params := model.GetFraudAlertsParams{
+		Status: req.Status,
+		Limit:  req.Limit,
+	}
+
+	ctx := c.Request().Context()
+
+	alerts, err := h.userService.GetFraudAlerts(ctx, params)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	resp := FraudAlertsResponse{
+		Alerts: alerts,
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) ReviewFraudAlert(c echo.Context) error {
+	adminID := c.Get("user_id").(uint)
+
+	var req ReviewFraudAlertRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	params := model.ReviewFraudAlertParams{
+		ID:       req.ID,
+		AdminID:  adminID,
+		Status:   req.Status,
+		Note:     req.Note,
+		Unfreeze: req.Unfreeze,
+	}
+
+	ctx := c.Request().Context()
+
+	alert, err := h.userService.ReviewFraudAlert(ctx, params)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	return c.JSON(http.StatusOK, alert)
+}
+
+func (h *Handler) SetUserStatus(c echo.Context) error {
+	adminID := c.Get("user_id").(uint)
+
+	var req SetUserStatusRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	params := model.SetUserStatusParams{
+		UserID:  req.ID,
+		AdminID: adminID,
+		Status:  req.Status,
+		Reason:  req.Reason,
+	}
+
+	ctx := c.Request().Context()
+
+	if err := h.userService.SetUserStatus(ctx, params); err != nil {
+		return serviceError(err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (h *Handler) GetUserStatusHistory(c echo.Context) error {
+	var req UserIDRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	history, err := h.userService.GetUserStatusHistory(ctx, req.ID)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	resp := UserStatusHistoryResponse{
+		History: history,
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
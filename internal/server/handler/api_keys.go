@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/labstack/echo/v4"
+)
+
+func (h *Handler) CreateServiceAccount(c echo.Context) error {
+	adminID := c.Get("user_id").(uint)
+
+	var req CreateServiceAccountRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	params := model.CreateServiceAccountParams{
+		Name:    req.Name,
+		AdminID: adminID,
+	}
+
+	ctx := c.Request().Context()
+
+	account, err := h.userService.CreateServiceAccount(ctx, params)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	return c.JSON(http.StatusCreated, account)
+}
+
+func (h *Handler) GetServiceAccounts(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	accounts, err := h.userService.GetServiceAccounts(ctx)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	resp := ServiceAccountsResponse{
+		Accounts: accounts,
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) CreateAPIKey(c echo.Context) error {
+	adminID := c.Get("user_id").(uint)
+
+	var req CreateAPIKeyRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	params := model.CreateAPIKeyParams{
+		UserID:  req.ID,
+		AdminID: adminID,
+		Name:    req.Name,
+		Scopes:  req.Scopes,
+		TTL:     time.Duration(req.ExpiresInHours) * time.Hour,
+	}
+
+	ctx := c.Request().Context()
+
+	key, err := h.userService.CreateAPIKey(ctx, params)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	return c.JSON(http.StatusCreated, key)
+}
+
+func (h *Handler) GetAPIKeys(c echo.Context) error {
+	var req UserIDRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	keys, err := h.userService.GetAPIKeys(ctx, req.ID)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	resp := APIKeysResponse{
+		Keys: keys,
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) RevokeAPIKey(c echo.Context) error {
+	var req APIKeyIDRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	key, err := h.userService.RevokeAPIKey(ctx, req.ID)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	return c.JSON(http.StatusOK, key)
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/labstack/echo/v4"
+)
+
+func (h *Handler) SendCoinBatch(c echo.Context) error {
+	userID := c.Get("user_id").(uint)
+
+	var req SendCoinBatchRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	params := model.SendCoinBatchParams{
+		FromUser:  userID,
+		Transfers: make([]model.BatchTransfer, 0, len(req.Transfers)),
+		OTP:       req.OTP,
+	}
+	for _, t := range req.Transfers {
+		params.Transfers = append(params.Transfers, model.BatchTransfer{
+			ToUser:   t.ToUser,
+			Amount:   t.Amount,
+			Memo:     t.Memo,
+			Category: t.Category,
+		})
+	}
+
+	ctx := c.Request().Context()
+
+	results, err := h.userService.SendCoinBatch(ctx, params)
+	if err != nil {
+		resp := SendCoinBatchResponse{Results: results, Errors: err.Error()}
+		return c.JSON(MapServiceErrorToStatusCode(err), resp)
+	}
+
+	return c.JSON(http.StatusOK, SendCoinBatchResponse{Results: results})
+}
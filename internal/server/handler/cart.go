@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/0x0FACED/merch-shop/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+func (h *Handler) GetCart(c echo.Context) error {
+	userID := c.Get("user_id").(uint)
+
+	ctx := c.Request().Context()
+
+	cart, err := h.userService.GetCart(ctx, userID)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	return c.JSON(http.StatusOK, cart)
+}
+
+func (h *Handler) AddCartItem(c echo.Context) error {
+	userID := c.Get("user_id").(uint)
+
+	var req AddCartItemRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	params := model.CartItemParams{
+		UserID:   userID,
+		Item:     req.Item,
+		Variant:  req.Variant,
+		Quantity: req.Quantity,
+	}
+
+	ctx := c.Request().Context()
+
+	cart, err := h.userService.AddCartItem(ctx, params)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	return c.JSON(http.StatusOK, cart)
+}
+
+func (h *Handler) UpdateCartItem(c echo.Context) error {
+	userID := c.Get("user_id").(uint)
+
+	var req UpdateCartItemRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	params := model.CartItemParams{
+		UserID:   userID,
+		Item:     req.Item,
+		Variant:  req.Variant,
+		Quantity: req.Quantity,
+	}
+
+	ctx := c.Request().Context()
+
+	cart, err := h.userService.UpdateCartItem(ctx, params)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	return c.JSON(http.StatusOK, cart)
+}
+
+func (h *Handler) RemoveCartItem(c echo.Context) error {
+	userID := c.Get("user_id").(uint)
+
+	var req CartItemRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	cart, err := h.userService.RemoveCartItem(ctx, userID, req.Item, req.Variant)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	return c.JSON(http.StatusOK, cart)
+}
+
+// Checkout оформляет корзину одним заказом. Если заказ не прошел из-за конкретной строки,
+// ее предмет приходит в поле item
+func (h *Handler) Checkout(c echo.Context) error {
+	userID := c.Get("user_id").(uint)
+
+	ctx := c.Request().Context()
+
+	order, err := h.userService.Checkout(ctx, userID)
+	if err != nil {
+		resp := CheckoutErrorResponse{Errors: err.Error()}
+
+		var lineErr *service.OrderLineError
+		if errors.As(err, &lineErr) {
+			resp.Item = lineErr.Item
+			resp.Variant = lineErr.Variant
+		}
+
+		return c.JSON(MapServiceErrorToStatusCode(err), resp)
+	}
+
+	return c.JSON(http.StatusCreated, order)
+}
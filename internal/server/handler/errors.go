@@ -12,15 +12,86 @@ func MapServiceErrorToStatusCode(err error) int {
 	switch {
 	// 401 — Ошибки аутентификации
 	case errors.Is(err, service.ErrInvalidLoginOrPassword),
-		errors.Is(err, service.ErrFailedComparingHashAndPassword):
+		errors.Is(err, service.ErrFailedComparingHashAndPassword),
+		errors.Is(err, service.ErrSessionRevoked),
+		errors.Is(err, service.ErrInvalidAPIKey),
+		errors.Is(err, service.ErrOIDCLoginFailed),
+		errors.Is(err, service.ErrTOTPRequired),
+		errors.Is(err, service.ErrInvalidTOTP):
 		return http.StatusUnauthorized
 
 	// 400 — Ошибки, связанные с неверными входными данными
 	case errors.Is(err, service.ErrInsufficientFunds),
 		errors.Is(err, service.ErrFailedToFindRecipient),
-		errors.Is(err, service.ErrNotFound):
+		errors.Is(err, service.ErrNotFound),
+		errors.Is(err, service.ErrUnknownCategory),
+		errors.Is(err, service.ErrSelfPaymentRequest),
+		errors.Is(err, service.ErrSelfScheduledTransfer),
+		errors.Is(err, service.ErrInvalidAllowancePeriod),
+		errors.Is(err, service.ErrInvalidAllowanceAmount),
+		errors.Is(err, service.ErrSelfGift),
+		errors.Is(err, service.ErrInvalidSchedule),
+		errors.Is(err, service.ErrScheduleInPast),
+		errors.Is(err, service.ErrEmptyBatch),
+		errors.Is(err, service.ErrInvalidReviewStatus),
+		errors.Is(err, service.ErrInvalidUserStatus),
+		errors.Is(err, service.ErrInvalidResetToken),
+		errors.Is(err, service.ErrInvalidScope),
+		errors.Is(err, service.ErrInvalidOIDCState),
+		errors.Is(err, service.ErrCartEmpty),
+		errors.Is(err, service.ErrCartFull),
+		errors.Is(err, service.ErrQuantityLimit),
+		errors.Is(err, service.ErrInvalidSaleWindow),
+		errors.Is(err, service.ErrVariantRequired),
+		errors.Is(err, service.ErrNegativePrice),
+		errors.Is(err, service.ErrInvalidOrderStatus),
+		errors.Is(err, service.ErrInvalidRefund),
+		errors.Is(err, service.ErrInvalidReturnReview):
 		return http.StatusBadRequest
 
+	// 403 — Нет прав, аккаунт заморожен/заблокирован или не подтвержден паролем
+	case errors.Is(err, service.ErrForbidden),
+		errors.Is(err, service.ErrInvalidPassword),
+		errors.Is(err, service.ErrAccountFrozen),
+		errors.Is(err, service.ErrAccountSuspended):
+		return http.StatusForbidden
+
+	// 404 — Ручка выключена в конфиге
+	case errors.Is(err, service.ErrOIDCDisabled),
+		errors.Is(err, service.ErrTOTPDisabled):
+		return http.StatusNotFound
+
+	// 409 — Операция конфликтует с текущим состоянием ресурса
+	case errors.Is(err, service.ErrPaymentRequestNotPending),
+		errors.Is(err, service.ErrPaymentRequestExpired),
+		errors.Is(err, service.ErrNotCancellable),
+		errors.Is(err, service.ErrFraudAlertReviewed),
+		errors.Is(err, service.ErrRecipientNotActive),
+		errors.Is(err, service.ErrUsernameTaken),
+		errors.Is(err, service.ErrTOTPAlreadyEnabled),
+		errors.Is(err, service.ErrTOTPNotEnabled),
+		errors.Is(err, service.ErrSoldOut),
+		errors.Is(err, service.ErrSaleNotStarted),
+		errors.Is(err, service.ErrSaleEnded),
+		errors.Is(err, service.ErrSKUTaken),
+		errors.Is(err, service.ErrOrderStatusTransition),
+		errors.Is(err, service.ErrNotEnoughItems),
+		errors.Is(err, service.ErrNotReturnable),
+		errors.Is(err, service.ErrReturnNotPending):
+		return http.StatusConflict
+
+	// 422 — Перевод или покупка корректны, но превышают лимиты юзера
+	case errors.Is(err, service.ErrSingleTransferLimit),
+		errors.Is(err, service.ErrDailyTransferLimit),
+		errors.Is(err, service.ErrWeeklyTransferLimit),
+		errors.Is(err, service.ErrDailyRecipientsLimit),
+		errors.Is(err, service.ErrPurchaseLimit):
+		return http.StatusUnprocessableEntity
+
+	// 429 — Слишком много неверных кодов 2FA подряд
+	case errors.Is(err, service.ErrTOTPLocked):
+		return http.StatusTooManyRequests
+
 	// 500 — Внутренние ошибки базы и транзакций
 	case errors.Is(err, service.ErrQueryFailed),
 		errors.Is(err, service.ErrScanFailed),
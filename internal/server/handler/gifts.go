@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/labstack/echo/v4"
+)
+
+func (h *Handler) GiftItem(c echo.Context) error {
+	userID := c.Get("user_id").(uint)
+
+	var req GiftItemRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	gift, err := h.userService.GiftItem(ctx, giftItemParams(userID, req))
+	if err != nil {
+		return serviceError(err)
+	}
+
+	return c.JSON(http.StatusCreated, gift)
+}
+
+// BuyGift - покупка в подарок, в ответе заказ отправителя с получателем
+func (h *Handler) BuyGift(c echo.Context) error {
+	userID := c.Get("user_id").(uint)
+
+	var req GiftItemRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	order, err := h.userService.BuyGift(ctx, giftItemParams(userID, req))
+	if err != nil {
+		return serviceError(err)
+	}
+
+	return c.JSON(http.StatusCreated, order)
+}
+
+func giftItemParams(userID uint, req GiftItemRequest) model.GiftItemParams {
+	return model.GiftItemParams{
+		FromUser: userID,
+		ToUser:   req.ToUser,
+		Item:     req.Item,
+		Variant:  req.Variant,
+		Quantity: req.Quantity,
+		Message:  req.Message,
+	}
+}
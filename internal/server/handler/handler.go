@@ -31,13 +31,134 @@ func NewHandler(u *service.MerchService, l *logger.ZapLogger, cfg *config.Server
 	}
 }
 
+// apiKeyScopes - какой скоуп нужен API ключу для ручки (метод и путь роута).
+// Ручки, которых здесь нет, доступны только по JWT
+var apiKeyScopes = map[string]string{
+	"GET /api/info":                        model.ScopeUsersRead,
+	"GET /api/paymentRequests":             model.ScopeUsersRead,
+	"GET /api/scheduledTransfers":          model.ScopeUsersRead,
+	"GET /api/scheduledTransfers/:id/runs": model.ScopeUsersRead,
+	"GET /api/cart":                        model.ScopeUsersRead,
+	"GET /api/items":                       model.ScopeUsersRead,
+	"GET /api/orders":                      model.ScopeUsersRead,
+	"GET /api/returns":                     model.ScopeUsersRead,
+
+	"POST /api/sendCoin":                    model.ScopeTransfersWrite,
+	"POST /api/sendCoin/batch":              model.ScopeTransfersWrite,
+	"POST /api/paymentRequests":             model.ScopeTransfersWrite,
+	"POST /api/paymentRequests/:id/accept":  model.ScopeTransfersWrite,
+	"POST /api/paymentRequests/:id/decline": model.ScopeTransfersWrite,
+	"POST /api/paymentRequests/:id/cancel":  model.ScopeTransfersWrite,
+	"POST /api/scheduledTransfers":          model.ScopeTransfersWrite,
+	"DELETE /api/scheduledTransfers/:id":    model.ScopeTransfersWrite,
+	"POST /api/gifts":                       model.ScopeTransfersWrite,
+
+	"GET /api/buy/:item":           model.ScopePurchasesWrite,
+	"POST /api/buy":                model.ScopePurchasesWrite,
+	"POST /api/cart/items":         model.ScopePurchasesWrite,
+	"PUT /api/cart/items/:item":    model.ScopePurchasesWrite,
+	"DELETE /api/cart/items/:item": model.ScopePurchasesWrite,
+	"POST /api/checkout":           model.ScopePurchasesWrite,
+	"POST /api/returns":            model.ScopePurchasesWrite,
+	"POST /api/gifts/buy":          model.ScopePurchasesWrite,
+}
+
 func (h *Handler) SetupRoutes(e *echo.Echo) {
-	e.POST("/api/auth", h.AuthUser) // Аутентификация юзера
-	group := e.Group("/api", AuthMiddleware)
+	e.POST("/api/auth", h.AuthUser)            // Аутентификация юзера
+	e.POST("/api/auth/reset", h.ResetPassword) // смена пароля по токену сброса, который выдал админ
+
+	// вход через SSO: login редиректит на IdP, IdP возвращает юзера на callback
+	e.GET("/api/auth/oidc/login", h.StartOIDCLogin)
+	e.GET("/api/auth/oidc/callback", h.FinishOIDCLogin)
 
-	group.GET("/info", h.GetUserInfo)   // Получаем всю инфу о юзере (транзакции, баланс, инвентарь)
-	group.GET("/buy/:item", h.BuyItem)  // Делаем покупку предмета юзером (why GET?)
+	group := e.Group("/api", h.AuthMiddleware)
+
+	group.GET("/info", h.GetUserInfo)   // Получаем всю инфу о юзере (транзакции, баланс, инвентарь), ?history=aggregated группирует историю
+	group.GET("/buy/:item", h.BuyItem)  // Делаем покупку предмета юзером (why GET?), ?variant=SKU выбирает вариант
 	group.POST("/sendCoin", h.SendCoin) // отправка монет кому-либо
+
+	// каталог с остатками на складе и ограничениями продажи
+	group.GET("/items", h.GetCatalog)
+
+	// покупка нескольких штук предмета одним заказом
+	group.POST("/buy", h.PurchaseItem)
+
+	// свои заказы со статусами выдачи
+	group.GET("/orders", h.GetOrders) // ?status=placed|ready_for_pickup|delivered|cancelled
+
+	// возврат предметов из выданных заказов, монеты возвращаются после одобрения админом
+	group.POST("/returns", h.CreateReturn)
+	group.GET("/returns", h.GetReturns) // ?status=pending|approved|rejected
+
+	// подарки предметов: из своего инвентаря или покупкой за свои монеты
+	group.POST("/gifts", h.GiftItem)
+	group.POST("/gifts/buy", h.BuyGift)
+
+	// отправка монет нескольким юзерам разом, все или ничего
+	group.POST("/sendCoin/batch", h.SendCoinBatch)
+
+	// корзина и оформление ее одним заказом
+	group.GET("/cart", h.GetCart)
+	group.POST("/cart/items", h.AddCartItem) // если предмет уже в корзине, количество увеличивается
+	group.PUT("/cart/items/:item", h.UpdateCartItem)
+	group.DELETE("/cart/items/:item", h.RemoveCartItem)
+	group.POST("/checkout", h.Checkout)
+
+	// запросы монет у других юзеров
+	group.POST("/paymentRequests", h.CreatePaymentRequest)
+	group.GET("/paymentRequests", h.GetPaymentRequests) // ?direction=incoming|outgoing
+	group.POST("/paymentRequests/:id/accept", h.AcceptPaymentRequest)
+	group.POST("/paymentRequests/:id/decline", h.DeclinePaymentRequest)
+	group.POST("/paymentRequests/:id/cancel", h.CancelPaymentRequest)
+
+	// отложенные и регулярные переводы
+	group.POST("/scheduledTransfers", h.CreateScheduledTransfer)
+	group.GET("/scheduledTransfers", h.GetScheduledTransfers)
+	group.GET("/scheduledTransfers/:id/runs", h.GetScheduledTransferRuns)
+	group.DELETE("/scheduledTransfers/:id", h.CancelScheduledTransfer)
+
+	// свои данные: выгрузка и удаление аккаунта
+	group.GET("/me/export", h.ExportUserData)
+	group.DELETE("/me", h.DeleteUser)
+	group.POST("/me/password", h.ChangePassword) // отзывает все выданные токены, в ответе новый
+
+	// двухфакторная аутентификация: подключение, подтверждение первым кодом и отключение
+	group.POST("/me/2fa", h.EnrollTOTP)
+	group.POST("/me/2fa/confirm", h.ConfirmTOTP) // в ответе коды восстановления, показываются один раз
+	group.DELETE("/me/2fa", h.DisableTOTP)
+
+	// сессии: где юзер залогинен, отзыв сессии сразу отключает ее токен
+	group.GET("/me/sessions", h.GetSessions)
+	group.DELETE("/me/sessions/:id", h.RevokeSession)
+
+	// админские ручки, роль проверяется в AdminMiddleware
+	admin := e.Group("/api/admin", h.AuthMiddleware, h.AdminMiddleware)
+	admin.GET("/fraudAlerts", h.GetFraudAlerts) // ?status=open|confirmed|dismissed
+	admin.POST("/fraudAlerts/:id/review", h.ReviewFraudAlert)
+	admin.POST("/users/:id/status", h.SetUserStatus) // заморозка, блокировка, удаление и обратно
+	admin.GET("/users/:id/status", h.GetUserStatusHistory)
+	admin.POST("/users/:id/passwordReset", h.CreatePasswordReset) // токен уходит юзеру через outbox
+
+	// склад, лимит на юзера и окно продажи предмета
+	admin.PUT("/items/:item", h.SetItemRules)
+	admin.PUT("/items/:item/variants/:sku", h.SetItemVariant) // создает вариант или заменяет его
+
+	// выдача заказов: placed -> ready_for_pickup -> delivered, до выдачи заказ можно отменить
+	admin.GET("/orders", h.GetAllOrders) // ?status=...&userId=...
+	admin.POST("/orders/:id/status", h.SetOrderStatus)
+	admin.GET("/orders/:id/status", h.GetOrderStatusHistory)
+
+	// возвраты: одобрение забирает предметы и возвращает монеты, можно вернуть меньше суммы покупки
+	admin.GET("/returns", h.GetAllReturns) // ?status=pending|approved|rejected
+	admin.POST("/returns/:id/approve", h.ApproveReturn)
+	admin.POST("/returns/:id/reject", h.RejectReturn)
+
+	// сервисные аккаунты и их API ключи
+	admin.POST("/serviceAccounts", h.CreateServiceAccount)
+	admin.GET("/serviceAccounts", h.GetServiceAccounts)
+	admin.POST("/serviceAccounts/:id/apiKeys", h.CreateAPIKey) // ключ есть только в этом ответе
+	admin.GET("/serviceAccounts/:id/apiKeys", h.GetAPIKeys)
+	admin.DELETE("/apiKeys/:id", h.RevokeAPIKey)
 }
 
 func (h *Handler) AuthUser(c echo.Context) error {
@@ -59,6 +180,7 @@ func (h *Handler) AuthUser(c echo.Context) error {
 	params := model.AuthUserParams{
 		Username: req.Username,
 		Password: req.Password,
+		OTP:      req.OTP,
 	}
 
 	ctx := c.Request().Context()
@@ -69,12 +191,7 @@ func (h *Handler) AuthUser(c echo.Context) error {
 		return echo.NewHTTPError(MapServiceErrorToStatusCode(err), resp)
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": user.ID,
-		"exp":     time.Now().Add(time.Hour * 72).Unix(),
-	})
-
-	tokenString, err := token.SignedString([]byte(jwtSecret))
+	tokenString, err := h.issueToken(c, user)
 	if err != nil {
 		resp := ErrorResponse{Errors: err.Error()}
 		return echo.NewHTTPError(MapServiceErrorToStatusCode(err), resp)
@@ -87,13 +204,60 @@ func (h *Handler) AuthUser(c echo.Context) error {
 	return c.JSON(http.StatusOK, resp)
 }
 
+// tokenTTL - срок жизни JWT, столько же живет его сессия
+const tokenTTL = 72 * time.Hour
+
+// issueToken заводит сессию и выдает привязанный к ней JWT. ver - версия токенов юзера,
+// после смены пароля старые версии не принимаются, sid - сессия, ее юзер может отозвать
+func (h *Handler) issueToken(c echo.Context, user *model.User) (string, error) {
+	expiresAt := time.Now().Add(tokenTTL)
+
+	params := model.CreateSessionParams{
+		UserID:    user.ID,
+		UserAgent: c.Request().UserAgent(),
+		IP:        c.RealIP(),
+		TTL:       tokenTTL,
+	}
+
+	session, err := h.userService.CreateSession(c.Request().Context(), params)
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": user.ID,
+		"ver":     user.TokenVersion,
+		"sid":     session.ID,
+		"exp":     expiresAt.Unix(),
+	})
+
+	return token.SignedString([]byte(jwtSecret))
+}
+
 func (h *Handler) GetUserInfo(c echo.Context) error {
 	userID := c.Get("user_id").(uint)
 
+	var req InfoRequest
+
+	if err := c.Bind(&req); err != nil {
+		resp := ErrorResponse{Errors: err.Error()}
+		return echo.NewHTTPError(http.StatusBadRequest, resp)
+	}
+
+	if err := c.Validate(&req); err != nil {
+		if validationErrs, ok := err.(*validator.ValidationErrorsResponse); ok {
+			return c.JSON(http.StatusBadRequest, validationErrs)
+		}
+		resp := ErrorResponse{Errors: err.Error()}
+		return echo.NewHTTPError(http.StatusBadRequest, resp)
+	}
+
 	ctx := c.Request().Context()
 
 	params := model.GetUserInfoParams{
-		ID: userID,
+		ID:          userID,
+		HistoryMode: req.History,
+		Category:    req.Category,
 	}
 
 	userInfo, err := h.userService.GetUserInfo(ctx, params)
@@ -106,6 +270,9 @@ func (h *Handler) GetUserInfo(c echo.Context) error {
 		Coins:       userInfo.Coins,
 		Inventory:   userInfo.Inventory,
 		CoinHistory: userInfo.CoinHistory,
+		GiftHistory: userInfo.GiftHistory,
+
+		ExpiringCoins: userInfo.Expiring,
 	}
 
 	return c.JSON(http.StatusOK, resp)
@@ -114,12 +281,14 @@ func (h *Handler) GetUserInfo(c echo.Context) error {
 func (h *Handler) BuyItem(c echo.Context) error {
 	userID := c.Get("user_id").(uint)
 	item := c.Param("item")
+	variant := c.QueryParam("variant") // SKU, без него - вариант по умолчанию
 
 	ctx := c.Request().Context()
 
 	params := model.BuyItemParams{
-		UserID: userID,
-		Item:   item,
+		UserID:  userID,
+		Item:    item,
+		Variant: variant,
 	}
 	if err := h.userService.BuyItem(ctx, params); err != nil {
 		resp := ErrorResponse{Errors: err.Error()}
@@ -152,6 +321,9 @@ func (h *Handler) SendCoin(c echo.Context) error {
 		FromUser: userID,
 		ToUser:   req.ToUser,
 		Amount:   req.Amount,
+		Memo:     req.Memo,
+		Category: req.Category,
+		OTP:      req.OTP,
 	}
 
 	ctx := c.Request().Context()
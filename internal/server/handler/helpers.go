@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/0x0FACED/merch-shop/internal/server/validator"
+	"github.com/labstack/echo/v4"
+)
+
+// bindAndValidate биндит запрос (тело, query и path параметры) и валидирует его.
+// Возвращает готовую *echo.HTTPError с кодом 400, ее можно сразу отдавать из хендлера
+func bindAndValidate(c echo.Context, req any) error {
+	if err := c.Bind(req); err != nil {
+		resp := ErrorResponse{Errors: err.Error()}
+		return echo.NewHTTPError(http.StatusBadRequest, resp)
+	}
+
+	if err := c.Validate(req); err != nil {
+		if validationErrs, ok := err.(*validator.ValidationErrorsResponse); ok {
+			return echo.NewHTTPError(http.StatusBadRequest, validationErrs)
+		}
+		resp := ErrorResponse{Errors: err.Error()}
+		return echo.NewHTTPError(http.StatusBadRequest, resp)
+	}
+
+	return nil
+}
+
+// serviceError превращает ошибку сервиса в *echo.HTTPError с нужным статус кодом
+func serviceError(err error) error {
+	resp := ErrorResponse{Errors: err.Error()}
+	return echo.NewHTTPError(MapServiceErrorToStatusCode(err), resp)
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/labstack/echo/v4"
+)
+
+func (h *Handler) GetCatalog(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	items, err := h.userService.GetCatalog(ctx)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	resp := CatalogResponse{
+		Items: items,
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// SetItemRules заменяет склад, лимит на юзера и окно продажи предмета, в ответе - предмет из каталога
+func (h *Handler) SetItemRules(c echo.Context) error {
+	var req SetItemRulesRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	params := model.SetItemRulesParams{
+		Item:         req.Item,
+		Stock:        req.Stock,
+		PerUserLimit: req.PerUserLimit,
+		SaleStartsAt: req.SaleStartsAt,
+		SaleEndsAt:   req.SaleEndsAt,
+	}
+
+	ctx := c.Request().Context()
+
+	item, err := h.userService.SetItemRules(ctx, params)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	return c.JSON(http.StatusOK, item)
+}
+
+func (h *Handler) SetItemVariant(c echo.Context) error {
+	var req SetItemVariantRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	params := model.SetItemVariantParams{
+		Item:       req.Item,
+		SKU:        req.SKU,
+		Size:       req.Size,
+		Color:      req.Color,
+		PriceDelta: req.PriceDelta,
+		Stock:      req.Stock,
+	}
+
+	ctx := c.Request().Context()
+
+	variant, err := h.userService.SetItemVariant(ctx, params)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	return c.JSON(http.StatusOK, variant)
+}
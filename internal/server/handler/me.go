@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/labstack/echo/v4"
+)
+
+// ExportUserData отдает все данные юзера одним JSON файлом
+func (h *Handler) ExportUserData(c echo.Context) error {
+	userID := c.Get("user_id").(uint)
+
+	ctx := c.Request().Context()
+
+	export, err := h.userService.ExportUserData(ctx, userID)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("merch-shop-export-%d.json", userID)),
+	)
+
+	return c.JSON(http.StatusOK, export)
+}
+
+// DeleteUser удаляет аккаунт юзера, удаление нужно подтвердить паролем
+func (h *Handler) DeleteUser(c echo.Context) error {
+	userID := c.Get("user_id").(uint)
+
+	var req DeleteUserRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	params := model.DeleteUserParams{
+		UserID:   userID,
+		Password: req.Password,
+	}
+
+	ctx := c.Request().Context()
+
+	if err := h.userService.DeleteUser(ctx, params); err != nil {
+		return serviceError(err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
@@ -1,10 +1,14 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/0x0FACED/merch-shop/internal/service"
 	"github.com/golang-jwt/jwt"
 	"github.com/labstack/echo/v4"
 )
@@ -13,7 +17,11 @@ var (
 	jwtSecret = ""
 )
 
-func AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+// AuthMiddleware проверяет JWT, статус юзера, версию токена и его сессию. Они читаются из базы
+// на каждый запрос (одним запросом, см. database.GetUserAccess), чтобы блокировка юзера,
+// смена пароля и отзыв сессии действовали сразу, а не после истечения его токена.
+// Вместо JWT можно передать API ключ сервисного аккаунта, см. authAPIKey
+func (h *Handler) AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		tokenStr := c.Request().Header.Get("Authorization")
 		if tokenStr == "" {
@@ -27,6 +35,11 @@ func AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 			return echo.NewHTTPError(http.StatusUnauthorized, resp)
 		}
 
+		// API ключи сервисных аккаунтов передаются так же, как JWT, и отличаются префиксом
+		if strings.HasPrefix(parts[1], model.APIKeyPrefix) {
+			return h.authAPIKey(c, next, parts[1])
+		}
+
 		token, err := jwt.Parse(parts[1], func(token *jwt.Token) (any, error) {
 			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -51,7 +64,41 @@ func AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 			return echo.NewHTTPError(http.StatusUnauthorized, resp)
 		}
 
+		// в токенах, выданных до появления версий, claim нет - это версия 0.
+		// Так же с сессиями: sid 0 - старый токен без сессии
+		version, _ := claims["ver"].(float64)
+		sessionID, _ := claims["sid"].(float64)
+
+		if err := h.userService.CheckUserAccess(c.Request().Context(), uint(userID), int(version), uint(sessionID)); err != nil {
+			// юзера удалили из базы - для клиента это тот же невалидный токен
+			if errors.Is(err, service.ErrNotFound) {
+				resp := ErrorResponse{Errors: "invalid user_id"}
+				return echo.NewHTTPError(http.StatusUnauthorized, resp)
+			}
+			return serviceError(err)
+		}
+
 		c.Set("user_id", uint(userID))
+		c.Set("session_id", uint(sessionID))
 		return next(c)
 	}
 }
+
+// authAPIKey пускает запрос по API ключу, если у ключа есть скоуп ручки из apiKeyScopes.
+// Ручки, которых там нет (админка, управление своим аккаунтом), ключам недоступны
+func (h *Handler) authAPIKey(c echo.Context, next echo.HandlerFunc, key string) error {
+	auth, err := h.userService.AuthAPIKey(c.Request().Context(), key)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	scope, ok := apiKeyScopes[c.Request().Method+" "+c.Path()]
+	if !ok || !slices.Contains(auth.Scopes, scope) {
+		resp := ErrorResponse{Errors: "api key has no access to this endpoint"}
+		return echo.NewHTTPError(http.StatusForbidden, resp)
+	}
+
+	c.Set("user_id", auth.UserID)
+	c.Set("api_key_id", auth.KeyID)
+	return next(c)
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/labstack/echo/v4"
+)
+
+// StartOIDCLogin отправляет юзера логиниться в IdP
+func (h *Handler) StartOIDCLogin(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	authURL, err := h.userService.StartOIDCLogin(ctx)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	return c.Redirect(http.StatusFound, authURL)
+}
+
+// FinishOIDCLogin принимает юзера обратно от IdP и выдает наш токен, как POST /api/auth
+func (h *Handler) FinishOIDCLogin(c echo.Context) error {
+	var req OIDCCallbackRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	params := model.FinishOIDCLoginParams{
+		State: req.State,
+		Code:  req.Code,
+		Error: req.Error,
+	}
+
+	ctx := c.Request().Context()
+
+	user, err := h.userService.FinishOIDCLogin(ctx, params)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	token, err := h.issueToken(c, user)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	return c.JSON(http.StatusOK, AuthResponse{Token: token})
+}
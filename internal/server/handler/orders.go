@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/labstack/echo/v4"
+)
+
+// PurchaseItem покупает несколько штук предмета одним заказом, в ответе - созданный заказ
+func (h *Handler) PurchaseItem(c echo.Context) error {
+	userID := c.Get("user_id").(uint)
+
+	var req PurchaseItemRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	params := model.PurchaseItemParams{
+		UserID:   userID,
+		Item:     req.Item,
+		Variant:  req.Variant,
+		Quantity: req.Quantity,
+	}
+
+	ctx := c.Request().Context()
+
+	order, err := h.userService.PurchaseItem(ctx, params)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	return c.JSON(http.StatusCreated, order)
+}
+
+// GetOrders возвращает заказы юзера со статусами, новые первыми
+func (h *Handler) GetOrders(c echo.Context) error {
+	userID := c.Get("user_id").(uint)
+
+	var req OrdersRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	params := model.GetOrdersParams{
+		UserID: userID,
+		Status: req.Status,
+		Limit:  req.Limit,
+	}
+
+	ctx := c.Request().Context()
+
+	orders, err := h.userService.GetOrders(ctx, params)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	resp := OrdersResponse{
+		Orders: orders,
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// GetAllOrders - заказы всех юзеров для офис-менеджера, например все ready_for_pickup
+func (h *Handler) GetAllOrders(c echo.Context) error {
+	var req AdminOrdersRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	params := model.GetOrdersParams{
+		UserID: req.UserID,
+		Status: req.Status,
+		Limit:  req.Limit,
+	}
+
+	ctx := c.Request().Context()
+
+	orders, err := h.userService.GetOrders(ctx, params)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	resp := OrdersResponse{
+		Orders: orders,
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) SetOrderStatus(c echo.Context) error {
+	adminID := c.Get("user_id").(uint)
+
+	var req SetOrderStatusRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	params := model.SetOrderStatusParams{
+		OrderID: req.ID,
+		AdminID: adminID,
+		Status:  req.Status,
+		Reason:  req.Reason,
+	}
+
+	ctx := c.Request().Context()
+
+	order, err := h.userService.SetOrderStatus(ctx, params)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	return c.JSON(http.StatusOK, order)
+}
+
+func (h *Handler) GetOrderStatusHistory(c echo.Context) error {
+	var req OrderIDRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	history, err := h.userService.GetOrderStatusHistory(ctx, req.ID)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	resp := OrderStatusHistoryResponse{
+		History: history,
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
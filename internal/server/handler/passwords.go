@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/labstack/echo/v4"
+)
+
+// ChangePassword меняет пароль. Все токены юзера, включая текущий, отзываются,
+// поэтому в ответе новый токен
+func (h *Handler) ChangePassword(c echo.Context) error {
+	userID := c.Get("user_id").(uint)
+
+	var req ChangePasswordRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	params := model.ChangePasswordParams{
+		UserID:      userID,
+		OldPassword: req.OldPassword,
+		NewPassword: req.NewPassword,
+	}
+
+	ctx := c.Request().Context()
+
+	user, err := h.userService.ChangePassword(ctx, params)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	token, err := h.issueToken(c, user)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	return c.JSON(http.StatusOK, AuthResponse{Token: token})
+}
+
+// CreatePasswordReset выдает юзеру токен сброса пароля, сам токен в ответ не попадает
+func (h *Handler) CreatePasswordReset(c echo.Context) error {
+	adminID := c.Get("user_id").(uint)
+
+	var req UserIDRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	params := model.CreatePasswordResetParams{
+		UserID:  req.ID,
+		AdminID: adminID,
+	}
+
+	ctx := c.Request().Context()
+
+	reset, err := h.userService.CreatePasswordReset(ctx, params)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	return c.JSON(http.StatusCreated, reset)
+}
+
+// ResetPassword ставит новый пароль по токену сброса. Ручка без авторизации:
+// токен сам по себе подтверждает, что запрос от владельца аккаунта
+func (h *Handler) ResetPassword(c echo.Context) error {
+	var req ResetPasswordRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	params := model.ResetPasswordParams{
+		Token:       req.Token,
+		NewPassword: req.NewPassword,
+	}
+
+	ctx := c.Request().Context()
+
+	if err := h.userService.ResetPassword(ctx, params); err != nil {
+		return serviceError(err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/labstack/echo/v4"
+)
+
+func (h *Handler) CreatePaymentRequest(c echo.Context) error {
+	userID := c.Get("user_id").(uint)
+
+	var req CreatePaymentRequestRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	params := model.CreatePaymentRequestParams{
+		FromUser: userID,
+		ToUser:   req.ToUser,
+		Amount:   req.Amount,
+		Memo:     req.Memo,
+		TTL:      time.Duration(req.ExpiresInHours) * time.Hour,
+	}
+
+	ctx := c.Request().Context()
+
+	pr, err := h.userService.CreatePaymentRequest(ctx, params)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	return c.JSON(http.StatusCreated, pr)
+}
+
+func (h *Handler) GetPaymentRequests(c echo.Context) error {
+	userID := c.Get("user_id").(uint)
+
+	var req PaymentRequestsRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	params := model.GetPaymentRequestsParams{
+		UserID:    userID,
+		Direction: req.Direction,
+	}
+	if params.Direction == "" {
+		params.Direction = model.PaymentRequestsIncoming
+	}
+
+	ctx := c.Request().Context()
+
+	requests, err := h.userService.GetPaymentRequests(ctx, params)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	resp := PaymentRequestsResponse{
+		Requests: requests,
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) AcceptPaymentRequest(c echo.Context) error {
+	return h.resolvePaymentRequest(c, h.userService.AcceptPaymentRequest)
+}
+
+func (h *Handler) DeclinePaymentRequest(c echo.Context) error {
+	return h.resolvePaymentRequest(c, h.userService.DeclinePaymentRequest)
+}
+
+func (h *Handler) CancelPaymentRequest(c echo.Context) error {
+	return h.resolvePaymentRequest(c, h.userService.CancelPaymentRequest)
+}
+
+// resolvePaymentRequest - общая часть accept/decline/cancel, отличается только метод сервиса
+func (h *Handler) resolvePaymentRequest(
+	c echo.Context,
+	resolve func(ctx context.Context, params model.ResolvePaymentRequestParams) (*model.PaymentRequest, error),
+) error {
+	userID := c.Get("user_id").(uint)
+
+	var req PaymentRequestIDRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	params := model.ResolvePaymentRequestParams{
+		ID:     req.ID,
+		UserID: userID,
+		OTP:    req.OTP,
+	}
+
+	pr, err := resolve(c.Request().Context(), params)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	return c.JSON(http.StatusOK, pr)
+}
@@ -1,11 +1,232 @@
 package handler
 
+import "time"
+
 type AuthRequest struct {
 	Username string `json:"username" validate:"required,alphanum,max=255"`
 	Password string `json:"password" validate:"required,alphanum,min=4,max=128"`
+	OTP      string `json:"otp" validate:"omitempty,max=32,printascii"` // если у юзера включена 2FA
 }
 
 type SendCoinRequest struct {
-	ToUser string `json:"toUser" validate:"required,alphanum,max=255"`
-	Amount int    `json:"amount" validate:"required,gt=0"`
+	ToUser   string `json:"toUser" validate:"required,alphanum,max=255"`
+	Amount   int    `json:"amount" validate:"required,gt=0"`
+	Memo     string `json:"memo" validate:"omitempty,max=255,memo"`
+	Category string `json:"category" validate:"omitempty,max=32"`
+	// код 2FA для крупного перевода. В пачке игнорируется, там он один на всю пачку
+	OTP string `json:"otp" validate:"omitempty,max=32,printascii"`
+}
+
+// SendCoinBatchRequest - ошибки валидации отдельных переводов приходят с индексом в массиве
+type SendCoinBatchRequest struct {
+	Transfers []SendCoinRequest `json:"transfers" validate:"required,min=1,max=100,dive"`
+	OTP       string            `json:"otp" validate:"omitempty,max=32,printascii"`
+}
+
+type InfoRequest struct {
+	History  string `query:"history" validate:"omitempty,oneof=raw aggregated"`
+	Category string `query:"category" validate:"omitempty,max=32"`
+}
+
+type CreatePaymentRequestRequest struct {
+	ToUser         string `json:"toUser" validate:"required,alphanum,max=255"`
+	Amount         int    `json:"amount" validate:"required,gt=0"`
+	Memo           string `json:"memo" validate:"omitempty,max=255,memo"`
+	ExpiresInHours int    `json:"expiresInHours" validate:"omitempty,gt=0,lte=720"`
+}
+
+type PaymentRequestsRequest struct {
+	Direction string `query:"direction" validate:"omitempty,oneof=incoming outgoing"`
+}
+
+type PaymentRequestIDRequest struct {
+	ID uint `param:"id" validate:"required,gt=0"`
+	// код 2FA для принятия крупного запроса, для decline и cancel игнорируется
+	OTP string `json:"otp" validate:"omitempty,max=32,printascii"`
+}
+
+// CreateScheduledTransferRequest - нужно указать либо runAt (разовый перевод), либо schedule (cron, UTC)
+type CreateScheduledTransferRequest struct {
+	ToUser   string     `json:"toUser" validate:"required,alphanum,max=255"`
+	Amount   int        `json:"amount" validate:"required,gt=0"`
+	Memo     string     `json:"memo" validate:"omitempty,max=255,memo"`
+	Category string     `json:"category" validate:"omitempty,max=32"`
+	RunAt    *time.Time `json:"runAt" validate:"required_without=Schedule,excluded_with=Schedule"`
+	Schedule string     `json:"schedule" validate:"required_without=RunAt,max=255"`
+	// код 2FA, если сумма одного запуска больше порога step-up
+	OTP string `json:"otp" validate:"omitempty,max=32,printascii"`
+}
+
+type ScheduledTransferIDRequest struct {
+	ID uint `param:"id" validate:"required,gt=0"`
+}
+
+type FraudAlertsRequest struct {
+	Status string `query:"status" validate:"omitempty,oneof=open confirmed dismissed"`
+	Limit  int    `query:"limit" validate:"omitempty,gt=0,lte=100"`
+}
+
+type ReviewFraudAlertRequest struct {
+	ID       uint   `param:"id" validate:"required,gt=0"`
+	Status   string `json:"status" validate:"required,oneof=confirmed dismissed"`
+	Note     string `json:"note" validate:"omitempty,max=1024"`
+	Unfreeze bool   `json:"unfreeze"`
+}
+
+type SetUserStatusRequest struct {
+	ID     uint   `param:"id" validate:"required,gt=0"`
+	Status string `json:"status" validate:"required,oneof=active frozen suspended"`
+	Reason string `json:"reason" validate:"required,max=1024,memo"`
+}
+
+type UserIDRequest struct {
+	ID uint `param:"id" validate:"required,gt=0"`
+}
+
+// ConfirmTOTPRequest - первый код из приложения после EnrollTOTP
+type ConfirmTOTPRequest struct {
+	Code string `json:"code" validate:"required,numeric,len=6"`
+}
+
+// DisableTOTPRequest - код TOTP или код восстановления
+type DisableTOTPRequest struct {
+	Code string `json:"code" validate:"required,max=32,printascii"`
+}
+
+// DeleteUserRequest - удаление аккаунта подтверждается текущим паролем
+type DeleteUserRequest struct {
+	Password string `json:"password" validate:"required,max=128"`
+}
+
+// ChangePasswordRequest - новый пароль валидируется так же, как при входе, иначе с ним нельзя будет войти
+type ChangePasswordRequest struct {
+	OldPassword string `json:"oldPassword" validate:"required,max=128"`
+	NewPassword string `json:"newPassword" validate:"required,alphanum,min=4,max=128"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required,hexadecimal,len=64"`
+	NewPassword string `json:"newPassword" validate:"required,alphanum,min=4,max=128"`
+}
+
+// OIDCCallbackRequest - редирект от IdP: code при успехе или error, если юзер не вошел
+type OIDCCallbackRequest struct {
+	State string `query:"state" validate:"required,hexadecimal,len=64"`
+	Code  string `query:"code" validate:"required_without=Error,max=2048"`
+	Error string `query:"error" validate:"max=256"`
+}
+
+// CreateServiceAccountRequest - имя аккаунта занимает username, поэтому правила те же, что у логина
+type CreateServiceAccountRequest struct {
+	Name string `json:"name" validate:"required,alphanum,max=255"`
+}
+
+type CreateAPIKeyRequest struct {
+	ID             uint     `param:"id" validate:"required,gt=0"`
+	Name           string   `json:"name" validate:"omitempty,max=255,memo"`
+	Scopes         []string `json:"scopes" validate:"required,min=1,dive,oneof=users:read transfers:write purchases:write"`
+	ExpiresInHours int      `json:"expiresInHours" validate:"omitempty,gt=0,lte=87600"`
+}
+
+type APIKeyIDRequest struct {
+	ID uint `param:"id" validate:"required,gt=0"`
+}
+
+type SessionIDRequest struct {
+	ID uint `param:"id" validate:"required,gt=0"`
+}
+
+// Variant - SKU варианта предмета, без него - вариант по умолчанию
+type AddCartItemRequest struct {
+	Item     string `json:"item" validate:"required,max=255"`
+	Variant  string `json:"variant" validate:"omitempty,max=255"`
+	Quantity int    `json:"quantity" validate:"required,gt=0"`
+}
+
+type UpdateCartItemRequest struct {
+	Item     string `param:"item" validate:"required,max=255"`
+	Variant  string `json:"variant" validate:"omitempty,max=255"`
+	Quantity int    `json:"quantity" validate:"required,gt=0"`
+}
+
+// CartItemRequest - удаление из корзины, тела у DELETE нет, поэтому вариант в ?variant=
+type CartItemRequest struct {
+	Item    string `param:"item" validate:"required,max=255"`
+	Variant string `query:"variant" validate:"omitempty,max=255"`
+}
+
+// SetItemVariantRequest - вариант создается или заменяется целиком, без stock склад не ограничен
+type SetItemVariantRequest struct {
+	Item       string `param:"item" validate:"required,max=255"`
+	SKU        string `param:"sku" validate:"required,max=255,printascii"`
+	Size       string `json:"size" validate:"omitempty,max=32"`
+	Color      string `json:"color" validate:"omitempty,max=32"`
+	PriceDelta int    `json:"priceDelta"`
+	Stock      *int   `json:"stock" validate:"omitempty,gte=0"`
+}
+
+// SetItemRulesRequest - ограничения продажи заменяются целиком, отсутствующее поле снимает ограничение
+type SetItemRulesRequest struct {
+	Item         string     `param:"item" validate:"required,max=255"`
+	Stock        *int       `json:"stock" validate:"omitempty,gte=0"`
+	PerUserLimit *int       `json:"perUserLimit" validate:"omitempty,gt=0"`
+	SaleStartsAt *time.Time `json:"saleStartsAt"`
+	SaleEndsAt   *time.Time `json:"saleEndsAt"`
+}
+
+type PurchaseItemRequest struct {
+	Item     string `json:"item" validate:"required,max=255"`
+	Variant  string `json:"variant" validate:"omitempty,max=255"`
+	Quantity int    `json:"quantity" validate:"required,gt=0"`
+}
+
+type OrdersRequest struct {
+	Status string `query:"status" validate:"omitempty,oneof=placed ready_for_pickup delivered cancelled"`
+	Limit  int    `query:"limit" validate:"omitempty,gt=0,lte=100"`
+}
+
+// AdminOrdersRequest - заказы всех юзеров, userId - только заказы одного юзера
+type AdminOrdersRequest struct {
+	UserID uint   `query:"userId"`
+	Status string `query:"status" validate:"omitempty,oneof=placed ready_for_pickup delivered cancelled"`
+	Limit  int    `query:"limit" validate:"omitempty,gt=0,lte=100"`
+}
+
+// SetOrderStatusRequest - в placed заказ не возвращается, причина обязательна для отмены (проверяет сервис)
+type SetOrderStatusRequest struct {
+	ID     uint   `param:"id" validate:"required,gt=0"`
+	Status string `json:"status" validate:"required,oneof=ready_for_pickup delivered cancelled"`
+	Reason string `json:"reason" validate:"omitempty,max=1024,memo"`
+}
+
+type OrderIDRequest struct {
+	ID uint `param:"id" validate:"required,gt=0"`
+}
+
+type CreateReturnRequest struct {
+	Item     string `json:"item" validate:"required,max=255"`
+	Variant  string `json:"variant" validate:"omitempty,max=255"`
+	Quantity int    `json:"quantity" validate:"required,gt=0"`
+	Reason   string `json:"reason" validate:"required,max=1024,memo"`
+}
+
+// GiftItemRequest - подарок из инвентаря (/api/gifts) или покупкой (/api/gifts/buy)
+type GiftItemRequest struct {
+	ToUser   string `json:"toUser" validate:"required,alphanum,max=255"`
+	Item     string `json:"item" validate:"required,max=255"`
+	Variant  string `json:"variant" validate:"omitempty,max=255"`
+	Quantity int    `json:"quantity" validate:"required,gt=0"`
+	Message  string `json:"message" validate:"omitempty,max=1024,memo"`
+}
+
+type ReturnsRequest struct {
+	Status string `query:"status" validate:"omitempty,oneof=pending approved rejected"`
+	Limit  int    `query:"limit" validate:"omitempty,gt=0,lte=100"`
+}
+
+// ReviewReturnRequest - refund задает частичный возврат, без него возвращается вся сумма покупки
+type ReviewReturnRequest struct {
+	ID     uint   `param:"id" validate:"required,gt=0"`
+	Refund *int   `json:"refund" validate:"omitempty,gte=0"`
+	Note   string `json:"note" validate:"omitempty,max=1024,memo"`
 }
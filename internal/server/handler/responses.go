@@ -10,8 +10,79 @@ type InfoResponse struct {
 	Coins       uint              `json:"coins"`
 	Inventory   []model.Item      `json:"inventory"`
 	CoinHistory model.CoinHistory `json:"coinHistory"`
+	GiftHistory model.GiftHistory `json:"giftHistory"`
+	// ближайшие сгорания монет, если срок жизни монет включен
+	ExpiringCoins []model.ExpiringCoins `json:"expiringCoins,omitempty"`
+}
+
+// SendCoinBatchResponse - результаты в порядке переводов в запросе. При ошибке
+// Errors описывает ее, а в Results видно, какой перевод упал
+type SendCoinBatchResponse struct {
+	Results []model.BatchTransferResult `json:"results"`
+	Errors  string                      `json:"errors,omitempty"`
 }
 
 type AuthResponse struct {
 	Token string `json:"token"`
 }
+
+type PaymentRequestsResponse struct {
+	Requests []model.PaymentRequest `json:"requests"`
+}
+
+type ScheduledTransfersResponse struct {
+	Transfers []model.ScheduledTransfer `json:"transfers"`
+}
+
+type ScheduledTransferRunsResponse struct {
+	Runs []model.ScheduledTransferRun `json:"runs"`
+}
+
+type FraudAlertsResponse struct {
+	Alerts []model.FraudAlert `json:"alerts"`
+}
+
+type UserStatusHistoryResponse struct {
+	History []model.UserStatusChange `json:"history"`
+}
+
+type ServiceAccountsResponse struct {
+	Accounts []model.ServiceAccount `json:"accounts"`
+}
+
+type APIKeysResponse struct {
+	Keys []model.APIKey `json:"keys"`
+}
+
+// RecoveryCodesResponse - коды восстановления 2FA, показываются один раз
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type SessionsResponse struct {
+	Sessions []model.Session `json:"sessions"`
+}
+
+type CatalogResponse struct {
+	Items []model.CatalogItem `json:"items"`
+}
+
+type OrdersResponse struct {
+	Orders []model.Order `json:"orders"`
+}
+
+type OrderStatusHistoryResponse struct {
+	History []model.OrderStatusChange `json:"history"`
+}
+
+type ReturnsResponse struct {
+	Returns []model.ReturnRequest `json:"returns"`
+}
+
+// CheckoutErrorResponse - ошибка оформления заказа. Item и Variant - предмет и SKU варианта строки
+// корзины, из-за которой заказ не прошел, пусто, если дело не в конкретной строке
+type CheckoutErrorResponse struct {
+	Errors  string `json:"errors"`
+	Item    string `json:"item,omitempty"`
+	Variant string `json:"variant,omitempty"`
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/labstack/echo/v4"
+)
+
+func (h *Handler) CreateReturn(c echo.Context) error {
+	userID := c.Get("user_id").(uint)
+
+	var req CreateReturnRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	params := model.CreateReturnParams{
+		UserID:   userID,
+		Item:     req.Item,
+		Variant:  req.Variant,
+		Quantity: req.Quantity,
+		Reason:   req.Reason,
+	}
+
+	ctx := c.Request().Context()
+
+	ret, err := h.userService.CreateReturn(ctx, params)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	return c.JSON(http.StatusCreated, ret)
+}
+
+func (h *Handler) GetReturns(c echo.Context) error {
+	userID := c.Get("user_id").(uint)
+
+	var req ReturnsRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	params := model.GetReturnsParams{
+		UserID: userID,
+		Status: req.Status,
+		Limit:  req.Limit,
+	}
+
+	return h.listReturns(c, params)
+}
+
+// GetAllReturns - возвраты всех юзеров для админа, например все pending
+func (h *Handler) GetAllReturns(c echo.Context) error {
+	var req ReturnsRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	params := model.GetReturnsParams{
+		Status: req.Status,
+		Limit:  req.Limit,
+	}
+
+	return h.listReturns(c, params)
+}
+
+func (h *Handler) listReturns(c echo.Context, params model.GetReturnsParams) error {
+	ctx := c.Request().Context()
+
+	returns, err := h.userService.GetReturns(ctx, params)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	resp := ReturnsResponse{
+		Returns: returns,
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) ApproveReturn(c echo.Context) error {
+	return h.reviewReturn(c, model.ReturnApproved)
+}
+
+func (h *Handler) RejectReturn(c echo.Context) error {
+	return h.reviewReturn(c, model.ReturnRejected)
+}
+
+func (h *Handler) reviewReturn(c echo.Context, status string) error {
+	adminID := c.Get("user_id").(uint)
+
+	var req ReviewReturnRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	params := model.ReviewReturnParams{
+		ID:      req.ID,
+		AdminID: adminID,
+		Status:  status,
+		Refund:  req.Refund,
+		Note:    req.Note,
+	}
+
+	ctx := c.Request().Context()
+
+	ret, err := h.userService.ReviewReturn(ctx, params)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	return c.JSON(http.StatusOK, ret)
+}
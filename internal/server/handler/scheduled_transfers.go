@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/labstack/echo/v4"
+)
+
+func (h *Handler) CreateScheduledTransfer(c echo.Context) error {
+	userID := c.Get("user_id").(uint)
+
+	var req CreateScheduledTransferRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	params := model.CreateScheduledTransferParams{
+		FromUser: userID,
+		ToUser:   req.ToUser,
+		Amount:   req.Amount,
+		Memo:     req.Memo,
+		Category: req.Category,
+		Schedule: req.Schedule,
+		OTP:      req.OTP,
+	}
+	if req.RunAt != nil {
+		params.RunAt = *req.RunAt
+	}
+
+	ctx := c.Request().Context()
+
+	st, err := h.userService.CreateScheduledTransfer(ctx, params)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	return c.JSON(http.StatusCreated, st)
+}
+
+func (h *Handler) GetScheduledTransfers(c echo.Context) error {
+	userID := c.Get("user_id").(uint)
+
+	params := model.GetScheduledTransfersParams{
+		UserID: userID,
+	}
+
+	ctx := c.Request().Context()
+
+	transfers, err := h.userService.GetScheduledTransfers(ctx, params)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	resp := ScheduledTransfersResponse{
+		Transfers: transfers,
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) GetScheduledTransferRuns(c echo.Context) error {
+	userID := c.Get("user_id").(uint)
+
+	var req ScheduledTransferIDRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	params := model.ScheduledTransferIDParams{
+		ID:     req.ID,
+		UserID: userID,
+	}
+
+	ctx := c.Request().Context()
+
+	runs, err := h.userService.GetScheduledTransferRuns(ctx, params)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	resp := ScheduledTransferRunsResponse{
+		Runs: runs,
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) CancelScheduledTransfer(c echo.Context) error {
+	userID := c.Get("user_id").(uint)
+
+	var req ScheduledTransferIDRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	params := model.ScheduledTransferIDParams{
+		ID:     req.ID,
+		UserID: userID,
+	}
+
+	ctx := c.Request().Context()
+
+	if err := h.userService.CancelScheduledTransfer(ctx, params); err != nil {
+		return serviceError(err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
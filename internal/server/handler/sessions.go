@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/labstack/echo/v4"
+)
+
+// GetSessions отдает действующие сессии юзера, текущая помечена current
+func (h *Handler) GetSessions(c echo.Context) error {
+	userID := c.Get("user_id").(uint)
+	sessionID := c.Get("session_id").(uint)
+
+	ctx := c.Request().Context()
+
+	sessions, err := h.userService.GetSessions(ctx, userID, sessionID)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	resp := SessionsResponse{
+		Sessions: sessions,
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// RevokeSession отзывает сессию, ее токен перестает приниматься сразу
+func (h *Handler) RevokeSession(c echo.Context) error {
+	userID := c.Get("user_id").(uint)
+
+	var req SessionIDRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	params := model.SessionIDParams{
+		ID:     req.ID,
+		UserID: userID,
+	}
+
+	ctx := c.Request().Context()
+
+	if err := h.userService.RevokeSession(ctx, params); err != nil {
+		return serviceError(err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
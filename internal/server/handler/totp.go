@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// EnrollTOTP начинает подключение 2FA. Секрет и otpauth:// ссылку нужно добавить
+// в приложение-аутентификатор, а затем подтвердить первым кодом
+func (h *Handler) EnrollTOTP(c echo.Context) error {
+	userID := c.Get("user_id").(uint)
+
+	ctx := c.Request().Context()
+
+	enrollment, err := h.userService.EnrollTOTP(ctx, userID)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	return c.JSON(http.StatusCreated, enrollment)
+}
+
+func (h *Handler) ConfirmTOTP(c echo.Context) error {
+	userID := c.Get("user_id").(uint)
+
+	var req ConfirmTOTPRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	codes, err := h.userService.ConfirmTOTP(ctx, userID, req.Code)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	return c.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (h *Handler) DisableTOTP(c echo.Context) error {
+	userID := c.Get("user_id").(uint)
+
+	var req DisableTOTPRequest
+	if err := bindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	if err := h.userService.DisableTOTP(ctx, userID, req.Code); err != nil {
+		return serviceError(err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"errors"
+	"expvar"
 	"fmt"
 	"net/http"
 	"net/http/pprof"
@@ -46,6 +47,7 @@ func NewServer(cfg *config.ServiceConfig, h *handler.Handler) (*Server, error) {
 	pprofMux.Handle("/debug/pprof/profile", http.HandlerFunc(pprof.Profile))
 	pprofMux.Handle("/debug/pprof/symbol", http.HandlerFunc(pprof.Symbol))
 	pprofMux.Handle("/debug/pprof/trace", http.HandlerFunc(pprof.Trace))
+	pprofMux.Handle("/debug/vars", expvar.Handler()) // метрики, в т.ч. cache_hits/cache_misses
 
 	pprofSrv := &http.Server{
 		Addr:    ":6060",
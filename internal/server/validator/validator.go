@@ -2,12 +2,15 @@ package validator
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
 
 	"github.com/go-playground/validator/v10"
 )
 
 type ValidationError struct {
-	Index int    `json:"index,omitempty"` // Индекс записи в массиве
+	Index *int   `json:"index,omitempty"` // Индекс записи в массиве, nil если ошибка не в массиве
 	Field string `json:"field"`           // Поле, где произошла ошибка
 	Tag   string `json:"tag"`             // Тэг валидации, который не прошел
 }
@@ -24,7 +27,10 @@ func (v *ValidationErrorsResponse) Error() string {
 
 	var result string
 	for _, err := range v.Errors {
-		result += fmt.Sprintf("Index: %d, Field: %s, Tag: %s; ", err.Index, err.Field, err.Tag)
+		if err.Index != nil {
+			result += fmt.Sprintf("Index: %d, ", *err.Index)
+		}
+		result += fmt.Sprintf("Field: %s, Tag: %s; ", err.Field, err.Tag)
 	}
 
 	return fmt.Sprintf("validation errors: %s", result)
@@ -35,11 +41,34 @@ type APIValidator struct {
 }
 
 func NewAPIValidator() *APIValidator {
+	v := validator.New()
+
+	// Ошибку не проверяем: она возможна только при пустом теге или nil функции
+	_ = v.RegisterValidation("memo", validateMemo)
+
 	return &APIValidator{
-		validator: validator.New(),
+		validator: v,
 	}
 }
 
+// validateMemo проверяет комментарий к переводу: он не должен состоять
+// только из пробелов и не должен содержать управляющих символов (переносы строк, \x00 и т.д.)
+func validateMemo(fl validator.FieldLevel) bool {
+	memo := fl.Field().String()
+
+	if strings.TrimSpace(memo) == "" {
+		return false
+	}
+
+	for _, r := range memo {
+		if unicode.IsControl(r) || r == unicode.ReplacementChar {
+			return false
+		}
+	}
+
+	return true
+}
+
 func (v *APIValidator) Validate(i any) error {
 	if errors := v.ValidateStruct(i); len(errors) > 0 {
 		return &ValidationErrorsResponse{Errors: errors}
@@ -57,6 +86,7 @@ func (v *APIValidator) ValidateStruct(i any) []*ValidationError {
 	if validationErrs, ok := err.(validator.ValidationErrors); ok {
 		for _, fieldErr := range validationErrs {
 			validationErrors = append(validationErrors, &ValidationError{
+				Index: sliceIndex(fieldErr.StructNamespace()),
 				Field: fieldErr.StructNamespace(),
 				Tag:   fieldErr.Tag(),
 			})
@@ -65,3 +95,24 @@ func (v *APIValidator) ValidateStruct(i any) []*ValidationError {
 
 	return validationErrors
 }
+
+// sliceIndex достает индекс первого элемента массива из пути до поля,
+// например 3 из "SendCoinBatchRequest.Transfers[3].Amount"
+func sliceIndex(namespace string) *int {
+	start := strings.IndexByte(namespace, '[')
+	if start < 0 {
+		return nil
+	}
+
+	end := strings.IndexByte(namespace[start:], ']')
+	if end < 0 {
+		return nil
+	}
+
+	idx, err := strconv.Atoi(namespace[start+1 : start+end])
+	if err != nil {
+		return nil
+	}
+
+	return &idx
+}
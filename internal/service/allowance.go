@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"go.uber.org/zap"
+)
+
+// ключ периода: 2026-10 для monthly или 2026-W42 для weekly
+var allowancePeriodRe = regexp.MustCompile(`^\d{4}-(0[1-9]|1[0-2]|W(0[1-9]|[1-4]\d|5[0-3]))$`)
+
+// AllowancePeriod возвращает ключ периода, в который попадает t (в UTC)
+func AllowancePeriod(period string, t time.Time) (string, error) {
+	t = t.UTC()
+
+	switch period {
+	case model.AllowancePeriodMonthly:
+		return t.Format("2006-01"), nil
+	case model.AllowancePeriodWeekly:
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week), nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidAllowancePeriod, period)
+	}
+}
+
+// GrantAllowance начисляет норму монет всем юзерам за период. Повторный вызов
+// за тот же период ничего не начислит. Каждое начисление попадает в историю от @system
+func (s *MerchService) GrantAllowance(ctx context.Context, params model.GrantAllowanceParams) (*model.AllowanceResult, error) {
+	s.logger.Info("GrantAllowance() request", zap.Any("params", params))
+
+	if !allowancePeriodRe.MatchString(params.Period) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidAllowancePeriod, params.Period)
+	}
+
+	if params.Amount <= 0 {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidAllowanceAmount, params.Amount)
+	}
+
+	result, err := s.repo.GrantAllowance(ctx, params)
+	if err != nil {
+		s.logger.Error("GrantAllowance() -> GrantAllowance() request | error",
+			zap.Any("params", params),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	// режем на пачки, чтобы не слать в кэш один огромный DEL
+	const chunk = 500
+	for start := 0; start < len(result.UserIDs); start += chunk {
+		end := min(start+chunk, len(result.UserIDs))
+		s.invalidateUserInfo(ctx, result.UserIDs[start:end]...)
+	}
+
+	s.logger.Info("GrantAllowance() response",
+		zap.Any("params", params),
+		zap.Int("users", result.Users),
+		zap.Int("total_amount", result.TotalAmount),
+	)
+
+	return result, nil
+}
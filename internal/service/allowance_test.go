@@ -0,0 +1,85 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/internal/cache"
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/0x0FACED/merch-shop/internal/service"
+	"github.com/0x0FACED/merch-shop/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAllowancePeriod(t *testing.T) {
+	at := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+
+	monthly, err := service.AllowancePeriod(model.AllowancePeriodMonthly, at)
+	assert.NoError(t, err)
+	assert.Equal(t, "2026-10", monthly)
+
+	weekly, err := service.AllowancePeriod(model.AllowancePeriodWeekly, at)
+	assert.NoError(t, err)
+	assert.Equal(t, "2026-W43", weekly)
+
+	_, err = service.AllowancePeriod("daily", at)
+	assert.ErrorIs(t, err, service.ErrInvalidAllowancePeriod)
+}
+
+// Тест начисления: кэш сбрасывается у всех, кому начислили
+func TestGrantAllowance_Success(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	deps := testDeps()
+	deps.Cache = cache.NewLRU(10, time.Minute)
+	userService := service.NewUserService(mockRepo, testLogger(), deps)
+
+	infoParams := model.GetUserInfoParams{ID: 1}
+	params := model.GrantAllowanceParams{Period: "2026-10", Amount: 100, MaxBalance: 2000}
+
+	mockRepo.On("GetUserInfo", mock.Anything, infoParams).Return(&model.UserInfo{Coins: 1000}, nil).Once()
+	mockRepo.On("GrantAllowance", mock.Anything, params).Return(&model.AllowanceResult{
+		Period:      "2026-10",
+		Users:       1,
+		TotalAmount: 100,
+		UserIDs:     []uint{1},
+	}, nil)
+	mockRepo.On("GetUserInfo", mock.Anything, infoParams).Return(&model.UserInfo{Coins: 1100}, nil).Once()
+
+	_, _ = userService.GetUserInfo(context.Background(), infoParams)
+
+	result, err := userService.GrantAllowance(context.Background(), params)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, result.TotalAmount)
+
+	info, err := userService.GetUserInfo(context.Background(), infoParams)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1100), info.Coins)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGrantAllowance_InvalidPeriod(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	params := model.GrantAllowanceParams{Period: "October", Amount: 100}
+
+	_, err := userService.GrantAllowance(context.Background(), params)
+
+	assert.ErrorIs(t, err, service.ErrInvalidAllowancePeriod)
+	mockRepo.AssertNotCalled(t, "GrantAllowance", mock.Anything, mock.Anything)
+}
+
+func TestGrantAllowance_InvalidAmount(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	for _, amount := range []int{0, -100} {
+		_, err := userService.GrantAllowance(context.Background(), model.GrantAllowanceParams{Period: "2026-10", Amount: amount})
+
+		assert.ErrorIs(t, err, service.ErrInvalidAllowanceAmount)
+		assert.NotErrorIs(t, err, service.ErrInvalidAllowancePeriod)
+	}
+	mockRepo.AssertNotCalled(t, "GrantAllowance", mock.Anything, mock.Anything)
+}
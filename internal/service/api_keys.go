@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"go.uber.org/zap"
+)
+
+// apiKeyBytes - сколько случайных байт в ключе, в hex он в два раза длиннее
+const apiKeyBytes = 24
+
+// apiKeyPrefixLen - сколько первых символов ключа храним открыто, чтобы отличать ключи в списке
+const apiKeyPrefixLen = len(model.APIKeyPrefix) + 8
+
+func (s *MerchService) CreateServiceAccount(ctx context.Context, params model.CreateServiceAccountParams) (*model.ServiceAccount, error) {
+	s.logger.Info("CreateServiceAccount() request", zap.Any("params", params))
+
+	account, err := s.repo.CreateServiceAccount(ctx, params)
+	if err != nil {
+		s.logger.Error("CreateServiceAccount() -> CreateServiceAccount() request | error",
+			zap.Any("params", params),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	s.logger.Info("CreateServiceAccount() response", zap.Any("account", account))
+
+	return account, nil
+}
+
+func (s *MerchService) GetServiceAccounts(ctx context.Context) ([]model.ServiceAccount, error) {
+	s.logger.Info("GetServiceAccounts() request")
+
+	accounts, err := s.repo.GetServiceAccounts(ctx)
+	if err != nil {
+		s.logger.Error("GetServiceAccounts() -> GetServiceAccounts() request | error", zap.Error(err))
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	return accounts, nil
+}
+
+// CreateAPIKey выпускает ключ для сервисного аккаунта. Сам ключ возвращается только здесь,
+// в базе остается его хэш
+func (s *MerchService) CreateAPIKey(ctx context.Context, params model.CreateAPIKeyParams) (*model.CreatedAPIKey, error) {
+	s.logger.Info("CreateAPIKey() request", zap.Any("params", params))
+
+	if len(params.Scopes) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", ErrInvalidScope)
+	}
+	for _, scope := range params.Scopes {
+		if !slices.Contains(model.APIKeyScopes, scope) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidScope, scope)
+		}
+	}
+
+	secret, err := randomHex(apiKeyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnknown, err)
+	}
+
+	key := model.APIKeyPrefix + secret
+	params.Prefix = key[:apiKeyPrefixLen]
+	params.KeyHash = hashSecret(key)
+
+	// повторы скоупов ничего не дают, а в списке ключей только путают
+	slices.Sort(params.Scopes)
+	params.Scopes = slices.Compact(params.Scopes)
+
+	apiKey, err := s.repo.CreateAPIKey(ctx, params)
+	if err != nil {
+		s.logger.Error("CreateAPIKey() -> CreateAPIKey() request | error",
+			zap.Any("params", params),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	s.logger.Info("CreateAPIKey() response", zap.Any("key", apiKey))
+
+	return &model.CreatedAPIKey{APIKey: *apiKey, Key: key}, nil
+}
+
+func (s *MerchService) GetAPIKeys(ctx context.Context, userID uint) ([]model.APIKey, error) {
+	s.logger.Info("GetAPIKeys() request", zap.Uint("user_id", userID))
+
+	keys, err := s.repo.GetAPIKeys(ctx, userID)
+	if err != nil {
+		s.logger.Error("GetAPIKeys() -> GetAPIKeys() request | error",
+			zap.Uint("user_id", userID),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	return keys, nil
+}
+
+func (s *MerchService) RevokeAPIKey(ctx context.Context, id uint) (*model.APIKey, error) {
+	s.logger.Info("RevokeAPIKey() request", zap.Uint("id", id))
+
+	key, err := s.repo.RevokeAPIKey(ctx, id)
+	if err != nil {
+		s.logger.Error("RevokeAPIKey() -> RevokeAPIKey() request | error",
+			zap.Uint("id", id),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	s.logger.Info("RevokeAPIKey() response", zap.Any("key", key))
+
+	return key, nil
+}
+
+// AuthAPIKey проверяет ключ и статус его владельца. Вызывается из AuthMiddleware
+// на каждый запрос с ключом, поэтому без логирования успешного случая
+func (s *MerchService) AuthAPIKey(ctx context.Context, key string) (*model.APIKeyAuth, error) {
+	if !strings.HasPrefix(key, model.APIKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	auth, err := s.repo.AuthAPIKey(ctx, hashSecret(key))
+	if err != nil {
+		// сам ключ в лог не пишем
+		s.logger.Error("AuthAPIKey() -> AuthAPIKey() request | error", zap.Error(err))
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	if !model.CanLogin(auth.Status) {
+		return nil, ErrAccountSuspended
+	}
+
+	return auth, nil
+}
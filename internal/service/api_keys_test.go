@@ -0,0 +1,98 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/0x0FACED/merch-shop/internal/database"
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/0x0FACED/merch-shop/internal/service"
+	"github.com/0x0FACED/merch-shop/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Ключ отдается один раз, а в базу уходят только его хэш и начало
+func TestCreateAPIKey_Success(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	var saved model.CreateAPIKeyParams
+	mockRepo.On("CreateAPIKey", mock.Anything, mock.MatchedBy(func(p model.CreateAPIKeyParams) bool {
+		saved = p
+		return p.UserID == 5 && p.KeyHash != ""
+	})).Return(&model.APIKey{ID: 1, UserID: 5}, nil)
+
+	key, err := userService.CreateAPIKey(context.Background(), model.CreateAPIKeyParams{
+		UserID:  5,
+		AdminID: 1,
+		Scopes:  []string{model.ScopeTransfersWrite, model.ScopeUsersRead, model.ScopeTransfersWrite},
+	})
+	assert.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(key.Key, model.APIKeyPrefix))
+	assert.True(t, strings.HasPrefix(key.Key, saved.Prefix))
+	assert.NotContains(t, saved.KeyHash, key.Key)
+	assert.Equal(t, []string{model.ScopeTransfersWrite, model.ScopeUsersRead}, saved.Scopes)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateAPIKey_InvalidScope(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	_, err := userService.CreateAPIKey(context.Background(), model.CreateAPIKeyParams{UserID: 5, Scopes: []string{"admin:write"}})
+	assert.ErrorIs(t, err, service.ErrInvalidScope)
+
+	_, err = userService.CreateAPIKey(context.Background(), model.CreateAPIKeyParams{UserID: 5})
+	assert.ErrorIs(t, err, service.ErrInvalidScope)
+
+	mockRepo.AssertNotCalled(t, "CreateAPIKey", mock.Anything, mock.Anything)
+}
+
+func TestAuthAPIKey(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	mockRepo.On("AuthAPIKey", mock.Anything, mock.Anything).Return(&model.APIKeyAuth{
+		KeyID:  1,
+		UserID: 5,
+		Scopes: []string{model.ScopeUsersRead},
+		Status: model.UserStatusActive,
+	}, nil).Once()
+	mockRepo.On("AuthAPIKey", mock.Anything, mock.Anything).Return(&model.APIKeyAuth{
+		KeyID:  1,
+		UserID: 5,
+		Status: model.UserStatusSuspended,
+	}, nil).Once()
+	mockRepo.On("AuthAPIKey", mock.Anything, mock.Anything).Return(nil, database.ErrInvalidAPIKey).Once()
+
+	auth, err := userService.AuthAPIKey(context.Background(), "msk_abc")
+	assert.NoError(t, err)
+	assert.Equal(t, uint(5), auth.UserID)
+
+	// ключ заблокированного аккаунта не работает
+	_, err = userService.AuthAPIKey(context.Background(), "msk_abc")
+	assert.ErrorIs(t, err, service.ErrAccountSuspended)
+
+	_, err = userService.AuthAPIKey(context.Background(), "msk_revoked")
+	assert.ErrorIs(t, err, service.ErrInvalidAPIKey)
+
+	// без префикса в базу не ходим
+	_, err = userService.AuthAPIKey(context.Background(), "abc")
+	assert.ErrorIs(t, err, service.ErrInvalidAPIKey)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateServiceAccount_Taken(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	params := model.CreateServiceAccountParams{Name: "slackbot", AdminID: 1}
+	mockRepo.On("CreateServiceAccount", mock.Anything, params).Return(nil, database.ErrUsernameTaken)
+
+	_, err := userService.CreateServiceAccount(context.Background(), params)
+	assert.ErrorIs(t, err, service.ErrUsernameTaken)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"go.uber.org/zap"
+)
+
+// SendCoinBatch выполняет пачку переводов от одного отправителя атомарно.
+// Результаты возвращаются в порядке переводов и при ошибке тоже: упавший перевод
+// помечается failed, остальные rolled_back. Ошибка при этом - *BatchTransferError
+func (s *MerchService) SendCoinBatch(ctx context.Context, params model.SendCoinBatchParams) ([]model.BatchTransferResult, error) {
+	s.logger.Info("SendCoinBatch() request", zap.Any("params", params))
+
+	if len(params.Transfers) == 0 {
+		return nil, ErrEmptyBatch
+	}
+
+	for i, t := range params.Transfers {
+		if err := s.checkCategory(t.Category); err != nil {
+			s.logger.Error("SendCoinBatch() -> checkCategory() | error", zap.Any("params", params), zap.Error(err))
+			err = &BatchTransferError{Index: i, Err: err}
+			return batchResults(params.Transfers, err), err
+		}
+	}
+
+	// крупной считается вся пачка, иначе проверку легко обойти, разбив перевод на части
+	total := 0
+	for _, t := range params.Transfers {
+		total += t.Amount
+	}
+	if err := s.checkStepUp(ctx, params.FromUser, total, params.OTP); err != nil {
+		s.logger.Error("SendCoinBatch() -> checkStepUp() | error", zap.Uint("from_user", params.FromUser), zap.Error(err))
+		return batchResults(params.Transfers, err), err
+	}
+
+	params.Limits = s.transferLimits()
+
+	toUserIDs, err := s.repo.SendCoinBatch(ctx, params)
+	if err != nil {
+		s.logger.Error("SendCoinBatch() -> SendCoinBatch() request | error",
+			zap.Any("params", params),
+			zap.Error(err),
+		)
+		err = MapDBErrorToServiceError(err)
+		return batchResults(params.Transfers, err), err
+	}
+
+	s.invalidateUserInfo(ctx, append(toUserIDs, params.FromUser)...)
+
+	s.logger.Info("SendCoinBatch() response", zap.Any("params", params))
+
+	return batchResults(params.Transfers, nil), nil
+}
+
+// batchResults собирает результаты по каждому переводу. Если ошибка не привязана
+// к конкретному переводу (например, не удалось начать транзакцию), то все rolled_back
+func batchResults(transfers []model.BatchTransfer, err error) []model.BatchTransferResult {
+	failed := -1
+	var batchErr *BatchTransferError
+	if errors.As(err, &batchErr) {
+		failed = batchErr.Index
+	}
+
+	results := make([]model.BatchTransferResult, 0, len(transfers))
+	for i, t := range transfers {
+		result := model.BatchTransferResult{
+			ToUser: t.ToUser,
+			Amount: t.Amount,
+			Status: model.BatchTransferStatusSent,
+		}
+
+		switch {
+		case i == failed:
+			result.Status = model.BatchTransferStatusFailed
+			result.Error = batchErr.Err.Error()
+		case err != nil:
+			result.Status = model.BatchTransferStatusRolledBack
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
@@ -0,0 +1,105 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/0x0FACED/merch-shop/internal/database"
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/0x0FACED/merch-shop/internal/service"
+	"github.com/0x0FACED/merch-shop/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func testBatchParams() model.SendCoinBatchParams {
+	return model.SendCoinBatchParams{
+		FromUser: 1,
+		Transfers: []model.BatchTransfer{
+			{ToUser: "alice", Amount: 100, Category: "kudos"},
+			{ToUser: "bob", Amount: 100},
+			{ToUser: "carol", Amount: 100},
+		},
+	}
+}
+
+// Тест успешной пачки: у всех переводов статус sent
+func TestSendCoinBatch_Success(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	params := testBatchParams()
+	mockRepo.On("SendCoinBatch", mock.Anything, params).Return([]uint{2, 3, 4}, nil)
+
+	results, err := userService.SendCoinBatch(context.Background(), params)
+	assert.NoError(t, err)
+	assert.Len(t, results, 3)
+	for _, r := range results {
+		assert.Equal(t, model.BatchTransferStatusSent, r.Status)
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+// Тест ошибки в одном переводе: индекс сохраняется, остальные переводы откатились
+func TestSendCoinBatch_InsufficientFunds(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	params := testBatchParams()
+	mockRepo.On("SendCoinBatch", mock.Anything, params).
+		Return(nil, &database.BatchTransferError{Index: 2, Err: database.ErrInsufficientFunds})
+
+	results, err := userService.SendCoinBatch(context.Background(), params)
+	assert.ErrorIs(t, err, service.ErrInsufficientFunds)
+
+	var batchErr *service.BatchTransferError
+	if assert.ErrorAs(t, err, &batchErr) {
+		assert.Equal(t, 2, batchErr.Index)
+	}
+
+	assert.Equal(t, model.BatchTransferStatusRolledBack, results[0].Status)
+	assert.Equal(t, model.BatchTransferStatusRolledBack, results[1].Status)
+	assert.Equal(t, model.BatchTransferStatusFailed, results[2].Status)
+	assert.Equal(t, service.ErrInsufficientFunds.Error(), results[2].Error)
+	mockRepo.AssertExpectations(t)
+}
+
+// Тест неизвестной категории: в базу не ходим
+func TestSendCoinBatch_UnknownCategory(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	params := testBatchParams()
+	params.Transfers[1].Category = "bribe"
+
+	results, err := userService.SendCoinBatch(context.Background(), params)
+	assert.ErrorIs(t, err, service.ErrUnknownCategory)
+	assert.Equal(t, model.BatchTransferStatusFailed, results[1].Status)
+	mockRepo.AssertNotCalled(t, "SendCoinBatch", mock.Anything, mock.Anything)
+}
+
+// Тест ошибки, не привязанной к переводу: все переводы rolled_back
+func TestSendCoinBatch_DBError(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	params := testBatchParams()
+	mockRepo.On("SendCoinBatch", mock.Anything, params).
+		Return(nil, fmt.Errorf("%w: boom", database.ErrFailedToBeginTx))
+
+	results, err := userService.SendCoinBatch(context.Background(), params)
+	assert.ErrorIs(t, err, service.ErrFailedToBeginTx)
+	for _, r := range results {
+		assert.Equal(t, model.BatchTransferStatusRolledBack, r.Status)
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSendCoinBatch_Empty(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	_, err := userService.SendCoinBatch(context.Background(), model.SendCoinBatchParams{FromUser: 1})
+	assert.ErrorIs(t, err, service.ErrEmptyBatch)
+}
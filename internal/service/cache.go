@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/internal/cache"
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"go.uber.org/zap"
+)
+
+var _ userInfoCache = (*cache.LRU)(nil)
+var _ userInfoCache = (*cache.Redis)(nil)
+
+// userInfoCache - кэш, через который читается информация о юзере.
+// Реализации лежат в internal/cache
+type userInfoCache interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Peek(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// userInfoKey строит ключ кэша. Для raw и aggregated режимов ответы разные,
+// поэтому храним их отдельно. gen - поколение кэша юзера, см. userInfoGeneration
+func userInfoKey(userID uint, mode, gen string) string {
+	if mode == "" {
+		mode = model.CoinHistoryModeRaw
+	}
+	return fmt.Sprintf("user_info:%d:%s:%s", userID, mode, gen)
+}
+
+func userInfoGenKey(userID uint) string {
+	return fmt.Sprintf("user_info_gen:%d", userID)
+}
+
+// cacheable - кэшируем только ответы без фильтра по категории,
+// иначе при инвалидации пришлось бы перебирать все категории
+func cacheable(params model.GetUserInfoParams) bool {
+	return params.Category == ""
+}
+
+// userInfoGeneration возвращает текущее поколение кэша юзера, если его нет - заводит новое.
+// Инвалидация удаляет поколение, поэтому ответ, прочитанный из базы до инвалидации,
+// записывается под старым поколением и больше никем не читается
+func (s *MerchService) userInfoGeneration(ctx context.Context, userID uint) (string, error) {
+	key := userInfoGenKey(userID)
+
+	// поколение читаем мимо счетчиков: попадание или промах - это про сам ответ
+	gen, ok, err := s.cache.Peek(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		return string(gen), nil
+	}
+
+	gen = strconv.AppendInt(nil, time.Now().UnixNano(), 36)
+	if err := s.cache.Set(ctx, key, gen); err != nil {
+		return "", err
+	}
+
+	return string(gen), nil
+}
+
+// cachedUserInfo достает информацию о юзере из кэша. Ошибки кэша не фатальны,
+// в этом случае просто идем в базу. Поколение нужно передать в storeUserInfo,
+// пустое - ответ не кэшируем
+func (s *MerchService) cachedUserInfo(ctx context.Context, params model.GetUserInfoParams) (*model.UserInfo, string, bool) {
+	if !cacheable(params) {
+		return nil, "", false
+	}
+
+	gen, err := s.userInfoGeneration(ctx, params.ID)
+	if err != nil {
+		s.logger.Error("cachedUserInfo() -> userInfoGeneration() request | error", zap.Any("params", params), zap.Error(err))
+		return nil, "", false
+	}
+
+	data, ok, err := s.cache.Get(ctx, userInfoKey(params.ID, params.HistoryMode, gen))
+	if err != nil {
+		s.logger.Error("cachedUserInfo() -> Get() request | error", zap.Any("params", params), zap.Error(err))
+		return nil, gen, false
+	}
+	if !ok {
+		return nil, gen, false
+	}
+
+	userInfo := &model.UserInfo{}
+	if err := json.Unmarshal(data, userInfo); err != nil {
+		s.logger.Error("cachedUserInfo() -> Unmarshal() | error", zap.Any("params", params), zap.Error(err))
+		return nil, gen, false
+	}
+
+	return userInfo, gen, true
+}
+
+func (s *MerchService) storeUserInfo(ctx context.Context, params model.GetUserInfoParams, gen string, userInfo *model.UserInfo) {
+	if !cacheable(params) || gen == "" {
+		return
+	}
+
+	data, err := json.Marshal(userInfo)
+	if err != nil {
+		s.logger.Error("storeUserInfo() -> Marshal() | error", zap.Any("params", params), zap.Error(err))
+		return
+	}
+
+	if err := s.cache.Set(ctx, userInfoKey(params.ID, params.HistoryMode, gen), data); err != nil {
+		s.logger.Error("storeUserInfo() -> Set() request | error", zap.Any("params", params), zap.Error(err))
+	}
+}
+
+// invalidateUserInfo сбрасывает поколение кэша юзеров: ответы во всех режимах
+// перестают читаться и вытесняются по TTL.
+// Вызывается после коммита операций, которые меняют баланс, инвентарь или историю
+func (s *MerchService) invalidateUserInfo(ctx context.Context, userIDs ...uint) {
+	keys := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		keys = append(keys, userInfoGenKey(id))
+	}
+
+	if err := s.cache.Delete(ctx, keys...); err != nil {
+		s.logger.Error("invalidateUserInfo() -> Delete() request | error", zap.Any("user_ids", userIDs), zap.Error(err))
+	}
+}
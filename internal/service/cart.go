@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"go.uber.org/zap"
+)
+
+// AddCartItem кладет предмет в корзину и возвращает корзину целиком
+func (s *MerchService) AddCartItem(ctx context.Context, params model.CartItemParams) (*model.Cart, error) {
+	s.logger.Info("AddCartItem() request", zap.Any("params", params))
+
+	params.MaxQuantity = s.shop.MaxItemQuantity
+	params.MaxLines = s.shop.MaxCartLines
+
+	if err := s.repo.AddCartItem(ctx, params); err != nil {
+		s.logger.Error("AddCartItem() -> AddCartItem() request | error",
+			zap.Any("params", params),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	return s.GetCart(ctx, params.UserID)
+}
+
+// UpdateCartItem меняет количество предмета в корзине и возвращает корзину целиком
+func (s *MerchService) UpdateCartItem(ctx context.Context, params model.CartItemParams) (*model.Cart, error) {
+	s.logger.Info("UpdateCartItem() request", zap.Any("params", params))
+
+	params.MaxQuantity = s.shop.MaxItemQuantity
+	params.MaxLines = s.shop.MaxCartLines
+
+	if err := s.repo.UpdateCartItem(ctx, params); err != nil {
+		s.logger.Error("UpdateCartItem() -> UpdateCartItem() request | error",
+			zap.Any("params", params),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	return s.GetCart(ctx, params.UserID)
+}
+
+// RemoveCartItem убирает вариант предмета из корзины и возвращает корзину целиком.
+// Пустой variant - вариант по умолчанию
+func (s *MerchService) RemoveCartItem(ctx context.Context, userID uint, item, variant string) (*model.Cart, error) {
+	s.logger.Info("RemoveCartItem() request", zap.Uint("user_id", userID), zap.String("item", item), zap.String("variant", variant))
+
+	if err := s.repo.RemoveCartItem(ctx, userID, item, variant); err != nil {
+		s.logger.Error("RemoveCartItem() -> RemoveCartItem() request | error",
+			zap.Uint("user_id", userID),
+			zap.String("item", item),
+			zap.String("variant", variant),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	return s.GetCart(ctx, userID)
+}
+
+func (s *MerchService) GetCart(ctx context.Context, userID uint) (*model.Cart, error) {
+	s.logger.Info("GetCart() request", zap.Uint("user_id", userID))
+
+	cart, err := s.repo.GetCart(ctx, userID)
+	if err != nil {
+		s.logger.Error("GetCart() -> GetCart() request | error",
+			zap.Uint("user_id", userID),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	return cart, nil
+}
+
+// Checkout оформляет корзину одним заказом. Если не прошла конкретная строка,
+// ошибка - *OrderLineError с ее предметом
+func (s *MerchService) Checkout(ctx context.Context, userID uint) (*model.Order, error) {
+	s.logger.Info("Checkout() request", zap.Uint("user_id", userID))
+
+	params := model.CheckoutParams{
+		UserID:      userID,
+		MaxQuantity: s.shop.MaxItemQuantity,
+	}
+
+	order, err := s.repo.Checkout(ctx, params)
+	if err != nil {
+		s.logger.Error("Checkout() -> Checkout() request | error",
+			zap.Any("params", params),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	// в GetUserInfo изменились баланс и инвентарь
+	s.invalidateUserInfo(ctx, userID)
+
+	s.logger.Info("Checkout() response", zap.Uint("user_id", userID), zap.Any("order", order))
+
+	return order, nil
+}
@@ -0,0 +1,111 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/0x0FACED/merch-shop/internal/database"
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/0x0FACED/merch-shop/internal/service"
+	"github.com/0x0FACED/merch-shop/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Лимиты корзины сервис берет из конфига и возвращает обновленную корзину
+func TestAddCartItem_Success(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	mockRepo.On("AddCartItem", mock.Anything, model.CartItemParams{
+		UserID:      1,
+		Item:        "socks",
+		Quantity:    3,
+		MaxQuantity: 100,
+		MaxLines:    20,
+	}).Return(nil)
+	cart := &model.Cart{Lines: []model.CartLine{{Item: "socks", Quantity: 3, Price: 10, Total: 30}}, Total: 30}
+	mockRepo.On("GetCart", mock.Anything, uint(1)).Return(cart, nil)
+
+	got, err := userService.AddCartItem(context.Background(), model.CartItemParams{UserID: 1, Item: "socks", Quantity: 3})
+	require.NoError(t, err)
+	assert.Equal(t, cart, got)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCheckout_Success(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	order := &model.Order{ID: 5, Total: 130, Lines: []model.OrderLine{
+		{Item: "socks", Quantity: 3, Price: 10},
+		{Item: "book", Quantity: 2, Price: 50},
+	}}
+	mockRepo.On("Checkout", mock.Anything, model.CheckoutParams{UserID: 1, MaxQuantity: 100}).Return(order, nil)
+
+	got, err := userService.Checkout(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, order, got)
+	mockRepo.AssertExpectations(t)
+}
+
+// Ошибка строки доходит до хендлера вместе с предметом, сама ошибка маппится как обычно
+func TestCheckout_LineError(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	mockRepo.On("Checkout", mock.Anything, mock.Anything).
+		Return(nil, &database.OrderLineError{Item: "hoody", Err: database.ErrQuantityLimit})
+
+	_, err := userService.Checkout(context.Background(), 1)
+
+	var lineErr *service.OrderLineError
+	require.True(t, errors.As(err, &lineErr))
+	assert.Equal(t, "hoody", lineErr.Item)
+	assert.ErrorIs(t, err, service.ErrQuantityLimit)
+}
+
+func TestCheckout_InsufficientFunds(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	mockRepo.On("Checkout", mock.Anything, mock.Anything).Return(nil, database.ErrInsufficientFunds)
+
+	_, err := userService.Checkout(context.Background(), 1)
+	assert.ErrorIs(t, err, service.ErrInsufficientFunds)
+
+	var lineErr *service.OrderLineError
+	assert.False(t, errors.As(err, &lineErr), "balance is checked for the whole cart")
+}
+
+// Вариант строки доходит до хендлера вместе с предметом
+func TestCheckout_VariantLineError(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	mockRepo.On("Checkout", mock.Anything, mock.Anything).
+		Return(nil, &database.OrderLineError{Item: "hoody", Variant: "hoody-l", Err: database.ErrSoldOut})
+
+	_, err := userService.Checkout(context.Background(), 1)
+
+	var lineErr *service.OrderLineError
+	require.True(t, errors.As(err, &lineErr))
+	assert.Equal(t, "hoody", lineErr.Item)
+	assert.Equal(t, "hoody-l", lineErr.Variant)
+	assert.ErrorIs(t, err, service.ErrSoldOut)
+	assert.EqualError(t, err, `item "hoody" variant "hoody-l": item is sold out`)
+}
+
+func TestRemoveCartItem_Variant(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	mockRepo.On("RemoveCartItem", mock.Anything, uint(1), "hoody", "hoody-l").Return(nil)
+	mockRepo.On("GetCart", mock.Anything, uint(1)).Return(&model.Cart{}, nil)
+
+	_, err := userService.RemoveCartItem(context.Background(), 1, "hoody", "hoody-l")
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
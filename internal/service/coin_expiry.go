@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"go.uber.org/zap"
+)
+
+// ExpireCoins сжигает монеты, полученные раньше чем Lifetime назад. За вызов
+// обрабатывается не больше Limit юзеров, остальные сгорят на следующем проходе.
+// Нулевой Lifetime означает, что монеты не сгорают
+func (s *MerchService) ExpireCoins(ctx context.Context, params model.ExpireCoinsParams) (*model.ExpiryResult, error) {
+	s.logger.Info("ExpireCoins() request", zap.Any("params", params))
+
+	if params.Lifetime <= 0 {
+		return &model.ExpiryResult{}, nil
+	}
+
+	result, err := s.repo.ExpireCoins(ctx, params)
+	if err != nil {
+		s.logger.Error("ExpireCoins() -> ExpireCoins() request | error",
+			zap.Any("params", params),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	s.invalidateUserInfo(ctx, result.UserIDs...)
+
+	s.logger.Info("ExpireCoins() response",
+		zap.Any("params", params),
+		zap.Int("users", result.Users),
+		zap.Int("total_amount", result.TotalAmount),
+	)
+
+	return result, nil
+}
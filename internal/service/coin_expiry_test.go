@@ -0,0 +1,90 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/internal/cache"
+	"github.com/0x0FACED/merch-shop/internal/database"
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/0x0FACED/merch-shop/internal/service"
+	"github.com/0x0FACED/merch-shop/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Тест сгорания: кэш сбрасывается у всех, у кого сгорели монеты
+func TestExpireCoins_Success(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	deps := testDeps()
+	deps.Cache = cache.NewLRU(10, time.Minute)
+	userService := service.NewUserService(mockRepo, testLogger(), deps)
+
+	infoParams := model.GetUserInfoParams{ID: 1}
+	params := model.ExpireCoinsParams{Lifetime: 365 * 24 * time.Hour, Limit: 100}
+
+	mockRepo.On("GetUserInfo", mock.Anything, infoParams).Return(&model.UserInfo{Coins: 1000}, nil).Once()
+	mockRepo.On("ExpireCoins", mock.Anything, params).Return(&model.ExpiryResult{
+		Users:       1,
+		TotalAmount: 1000,
+		UserIDs:     []uint{1},
+	}, nil)
+	mockRepo.On("GetUserInfo", mock.Anything, infoParams).Return(&model.UserInfo{Coins: 0}, nil).Once()
+
+	_, _ = userService.GetUserInfo(context.Background(), infoParams)
+
+	result, err := userService.ExpireCoins(context.Background(), params)
+	assert.NoError(t, err)
+	assert.Equal(t, 1000, result.TotalAmount)
+
+	info, err := userService.GetUserInfo(context.Background(), infoParams)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(0), info.Coins)
+	mockRepo.AssertExpectations(t)
+}
+
+// Нулевой срок жизни - монеты не сгорают, в базу не ходим
+func TestExpireCoins_Disabled(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	result, err := userService.ExpireCoins(context.Background(), model.ExpireCoinsParams{Limit: 100})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.Users)
+	mockRepo.AssertNotCalled(t, "ExpireCoins", mock.Anything, mock.Anything)
+}
+
+func TestExpireCoins_DBError(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	params := model.ExpireCoinsParams{Lifetime: time.Hour, Limit: 100}
+	mockRepo.On("ExpireCoins", mock.Anything, params).Return(nil, database.ErrFailedToBeginTx)
+
+	_, err := userService.ExpireCoins(context.Background(), params)
+	assert.ErrorIs(t, err, service.ErrFailedToBeginTx)
+	mockRepo.AssertExpectations(t)
+}
+
+// Срок жизни монет из конфига передается в репозиторий, чтобы посчитать ближайшие сгорания
+func TestGetUserInfo_ExpiringCoins(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	cfg := testTransferConfig()
+	cfg.CoinLifetime = 365 * 24 * time.Hour
+	deps := testDeps()
+	deps.Transfer = cfg
+	userService := service.NewUserService(mockRepo, testLogger(), deps)
+
+	expiresAt := time.Date(2027, time.October, 19, 0, 0, 0, 0, time.UTC)
+	mockRepo.On("GetUserInfo", mock.Anything, model.GetUserInfoParams{ID: 1, CoinLifetime: cfg.CoinLifetime}).
+		Return(&model.UserInfo{
+			Coins:    1000,
+			Expiring: []model.ExpiringCoins{{Amount: 1000, ExpiresAt: expiresAt}},
+		}, nil)
+
+	info, err := userService.GetUserInfo(context.Background(), model.GetUserInfoParams{ID: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []model.ExpiringCoins{{Amount: 1000, ExpiresAt: expiresAt}}, info.Expiring)
+	mockRepo.AssertExpectations(t)
+}
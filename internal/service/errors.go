@@ -23,10 +23,123 @@ var (
 	ErrFailedToSaveTransaction = errors.New("failed to save transaction")
 	ErrFailedToCommitTx        = errors.New("failed to commit tx")
 
+	ErrUnknownCategory = errors.New("unknown transfer category")
+
+	ErrPaymentRequestNotPending = errors.New("payment request is not pending")
+	ErrPaymentRequestExpired    = errors.New("payment request is expired")
+	ErrSelfPaymentRequest       = errors.New("cannot request coins from yourself")
+	ErrSelfScheduledTransfer    = errors.New("cannot schedule a transfer to yourself")
+
+	ErrInvalidSchedule = errors.New("invalid schedule")
+	ErrScheduleInPast  = errors.New("scheduled time is in the past")
+	ErrNotCancellable  = errors.New("scheduled transfer is not active")
+
+	ErrInvalidAllowancePeriod = errors.New("invalid allowance period")
+	ErrInvalidAllowanceAmount = errors.New("allowance amount must be positive")
+
+	ErrEmptyBatch = errors.New("batch has no transfers")
+
+	ErrSingleTransferLimit  = errors.New("transfer exceeds max single transfer amount")
+	ErrDailyTransferLimit   = errors.New("daily transfer limit exceeded")
+	ErrWeeklyTransferLimit  = errors.New("weekly transfer limit exceeded")
+	ErrDailyRecipientsLimit = errors.New("daily recipients limit exceeded")
+
+	ErrAccountFrozen       = errors.New("account is frozen")
+	ErrAccountSuspended    = errors.New("account is suspended")
+	ErrRecipientNotActive  = errors.New("recipient account is not active")
+	ErrInvalidUserStatus   = errors.New("invalid user status")
+	ErrForbidden           = errors.New("forbidden")
+	ErrFraudAlertReviewed  = errors.New("fraud alert is already reviewed")
+	ErrInvalidReviewStatus = errors.New("invalid review status")
+
+	ErrInvalidPassword   = errors.New("invalid password")
+	ErrInvalidResetToken = errors.New("invalid or expired reset token")
+	ErrSessionRevoked    = errors.New("session is revoked")
+	ErrInvalidAPIKey     = errors.New("invalid api key")
+	ErrInvalidScope      = errors.New("invalid api key scope")
+	ErrUsernameTaken     = errors.New("username is already taken")
+	ErrOIDCDisabled      = errors.New("oidc login is disabled")
+	ErrInvalidOIDCState  = errors.New("invalid or expired oidc login state")
+	ErrOIDCLoginFailed   = errors.New("oidc login failed")
+
+	ErrTOTPDisabled       = errors.New("two-factor authentication is not configured")
+	ErrTOTPRequired       = errors.New("two-factor code required")
+	ErrInvalidTOTP        = errors.New("invalid two-factor code")
+	ErrTOTPLocked         = errors.New("too many invalid two-factor codes, try again later")
+	ErrTOTPAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTOTPNotEnabled     = errors.New("two-factor authentication is not enabled")
+
+	ErrCartEmpty     = errors.New("cart is empty")
+	ErrCartFull      = errors.New("too many different items in cart")
+	ErrQuantityLimit = errors.New("item quantity exceeds limit")
+
+	ErrSoldOut           = errors.New("item is sold out")
+	ErrPurchaseLimit     = errors.New("per-user purchase limit reached")
+	ErrSaleNotStarted    = errors.New("item sale has not started")
+	ErrSaleEnded         = errors.New("item sale has ended")
+	ErrInvalidSaleWindow = errors.New("sale must end after it starts")
+
+	ErrVariantRequired = errors.New("item has variants, specify one")
+	ErrSKUTaken        = errors.New("sku is already taken")
+	ErrNegativePrice   = errors.New("variant price must not be negative")
+
+	ErrInvalidOrderStatus    = errors.New("invalid order status")
+	ErrOrderStatusTransition = errors.New("order status cannot be changed")
+	ErrNotEnoughItems        = errors.New("not enough items in inventory")
+
+	ErrNotReturnable       = errors.New("not enough units to return within the return window")
+	ErrReturnNotPending    = errors.New("return request is not pending")
+	ErrInvalidRefund       = errors.New("invalid refund amount")
+	ErrInvalidReturnReview = errors.New("invalid return review status")
+
+	ErrSelfGift = errors.New("cannot gift to yourself")
+
 	ErrUnknown = errors.New("unknown error")
 )
 
+// BatchTransferError - ошибка в конкретном переводе из пачки, Index - его номер в запросе
+type BatchTransferError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchTransferError) Error() string {
+	return fmt.Sprintf("transfer %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchTransferError) Unwrap() error {
+	return e.Err
+}
+
+// OrderLineError - ошибка в конкретной строке заказа, Item и Variant - предмет и SKU варианта строки
+type OrderLineError struct {
+	Item    string
+	Variant string
+	Err     error
+}
+
+func (e *OrderLineError) Error() string {
+	if e.Variant != "" {
+		return fmt.Sprintf("item %q variant %q: %v", e.Item, e.Variant, e.Err)
+	}
+	return fmt.Sprintf("item %q: %v", e.Item, e.Err)
+}
+
+func (e *OrderLineError) Unwrap() error {
+	return e.Err
+}
+
 func MapDBErrorToServiceError(err error) error {
+	// индекс перевода сохраняем, а саму ошибку маппим как обычно
+	var batchErr *database.BatchTransferError
+	if errors.As(err, &batchErr) {
+		return &BatchTransferError{Index: batchErr.Index, Err: MapDBErrorToServiceError(batchErr.Err)}
+	}
+	var lineErr *database.OrderLineError
+	if errors.As(err, &lineErr) {
+		return &OrderLineError{Item: lineErr.Item, Variant: lineErr.Variant, Err: MapDBErrorToServiceError(lineErr.Err)}
+	}
+
 	switch {
 	case errors.Is(err, database.ErrInvalidLoginOrPassword):
 		return ErrInvalidLoginOrPassword
@@ -38,6 +151,80 @@ func MapDBErrorToServiceError(err error) error {
 	case errors.Is(err, database.ErrNotFound):
 		return ErrNotFound
 
+	case errors.Is(err, database.ErrPaymentRequestNotPending):
+		return ErrPaymentRequestNotPending
+	case errors.Is(err, database.ErrPaymentRequestExpired):
+		return ErrPaymentRequestExpired
+	case errors.Is(err, database.ErrSelfPaymentRequest):
+		return ErrSelfPaymentRequest
+	case errors.Is(err, database.ErrSelfScheduledTransfer):
+		return ErrSelfScheduledTransfer
+	case errors.Is(err, database.ErrNotCancellable):
+		return ErrNotCancellable
+
+	case errors.Is(err, database.ErrSingleTransferLimit):
+		return ErrSingleTransferLimit
+	case errors.Is(err, database.ErrDailyTransferLimit):
+		return ErrDailyTransferLimit
+	case errors.Is(err, database.ErrWeeklyTransferLimit):
+		return ErrWeeklyTransferLimit
+	case errors.Is(err, database.ErrDailyRecipientsLimit):
+		return ErrDailyRecipientsLimit
+
+	case errors.Is(err, database.ErrAccountFrozen):
+		return ErrAccountFrozen
+	case errors.Is(err, database.ErrAccountSuspended):
+		return ErrAccountSuspended
+	case errors.Is(err, database.ErrRecipientNotActive):
+		return ErrRecipientNotActive
+	case errors.Is(err, database.ErrFraudAlertReviewed):
+		return ErrFraudAlertReviewed
+	case errors.Is(err, database.ErrInvalidResetToken):
+		return ErrInvalidResetToken
+	case errors.Is(err, database.ErrInvalidAPIKey):
+		return ErrInvalidAPIKey
+	case errors.Is(err, database.ErrUsernameTaken):
+		return ErrUsernameTaken
+	case errors.Is(err, database.ErrInvalidOIDCState):
+		return ErrInvalidOIDCState
+	case errors.Is(err, database.ErrTOTPAlreadyEnabled):
+		return ErrTOTPAlreadyEnabled
+	case errors.Is(err, database.ErrTOTPCodeUsed),
+		errors.Is(err, database.ErrInvalidRecoveryCode):
+		return ErrInvalidTOTP
+	case errors.Is(err, database.ErrCartEmpty):
+		return ErrCartEmpty
+	case errors.Is(err, database.ErrCartFull):
+		return ErrCartFull
+	case errors.Is(err, database.ErrQuantityLimit):
+		return ErrQuantityLimit
+	case errors.Is(err, database.ErrSoldOut):
+		return ErrSoldOut
+	case errors.Is(err, database.ErrPurchaseLimit):
+		return ErrPurchaseLimit
+	case errors.Is(err, database.ErrSaleNotStarted):
+		return ErrSaleNotStarted
+	case errors.Is(err, database.ErrSaleEnded):
+		return ErrSaleEnded
+	case errors.Is(err, database.ErrVariantRequired):
+		return ErrVariantRequired
+	case errors.Is(err, database.ErrSKUTaken):
+		return ErrSKUTaken
+	case errors.Is(err, database.ErrNegativePrice):
+		return ErrNegativePrice
+	case errors.Is(err, database.ErrOrderStatusTransition):
+		return ErrOrderStatusTransition
+	case errors.Is(err, database.ErrNotEnoughItems):
+		return ErrNotEnoughItems
+	case errors.Is(err, database.ErrNotReturnable):
+		return ErrNotReturnable
+	case errors.Is(err, database.ErrReturnNotPending):
+		return ErrReturnNotPending
+	case errors.Is(err, database.ErrInvalidRefund):
+		return ErrInvalidRefund
+	case errors.Is(err, database.ErrSelfGift):
+		return ErrSelfGift
+
 	case errors.Is(err, database.ErrQueryFailed):
 		return ErrQueryFailed
 	case errors.Is(err, database.ErrScanFailed):
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"go.uber.org/zap"
+)
+
+const maxFraudAlertsLimit = 100
+
+// fraudRule - одно правило антифрода. Правило с выключенным порогом не запускается
+type fraudRule struct {
+	name    string
+	enabled func(params model.FraudDetectionParams) bool
+	find    func(ctx context.Context, params model.FraudDetectionParams) ([]model.FraudFinding, error)
+}
+
+func (s *MerchService) fraudRules() []fraudRule {
+	return []fraudRule{
+		{
+			name: model.FraudRuleCycle,
+			enabled: func(p model.FraudDetectionParams) bool {
+				return p.CycleMaxLength >= 2
+			},
+			find: s.repo.FindTransferCycles,
+		},
+		{
+			name: model.FraudRuleFreshFanIn,
+			enabled: func(p model.FraudDetectionParams) bool {
+				return p.FanInMinSenders > 0 && p.FreshAccountAge > 0
+			},
+			find: s.repo.FindFreshFanIn,
+		},
+		{
+			name: model.FraudRuleVelocity,
+			enabled: func(p model.FraudDetectionParams) bool {
+				return p.VelocityWindow > 0 && (p.VelocityMaxTransfers > 0 || p.VelocityMaxAmount > 0)
+			},
+			find: s.repo.FindVelocitySpikes,
+		},
+	}
+}
+
+// DetectFraud прогоняет все включенные правила по переводам за окно и сохраняет находки как алерты.
+// Упавшее правило не мешает остальным: их находки сохраняются, а ошибка возвращается в конце
+func (s *MerchService) DetectFraud(ctx context.Context, params model.FraudDetectionParams) (*model.FraudDetectionResult, error) {
+	s.logger.Info("DetectFraud() request", zap.Any("params", params))
+
+	var (
+		findings []model.FraudFinding
+		errs     []error
+	)
+	for _, rule := range s.fraudRules() {
+		if !rule.enabled(params) {
+			continue
+		}
+
+		found, err := rule.find(ctx, params)
+		if err != nil {
+			s.logger.Error("DetectFraud() -> find() request | error",
+				zap.String("rule", rule.name),
+				zap.Error(err),
+			)
+			errs = append(errs, fmt.Errorf("rule %s: %w", rule.name, MapDBErrorToServiceError(err)))
+			continue
+		}
+
+		findings = append(findings, found...)
+	}
+
+	result := &model.FraudDetectionResult{}
+	if len(findings) > 0 {
+		saved, err := s.repo.SaveFraudAlerts(ctx, model.SaveFraudAlertsParams{
+			Findings:   findings,
+			Cooldown:   params.Window,
+			AutoFreeze: params.AutoFreeze,
+		})
+		if err != nil {
+			s.logger.Error("DetectFraud() -> SaveFraudAlerts() request | error", zap.Error(err))
+			return nil, MapDBErrorToServiceError(err)
+		}
+		result = saved
+	}
+
+	if result.Alerts > 0 {
+		s.logger.Info("DetectFraud() new alerts",
+			zap.Int("findings", result.Findings),
+			zap.Int("alerts", result.Alerts),
+			zap.Uints("frozen", result.Frozen),
+		)
+	}
+
+	return result, errors.Join(errs...)
+}
+
+func (s *MerchService) GetFraudAlerts(ctx context.Context, params model.GetFraudAlertsParams) ([]model.FraudAlert, error) {
+	s.logger.Info("GetFraudAlerts() request", zap.Any("params", params))
+
+	if params.Limit <= 0 || params.Limit > maxFraudAlertsLimit {
+		params.Limit = maxFraudAlertsLimit
+	}
+
+	alerts, err := s.repo.GetFraudAlerts(ctx, params)
+	if err != nil {
+		s.logger.Error("GetFraudAlerts() -> GetFraudAlerts() request | error",
+			zap.Any("params", params),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	return alerts, nil
+}
+
+// ReviewFraudAlert закрывает алерт решением админа: confirmed или dismissed
+func (s *MerchService) ReviewFraudAlert(ctx context.Context, params model.ReviewFraudAlertParams) (*model.FraudAlert, error) {
+	s.logger.Info("ReviewFraudAlert() request", zap.Any("params", params))
+
+	if params.Status != model.FraudAlertConfirmed && params.Status != model.FraudAlertDismissed {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidReviewStatus, params.Status)
+	}
+
+	alert, err := s.repo.ReviewFraudAlert(ctx, params)
+	if err != nil {
+		s.logger.Error("ReviewFraudAlert() -> ReviewFraudAlert() request | error",
+			zap.Any("params", params),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	s.logger.Info("ReviewFraudAlert() response", zap.Any("params", params))
+
+	return alert, nil
+}
+
+// CheckAdmin возвращает ErrForbidden, если юзер не админ. Роль читается из базы
+// на каждый запрос, чтобы снятие админки действовало сразу, а не после истечения токена
+func (s *MerchService) CheckAdmin(ctx context.Context, userID uint) error {
+	role, err := s.repo.GetUserRole(ctx, userID)
+	if err != nil {
+		s.logger.Error("CheckAdmin() -> GetUserRole() request | error",
+			zap.Uint("user_id", userID),
+			zap.Error(err),
+		)
+		return MapDBErrorToServiceError(err)
+	}
+
+	if role != model.RoleAdmin {
+		return ErrForbidden
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/internal/database"
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/0x0FACED/merch-shop/internal/service"
+	"github.com/0x0FACED/merch-shop/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func testFraudParams() model.FraudDetectionParams {
+	return model.FraudDetectionParams{
+		Window:               24 * time.Hour,
+		CycleMaxLength:       4,
+		CycleMinAmount:       50,
+		FreshAccountAge:      72 * time.Hour,
+		FanInMinSenders:      5,
+		VelocityWindow:       time.Hour,
+		VelocityMaxTransfers: 30,
+		AutoFreeze:           true,
+	}
+}
+
+// Тест прохода антифрода: находки всех правил сохраняются одним вызовом
+func TestDetectFraud_Success(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	params := testFraudParams()
+	cycle := model.FraudFinding{Rule: model.FraudRuleCycle, Fingerprint: "cycle:1,2", UserIDs: []uint{1, 2}}
+	fanIn := model.FraudFinding{Rule: model.FraudRuleFreshFanIn, Fingerprint: "fresh_fan_in:3", UserIDs: []uint{3}}
+
+	mockRepo.On("FindTransferCycles", mock.Anything, params).Return([]model.FraudFinding{cycle}, nil)
+	mockRepo.On("FindFreshFanIn", mock.Anything, params).Return([]model.FraudFinding{fanIn}, nil)
+	mockRepo.On("FindVelocitySpikes", mock.Anything, params).Return(nil, nil)
+	mockRepo.On("SaveFraudAlerts", mock.Anything, model.SaveFraudAlertsParams{
+		Findings:   []model.FraudFinding{cycle, fanIn},
+		Cooldown:   params.Window,
+		AutoFreeze: true,
+	}).Return(&model.FraudDetectionResult{Findings: 2, Alerts: 2, Frozen: []uint{1, 2, 3}}, nil)
+
+	result, err := userService.DetectFraud(context.Background(), params)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Alerts)
+	assert.Equal(t, []uint{1, 2, 3}, result.Frozen)
+	mockRepo.AssertExpectations(t)
+}
+
+// Правила с нулевым порогом не запускаются, а без находок нечего сохранять
+func TestDetectFraud_DisabledRules(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	params := model.FraudDetectionParams{Window: time.Hour, CycleMaxLength: 3}
+	mockRepo.On("FindTransferCycles", mock.Anything, params).Return(nil, nil)
+
+	result, err := userService.DetectFraud(context.Background(), params)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.Alerts)
+	mockRepo.AssertNotCalled(t, "FindFreshFanIn", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "FindVelocitySpikes", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "SaveFraudAlerts", mock.Anything, mock.Anything)
+}
+
+// Упавшее правило не мешает сохранить находки остальных
+func TestDetectFraud_RuleError(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	params := testFraudParams()
+	velocity := model.FraudFinding{Rule: model.FraudRuleVelocity, Fingerprint: "velocity:4", UserIDs: []uint{4}}
+
+	mockRepo.On("FindTransferCycles", mock.Anything, params).Return(nil, database.ErrQueryFailed)
+	mockRepo.On("FindFreshFanIn", mock.Anything, params).Return(nil, nil)
+	mockRepo.On("FindVelocitySpikes", mock.Anything, params).Return([]model.FraudFinding{velocity}, nil)
+	mockRepo.On("SaveFraudAlerts", mock.Anything, mock.Anything).
+		Return(&model.FraudDetectionResult{Findings: 1, Alerts: 1}, nil)
+
+	result, err := userService.DetectFraud(context.Background(), params)
+	assert.ErrorIs(t, err, service.ErrQueryFailed)
+	assert.Equal(t, 1, result.Alerts)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestReviewFraudAlert_Success(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	params := model.ReviewFraudAlertParams{ID: 1, AdminID: 9, Status: model.FraudAlertDismissed, Unfreeze: true}
+	mockRepo.On("ReviewFraudAlert", mock.Anything, params).
+		Return(&model.FraudAlert{ID: 1, Status: model.FraudAlertDismissed}, nil)
+
+	alert, err := userService.ReviewFraudAlert(context.Background(), params)
+	assert.NoError(t, err)
+	assert.Equal(t, model.FraudAlertDismissed, alert.Status)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestReviewFraudAlert_InvalidStatus(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	_, err := userService.ReviewFraudAlert(context.Background(), model.ReviewFraudAlertParams{ID: 1, Status: model.FraudAlertOpen})
+	assert.ErrorIs(t, err, service.ErrInvalidReviewStatus)
+	mockRepo.AssertNotCalled(t, "ReviewFraudAlert", mock.Anything, mock.Anything)
+}
+
+func TestReviewFraudAlert_AlreadyReviewed(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	params := model.ReviewFraudAlertParams{ID: 1, AdminID: 9, Status: model.FraudAlertConfirmed}
+	mockRepo.On("ReviewFraudAlert", mock.Anything, params).Return(nil, database.ErrFraudAlertReviewed)
+
+	_, err := userService.ReviewFraudAlert(context.Background(), params)
+	assert.ErrorIs(t, err, service.ErrFraudAlertReviewed)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCheckAdmin(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	mockRepo.On("GetUserRole", mock.Anything, uint(1)).Return(model.RoleAdmin, nil)
+	mockRepo.On("GetUserRole", mock.Anything, uint(2)).Return(model.RoleUser, nil)
+
+	assert.NoError(t, userService.CheckAdmin(context.Background(), 1))
+	assert.ErrorIs(t, userService.CheckAdmin(context.Background(), 2), service.ErrForbidden)
+}
+
+// Замороженный юзер не может переводить монеты
+func TestSendCoin_AccountFrozen(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	params := model.SendCoinParams{FromUser: 1, ToUser: "receiver", Amount: 100}
+	mockRepo.On("SendCoin", mock.Anything, params).Return(0, database.ErrAccountFrozen)
+
+	err := userService.SendCoin(context.Background(), params)
+	assert.ErrorIs(t, err, service.ErrAccountFrozen)
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"go.uber.org/zap"
+)
+
+// GiftItem отдает штуки предмета из инвентаря отправителя другому юзеру
+func (s *MerchService) GiftItem(ctx context.Context, params model.GiftItemParams) (*model.SentGift, error) {
+	s.logger.Info("GiftItem() request", zap.Any("params", params))
+
+	gift, err := s.repo.GiftItem(ctx, params)
+	if err != nil {
+		s.logger.Error("GiftItem() -> GiftItem() request | error",
+			zap.Any("params", params),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	s.invalidateUserInfo(ctx, params.FromUser, gift.UserID)
+
+	s.logger.Info("GiftItem() response", zap.Any("params", params), zap.Any("gift", gift))
+
+	return gift, nil
+}
+
+// BuyGift покупает предмет за счет отправителя сразу в инвентарь получателя.
+// Ограничение на количество такое же, как у обычной покупки
+func (s *MerchService) BuyGift(ctx context.Context, params model.GiftItemParams) (*model.Order, error) {
+	s.logger.Info("BuyGift() request", zap.Any("params", params))
+
+	params.MaxQuantity = s.shop.MaxItemQuantity
+
+	order, err := s.repo.BuyGift(ctx, params)
+	if err != nil {
+		s.logger.Error("BuyGift() -> BuyGift() request | error",
+			zap.Any("params", params),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	s.invalidateUserInfo(ctx, params.FromUser, order.RecipientID)
+
+	s.logger.Info("BuyGift() response", zap.Any("params", params), zap.Any("order", order))
+
+	return order, nil
+}
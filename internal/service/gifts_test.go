@@ -0,0 +1,39 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/0x0FACED/merch-shop/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Лимит на количество в покупке подарка сервис берет из конфига, как у обычной покупки
+func TestBuyGift_MaxQuantity(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	order := &model.Order{ID: 1, Total: 40, RecipientID: 2, Recipient: "friend"}
+	mockRepo.On("BuyGift", mock.Anything, model.GiftItemParams{
+		FromUser:    1,
+		ToUser:      "friend",
+		Item:        "cup",
+		Quantity:    2,
+		Message:     "happy birthday",
+		MaxQuantity: 100,
+	}).Return(order, nil)
+
+	got, err := userService.BuyGift(context.Background(), model.GiftItemParams{
+		FromUser: 1,
+		ToUser:   "friend",
+		Item:     "cup",
+		Quantity: 2,
+		Message:  "happy birthday",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, order, got)
+	mockRepo.AssertExpectations(t)
+}
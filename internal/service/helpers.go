@@ -3,7 +3,9 @@ package service
 import (
 	"errors"
 	"fmt"
+	"slices"
 
+	"github.com/0x0FACED/merch-shop/internal/model"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -25,3 +27,23 @@ func calcHash(password string) (string, error) {
 	}
 	return string(hash), nil
 }
+
+// checkCategory проверяет, что категория перевода есть в конфиге.
+// Пустая категория допустима, она опциональна
+func (s *MerchService) checkCategory(category string) error {
+	if category == "" || slices.Contains(s.transfer.Categories, category) {
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrUnknownCategory, category)
+}
+
+// transferLimits - лимиты переводов по умолчанию из конфига,
+// переопределения для ролей база применяет сама
+func (s *MerchService) transferLimits() model.TransferLimits {
+	return model.TransferLimits{
+		MaxSingle:       s.transfer.MaxSingle,
+		DailyTotal:      s.transfer.DailyTotal,
+		WeeklyTotal:     s.transfer.WeeklyTotal,
+		DailyRecipients: s.transfer.DailyRecipients,
+	}
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"go.uber.org/zap"
+)
+
+// GetCatalog возвращает предметы магазина с остатками на складе и ограничениями продажи
+func (s *MerchService) GetCatalog(ctx context.Context) ([]model.CatalogItem, error) {
+	s.logger.Info("GetCatalog() request")
+
+	items, err := s.repo.GetCatalog(ctx)
+	if err != nil {
+		s.logger.Error("GetCatalog() -> GetCatalog() request | error", zap.Error(err))
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	return items, nil
+}
+
+// SetItemRules задает склад, лимит на юзера и окно продажи предмета от имени админа.
+// Границы окна хранятся в UTC, как и остальное время в базе
+func (s *MerchService) SetItemRules(ctx context.Context, params model.SetItemRulesParams) (*model.CatalogItem, error) {
+	s.logger.Info("SetItemRules() request", zap.Any("params", params))
+
+	if params.SaleStartsAt != nil && params.SaleEndsAt != nil && !params.SaleEndsAt.After(*params.SaleStartsAt) {
+		return nil, ErrInvalidSaleWindow
+	}
+	params.SaleStartsAt = utcTime(params.SaleStartsAt)
+	params.SaleEndsAt = utcTime(params.SaleEndsAt)
+
+	item, err := s.repo.SetItemRules(ctx, params)
+	if err != nil {
+		s.logger.Error("SetItemRules() -> SetItemRules() request | error",
+			zap.Any("params", params),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	s.logger.Info("SetItemRules() response", zap.Any("item", item))
+
+	return item, nil
+}
+
+// SetItemVariant создает вариант предмета или заменяет его размер, цвет, надбавку к цене и склад
+func (s *MerchService) SetItemVariant(ctx context.Context, params model.SetItemVariantParams) (*model.ItemVariant, error) {
+	s.logger.Info("SetItemVariant() request", zap.Any("params", params))
+
+	variant, err := s.repo.SetItemVariant(ctx, params)
+	if err != nil {
+		s.logger.Error("SetItemVariant() -> SetItemVariant() request | error",
+			zap.Any("params", params),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	s.logger.Info("SetItemVariant() response", zap.Any("variant", variant))
+
+	return variant, nil
+}
+
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
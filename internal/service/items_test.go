@@ -0,0 +1,69 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/internal/database"
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/0x0FACED/merch-shop/internal/service"
+	"github.com/0x0FACED/merch-shop/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Границы окна продажи уходят в базу в UTC
+func TestSetItemRules_Success(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	msk := time.FixedZone("MSK", 3*60*60)
+	start := time.Date(2026, 11, 1, 12, 0, 0, 0, msk)
+	end := start.Add(48 * time.Hour)
+	stock := 40
+
+	item := &model.CatalogItem{Name: "pink-hoody", Price: 500, Stock: &stock, Available: true}
+	mockRepo.On("SetItemRules", mock.Anything, mock.MatchedBy(func(p model.SetItemRulesParams) bool {
+		return p.Item == "pink-hoody" && *p.Stock == 40 && p.PerUserLimit == nil &&
+			p.SaleStartsAt.Location() == time.UTC && p.SaleStartsAt.Equal(start) &&
+			p.SaleEndsAt.Location() == time.UTC && p.SaleEndsAt.Equal(end)
+	})).Return(item, nil)
+
+	got, err := userService.SetItemRules(context.Background(), model.SetItemRulesParams{
+		Item:         "pink-hoody",
+		Stock:        &stock,
+		SaleStartsAt: &start,
+		SaleEndsAt:   &end,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, item, got)
+	assert.Equal(t, msk, start.Location(), "caller's time is not modified")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSetItemRules_InvalidWindow(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	start := time.Date(2026, 11, 1, 12, 0, 0, 0, time.UTC)
+
+	_, err := userService.SetItemRules(context.Background(), model.SetItemRulesParams{
+		Item:         "pink-hoody",
+		SaleStartsAt: &start,
+		SaleEndsAt:   &start,
+	})
+	assert.ErrorIs(t, err, service.ErrInvalidSaleWindow)
+	mockRepo.AssertNotCalled(t, "SetItemRules", mock.Anything, mock.Anything)
+}
+
+func TestSetItemRules_UnknownItem(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	mockRepo.On("SetItemRules", mock.Anything, mock.Anything).Return(nil, database.ErrNotFound)
+
+	_, err := userService.SetItemRules(context.Background(), model.SetItemRulesParams{Item: "yacht"})
+	assert.ErrorIs(t, err, service.ErrNotFound)
+}
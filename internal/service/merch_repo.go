@@ -10,7 +10,89 @@ type merchRepository interface {
 	AuthUser(ctx context.Context, params model.AuthUserParams) (*model.User, error)
 	CreateUser(ctx context.Context, params model.CreateUserParams) (*model.User, error)
 	GetUserInfo(ctx context.Context, params model.GetUserInfoParams) (*model.UserInfo, error)
-	GetUserBalance(ctx context.Context, userID uint) (uint, error)
-	SendCoin(ctx context.Context, params model.SendCoinParams) error
+	SendCoin(ctx context.Context, params model.SendCoinParams) (uint, error)
+	SendCoinBatch(ctx context.Context, params model.SendCoinBatchParams) ([]uint, error)
 	BuyItem(ctx context.Context, params model.BuyItemParams) error
+
+	CreatePaymentRequest(ctx context.Context, params model.CreatePaymentRequestParams) (*model.PaymentRequest, error)
+	GetPaymentRequests(ctx context.Context, params model.GetPaymentRequestsParams) ([]model.PaymentRequest, error)
+	GetPaymentRequest(ctx context.Context, id uint) (*model.PaymentRequest, error)
+	AcceptPaymentRequest(ctx context.Context, params model.ResolvePaymentRequestParams) (*model.PaymentRequest, error)
+	DeclinePaymentRequest(ctx context.Context, params model.ResolvePaymentRequestParams) (*model.PaymentRequest, error)
+	CancelPaymentRequest(ctx context.Context, params model.ResolvePaymentRequestParams) (*model.PaymentRequest, error)
+
+	CreateScheduledTransfer(ctx context.Context, params model.CreateScheduledTransferParams) (*model.ScheduledTransfer, error)
+	GetScheduledTransfers(ctx context.Context, params model.GetScheduledTransfersParams) ([]model.ScheduledTransfer, error)
+	GetScheduledTransferRuns(ctx context.Context, params model.ScheduledTransferIDParams) ([]model.ScheduledTransferRun, error)
+	CancelScheduledTransfer(ctx context.Context, params model.ScheduledTransferIDParams) error
+	ClaimScheduledTransfers(ctx context.Context, params model.ClaimScheduledTransfersParams) ([]model.ScheduledTransfer, error)
+	ExecuteScheduledTransfer(ctx context.Context, params model.ExecuteScheduledTransferParams) (uint, error)
+	RecordScheduledTransferFailure(ctx context.Context, params model.RecordScheduledTransferFailureParams) error
+
+	GrantAllowance(ctx context.Context, params model.GrantAllowanceParams) (*model.AllowanceResult, error)
+	ExpireCoins(ctx context.Context, params model.ExpireCoinsParams) (*model.ExpiryResult, error)
+
+	FindTransferCycles(ctx context.Context, params model.FraudDetectionParams) ([]model.FraudFinding, error)
+	FindFreshFanIn(ctx context.Context, params model.FraudDetectionParams) ([]model.FraudFinding, error)
+	FindVelocitySpikes(ctx context.Context, params model.FraudDetectionParams) ([]model.FraudFinding, error)
+	SaveFraudAlerts(ctx context.Context, params model.SaveFraudAlertsParams) (*model.FraudDetectionResult, error)
+	GetFraudAlerts(ctx context.Context, params model.GetFraudAlertsParams) ([]model.FraudAlert, error)
+	ReviewFraudAlert(ctx context.Context, params model.ReviewFraudAlertParams) (*model.FraudAlert, error)
+	GetUserRole(ctx context.Context, userID uint) (string, error)
+
+	GetUserAccess(ctx context.Context, userID, sessionID uint) (*model.UserAccess, error)
+	SetUserStatus(ctx context.Context, params model.SetUserStatusParams) error
+	GetUserStatusHistory(ctx context.Context, userID uint) ([]model.UserStatusChange, error)
+
+	GetUser(ctx context.Context, userID uint) (*model.User, error)
+	ExportUserData(ctx context.Context, userID uint) (*model.UserExport, error)
+	DeleteUser(ctx context.Context, userID uint) ([]uint, error)
+
+	SetPassword(ctx context.Context, params model.SetPasswordParams) (int, error)
+	CreatePasswordReset(ctx context.Context, params model.CreatePasswordResetParams) (*model.PasswordReset, error)
+	ResetPassword(ctx context.Context, params model.ResetPasswordParams) (uint, error)
+
+	CreateServiceAccount(ctx context.Context, params model.CreateServiceAccountParams) (*model.ServiceAccount, error)
+	GetServiceAccounts(ctx context.Context) ([]model.ServiceAccount, error)
+	CreateAPIKey(ctx context.Context, params model.CreateAPIKeyParams) (*model.APIKey, error)
+	GetAPIKeys(ctx context.Context, userID uint) ([]model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uint) (*model.APIKey, error)
+	AuthAPIKey(ctx context.Context, keyHash string) (*model.APIKeyAuth, error)
+
+	CreateOIDCState(ctx context.Context, params model.CreateOIDCStateParams) error
+	ConsumeOIDCState(ctx context.Context, stateHash string) (*model.OIDCState, error)
+	LoginOIDCUser(ctx context.Context, params model.OIDCLoginParams) (*model.User, error)
+
+	CreateTOTP(ctx context.Context, params model.CreateTOTPParams) error
+	GetTOTP(ctx context.Context, userID uint) (*model.TOTP, error)
+	ConfirmTOTP(ctx context.Context, params model.ConfirmTOTPParams) error
+	UseTOTPStep(ctx context.Context, userID uint, step int64) error
+	UseRecoveryCode(ctx context.Context, userID uint, codeHash string) error
+	RecordTOTPFailure(ctx context.Context, params model.TOTPFailureParams) error
+	DeleteTOTP(ctx context.Context, userID uint) error
+
+	CreateSession(ctx context.Context, params model.CreateSessionParams) (*model.Session, error)
+	GetSessions(ctx context.Context, userID uint) ([]model.Session, error)
+	RevokeSession(ctx context.Context, params model.SessionIDParams) error
+
+	AddCartItem(ctx context.Context, params model.CartItemParams) error
+	UpdateCartItem(ctx context.Context, params model.CartItemParams) error
+	RemoveCartItem(ctx context.Context, userID uint, item, variant string) error
+	GetCart(ctx context.Context, userID uint) (*model.Cart, error)
+	Checkout(ctx context.Context, params model.CheckoutParams) (*model.Order, error)
+	PurchaseItem(ctx context.Context, params model.PurchaseItemParams) (*model.Order, error)
+	GetOrders(ctx context.Context, params model.GetOrdersParams) ([]model.Order, error)
+	SetOrderStatus(ctx context.Context, params model.SetOrderStatusParams) (*model.Order, error)
+	GetOrderStatusHistory(ctx context.Context, orderID uint) ([]model.OrderStatusChange, error)
+
+	CreateReturn(ctx context.Context, params model.CreateReturnParams) (*model.ReturnRequest, error)
+	GetReturns(ctx context.Context, params model.GetReturnsParams) ([]model.ReturnRequest, error)
+	ReviewReturn(ctx context.Context, params model.ReviewReturnParams) (*model.ReturnRequest, error)
+
+	GiftItem(ctx context.Context, params model.GiftItemParams) (*model.SentGift, error)
+	BuyGift(ctx context.Context, params model.GiftItemParams) (*model.Order, error)
+
+	GetCatalog(ctx context.Context) ([]model.CatalogItem, error)
+	SetItemRules(ctx context.Context, params model.SetItemRulesParams) (*model.CatalogItem, error)
+	SetItemVariant(ctx context.Context, params model.SetItemVariantParams) (*model.ItemVariant, error)
 }
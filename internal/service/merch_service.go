@@ -4,8 +4,13 @@ import (
 	"context"
 	"errors"
 
+	"github.com/0x0FACED/merch-shop/config"
+	"github.com/0x0FACED/merch-shop/internal/cache"
+	"github.com/0x0FACED/merch-shop/internal/crypt"
 	"github.com/0x0FACED/merch-shop/internal/database"
 	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/0x0FACED/merch-shop/internal/oidc"
+	"github.com/0x0FACED/merch-shop/internal/outbox"
 	"github.com/0x0FACED/merch-shop/pkg/logger"
 	"go.uber.org/zap"
 )
@@ -13,25 +18,68 @@ import (
 var _ merchRepository = (*database.Postgres)(nil)
 
 type MerchService struct {
-	repo merchRepository
+	repo    merchRepository
+	cache   userInfoCache
+	mailer  mailer
+	idp     identityProvider
+	secrets secretCipher
+
+	transfer config.TransferConfig
+	auth     config.AuthConfig
+	shop     config.ShopConfig
 
 	logger *logger.ZapLogger
 }
 
-func NewUserService(db merchRepository, l *logger.ZapLogger) *MerchService {
+// Deps - зависимости и настройки сервиса помимо базы и логгера.
+// Если Cache nil, то используется cache.Noop, если Mailer nil - outbox.Noop,
+// если IdP nil - oidc.Disabled (вход через SSO выключен), если Secrets nil - crypt.Disabled (2FA недоступна)
+type Deps struct {
+	Cache   userInfoCache
+	Mailer  mailer
+	IdP     identityProvider
+	Secrets secretCipher
+
+	Transfer config.TransferConfig
+	Auth     config.AuthConfig
+	Shop     config.ShopConfig
+}
+
+func NewUserService(db merchRepository, l *logger.ZapLogger, deps Deps) *MerchService {
+	if deps.Cache == nil {
+		deps.Cache = cache.Noop{}
+	}
+	if deps.Mailer == nil {
+		deps.Mailer = outbox.Noop{}
+	}
+	if deps.IdP == nil {
+		deps.IdP = oidc.Disabled{}
+	}
+	if deps.Secrets == nil {
+		deps.Secrets = crypt.Disabled{}
+	}
+
 	return &MerchService{
-		repo:   db,
-		logger: l,
+		repo:     db,
+		cache:    deps.Cache,
+		mailer:   deps.Mailer,
+		idp:      deps.IdP,
+		secrets:  deps.Secrets,
+		transfer: deps.Transfer,
+		auth:     deps.Auth,
+		shop:     deps.Shop,
+		logger:   l,
 	}
 }
 
 func (s *MerchService) AuthUser(ctx context.Context, params model.AuthUserParams) (*model.User, error) {
-	s.logger.Info("AuthUser() request", zap.Any("params", params))
+	// в params пароль и код 2FA, поэтому логируем только имя
+	s.logger.Info("AuthUser() request", zap.String("username", params.Username))
 
 	user, err := s.repo.AuthUser(ctx, params)
 	if err != nil {
 		s.logger.Error("AuthUser() -> AuthUser() request | error",
-			zap.Any("params", params),
+			zap.String("username", params.Username),
 			zap.Error(err),
 		)
 		// Не нашли юзера, значит создаем его
@@ -59,14 +107,35 @@ func (s *MerchService) AuthUser(ctx context.Context, params model.AuthUserParams
 
 	if err := compareHashAndPassword(user.Password, params.Password); err != nil {
 		s.logger.Error("AuthUser() -> compareHashAndPassword() request | error",
-			zap.Any("params", params),
+			zap.String("username", params.Username),
+			zap.Uint("user_id", user.ID),
 			zap.Error(err),
 		)
 
 		return nil, ErrFailedComparingHashAndPassword
 	}
 
-	s.logger.Info("AuthUser() response", zap.Any("params", params), zap.Any("user", user))
+	if !model.CanLogin(user.Status) {
+		s.logger.Error("AuthUser() -> CanLogin() | error",
+			zap.String("username", params.Username),
+			zap.Uint("user_id", user.ID),
+			zap.String("status", user.Status),
+		)
+		return nil, ErrAccountSuspended
+	}
+
+	if user.TOTPEnabled {
+		if err := s.checkLoginTOTP(ctx, user.ID, params.OTP); err != nil {
+			s.logger.Error("AuthUser() -> checkLoginTOTP() | error",
+				zap.String("username", params.Username),
+				zap.Uint("user_id", user.ID),
+				zap.Error(err),
+			)
+			return nil, err
+		}
+	}
+
+	s.logger.Info("AuthUser() response", zap.String("username", params.Username), zap.Uint("user_id", user.ID))
 
 	return user, nil
 }
@@ -74,6 +143,19 @@ func (s *MerchService) AuthUser(ctx context.Context, params model.AuthUserParams
 func (s *MerchService) GetUserInfo(ctx context.Context, params model.GetUserInfoParams) (*model.UserInfo, error) {
 	s.logger.Info("GetUserInfo() request", zap.Any("params", params))
 
+	if err := s.checkCategory(params.Category); err != nil {
+		s.logger.Error("GetUserInfo() -> checkCategory() | error", zap.Any("params", params), zap.Error(err))
+		return nil, err
+	}
+
+	params.CoinLifetime = s.transfer.CoinLifetime
+
+	userInfo, gen, ok := s.cachedUserInfo(ctx, params)
+	if ok {
+		s.logger.Info("GetUserInfo() response from cache", zap.Any("params", params))
+		return userInfo, nil
+	}
+
 	userInfo, err := s.repo.GetUserInfo(ctx, params)
 	if err != nil {
 		s.logger.Error("GetUserInfo() -> GetUserInfo() request | error",
@@ -83,6 +165,8 @@ func (s *MerchService) GetUserInfo(ctx context.Context, params model.GetUserInfo
 		return nil, MapDBErrorToServiceError(err)
 	}
 
+	s.storeUserInfo(ctx, params, gen, userInfo)
+
 	s.logger.Info("GetUserInfo() response", zap.Any("params", params), zap.Any("user_info", userInfo))
 
 	return userInfo, nil
@@ -91,7 +175,20 @@ func (s *MerchService) GetUserInfo(ctx context.Context, params model.GetUserInfo
 func (s *MerchService) SendCoin(ctx context.Context, params model.SendCoinParams) error {
 	s.logger.Info("SendCoin() request", zap.Any("params", params))
 
-	if err := s.repo.SendCoin(ctx, params); err != nil {
+	if err := s.checkCategory(params.Category); err != nil {
+		s.logger.Error("SendCoin() -> checkCategory() | error", zap.Any("params", params), zap.Error(err))
+		return err
+	}
+
+	if err := s.checkStepUp(ctx, params.FromUser, params.Amount, params.OTP); err != nil {
+		s.logger.Error("SendCoin() -> checkStepUp() | error", zap.Uint("from_user", params.FromUser), zap.Error(err))
+		return err
+	}
+
+	params.Limits = s.transferLimits()
+
+	toUserID, err := s.repo.SendCoin(ctx, params)
+	if err != nil {
 		s.logger.Error("SendCoin() -> SendCoin() request | error",
 			zap.Any("params", params),
 			zap.Error(err),
@@ -99,6 +196,8 @@ func (s *MerchService) SendCoin(ctx context.Context, params model.SendCoinParams
 		return MapDBErrorToServiceError(err)
 	}
 
+	s.invalidateUserInfo(ctx, params.FromUser, toUserID)
+
 	s.logger.Info("SendCoin() response", zap.Any("params", params))
 
 	return nil
@@ -107,17 +206,6 @@ func (s *MerchService) SendCoin(ctx context.Context, params model.SendCoinParams
 func (s *MerchService) BuyItem(ctx context.Context, params model.BuyItemParams) error {
 	s.logger.Info("BuyItem() request", zap.Any("params", params))
 
-	balance, err := s.repo.GetUserBalance(ctx, params.UserID)
-	if err != nil {
-		s.logger.Error("BuyItem() -> GetUserBalance() request | error",
-			zap.Any("params", params),
-			zap.Error(err),
-		)
-		return MapDBErrorToServiceError(err)
-	}
-
-	params.Balance = balance
-
 	if err := s.repo.BuyItem(ctx, params); err != nil {
 		s.logger.Error("BuyItem() -> BuyItem() request | error",
 			zap.Any("params", params),
@@ -126,6 +214,8 @@ func (s *MerchService) BuyItem(ctx context.Context, params model.BuyItemParams)
 		return MapDBErrorToServiceError(err)
 	}
 
+	s.invalidateUserInfo(ctx, params.UserID)
+
 	s.logger.Info("BuyItem() response", zap.Any("params", params))
 
 	return nil
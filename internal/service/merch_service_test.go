@@ -3,9 +3,12 @@ package service_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/0x0FACED/merch-shop/config"
+	"github.com/0x0FACED/merch-shop/internal/cache"
 	"github.com/0x0FACED/merch-shop/internal/database"
 	"github.com/0x0FACED/merch-shop/internal/model"
 	"github.com/0x0FACED/merch-shop/internal/service"
@@ -13,6 +16,7 @@ import (
 	"github.com/0x0FACED/merch-shop/pkg/logger"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func testLogger() *logger.ZapLogger {
@@ -22,10 +26,49 @@ func testLogger() *logger.ZapLogger {
 	return logger.NewTestLogger(cfg)
 }
 
+func testTransferConfig() config.TransferConfig {
+	return config.TransferConfig{
+		Categories:        []string{"kudos", "reimbursement", "gift"},
+		PaymentRequestTTL: 24 * time.Hour,
+
+		ScheduledMaxFailures:   3,
+		ScheduledRetryInterval: time.Hour,
+	}
+}
+
+func testAuthConfig() config.AuthConfig {
+	return config.AuthConfig{
+		PasswordResetTTL: time.Hour,
+		OIDCStateTTL:     10 * time.Minute,
+	}
+}
+
+func testShopConfig() config.ShopConfig {
+	return config.ShopConfig{
+		MaxItemQuantity: 100,
+		MaxCartLines:    20,
+		ReturnWindow:    14 * 24 * time.Hour,
+	}
+}
+
+// testDeps - настройки сервиса для тестов, тест меняет только нужные ему поля
+func testDeps() service.Deps {
+	return service.Deps{
+		Transfer: testTransferConfig(),
+		Auth:     testAuthConfig(),
+		Shop:     testShopConfig(),
+	}
+}
+
+// newTestService создает сервис с настройками из testDeps
+func newTestService(repo *mocks.MockMerchRepository) *service.MerchService {
+	return service.NewUserService(repo, testLogger(), testDeps())
+}
+
 // Тест успешной аутентификации
 func TestAuthUser_Success(t *testing.T) {
 	mockRepo := new(mocks.MockMerchRepository)
-	userService := service.NewUserService(mockRepo, testLogger())
+	userService := newTestService(mockRepo)
 
 	params := model.AuthUserParams{Username: "testuser", Password: "test"}
 	mockUser := &model.User{ID: 1, Username: "testuser", Password: "$2a$04$sjoS1Bf2A30VG0Vt0LkQf..KmKQCHuS5wvDG5RFTCQ2F1EtVVmTcm"}
@@ -43,7 +86,7 @@ func TestAuthUser_Success(t *testing.T) {
 // Тест ошибки аутентификации
 func TestAuthUser_Fail(t *testing.T) {
 	mockRepo := new(mocks.MockMerchRepository)
-	userService := service.NewUserService(mockRepo, testLogger())
+	userService := newTestService(mockRepo)
 
 	params := model.AuthUserParams{Username: "unknown", Password: "password"}
 
@@ -58,7 +101,7 @@ func TestAuthUser_Fail(t *testing.T) {
 
 func TestAuthUser_CreateNewUser(t *testing.T) {
 	mockRepo := new(mocks.MockMerchRepository)
-	userService := service.NewUserService(mockRepo, testLogger())
+	userService := newTestService(mockRepo)
 
 	params := model.AuthUserParams{Username: "newuser", Password: "password"}
 	mockRepo.On("AuthUser", mock.Anything, params).Return(nil, database.ErrNotFound)
@@ -74,7 +117,7 @@ func TestAuthUser_CreateNewUser(t *testing.T) {
 
 func TestAuthUser_CreateUserFail(t *testing.T) {
 	mockRepo := new(mocks.MockMerchRepository)
-	userService := service.NewUserService(mockRepo, testLogger())
+	userService := newTestService(mockRepo)
 
 	params := model.AuthUserParams{Username: "testuser", Password: "test"}
 
@@ -90,7 +133,7 @@ func TestAuthUser_CreateUserFail(t *testing.T) {
 
 func TestAuthUser_WrongHashFormat(t *testing.T) {
 	mockRepo := new(mocks.MockMerchRepository)
-	userService := service.NewUserService(mockRepo, testLogger())
+	userService := newTestService(mockRepo)
 
 	params := model.AuthUserParams{Username: "testuser", Password: "test"}
 	mockUser := &model.User{ID: 1, Username: "testuser", Password: "invalid-hash"}
@@ -107,7 +150,7 @@ func TestAuthUser_WrongHashFormat(t *testing.T) {
 // Тест успешного получения инфы о юзере
 func TestGetUserInfo_Success(t *testing.T) {
 	mockRepo := new(mocks.MockMerchRepository)
-	userService := service.NewUserService(mockRepo, testLogger())
+	userService := newTestService(mockRepo)
 
 	params := model.GetUserInfoParams{ID: 1}
 
@@ -126,7 +169,7 @@ func TestGetUserInfo_Success(t *testing.T) {
 // Тест ошибки получения инфы о юзере
 func TestGetUserInfo_Fail(t *testing.T) {
 	mockRepo := new(mocks.MockMerchRepository)
-	userService := service.NewUserService(mockRepo, testLogger())
+	userService := newTestService(mockRepo)
 
 	params := model.GetUserInfoParams{ID: 1}
 
@@ -140,13 +183,36 @@ func TestGetUserInfo_Fail(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+// Тест получения агрегированной истории монет
+func TestGetUserInfo_AggregatedHistory(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	params := model.GetUserInfoParams{ID: 1, HistoryMode: model.CoinHistoryModeAggregated}
+
+	mockInfo := &model.UserInfo{
+		Coins: 1000,
+		CoinHistory: model.CoinHistory{
+			Received: []model.ReceivedTransaction{{User: "user2", Amount: 10, Count: 10}},
+		},
+	}
+
+	mockRepo.On("GetUserInfo", mock.Anything, params).Return(mockInfo, nil)
+
+	userInfo, err := userService.GetUserInfo(context.Background(), params)
+
+	assert.NoError(t, err)
+	assert.Len(t, userInfo.CoinHistory.Received, 1)
+	assert.Equal(t, 10, userInfo.CoinHistory.Received[0].Count)
+	mockRepo.AssertExpectations(t)
+}
+
 // Тест успешной покупки предмета
 func TestBuyItem_Success(t *testing.T) {
 	mockRepo := new(mocks.MockMerchRepository)
-	userService := service.NewUserService(mockRepo, testLogger())
+	userService := newTestService(mockRepo)
 
-	params := model.BuyItemParams{UserID: 1, Item: "hoody", Balance: 500}
-	mockRepo.On("GetUserBalance", mock.Anything, params.UserID).Return(500, nil)
+	params := model.BuyItemParams{UserID: 1, Item: "hoody"}
 	mockRepo.On("BuyItem", mock.Anything, params).Return(nil)
 
 	err := userService.BuyItem(context.Background(), params)
@@ -158,10 +224,9 @@ func TestBuyItem_Success(t *testing.T) {
 // Тест, когда у пользователя не хватает денег на покупку
 func TestBuyItem_NotEnoughBalance(t *testing.T) {
 	mockRepo := new(mocks.MockMerchRepository)
-	userService := service.NewUserService(mockRepo, testLogger())
+	userService := newTestService(mockRepo)
 
-	params := model.BuyItemParams{UserID: 1, Item: "hoody", Balance: 100}
-	mockRepo.On("GetUserBalance", mock.Anything, params.UserID).Return(100, nil)
+	params := model.BuyItemParams{UserID: 1, Item: "hoody"}
 	mockRepo.On("BuyItem", mock.Anything, params).Return(database.ErrInsufficientFunds)
 
 	err := userService.BuyItem(context.Background(), params)
@@ -174,10 +239,9 @@ func TestBuyItem_NotEnoughBalance(t *testing.T) {
 // Тест, когда предмета не существует
 func TestBuyItem_ItemDoesntExist(t *testing.T) {
 	mockRepo := new(mocks.MockMerchRepository)
-	userService := service.NewUserService(mockRepo, testLogger())
+	userService := newTestService(mockRepo)
 
-	params := model.BuyItemParams{UserID: 1, Item: "not-exists-item", Balance: 100}
-	mockRepo.On("GetUserBalance", mock.Anything, params.UserID).Return(100, nil)
+	params := model.BuyItemParams{UserID: 1, Item: "not-exists-item"}
 	mockRepo.On("BuyItem", mock.Anything, params).Return(database.ErrNotFound)
 
 	err := userService.BuyItem(context.Background(), params)
@@ -187,28 +251,13 @@ func TestBuyItem_ItemDoesntExist(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
-// Тест покупки предмета, когда не получается получить баланс юзера
-func TestBuyItem_GetUserBalanceError(t *testing.T) {
-	mockRepo := new(mocks.MockMerchRepository)
-	userService := service.NewUserService(mockRepo, testLogger())
-
-	params := model.BuyItemParams{UserID: 1, Item: "hoody"}
-	mockRepo.On("GetUserBalance", mock.Anything, params.UserID).Return(0, database.ErrQueryFailed)
-
-	err := userService.BuyItem(context.Background(), params)
-
-	assert.Error(t, err)
-	assert.Equal(t, database.ErrQueryFailed, err)
-	mockRepo.AssertExpectations(t)
-}
-
 // Тест успешной передачи монеток
 func TestSendCoin_Success(t *testing.T) {
 	mockRepo := new(mocks.MockMerchRepository)
-	userService := service.NewUserService(mockRepo, testLogger())
+	userService := newTestService(mockRepo)
 
 	params := model.SendCoinParams{FromUser: 1, ToUser: "user2", Amount: 100}
-	mockRepo.On("SendCoin", mock.Anything, params).Return(nil)
+	mockRepo.On("SendCoin", mock.Anything, params).Return(2, nil)
 
 	err := userService.SendCoin(context.Background(), params)
 
@@ -219,10 +268,10 @@ func TestSendCoin_Success(t *testing.T) {
 // Тест передачи монеток, если у пользователя недостаточно баланса
 func TestSendCoin_NotEnoughBalance(t *testing.T) {
 	mockRepo := new(mocks.MockMerchRepository)
-	userService := service.NewUserService(mockRepo, testLogger())
+	userService := newTestService(mockRepo)
 
 	params := model.SendCoinParams{FromUser: 1, ToUser: "user2", Amount: 5000}
-	mockRepo.On("SendCoin", mock.Anything, params).Return(database.ErrInsufficientFunds)
+	mockRepo.On("SendCoin", mock.Anything, params).Return(0, database.ErrInsufficientFunds)
 
 	err := userService.SendCoin(context.Background(), params)
 
@@ -234,10 +283,10 @@ func TestSendCoin_NotEnoughBalance(t *testing.T) {
 // Тест передачи монеток несуществующему пользователю
 func TestSendCoin_UserDoesntExist(t *testing.T) {
 	mockRepo := new(mocks.MockMerchRepository)
-	userService := service.NewUserService(mockRepo, testLogger())
+	userService := newTestService(mockRepo)
 
 	params := model.SendCoinParams{FromUser: 1, ToUser: "not-exist-user", Amount: 50}
-	mockRepo.On("SendCoin", mock.Anything, params).Return(database.ErrNotFound)
+	mockRepo.On("SendCoin", mock.Anything, params).Return(0, database.ErrNotFound)
 
 	err := userService.SendCoin(context.Background(), params)
 
@@ -248,11 +297,11 @@ func TestSendCoin_UserDoesntExist(t *testing.T) {
 
 func TestSendCoin_BeginTxFail(t *testing.T) {
 	mockRepo := new(mocks.MockMerchRepository)
-	userService := service.NewUserService(mockRepo, testLogger())
+	userService := newTestService(mockRepo)
 
 	params := model.SendCoinParams{FromUser: 1, ToUser: "user2", Amount: 100}
 
-	mockRepo.On("SendCoin", mock.Anything, params).Return(database.ErrFailedToBeginTx)
+	mockRepo.On("SendCoin", mock.Anything, params).Return(0, database.ErrFailedToBeginTx)
 
 	err := userService.SendCoin(context.Background(), params)
 
@@ -263,11 +312,11 @@ func TestSendCoin_BeginTxFail(t *testing.T) {
 
 func TestSendCoin_FailedFetchBalance(t *testing.T) {
 	mockRepo := new(mocks.MockMerchRepository)
-	userService := service.NewUserService(mockRepo, testLogger())
+	userService := newTestService(mockRepo)
 
 	params := model.SendCoinParams{FromUser: 1, ToUser: "user2", Amount: 100}
 
-	mockRepo.On("SendCoin", mock.Anything, params).Return(database.ErrFailedToFetchBalance)
+	mockRepo.On("SendCoin", mock.Anything, params).Return(0, database.ErrFailedToFetchBalance)
 
 	err := userService.SendCoin(context.Background(), params)
 
@@ -278,11 +327,11 @@ func TestSendCoin_FailedFetchBalance(t *testing.T) {
 
 func TestSendCoin_FailedCreditRecipient(t *testing.T) {
 	mockRepo := new(mocks.MockMerchRepository)
-	userService := service.NewUserService(mockRepo, testLogger())
+	userService := newTestService(mockRepo)
 
 	params := model.SendCoinParams{FromUser: 1, ToUser: "user2", Amount: 100}
 
-	mockRepo.On("SendCoin", mock.Anything, params).Return(database.ErrFailedToCreditRecipient)
+	mockRepo.On("SendCoin", mock.Anything, params).Return(0, database.ErrFailedToCreditRecipient)
 
 	err := userService.SendCoin(context.Background(), params)
 
@@ -293,11 +342,11 @@ func TestSendCoin_FailedCreditRecipient(t *testing.T) {
 
 func TestSendCoin_FailedDebitSender(t *testing.T) {
 	mockRepo := new(mocks.MockMerchRepository)
-	userService := service.NewUserService(mockRepo, testLogger())
+	userService := newTestService(mockRepo)
 
 	params := model.SendCoinParams{FromUser: 1, ToUser: "user2", Amount: 100}
 
-	mockRepo.On("SendCoin", mock.Anything, params).Return(database.ErrFailedToDebitSender)
+	mockRepo.On("SendCoin", mock.Anything, params).Return(0, database.ErrFailedToDebitSender)
 
 	err := userService.SendCoin(context.Background(), params)
 
@@ -315,4 +364,238 @@ func TestMapDBErrorToServiceError(t *testing.T) {
 
 	err = service.MapDBErrorToServiceError(errors.New("unknown"))
 	assert.Error(t, err)
+
+	// база оборачивает ошибки контекстом, маппинг должен смотреть сквозь обертку
+	tests := []struct {
+		dbErr   error
+		wantErr error
+	}{
+		{dbErr: database.ErrPaymentRequestNotPending, wantErr: service.ErrPaymentRequestNotPending},
+		{dbErr: database.ErrPaymentRequestExpired, wantErr: service.ErrPaymentRequestExpired},
+		{dbErr: database.ErrSelfPaymentRequest, wantErr: service.ErrSelfPaymentRequest},
+		{dbErr: database.ErrSelfScheduledTransfer, wantErr: service.ErrSelfScheduledTransfer},
+		{dbErr: database.ErrNotCancellable, wantErr: service.ErrNotCancellable},
+		{dbErr: database.ErrSingleTransferLimit, wantErr: service.ErrSingleTransferLimit},
+		{dbErr: database.ErrDailyTransferLimit, wantErr: service.ErrDailyTransferLimit},
+		{dbErr: database.ErrWeeklyTransferLimit, wantErr: service.ErrWeeklyTransferLimit},
+		{dbErr: database.ErrDailyRecipientsLimit, wantErr: service.ErrDailyRecipientsLimit},
+		{dbErr: database.ErrAccountFrozen, wantErr: service.ErrAccountFrozen},
+		{dbErr: database.ErrFraudAlertReviewed, wantErr: service.ErrFraudAlertReviewed},
+		{dbErr: database.ErrAccountSuspended, wantErr: service.ErrAccountSuspended},
+		{dbErr: database.ErrRecipientNotActive, wantErr: service.ErrRecipientNotActive},
+		{dbErr: database.ErrInvalidResetToken, wantErr: service.ErrInvalidResetToken},
+		{dbErr: database.ErrInvalidAPIKey, wantErr: service.ErrInvalidAPIKey},
+		{dbErr: database.ErrUsernameTaken, wantErr: service.ErrUsernameTaken},
+		{dbErr: database.ErrInvalidOIDCState, wantErr: service.ErrInvalidOIDCState},
+		{dbErr: database.ErrTOTPAlreadyEnabled, wantErr: service.ErrTOTPAlreadyEnabled},
+		{dbErr: database.ErrTOTPCodeUsed, wantErr: service.ErrInvalidTOTP},
+		{dbErr: database.ErrInvalidRecoveryCode, wantErr: service.ErrInvalidTOTP},
+		{dbErr: database.ErrCartEmpty, wantErr: service.ErrCartEmpty},
+		{dbErr: database.ErrCartFull, wantErr: service.ErrCartFull},
+		{dbErr: database.ErrQuantityLimit, wantErr: service.ErrQuantityLimit},
+		{dbErr: database.ErrSoldOut, wantErr: service.ErrSoldOut},
+		{dbErr: database.ErrPurchaseLimit, wantErr: service.ErrPurchaseLimit},
+		{dbErr: database.ErrSaleNotStarted, wantErr: service.ErrSaleNotStarted},
+		{dbErr: database.ErrSaleEnded, wantErr: service.ErrSaleEnded},
+		{dbErr: database.ErrVariantRequired, wantErr: service.ErrVariantRequired},
+		{dbErr: database.ErrSKUTaken, wantErr: service.ErrSKUTaken},
+		{dbErr: database.ErrNegativePrice, wantErr: service.ErrNegativePrice},
+		{dbErr: database.ErrOrderStatusTransition, wantErr: service.ErrOrderStatusTransition},
+		{dbErr: database.ErrNotEnoughItems, wantErr: service.ErrNotEnoughItems},
+		{dbErr: database.ErrNotReturnable, wantErr: service.ErrNotReturnable},
+		{dbErr: database.ErrReturnNotPending, wantErr: service.ErrReturnNotPending},
+		{dbErr: database.ErrInvalidRefund, wantErr: service.ErrInvalidRefund},
+		{dbErr: database.ErrSelfGift, wantErr: service.ErrSelfGift},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dbErr.Error(), func(t *testing.T) {
+			err := service.MapDBErrorToServiceError(fmt.Errorf("%w: details", tt.dbErr))
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+// Ошибка перевода из пачки маппится с сохранением его индекса
+func TestMapDBErrorToServiceError_BatchTransferError(t *testing.T) {
+	err := service.MapDBErrorToServiceError(&database.BatchTransferError{Index: 2, Err: database.ErrInsufficientFunds})
+	var batchErr *service.BatchTransferError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, 2, batchErr.Index)
+	assert.ErrorIs(t, err, service.ErrInsufficientFunds)
+}
+
+// Ошибка строки заказа маппится с сохранением предмета и варианта
+func TestMapDBErrorToServiceError_OrderLineError(t *testing.T) {
+	err := service.MapDBErrorToServiceError(&database.OrderLineError{Item: "hoody", Variant: "hoody-xl", Err: database.ErrQuantityLimit})
+	var lineErr *service.OrderLineError
+	require.ErrorAs(t, err, &lineErr)
+	assert.Equal(t, "hoody", lineErr.Item)
+	assert.Equal(t, "hoody-xl", lineErr.Variant)
+	assert.ErrorIs(t, err, service.ErrQuantityLimit)
+}
+
+// Тест чтения информации о юзере через кэш: второй запрос не идет в базу
+func TestGetUserInfo_CacheHit(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	deps := testDeps()
+	deps.Cache = cache.NewLRU(10, time.Minute)
+	userService := service.NewUserService(mockRepo, testLogger(), deps)
+
+	params := model.GetUserInfoParams{ID: 1}
+
+	mockRepo.On("GetUserInfo", mock.Anything, params).Return(&model.UserInfo{Coins: 1000}, nil).Once()
+
+	first, err := userService.GetUserInfo(context.Background(), params)
+	assert.NoError(t, err)
+
+	second, err := userService.GetUserInfo(context.Background(), params)
+	assert.NoError(t, err)
+
+	assert.Equal(t, first.Coins, second.Coins)
+	mockRepo.AssertNumberOfCalls(t, "GetUserInfo", 1)
+}
+
+// Тест инвалидации кэша после перевода монет: кэш сбрасывается и у отправителя, и у получателя
+func TestSendCoin_InvalidatesCache(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	deps := testDeps()
+	deps.Cache = cache.NewLRU(10, time.Minute)
+	userService := service.NewUserService(mockRepo, testLogger(), deps)
+
+	senderParams := model.GetUserInfoParams{ID: 1}
+	recipientParams := model.GetUserInfoParams{ID: 2}
+	sendParams := model.SendCoinParams{FromUser: 1, ToUser: "user2", Amount: 100}
+
+	mockRepo.On("GetUserInfo", mock.Anything, senderParams).Return(&model.UserInfo{Coins: 1000}, nil).Once()
+	mockRepo.On("GetUserInfo", mock.Anything, recipientParams).Return(&model.UserInfo{Coins: 1000}, nil).Once()
+	mockRepo.On("SendCoin", mock.Anything, sendParams).Return(2, nil)
+	mockRepo.On("GetUserInfo", mock.Anything, senderParams).Return(&model.UserInfo{Coins: 900}, nil).Once()
+	mockRepo.On("GetUserInfo", mock.Anything, recipientParams).Return(&model.UserInfo{Coins: 1100}, nil).Once()
+
+	_, _ = userService.GetUserInfo(context.Background(), senderParams)
+	_, _ = userService.GetUserInfo(context.Background(), recipientParams)
+
+	err := userService.SendCoin(context.Background(), sendParams)
+	assert.NoError(t, err)
+
+	sender, err := userService.GetUserInfo(context.Background(), senderParams)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(900), sender.Coins)
+
+	recipient, err := userService.GetUserInfo(context.Background(), recipientParams)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1100), recipient.Coins)
+
+	mockRepo.AssertExpectations(t)
+}
+
+// Чтение поколения кэша не попадает в счетчики: один промах и одно попадание на два запроса
+func TestGetUserInfo_CacheStats(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	deps := testDeps()
+	deps.Cache = cache.NewLRU(10, time.Minute)
+	userService := service.NewUserService(mockRepo, testLogger(), deps)
+
+	params := model.GetUserInfoParams{ID: 1}
+	mockRepo.On("GetUserInfo", mock.Anything, params).Return(&model.UserInfo{Coins: 1000}, nil).Once()
+
+	hitsBefore, missesBefore := cache.Stats()
+
+	for range 2 {
+		_, err := userService.GetUserInfo(context.Background(), params)
+		require.NoError(t, err)
+	}
+
+	hits, misses := cache.Stats()
+	assert.Equal(t, hitsBefore+1, hits)
+	assert.Equal(t, missesBefore+1, misses)
+	mockRepo.AssertExpectations(t)
+}
+
+// Тест чтения, которое обогнала инвалидация: устаревший ответ из базы не должен остаться в кэше
+func TestGetUserInfo_InvalidatedDuringRead(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	deps := testDeps()
+	deps.Cache = cache.NewLRU(10, time.Minute)
+	userService := service.NewUserService(mockRepo, testLogger(), deps)
+
+	params := model.GetUserInfoParams{ID: 1}
+	sendParams := model.SendCoinParams{FromUser: 1, ToUser: "user2", Amount: 100}
+
+	mockRepo.On("SendCoin", mock.Anything, sendParams).Return(2, nil)
+	// перевод коммитится, пока чтение еще не записало старый баланс в кэш
+	mockRepo.On("GetUserInfo", mock.Anything, params).Return(&model.UserInfo{Coins: 1000}, nil).Once().
+		Run(func(mock.Arguments) {
+			assert.NoError(t, userService.SendCoin(context.Background(), sendParams))
+		})
+	mockRepo.On("GetUserInfo", mock.Anything, params).Return(&model.UserInfo{Coins: 900}, nil).Once()
+
+	stale, err := userService.GetUserInfo(context.Background(), params)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1000), stale.Coins)
+
+	fresh, err := userService.GetUserInfo(context.Background(), params)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(900), fresh.Coins)
+
+	mockRepo.AssertExpectations(t)
+}
+
+// Тест перевода с комментарием и категорией
+func TestSendCoin_WithMemoAndCategory(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	params := model.SendCoinParams{FromUser: 1, ToUser: "user2", Amount: 10, Memo: "thanks for the code review", Category: "kudos"}
+	mockRepo.On("SendCoin", mock.Anything, params).Return(2, nil)
+
+	err := userService.SendCoin(context.Background(), params)
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+// Тест перевода с категорией, которой нет в конфиге: до базы запрос не доходит
+func TestSendCoin_UnknownCategory(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	params := model.SendCoinParams{FromUser: 1, ToUser: "user2", Amount: 10, Category: "bribe"}
+
+	err := userService.SendCoin(context.Background(), params)
+
+	assert.ErrorIs(t, err, service.ErrUnknownCategory)
+	mockRepo.AssertNotCalled(t, "SendCoin", mock.Anything, mock.Anything)
+}
+
+// Тест фильтрации истории по категории: такие ответы не кэшируются
+func TestGetUserInfo_FilterByCategory(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	deps := testDeps()
+	deps.Cache = cache.NewLRU(10, time.Minute)
+	userService := service.NewUserService(mockRepo, testLogger(), deps)
+
+	params := model.GetUserInfoParams{ID: 1, Category: "gift"}
+	mockRepo.On("GetUserInfo", mock.Anything, params).Return(&model.UserInfo{Coins: 1000}, nil)
+
+	_, err := userService.GetUserInfo(context.Background(), params)
+	assert.NoError(t, err)
+
+	_, err = userService.GetUserInfo(context.Background(), params)
+	assert.NoError(t, err)
+
+	mockRepo.AssertNumberOfCalls(t, "GetUserInfo", 2)
+}
+
+func TestGetUserInfo_UnknownCategory(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	params := model.GetUserInfoParams{ID: 1, Category: "bribe"}
+
+	_, err := userService.GetUserInfo(context.Background(), params)
+
+	assert.ErrorIs(t, err, service.ErrUnknownCategory)
+	mockRepo.AssertNotCalled(t, "GetUserInfo", mock.Anything, mock.Anything)
 }
@@ -35,17 +35,502 @@ func (m *MockMerchRepository) GetUserInfo(ctx context.Context, params model.GetU
 	return nil, args.Error(1)
 }
 
-func (m *MockMerchRepository) GetUserBalance(ctx context.Context, userID uint) (uint, error) {
+func (m *MockMerchRepository) SendCoin(ctx context.Context, params model.SendCoinParams) (uint, error) {
+	args := m.Called(ctx, params)
+	return uint(args.Int(0)), args.Error(1)
+}
+
+func (m *MockMerchRepository) BuyItem(ctx context.Context, params model.BuyItemParams) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
+}
+
+func (m *MockMerchRepository) CreatePaymentRequest(ctx context.Context, params model.CreatePaymentRequestParams) (*model.PaymentRequest, error) {
+	args := m.Called(ctx, params)
+	if pr, ok := args.Get(0).(*model.PaymentRequest); ok {
+		return pr, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) GetPaymentRequests(ctx context.Context, params model.GetPaymentRequestsParams) ([]model.PaymentRequest, error) {
+	args := m.Called(ctx, params)
+	if prs, ok := args.Get(0).([]model.PaymentRequest); ok {
+		return prs, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) AcceptPaymentRequest(ctx context.Context, params model.ResolvePaymentRequestParams) (*model.PaymentRequest, error) {
+	args := m.Called(ctx, params)
+	if pr, ok := args.Get(0).(*model.PaymentRequest); ok {
+		return pr, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) DeclinePaymentRequest(ctx context.Context, params model.ResolvePaymentRequestParams) (*model.PaymentRequest, error) {
+	args := m.Called(ctx, params)
+	if pr, ok := args.Get(0).(*model.PaymentRequest); ok {
+		return pr, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) CancelPaymentRequest(ctx context.Context, params model.ResolvePaymentRequestParams) (*model.PaymentRequest, error) {
+	args := m.Called(ctx, params)
+	if pr, ok := args.Get(0).(*model.PaymentRequest); ok {
+		return pr, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) CreateScheduledTransfer(ctx context.Context, params model.CreateScheduledTransferParams) (*model.ScheduledTransfer, error) {
+	args := m.Called(ctx, params)
+	if st, ok := args.Get(0).(*model.ScheduledTransfer); ok {
+		return st, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) GetScheduledTransfers(ctx context.Context, params model.GetScheduledTransfersParams) ([]model.ScheduledTransfer, error) {
+	args := m.Called(ctx, params)
+	if sts, ok := args.Get(0).([]model.ScheduledTransfer); ok {
+		return sts, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) GetScheduledTransferRuns(ctx context.Context, params model.ScheduledTransferIDParams) ([]model.ScheduledTransferRun, error) {
+	args := m.Called(ctx, params)
+	if runs, ok := args.Get(0).([]model.ScheduledTransferRun); ok {
+		return runs, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) CancelScheduledTransfer(ctx context.Context, params model.ScheduledTransferIDParams) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
+}
+
+func (m *MockMerchRepository) ClaimScheduledTransfers(ctx context.Context, params model.ClaimScheduledTransfersParams) ([]model.ScheduledTransfer, error) {
+	args := m.Called(ctx, params)
+	if sts, ok := args.Get(0).([]model.ScheduledTransfer); ok {
+		return sts, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) ExecuteScheduledTransfer(ctx context.Context, params model.ExecuteScheduledTransferParams) (uint, error) {
+	args := m.Called(ctx, params)
+	return uint(args.Int(0)), args.Error(1)
+}
+
+func (m *MockMerchRepository) RecordScheduledTransferFailure(ctx context.Context, params model.RecordScheduledTransferFailureParams) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
+}
+
+func (m *MockMerchRepository) GrantAllowance(ctx context.Context, params model.GrantAllowanceParams) (*model.AllowanceResult, error) {
+	args := m.Called(ctx, params)
+	if result, ok := args.Get(0).(*model.AllowanceResult); ok {
+		return result, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) ExpireCoins(ctx context.Context, params model.ExpireCoinsParams) (*model.ExpiryResult, error) {
+	args := m.Called(ctx, params)
+	if result, ok := args.Get(0).(*model.ExpiryResult); ok {
+		return result, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) SendCoinBatch(ctx context.Context, params model.SendCoinBatchParams) ([]uint, error) {
+	args := m.Called(ctx, params)
+	if ids, ok := args.Get(0).([]uint); ok {
+		return ids, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) FindTransferCycles(ctx context.Context, params model.FraudDetectionParams) ([]model.FraudFinding, error) {
+	args := m.Called(ctx, params)
+	if findings, ok := args.Get(0).([]model.FraudFinding); ok {
+		return findings, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) FindFreshFanIn(ctx context.Context, params model.FraudDetectionParams) ([]model.FraudFinding, error) {
+	args := m.Called(ctx, params)
+	if findings, ok := args.Get(0).([]model.FraudFinding); ok {
+		return findings, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) FindVelocitySpikes(ctx context.Context, params model.FraudDetectionParams) ([]model.FraudFinding, error) {
+	args := m.Called(ctx, params)
+	if findings, ok := args.Get(0).([]model.FraudFinding); ok {
+		return findings, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) SaveFraudAlerts(ctx context.Context, params model.SaveFraudAlertsParams) (*model.FraudDetectionResult, error) {
+	args := m.Called(ctx, params)
+	if result, ok := args.Get(0).(*model.FraudDetectionResult); ok {
+		return result, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) GetFraudAlerts(ctx context.Context, params model.GetFraudAlertsParams) ([]model.FraudAlert, error) {
+	args := m.Called(ctx, params)
+	if alerts, ok := args.Get(0).([]model.FraudAlert); ok {
+		return alerts, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) ReviewFraudAlert(ctx context.Context, params model.ReviewFraudAlertParams) (*model.FraudAlert, error) {
+	args := m.Called(ctx, params)
+	if alert, ok := args.Get(0).(*model.FraudAlert); ok {
+		return alert, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) GetUserRole(ctx context.Context, userID uint) (string, error) {
+	args := m.Called(ctx, userID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockMerchRepository) GetUserAccess(ctx context.Context, userID, sessionID uint) (*model.UserAccess, error) {
+	args := m.Called(ctx, userID, sessionID)
+	if access, ok := args.Get(0).(*model.UserAccess); ok {
+		return access, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) SetUserStatus(ctx context.Context, params model.SetUserStatusParams) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
+}
+
+func (m *MockMerchRepository) GetUserStatusHistory(ctx context.Context, userID uint) ([]model.UserStatusChange, error) {
+	args := m.Called(ctx, userID)
+	if history, ok := args.Get(0).([]model.UserStatusChange); ok {
+		return history, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) GetUser(ctx context.Context, userID uint) (*model.User, error) {
 	args := m.Called(ctx, userID)
+	if user, ok := args.Get(0).(*model.User); ok {
+		return user, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) ExportUserData(ctx context.Context, userID uint) (*model.UserExport, error) {
+	args := m.Called(ctx, userID)
+	if export, ok := args.Get(0).(*model.UserExport); ok {
+		return export, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) DeleteUser(ctx context.Context, userID uint) ([]uint, error) {
+	args := m.Called(ctx, userID)
+	if ids, ok := args.Get(0).([]uint); ok {
+		return ids, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) SetPassword(ctx context.Context, params model.SetPasswordParams) (int, error) {
+	args := m.Called(ctx, params)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockMerchRepository) CreatePasswordReset(ctx context.Context, params model.CreatePasswordResetParams) (*model.PasswordReset, error) {
+	args := m.Called(ctx, params)
+	if reset, ok := args.Get(0).(*model.PasswordReset); ok {
+		return reset, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) ResetPassword(ctx context.Context, params model.ResetPasswordParams) (uint, error) {
+	args := m.Called(ctx, params)
 	return uint(args.Int(0)), args.Error(1)
 }
 
-func (m *MockMerchRepository) SendCoin(ctx context.Context, params model.SendCoinParams) error {
+func (m *MockMerchRepository) CreateServiceAccount(ctx context.Context, params model.CreateServiceAccountParams) (*model.ServiceAccount, error) {
+	args := m.Called(ctx, params)
+	if account, ok := args.Get(0).(*model.ServiceAccount); ok {
+		return account, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) GetServiceAccounts(ctx context.Context) ([]model.ServiceAccount, error) {
+	args := m.Called(ctx)
+	if accounts, ok := args.Get(0).([]model.ServiceAccount); ok {
+		return accounts, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) CreateAPIKey(ctx context.Context, params model.CreateAPIKeyParams) (*model.APIKey, error) {
+	args := m.Called(ctx, params)
+	if key, ok := args.Get(0).(*model.APIKey); ok {
+		return key, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) GetAPIKeys(ctx context.Context, userID uint) ([]model.APIKey, error) {
+	args := m.Called(ctx, userID)
+	if keys, ok := args.Get(0).([]model.APIKey); ok {
+		return keys, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) RevokeAPIKey(ctx context.Context, id uint) (*model.APIKey, error) {
+	args := m.Called(ctx, id)
+	if key, ok := args.Get(0).(*model.APIKey); ok {
+		return key, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) AuthAPIKey(ctx context.Context, keyHash string) (*model.APIKeyAuth, error) {
+	args := m.Called(ctx, keyHash)
+	if auth, ok := args.Get(0).(*model.APIKeyAuth); ok {
+		return auth, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) CreateOIDCState(ctx context.Context, params model.CreateOIDCStateParams) error {
 	args := m.Called(ctx, params)
 	return args.Error(0)
 }
 
-func (m *MockMerchRepository) BuyItem(ctx context.Context, params model.BuyItemParams) error {
+func (m *MockMerchRepository) ConsumeOIDCState(ctx context.Context, stateHash string) (*model.OIDCState, error) {
+	args := m.Called(ctx, stateHash)
+	if state, ok := args.Get(0).(*model.OIDCState); ok {
+		return state, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) LoginOIDCUser(ctx context.Context, params model.OIDCLoginParams) (*model.User, error) {
+	args := m.Called(ctx, params)
+	if user, ok := args.Get(0).(*model.User); ok {
+		return user, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) CreateTOTP(ctx context.Context, params model.CreateTOTPParams) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
+}
+
+func (m *MockMerchRepository) GetTOTP(ctx context.Context, userID uint) (*model.TOTP, error) {
+	args := m.Called(ctx, userID)
+	if t, ok := args.Get(0).(*model.TOTP); ok {
+		return t, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) ConfirmTOTP(ctx context.Context, params model.ConfirmTOTPParams) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
+}
+
+func (m *MockMerchRepository) UseTOTPStep(ctx context.Context, userID uint, step int64) error {
+	args := m.Called(ctx, userID, step)
+	return args.Error(0)
+}
+
+func (m *MockMerchRepository) UseRecoveryCode(ctx context.Context, userID uint, codeHash string) error {
+	args := m.Called(ctx, userID, codeHash)
+	return args.Error(0)
+}
+
+func (m *MockMerchRepository) RecordTOTPFailure(ctx context.Context, params model.TOTPFailureParams) error {
 	args := m.Called(ctx, params)
 	return args.Error(0)
 }
+
+func (m *MockMerchRepository) DeleteTOTP(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockMerchRepository) CreateSession(ctx context.Context, params model.CreateSessionParams) (*model.Session, error) {
+	args := m.Called(ctx, params)
+	if session, ok := args.Get(0).(*model.Session); ok {
+		return session, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) GetSessions(ctx context.Context, userID uint) ([]model.Session, error) {
+	args := m.Called(ctx, userID)
+	if sessions, ok := args.Get(0).([]model.Session); ok {
+		return sessions, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) RevokeSession(ctx context.Context, params model.SessionIDParams) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
+}
+
+func (m *MockMerchRepository) AddCartItem(ctx context.Context, params model.CartItemParams) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
+}
+
+func (m *MockMerchRepository) UpdateCartItem(ctx context.Context, params model.CartItemParams) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
+}
+
+func (m *MockMerchRepository) RemoveCartItem(ctx context.Context, userID uint, item, variant string) error {
+	args := m.Called(ctx, userID, item, variant)
+	return args.Error(0)
+}
+
+func (m *MockMerchRepository) GetCart(ctx context.Context, userID uint) (*model.Cart, error) {
+	args := m.Called(ctx, userID)
+	if cart, ok := args.Get(0).(*model.Cart); ok {
+		return cart, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) Checkout(ctx context.Context, params model.CheckoutParams) (*model.Order, error) {
+	args := m.Called(ctx, params)
+	if order, ok := args.Get(0).(*model.Order); ok {
+		return order, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) PurchaseItem(ctx context.Context, params model.PurchaseItemParams) (*model.Order, error) {
+	args := m.Called(ctx, params)
+	if order, ok := args.Get(0).(*model.Order); ok {
+		return order, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) GetCatalog(ctx context.Context) ([]model.CatalogItem, error) {
+	args := m.Called(ctx)
+	if items, ok := args.Get(0).([]model.CatalogItem); ok {
+		return items, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) SetItemRules(ctx context.Context, params model.SetItemRulesParams) (*model.CatalogItem, error) {
+	args := m.Called(ctx, params)
+	if item, ok := args.Get(0).(*model.CatalogItem); ok {
+		return item, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) SetItemVariant(ctx context.Context, params model.SetItemVariantParams) (*model.ItemVariant, error) {
+	args := m.Called(ctx, params)
+	if variant, ok := args.Get(0).(*model.ItemVariant); ok {
+		return variant, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) GetOrders(ctx context.Context, params model.GetOrdersParams) ([]model.Order, error) {
+	args := m.Called(ctx, params)
+	if orders, ok := args.Get(0).([]model.Order); ok {
+		return orders, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) SetOrderStatus(ctx context.Context, params model.SetOrderStatusParams) (*model.Order, error) {
+	args := m.Called(ctx, params)
+	if order, ok := args.Get(0).(*model.Order); ok {
+		return order, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) GetOrderStatusHistory(ctx context.Context, orderID uint) ([]model.OrderStatusChange, error) {
+	args := m.Called(ctx, orderID)
+	if history, ok := args.Get(0).([]model.OrderStatusChange); ok {
+		return history, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) CreateReturn(ctx context.Context, params model.CreateReturnParams) (*model.ReturnRequest, error) {
+	args := m.Called(ctx, params)
+	if ret, ok := args.Get(0).(*model.ReturnRequest); ok {
+		return ret, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) GetReturns(ctx context.Context, params model.GetReturnsParams) ([]model.ReturnRequest, error) {
+	args := m.Called(ctx, params)
+	if returns, ok := args.Get(0).([]model.ReturnRequest); ok {
+		return returns, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) ReviewReturn(ctx context.Context, params model.ReviewReturnParams) (*model.ReturnRequest, error) {
+	args := m.Called(ctx, params)
+	if ret, ok := args.Get(0).(*model.ReturnRequest); ok {
+		return ret, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) GiftItem(ctx context.Context, params model.GiftItemParams) (*model.SentGift, error) {
+	args := m.Called(ctx, params)
+	if ret, ok := args.Get(0).(*model.SentGift); ok {
+		return ret, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) BuyGift(ctx context.Context, params model.GiftItemParams) (*model.Order, error) {
+	args := m.Called(ctx, params)
+	if ret, ok := args.Get(0).(*model.Order); ok {
+		return ret, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMerchRepository) GetPaymentRequest(ctx context.Context, id uint) (*model.PaymentRequest, error) {
+	args := m.Called(ctx, id)
+	if ret, ok := args.Get(0).(*model.PaymentRequest); ok {
+		return ret, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
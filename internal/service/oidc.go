@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/0x0FACED/merch-shop/internal/oidc"
+	"go.uber.org/zap"
+)
+
+// identityProvider - внешний IdP для входа через SSO, см. oidc.Client
+type identityProvider interface {
+	AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error)
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*model.OIDCIdentity, error)
+}
+
+const (
+	// oidcSecretBytes - длина state, nonce и PKCE verifier. В hex verifier
+	// получается 64 символа, это в пределах 43-128 из RFC 7636
+	oidcSecretBytes = 32
+
+	// maxOIDCUsernameLength - до стольких символов обрезается имя, придуманное из email
+	maxOIDCUsernameLength = 64
+)
+
+// StartOIDCLogin начинает вход через SSO: сохраняет state, nonce и PKCE verifier
+// и возвращает ссылку на IdP, куда нужно отправить юзера
+func (s *MerchService) StartOIDCLogin(ctx context.Context) (string, error) {
+	s.logger.Info("StartOIDCLogin() request")
+
+	var secrets [3]string
+	for i := range secrets {
+		secret, err := randomHex(oidcSecretBytes)
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", ErrUnknown, err)
+		}
+		secrets[i] = secret
+	}
+	state, nonce, verifier := secrets[0], secrets[1], secrets[2]
+
+	authURL, err := s.idp.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		s.logger.Error("StartOIDCLogin() -> AuthCodeURL() request | error", zap.Error(err))
+		return "", mapOIDCError(err)
+	}
+
+	params := model.CreateOIDCStateParams{
+		StateHash:    hashSecret(state),
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		TTL:          s.auth.OIDCStateTTL,
+	}
+
+	if err := s.repo.CreateOIDCState(ctx, params); err != nil {
+		s.logger.Error("StartOIDCLogin() -> CreateOIDCState() request | error", zap.Error(err))
+		return "", MapDBErrorToServiceError(err)
+	}
+
+	s.logger.Info("StartOIDCLogin() response")
+
+	return authURL, nil
+}
+
+// FinishOIDCLogin завершает вход по коду и state из редиректа IdP. Юзер ищется по
+// issuer + subject, при первом входе создается с кошельком, как при входе по паролю
+func (s *MerchService) FinishOIDCLogin(ctx context.Context, params model.FinishOIDCLoginParams) (*model.User, error) {
+	s.logger.Info("FinishOIDCLogin() request")
+
+	// state одноразовый, забираем его и при ошибке от IdP
+	state, err := s.repo.ConsumeOIDCState(ctx, hashSecret(params.State))
+	if err != nil {
+		s.logger.Error("FinishOIDCLogin() -> ConsumeOIDCState() request | error", zap.Error(err))
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	if params.Error != "" {
+		s.logger.Error("FinishOIDCLogin() -> IdP error", zap.String("error", params.Error))
+		return nil, fmt.Errorf("%w: %s", ErrOIDCLoginFailed, params.Error)
+	}
+
+	identity, err := s.idp.Exchange(ctx, params.Code, state.CodeVerifier, state.Nonce)
+	if err != nil {
+		s.logger.Error("FinishOIDCLogin() -> Exchange() request | error", zap.Error(err))
+		return nil, mapOIDCError(err)
+	}
+
+	loginParams := model.OIDCLoginParams{
+		Identity: *identity,
+		Username: oidcUsername(identity.Email),
+	}
+
+	user, err := s.repo.LoginOIDCUser(ctx, loginParams)
+	if err != nil {
+		s.logger.Error("FinishOIDCLogin() -> LoginOIDCUser() request | error",
+			zap.String("issuer", identity.Issuer),
+			zap.String("subject", identity.Subject),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	if !model.CanLogin(user.Status) {
+		s.logger.Error("FinishOIDCLogin() -> CanLogin() | error",
+			zap.Uint("user_id", user.ID),
+			zap.String("status", user.Status),
+		)
+		return nil, ErrAccountSuspended
+	}
+
+	s.logger.Info("FinishOIDCLogin() response", zap.Uint("user_id", user.ID))
+
+	return user, nil
+}
+
+// oidcUsername придумывает имя новому юзеру из части email до @. Остаются только
+// латинские буквы и цифры, как требует валидация логина. Если ничего не осталось - user
+func oidcUsername(email string) string {
+	local, _, _ := strings.Cut(email, "@")
+
+	var b strings.Builder
+	for _, r := range local {
+		if b.Len() == maxOIDCUsernameLength {
+			break
+		}
+		if r < 0x80 && (r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+
+	if b.Len() == 0 {
+		return "user"
+	}
+	return b.String()
+}
+
+func mapOIDCError(err error) error {
+	if errors.Is(err, oidc.ErrDisabled) {
+		return ErrOIDCDisabled
+	}
+	return fmt.Errorf("%w: %w", ErrOIDCLoginFailed, err)
+}
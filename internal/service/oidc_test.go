@@ -0,0 +1,132 @@
+package service_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/internal/database"
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/0x0FACED/merch-shop/internal/service"
+	"github.com/0x0FACED/merch-shop/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeIDP запоминает, с чем начали вход, и пускает только с теми же nonce и verifier
+type fakeIDP struct {
+	state, nonce, verifier string
+
+	identity model.OIDCIdentity
+	err      error
+}
+
+func (f *fakeIDP) AuthCodeURL(_ context.Context, state, nonce, codeVerifier string) (string, error) {
+	f.state, f.nonce, f.verifier = state, nonce, codeVerifier
+	return "https://idp.example.com/authorize?state=" + state, f.err
+}
+
+func (f *fakeIDP) Exchange(_ context.Context, code, codeVerifier, nonce string) (*model.OIDCIdentity, error) {
+	if code != "code" || codeVerifier != f.verifier || nonce != f.nonce {
+		return nil, errors.New("invalid_grant")
+	}
+	return &f.identity, nil
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestOIDCLogin_Success(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	idp := &fakeIDP{identity: model.OIDCIdentity{Issuer: "https://idp.example.com", Subject: "42", Email: "alice.smith@example.com"}}
+	deps := testDeps()
+	deps.IdP = idp
+	userService := service.NewUserService(mockRepo, testLogger(), deps)
+
+	var saved model.CreateOIDCStateParams
+	mockRepo.On("CreateOIDCState", mock.Anything, mock.MatchedBy(func(p model.CreateOIDCStateParams) bool {
+		saved = p
+		return true
+	})).Return(nil)
+
+	authURL, err := userService.StartOIDCLogin(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, authURL, idp.state)
+
+	// state хранится только хэшем, verifier и nonce - те же, что ушли в IdP
+	assert.Equal(t, sha256Hex(idp.state), saved.StateHash)
+	assert.Equal(t, idp.verifier, saved.CodeVerifier)
+	assert.Equal(t, idp.nonce, saved.Nonce)
+	assert.Equal(t, 10*time.Minute, saved.TTL)
+	assert.Len(t, saved.CodeVerifier, 64)
+
+	mockRepo.On("ConsumeOIDCState", mock.Anything, saved.StateHash).
+		Return(&model.OIDCState{CodeVerifier: saved.CodeVerifier, Nonce: saved.Nonce}, nil)
+	mockRepo.On("LoginOIDCUser", mock.Anything, model.OIDCLoginParams{Identity: idp.identity, Username: "alicesmith"}).
+		Return(&model.User{ID: 7, Username: "alicesmith", Status: model.UserStatusActive}, nil)
+
+	user, err := userService.FinishOIDCLogin(context.Background(), model.FinishOIDCLoginParams{State: idp.state, Code: "code"})
+	require.NoError(t, err)
+	assert.Equal(t, uint(7), user.ID)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestFinishOIDCLogin_InvalidState(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	deps := testDeps()
+	deps.IdP = &fakeIDP{}
+	userService := service.NewUserService(mockRepo, testLogger(), deps)
+
+	mockRepo.On("ConsumeOIDCState", mock.Anything, sha256Hex("state")).Return(nil, database.ErrInvalidOIDCState)
+
+	_, err := userService.FinishOIDCLogin(context.Background(), model.FinishOIDCLoginParams{State: "state", Code: "code"})
+	assert.ErrorIs(t, err, service.ErrInvalidOIDCState)
+	mockRepo.AssertNotCalled(t, "LoginOIDCUser", mock.Anything, mock.Anything)
+}
+
+// Ошибка от IdP вместо кода: state все равно забирается, юзер не входит
+func TestFinishOIDCLogin_IdPError(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	deps := testDeps()
+	deps.IdP = &fakeIDP{}
+	userService := service.NewUserService(mockRepo, testLogger(), deps)
+
+	mockRepo.On("ConsumeOIDCState", mock.Anything, sha256Hex("state")).Return(&model.OIDCState{}, nil)
+
+	_, err := userService.FinishOIDCLogin(context.Background(), model.FinishOIDCLoginParams{State: "state", Error: "access_denied"})
+	assert.ErrorIs(t, err, service.ErrOIDCLoginFailed)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "LoginOIDCUser", mock.Anything, mock.Anything)
+}
+
+func TestFinishOIDCLogin_Suspended(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	idp := &fakeIDP{identity: model.OIDCIdentity{Issuer: "https://idp.example.com", Subject: "42", Email: "Иван@example.com"}}
+	deps := testDeps()
+	deps.IdP = idp
+	userService := service.NewUserService(mockRepo, testLogger(), deps)
+
+	mockRepo.On("ConsumeOIDCState", mock.Anything, sha256Hex("state")).Return(&model.OIDCState{}, nil)
+	// из кириллицы имя не собрать, остается user
+	mockRepo.On("LoginOIDCUser", mock.Anything, model.OIDCLoginParams{Identity: idp.identity, Username: "user"}).
+		Return(&model.User{ID: 7, Status: model.UserStatusSuspended}, nil)
+
+	_, err := userService.FinishOIDCLogin(context.Background(), model.FinishOIDCLoginParams{State: "state", Code: "code"})
+	assert.ErrorIs(t, err, service.ErrAccountSuspended)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestStartOIDCLogin_Disabled(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	_, err := userService.StartOIDCLogin(context.Background())
+	assert.ErrorIs(t, err, service.ErrOIDCDisabled)
+	mockRepo.AssertNotCalled(t, "CreateOIDCState", mock.Anything, mock.Anything)
+}
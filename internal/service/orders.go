@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"go.uber.org/zap"
+)
+
+const maxOrdersLimit = 100
+
+var orderStatuses = []string{
+	model.OrderStatusPlaced,
+	model.OrderStatusReadyForPickup,
+	model.OrderStatusDelivered,
+	model.OrderStatusCancelled,
+}
+
+// PurchaseItem покупает Quantity штук предмета одним заказом, списывая цену за все штуки разом.
+// Лимит на количество - тот же, что для строки корзины. Старая покупка (BuyItem) - такой же заказ из одной штуки
+func (s *MerchService) PurchaseItem(ctx context.Context, params model.PurchaseItemParams) (*model.Order, error) {
+	s.logger.Info("PurchaseItem() request", zap.Any("params", params))
+
+	params.MaxQuantity = s.shop.MaxItemQuantity
+
+	order, err := s.repo.PurchaseItem(ctx, params)
+	if err != nil {
+		s.logger.Error("PurchaseItem() -> PurchaseItem() request | error",
+			zap.Any("params", params),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	s.invalidateUserInfo(ctx, params.UserID)
+
+	s.logger.Info("PurchaseItem() response", zap.Any("params", params), zap.Any("order", order))
+
+	return order, nil
+}
+
+// GetOrders возвращает заказы со статусами, новые первыми. UserID 0 - заказы всех юзеров
+func (s *MerchService) GetOrders(ctx context.Context, params model.GetOrdersParams) ([]model.Order, error) {
+	s.logger.Info("GetOrders() request", zap.Any("params", params))
+
+	if params.Limit <= 0 || params.Limit > maxOrdersLimit {
+		params.Limit = maxOrdersLimit
+	}
+
+	orders, err := s.repo.GetOrders(ctx, params)
+	if err != nil {
+		s.logger.Error("GetOrders() -> GetOrders() request | error",
+			zap.Any("params", params),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	return orders, nil
+}
+
+// SetOrderStatus двигает заказ по статусам от имени админа. Отмена возвращает юзеру монеты
+// и забирает предметы из инвентаря, поэтому причина для нее обязательна
+func (s *MerchService) SetOrderStatus(ctx context.Context, params model.SetOrderStatusParams) (*model.Order, error) {
+	s.logger.Info("SetOrderStatus() request", zap.Any("params", params))
+
+	if !slices.Contains(orderStatuses, params.Status) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidOrderStatus, params.Status)
+	}
+
+	if params.Status == model.OrderStatusCancelled && strings.TrimSpace(params.Reason) == "" {
+		return nil, fmt.Errorf("%w: reason is required to cancel", ErrInvalidOrderStatus)
+	}
+
+	order, err := s.repo.SetOrderStatus(ctx, params)
+	if err != nil {
+		s.logger.Error("SetOrderStatus() -> SetOrderStatus() request | error",
+			zap.Any("params", params),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	if order.Status == model.OrderStatusCancelled {
+		// у подарка предметы забираются из инвентаря получателя
+		ids := []uint{order.UserID}
+		if order.RecipientID != 0 {
+			ids = append(ids, order.RecipientID)
+		}
+		s.invalidateUserInfo(ctx, ids...)
+	}
+
+	s.logger.Info("SetOrderStatus() response", zap.Any("params", params), zap.Any("order", order))
+
+	return order, nil
+}
+
+func (s *MerchService) GetOrderStatusHistory(ctx context.Context, orderID uint) ([]model.OrderStatusChange, error) {
+	s.logger.Info("GetOrderStatusHistory() request", zap.Uint("order_id", orderID))
+
+	history, err := s.repo.GetOrderStatusHistory(ctx, orderID)
+	if err != nil {
+		s.logger.Error("GetOrderStatusHistory() -> GetOrderStatusHistory() request | error",
+			zap.Uint("order_id", orderID),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	return history, nil
+}
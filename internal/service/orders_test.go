@@ -0,0 +1,121 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/internal/cache"
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/0x0FACED/merch-shop/internal/service"
+	"github.com/0x0FACED/merch-shop/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Лимит на количество сервис берет из конфига, как для строки корзины
+func TestPurchaseItem_Success(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	order := &model.Order{ID: 3, Total: 30, Lines: []model.OrderLine{{Item: "socks", Quantity: 3, Price: 10}}}
+	mockRepo.On("PurchaseItem", mock.Anything, model.PurchaseItemParams{
+		UserID:      1,
+		Item:        "socks",
+		Quantity:    3,
+		MaxQuantity: 100,
+	}).Return(order, nil)
+
+	got, err := userService.PurchaseItem(context.Background(), model.PurchaseItemParams{UserID: 1, Item: "socks", Quantity: 3})
+	require.NoError(t, err)
+	assert.Equal(t, order, got)
+	mockRepo.AssertExpectations(t)
+}
+
+// Лимит выдачи ограничен сверху, как у алертов антифрода
+func TestGetOrders_Limit(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	orders := []model.Order{{ID: 1, Status: model.OrderStatusPlaced}}
+	mockRepo.On("GetOrders", mock.Anything, model.GetOrdersParams{UserID: 1, Limit: 100}).Return(orders, nil)
+
+	got, err := userService.GetOrders(context.Background(), model.GetOrdersParams{UserID: 1, Limit: 1000})
+	require.NoError(t, err)
+	assert.Equal(t, orders, got)
+	mockRepo.AssertExpectations(t)
+}
+
+// Неизвестный статус и отмена без причины отсекаются до базы
+func TestSetOrderStatus_Validation(t *testing.T) {
+	tests := []struct {
+		name   string
+		params model.SetOrderStatusParams
+	}{
+		{name: "unknown status", params: model.SetOrderStatusParams{OrderID: 1, AdminID: 2, Status: "lost"}},
+		{name: "cancel without reason", params: model.SetOrderStatusParams{OrderID: 1, AdminID: 2, Status: model.OrderStatusCancelled, Reason: "  "}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(mocks.MockMerchRepository)
+			userService := newTestService(mockRepo)
+
+			_, err := userService.SetOrderStatus(context.Background(), tt.params)
+			assert.ErrorIs(t, err, service.ErrInvalidOrderStatus)
+			mockRepo.AssertNotCalled(t, "SetOrderStatus", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestSetOrderStatus_Success(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	params := model.SetOrderStatusParams{OrderID: 1, AdminID: 2, Status: model.OrderStatusReadyForPickup}
+	order := &model.Order{ID: 1, UserID: 3, Status: model.OrderStatusReadyForPickup}
+	mockRepo.On("SetOrderStatus", mock.Anything, params).Return(order, nil)
+
+	got, err := userService.SetOrderStatus(context.Background(), params)
+	require.NoError(t, err)
+	assert.Equal(t, order, got)
+	mockRepo.AssertExpectations(t)
+}
+
+// Отмена подарка меняет инвентарь получателя, так что его закэшированная информация тоже сбрасывается
+func TestSetOrderStatus_CancelGiftInvalidatesRecipient(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	deps := testDeps()
+	deps.Cache = cache.NewLRU(10, time.Minute)
+	userService := service.NewUserService(mockRepo, testLogger(), deps)
+
+	buyer := model.GetUserInfoParams{ID: 1}
+	recipient := model.GetUserInfoParams{ID: 3}
+	params := model.SetOrderStatusParams{OrderID: 5, AdminID: 2, Status: model.OrderStatusCancelled, Reason: "out of stock"}
+	order := &model.Order{ID: 5, UserID: 1, RecipientID: 3, Status: model.OrderStatusCancelled}
+
+	mockRepo.On("GetUserInfo", mock.Anything, buyer).Return(&model.UserInfo{Coins: 900}, nil).Once()
+	mockRepo.On("GetUserInfo", mock.Anything, buyer).Return(&model.UserInfo{Coins: 1000}, nil).Once()
+	mockRepo.On("GetUserInfo", mock.Anything, recipient).Return(&model.UserInfo{Inventory: []model.Item{{Type: "cup", Quantity: 1}}}, nil).Once()
+	mockRepo.On("GetUserInfo", mock.Anything, recipient).Return(&model.UserInfo{}, nil).Once()
+	mockRepo.On("SetOrderStatus", mock.Anything, params).Return(order, nil)
+
+	for _, p := range []model.GetUserInfoParams{buyer, recipient} {
+		_, err := userService.GetUserInfo(context.Background(), p)
+		require.NoError(t, err)
+	}
+
+	_, err := userService.SetOrderStatus(context.Background(), params)
+	require.NoError(t, err)
+
+	info, err := userService.GetUserInfo(context.Background(), buyer)
+	require.NoError(t, err)
+	assert.Equal(t, uint(1000), info.Coins)
+
+	info, err = userService.GetUserInfo(context.Background(), recipient)
+	require.NoError(t, err)
+	assert.Empty(t, info.Inventory)
+
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/0x0FACED/merch-shop/internal/outbox"
+	"go.uber.org/zap"
+)
+
+var _ mailer = (*outbox.File)(nil)
+
+// mailer отправляет письма юзерам. Пока единственная реализация - outbox.File
+type mailer interface {
+	Send(ctx context.Context, msg model.Message) error
+}
+
+// resetTokenBytes - длина токена сброса пароля, в hex он в два раза длиннее
+const resetTokenBytes = 32
+
+// ChangePassword проверяет старый пароль и ставит новый. Все выданные токены отзываются,
+// поэтому возвращается юзер с новой версией токенов, чтобы сразу выдать ему свежий
+func (s *MerchService) ChangePassword(ctx context.Context, params model.ChangePasswordParams) (*model.User, error) {
+	s.logger.Info("ChangePassword() request", zap.Uint("user_id", params.UserID))
+
+	user, err := s.repo.GetUser(ctx, params.UserID)
+	if err != nil {
+		s.logger.Error("ChangePassword() -> GetUser() request | error",
+			zap.Uint("user_id", params.UserID),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	if err := compareHashAndPassword(user.Password, params.OldPassword); err != nil {
+		s.logger.Error("ChangePassword() -> compareHashAndPassword() request | error",
+			zap.Uint("user_id", params.UserID),
+			zap.Error(err),
+		)
+		return nil, ErrInvalidPassword
+	}
+
+	hash, err := calcHash(params.NewPassword)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnknown, err)
+	}
+
+	version, err := s.repo.SetPassword(ctx, model.SetPasswordParams{UserID: params.UserID, PasswordHash: hash})
+	if err != nil {
+		s.logger.Error("ChangePassword() -> SetPassword() request | error",
+			zap.Uint("user_id", params.UserID),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	user.Password = hash
+	user.TokenVersion = version
+
+	s.logger.Info("ChangePassword() response", zap.Uint("user_id", params.UserID))
+
+	return user, nil
+}
+
+// CreatePasswordReset выдает юзеру одноразовый токен сброса пароля от имени админа.
+// Токен уходит только письмом, в базе лежит его хэш, в ответ админу он не попадает
+func (s *MerchService) CreatePasswordReset(ctx context.Context, params model.CreatePasswordResetParams) (*model.PasswordReset, error) {
+	s.logger.Info("CreatePasswordReset() request",
+		zap.Uint("user_id", params.UserID),
+		zap.Uint("admin_id", params.AdminID),
+	)
+
+	token, err := randomHex(resetTokenBytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnknown, err)
+	}
+
+	params.TokenHash = hashSecret(token)
+	params.TTL = s.auth.PasswordResetTTL
+
+	reset, err := s.repo.CreatePasswordReset(ctx, params)
+	if err != nil {
+		s.logger.Error("CreatePasswordReset() -> CreatePasswordReset() request | error",
+			zap.Uint("user_id", params.UserID),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	msg := model.Message{
+		To:      reset.Username,
+		Subject: "Password reset",
+		Body: fmt.Sprintf(
+			"Your password reset token: %s\nIt expires at %s. Send it with a new password to POST /api/auth/reset.",
+			token, reset.ExpiresAt.UTC().Format(time.RFC3339),
+		),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		s.logger.Error("CreatePasswordReset() -> Send() request | error",
+			zap.Uint("user_id", params.UserID),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("%w: %w", ErrUnknown, err)
+	}
+
+	s.logger.Info("CreatePasswordReset() response", zap.Uint("user_id", params.UserID))
+
+	return reset, nil
+}
+
+// ResetPassword ставит новый пароль по токену сброса и отзывает все выданные токены юзера
+func (s *MerchService) ResetPassword(ctx context.Context, params model.ResetPasswordParams) error {
+	s.logger.Info("ResetPassword() request")
+
+	hash, err := calcHash(params.NewPassword)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUnknown, err)
+	}
+
+	params.TokenHash = hashSecret(params.Token)
+	params.PasswordHash = hash
+
+	userID, err := s.repo.ResetPassword(ctx, params)
+	if err != nil {
+		s.logger.Error("ResetPassword() -> ResetPassword() request | error", zap.Error(err))
+		return MapDBErrorToServiceError(err)
+	}
+
+	s.logger.Info("ResetPassword() response", zap.Uint("user_id", userID))
+
+	return nil
+}
+
+// randomHex возвращает n случайных байт в hex
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashSecret хэширует токены сброса и API ключи. Они случайные и длинные,
+// поэтому достаточно sha256 без соли, а поиск по хэшу идет через индекс
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,127 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/internal/database"
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/0x0FACED/merch-shop/internal/service"
+	"github.com/0x0FACED/merch-shop/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// mockMailer запоминает отправленные письма
+type mockMailer struct {
+	messages []model.Message
+	err      error
+}
+
+func (m *mockMailer) Send(_ context.Context, msg model.Message) error {
+	m.messages = append(m.messages, msg)
+	return m.err
+}
+
+// После смены пароля возвращается новая версия токенов
+func TestChangePassword_Success(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	mockRepo.On("GetUser", mock.Anything, uint(1)).Return(&model.User{ID: 1, Password: testPasswordHash}, nil)
+	mockRepo.On("SetPassword", mock.Anything, mock.MatchedBy(func(p model.SetPasswordParams) bool {
+		return p.UserID == 1 && p.PasswordHash != "" && p.PasswordHash != testPasswordHash
+	})).Return(1, nil)
+
+	user, err := userService.ChangePassword(context.Background(), model.ChangePasswordParams{
+		UserID:      1,
+		OldPassword: "test",
+		NewPassword: "newpassword",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, user.TokenVersion)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestChangePassword_InvalidPassword(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	mockRepo.On("GetUser", mock.Anything, uint(1)).Return(&model.User{ID: 1, Password: testPasswordHash}, nil)
+
+	_, err := userService.ChangePassword(context.Background(), model.ChangePasswordParams{
+		UserID:      1,
+		OldPassword: "wrong",
+		NewPassword: "newpassword",
+	})
+	assert.ErrorIs(t, err, service.ErrInvalidPassword)
+	mockRepo.AssertNotCalled(t, "SetPassword", mock.Anything, mock.Anything)
+}
+
+// Токен уходит письмом, а в базу попадает только его хэш
+func TestCreatePasswordReset_Success(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	mailer := &mockMailer{}
+	deps := testDeps()
+	deps.Mailer = mailer
+	userService := service.NewUserService(mockRepo, testLogger(), deps)
+
+	var saved model.CreatePasswordResetParams
+	expiresAt := time.Now().Add(time.Hour)
+	mockRepo.On("CreatePasswordReset", mock.Anything, mock.MatchedBy(func(p model.CreatePasswordResetParams) bool {
+		saved = p
+		return p.UserID == 2 && p.AdminID == 1 && p.TTL == time.Hour
+	})).Return(&model.PasswordReset{UserID: 2, Username: "user2", ExpiresAt: expiresAt}, nil)
+
+	reset, err := userService.CreatePasswordReset(context.Background(), model.CreatePasswordResetParams{UserID: 2, AdminID: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), reset.UserID)
+
+	if assert.Len(t, mailer.messages, 1) {
+		assert.Equal(t, "user2", mailer.messages[0].To)
+
+		token := regexp.MustCompile(`[0-9a-f]{64}`).FindString(mailer.messages[0].Body)
+		assert.NotEmpty(t, token)
+		assert.NotEqual(t, token, saved.TokenHash)
+		assert.NotContains(t, mailer.messages[0].Body, saved.TokenHash)
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreatePasswordReset_MailerError(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	mailer := &mockMailer{err: errors.New("disk full")}
+	deps := testDeps()
+	deps.Mailer = mailer
+	userService := service.NewUserService(mockRepo, testLogger(), deps)
+
+	mockRepo.On("CreatePasswordReset", mock.Anything, mock.Anything).
+		Return(&model.PasswordReset{UserID: 2, Username: "user2"}, nil)
+
+	_, err := userService.CreatePasswordReset(context.Background(), model.CreatePasswordResetParams{UserID: 2, AdminID: 1})
+	assert.ErrorIs(t, err, service.ErrUnknown)
+}
+
+// Токен ищется по хэшу
+func TestResetPassword(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	// sha256("token")
+	const tokenHash = "3c469e9d6c5875d37a43f353d4f88e61fcf812c66eee3457465a40b0da4153e0"
+
+	mockRepo.On("ResetPassword", mock.Anything, mock.MatchedBy(func(p model.ResetPasswordParams) bool {
+		return p.TokenHash == tokenHash && p.PasswordHash != ""
+	})).Return(2, nil).Once()
+	mockRepo.On("ResetPassword", mock.Anything, mock.Anything).Return(0, database.ErrInvalidResetToken)
+
+	err := userService.ResetPassword(context.Background(), model.ResetPasswordParams{Token: "token", NewPassword: "newpassword"})
+	assert.NoError(t, err)
+
+	err = userService.ResetPassword(context.Background(), model.ResetPasswordParams{Token: "token", NewPassword: "newpassword"})
+	assert.ErrorIs(t, err, service.ErrInvalidResetToken)
+	mockRepo.AssertExpectations(t)
+}
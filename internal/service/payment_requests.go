@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"go.uber.org/zap"
+)
+
+// CreatePaymentRequest создает запрос монет. Если срок жизни не передан,
+// используется TransferConfig.PaymentRequestTTL
+func (s *MerchService) CreatePaymentRequest(ctx context.Context, params model.CreatePaymentRequestParams) (*model.PaymentRequest, error) {
+	s.logger.Info("CreatePaymentRequest() request", zap.Any("params", params))
+
+	if params.TTL <= 0 {
+		params.TTL = s.transfer.PaymentRequestTTL
+	}
+
+	pr, err := s.repo.CreatePaymentRequest(ctx, params)
+	if err != nil {
+		s.logger.Error("CreatePaymentRequest() -> CreatePaymentRequest() request | error",
+			zap.Any("params", params),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	s.logger.Info("CreatePaymentRequest() response", zap.Any("params", params), zap.Any("payment_request", pr))
+
+	return pr, nil
+}
+
+func (s *MerchService) GetPaymentRequests(ctx context.Context, params model.GetPaymentRequestsParams) ([]model.PaymentRequest, error) {
+	s.logger.Info("GetPaymentRequests() request", zap.Any("params", params))
+
+	requests, err := s.repo.GetPaymentRequests(ctx, params)
+	if err != nil {
+		s.logger.Error("GetPaymentRequests() -> GetPaymentRequests() request | error",
+			zap.Any("params", params),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	s.logger.Info("GetPaymentRequests() response", zap.Any("params", params), zap.Int("count", len(requests)))
+
+	return requests, nil
+}
+
+// AcceptPaymentRequest принимает запрос и переводит монеты автору запроса.
+// Ошибки перевода (нехватка монет и т.д.) и код 2FA для крупной суммы такие же, как у SendCoin
+func (s *MerchService) AcceptPaymentRequest(ctx context.Context, params model.ResolvePaymentRequestParams) (*model.PaymentRequest, error) {
+	s.logger.Info("AcceptPaymentRequest() request", zap.Any("params", params))
+
+	if s.auth.TOTPStepUpAmount > 0 {
+		pr, err := s.repo.GetPaymentRequest(ctx, params.ID)
+		if err != nil {
+			s.logger.Error("AcceptPaymentRequest() -> GetPaymentRequest() request | error", zap.Uint("id", params.ID), zap.Error(err))
+			return nil, MapDBErrorToServiceError(err)
+		}
+		// чужой запрос отклонит сама база, код у такого юзера не спрашиваем
+		if pr.ToUserID == params.UserID {
+			if err := s.checkStepUp(ctx, params.UserID, pr.Amount, params.OTP); err != nil {
+				s.logger.Error("AcceptPaymentRequest() -> checkStepUp() | error", zap.Uint("user_id", params.UserID), zap.Error(err))
+				return nil, err
+			}
+		}
+	}
+
+	params.Limits = s.transferLimits()
+
+	pr, err := s.repo.AcceptPaymentRequest(ctx, params)
+	if err != nil {
+		s.logger.Error("AcceptPaymentRequest() -> AcceptPaymentRequest() request | error",
+			zap.Any("params", params),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	s.invalidateUserInfo(ctx, pr.FromUserID, pr.ToUserID)
+
+	s.logger.Info("AcceptPaymentRequest() response", zap.Any("params", params), zap.Any("payment_request", pr))
+
+	return pr, nil
+}
+
+func (s *MerchService) DeclinePaymentRequest(ctx context.Context, params model.ResolvePaymentRequestParams) (*model.PaymentRequest, error) {
+	s.logger.Info("DeclinePaymentRequest() request", zap.Any("params", params))
+
+	pr, err := s.repo.DeclinePaymentRequest(ctx, params)
+	if err != nil {
+		s.logger.Error("DeclinePaymentRequest() -> DeclinePaymentRequest() request | error",
+			zap.Any("params", params),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	s.logger.Info("DeclinePaymentRequest() response", zap.Any("params", params), zap.Any("payment_request", pr))
+
+	return pr, nil
+}
+
+func (s *MerchService) CancelPaymentRequest(ctx context.Context, params model.ResolvePaymentRequestParams) (*model.PaymentRequest, error) {
+	s.logger.Info("CancelPaymentRequest() request", zap.Any("params", params))
+
+	pr, err := s.repo.CancelPaymentRequest(ctx, params)
+	if err != nil {
+		s.logger.Error("CancelPaymentRequest() -> CancelPaymentRequest() request | error",
+			zap.Any("params", params),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	s.logger.Info("CancelPaymentRequest() response", zap.Any("params", params), zap.Any("payment_request", pr))
+
+	return pr, nil
+}
@@ -0,0 +1,118 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/internal/cache"
+	"github.com/0x0FACED/merch-shop/internal/database"
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/0x0FACED/merch-shop/internal/service"
+	"github.com/0x0FACED/merch-shop/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Тест создания запроса монет без срока жизни: подставляется TTL из конфига
+func TestCreatePaymentRequest_DefaultExpiry(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	params := model.CreatePaymentRequestParams{FromUser: 1, ToUser: "user2", Amount: 50}
+	expected := params
+	expected.TTL = 24 * time.Hour
+
+	mockRepo.On("CreatePaymentRequest", mock.Anything, expected).Return(&model.PaymentRequest{ID: 1, Status: model.PaymentRequestPending}, nil)
+
+	pr, err := userService.CreatePaymentRequest(context.Background(), params)
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.PaymentRequestPending, pr.Status)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreatePaymentRequest_Self(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	params := model.CreatePaymentRequestParams{FromUser: 1, ToUser: "user1", Amount: 50}
+	mockRepo.On("CreatePaymentRequest", mock.Anything, mock.Anything).Return(nil, database.ErrSelfPaymentRequest)
+
+	_, err := userService.CreatePaymentRequest(context.Background(), params)
+
+	assert.ErrorIs(t, err, service.ErrSelfPaymentRequest)
+	mockRepo.AssertExpectations(t)
+}
+
+// Тест принятия запроса: после перевода кэш сбрасывается у обоих участников
+func TestAcceptPaymentRequest_InvalidatesCache(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	deps := testDeps()
+	deps.Cache = cache.NewLRU(10, time.Minute)
+	userService := service.NewUserService(mockRepo, testLogger(), deps)
+
+	infoParams := model.GetUserInfoParams{ID: 2}
+	params := model.ResolvePaymentRequestParams{ID: 1, UserID: 2}
+
+	mockRepo.On("GetUserInfo", mock.Anything, infoParams).Return(&model.UserInfo{Coins: 1000}, nil).Once()
+	mockRepo.On("AcceptPaymentRequest", mock.Anything, params).Return(&model.PaymentRequest{
+		ID:         1,
+		FromUserID: 1,
+		ToUserID:   2,
+		Amount:     50,
+		Status:     model.PaymentRequestAccepted,
+	}, nil)
+	mockRepo.On("GetUserInfo", mock.Anything, infoParams).Return(&model.UserInfo{Coins: 950}, nil).Once()
+
+	_, _ = userService.GetUserInfo(context.Background(), infoParams)
+
+	pr, err := userService.AcceptPaymentRequest(context.Background(), params)
+	assert.NoError(t, err)
+	assert.Equal(t, model.PaymentRequestAccepted, pr.Status)
+
+	info, err := userService.GetUserInfo(context.Background(), infoParams)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(950), info.Coins)
+	mockRepo.AssertExpectations(t)
+}
+
+// Тест принятия запроса при нехватке монет: ошибка такая же, как у SendCoin
+func TestAcceptPaymentRequest_InsufficientFunds(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	params := model.ResolvePaymentRequestParams{ID: 1, UserID: 2}
+	mockRepo.On("AcceptPaymentRequest", mock.Anything, params).Return(nil, database.ErrInsufficientFunds)
+
+	_, err := userService.AcceptPaymentRequest(context.Background(), params)
+
+	assert.ErrorIs(t, err, service.ErrInsufficientFunds)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestDeclinePaymentRequest_Expired(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	params := model.ResolvePaymentRequestParams{ID: 1, UserID: 2}
+	mockRepo.On("DeclinePaymentRequest", mock.Anything, params).Return(nil, database.ErrPaymentRequestExpired)
+
+	_, err := userService.DeclinePaymentRequest(context.Background(), params)
+
+	assert.ErrorIs(t, err, service.ErrPaymentRequestExpired)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCancelPaymentRequest_NotPending(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	params := model.ResolvePaymentRequestParams{ID: 1, UserID: 1}
+	mockRepo.On("CancelPaymentRequest", mock.Anything, params).Return(nil, database.ErrPaymentRequestNotPending)
+
+	_, err := userService.CancelPaymentRequest(context.Background(), params)
+
+	assert.ErrorIs(t, err, service.ErrPaymentRequestNotPending)
+	mockRepo.AssertExpectations(t)
+}
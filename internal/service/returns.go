@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"go.uber.org/zap"
+)
+
+const maxReturnsLimit = 100
+
+// CreateReturn просит вернуть штуки предмета из выданных заказов. Срок возврата берется из конфига
+func (s *MerchService) CreateReturn(ctx context.Context, params model.CreateReturnParams) (*model.ReturnRequest, error) {
+	s.logger.Info("CreateReturn() request", zap.Any("params", params))
+
+	params.Window = s.shop.ReturnWindow
+
+	ret, err := s.repo.CreateReturn(ctx, params)
+	if err != nil {
+		s.logger.Error("CreateReturn() -> CreateReturn() request | error",
+			zap.Any("params", params),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	s.logger.Info("CreateReturn() response", zap.Any("params", params), zap.Any("return", ret))
+
+	return ret, nil
+}
+
+// GetReturns возвращает возвраты, новые первыми. UserID 0 - возвраты всех юзеров
+func (s *MerchService) GetReturns(ctx context.Context, params model.GetReturnsParams) ([]model.ReturnRequest, error) {
+	s.logger.Info("GetReturns() request", zap.Any("params", params))
+
+	if params.Limit <= 0 || params.Limit > maxReturnsLimit {
+		params.Limit = maxReturnsLimit
+	}
+
+	returns, err := s.repo.GetReturns(ctx, params)
+	if err != nil {
+		s.logger.Error("GetReturns() -> GetReturns() request | error",
+			zap.Any("params", params),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	return returns, nil
+}
+
+// ReviewReturn одобряет или отклоняет возврат. При одобрении юзеру возвращаются монеты,
+// Refund меньше суммы по ценам покупки - частичный возврат
+func (s *MerchService) ReviewReturn(ctx context.Context, params model.ReviewReturnParams) (*model.ReturnRequest, error) {
+	s.logger.Info("ReviewReturn() request", zap.Any("params", params))
+
+	if params.Status != model.ReturnApproved && params.Status != model.ReturnRejected {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidReturnReview, params.Status)
+	}
+
+	if params.Refund != nil {
+		if params.Status != model.ReturnApproved {
+			return nil, fmt.Errorf("%w: refund is only for approval", ErrInvalidReturnReview)
+		}
+		if *params.Refund < 0 {
+			return nil, fmt.Errorf("%w: must not be negative", ErrInvalidRefund)
+		}
+	}
+
+	ret, err := s.repo.ReviewReturn(ctx, params)
+	if err != nil {
+		s.logger.Error("ReviewReturn() -> ReviewReturn() request | error",
+			zap.Any("params", params),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	if ret.Status == model.ReturnApproved {
+		s.invalidateUserInfo(ctx, ret.UserID)
+	}
+
+	s.logger.Info("ReviewReturn() response", zap.Any("params", params), zap.Any("return", ret))
+
+	return ret, nil
+}
@@ -0,0 +1,61 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/0x0FACED/merch-shop/internal/service"
+	"github.com/0x0FACED/merch-shop/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Срок возврата сервис берет из конфига
+func TestCreateReturn_Window(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	ret := &model.ReturnRequest{ID: 1, Item: "t-shirt", Quantity: 2, Amount: 160, Status: model.ReturnPending}
+	mockRepo.On("CreateReturn", mock.Anything, model.CreateReturnParams{
+		UserID:   1,
+		Item:     "t-shirt",
+		Quantity: 2,
+		Reason:   "wrong size",
+		Window:   14 * 24 * time.Hour,
+	}).Return(ret, nil)
+
+	got, err := userService.CreateReturn(context.Background(), model.CreateReturnParams{UserID: 1, Item: "t-shirt", Quantity: 2, Reason: "wrong size"})
+	require.NoError(t, err)
+	assert.Equal(t, ret, got)
+	mockRepo.AssertExpectations(t)
+}
+
+// Сумма возврата задается только при одобрении и не может быть отрицательной
+func TestReviewReturn_Validation(t *testing.T) {
+	refund := 10
+	negative := -1
+
+	tests := []struct {
+		name    string
+		params  model.ReviewReturnParams
+		wantErr error
+	}{
+		{name: "unknown status", params: model.ReviewReturnParams{ID: 1, AdminID: 2, Status: model.ReturnPending}, wantErr: service.ErrInvalidReturnReview},
+		{name: "refund on reject", params: model.ReviewReturnParams{ID: 1, AdminID: 2, Status: model.ReturnRejected, Refund: &refund}, wantErr: service.ErrInvalidReturnReview},
+		{name: "negative refund", params: model.ReviewReturnParams{ID: 1, AdminID: 2, Status: model.ReturnApproved, Refund: &negative}, wantErr: service.ErrInvalidRefund},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(mocks.MockMerchRepository)
+			userService := newTestService(mockRepo)
+
+			_, err := userService.ReviewReturn(context.Background(), tt.params)
+			assert.ErrorIs(t, err, tt.wantErr)
+			mockRepo.AssertNotCalled(t, "ReviewReturn", mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/internal/database"
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+// CreateScheduledTransfer создает разовый (RunAt) или регулярный (Schedule) перевод.
+// Cron выражение - стандартное, 5 полей, время в UTC. Код 2FA для крупной суммы
+// проверяется один раз здесь: запуски идут без юзера, а сумма каждого запуска не меняется
+func (s *MerchService) CreateScheduledTransfer(ctx context.Context, params model.CreateScheduledTransferParams) (*model.ScheduledTransfer, error) {
+	s.logger.Info("CreateScheduledTransfer() request", zap.Any("params", params))
+
+	if err := s.checkCategory(params.Category); err != nil {
+		s.logger.Error("CreateScheduledTransfer() -> checkCategory() | error", zap.Any("params", params), zap.Error(err))
+		return nil, err
+	}
+
+	if err := s.checkStepUp(ctx, params.FromUser, params.Amount, params.OTP); err != nil {
+		s.logger.Error("CreateScheduledTransfer() -> checkStepUp() | error", zap.Uint("from_user", params.FromUser), zap.Error(err))
+		return nil, err
+	}
+
+	now := time.Now()
+
+	if params.Schedule != "" {
+		schedule, err := cron.ParseStandard(params.Schedule)
+		if err != nil {
+			s.logger.Error("CreateScheduledTransfer() -> ParseStandard() | error", zap.Any("params", params), zap.Error(err))
+			return nil, fmt.Errorf("%w: %w", ErrInvalidSchedule, err)
+		}
+		params.RunAt = schedule.Next(now.UTC())
+	} else if !params.RunAt.After(now) {
+		return nil, ErrScheduleInPast
+	}
+
+	st, err := s.repo.CreateScheduledTransfer(ctx, params)
+	if err != nil {
+		s.logger.Error("CreateScheduledTransfer() -> CreateScheduledTransfer() request | error",
+			zap.Any("params", params),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	s.logger.Info("CreateScheduledTransfer() response", zap.Any("params", params), zap.Any("scheduled_transfer", st))
+
+	return st, nil
+}
+
+func (s *MerchService) GetScheduledTransfers(ctx context.Context, params model.GetScheduledTransfersParams) ([]model.ScheduledTransfer, error) {
+	s.logger.Info("GetScheduledTransfers() request", zap.Any("params", params))
+
+	transfers, err := s.repo.GetScheduledTransfers(ctx, params)
+	if err != nil {
+		s.logger.Error("GetScheduledTransfers() -> GetScheduledTransfers() request | error",
+			zap.Any("params", params),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	return transfers, nil
+}
+
+func (s *MerchService) GetScheduledTransferRuns(ctx context.Context, params model.ScheduledTransferIDParams) ([]model.ScheduledTransferRun, error) {
+	s.logger.Info("GetScheduledTransferRuns() request", zap.Any("params", params))
+
+	runs, err := s.repo.GetScheduledTransferRuns(ctx, params)
+	if err != nil {
+		s.logger.Error("GetScheduledTransferRuns() -> GetScheduledTransferRuns() request | error",
+			zap.Any("params", params),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	return runs, nil
+}
+
+func (s *MerchService) CancelScheduledTransfer(ctx context.Context, params model.ScheduledTransferIDParams) error {
+	s.logger.Info("CancelScheduledTransfer() request", zap.Any("params", params))
+
+	if err := s.repo.CancelScheduledTransfer(ctx, params); err != nil {
+		s.logger.Error("CancelScheduledTransfer() -> CancelScheduledTransfer() request | error",
+			zap.Any("params", params),
+			zap.Error(err),
+		)
+		return MapDBErrorToServiceError(err)
+	}
+
+	return nil
+}
+
+// RunScheduledTransfers берет в аренду переводы, время которых пришло, и выполняет их.
+// Вызывается планировщиком. Возвращает количество успешно выполненных переводов
+func (s *MerchService) RunScheduledTransfers(ctx context.Context, params model.ClaimScheduledTransfersParams) (int, error) {
+	transfers, err := s.repo.ClaimScheduledTransfers(ctx, params)
+	if err != nil {
+		s.logger.Error("RunScheduledTransfers() -> ClaimScheduledTransfers() request | error",
+			zap.Any("params", params),
+			zap.Error(err),
+		)
+		return 0, MapDBErrorToServiceError(err)
+	}
+
+	executed := 0
+	for _, st := range transfers {
+		if err := s.runScheduledTransfer(ctx, params.Owner, st); err != nil {
+			s.logger.Error("RunScheduledTransfers() -> runScheduledTransfer() | error",
+				zap.Uint("scheduled_transfer_id", st.ID),
+				zap.Error(err),
+			)
+			continue
+		}
+		executed++
+	}
+
+	if len(transfers) > 0 {
+		s.logger.Info("RunScheduledTransfers() response", zap.Int("claimed", len(transfers)), zap.Int("executed", executed))
+	}
+
+	return executed, nil
+}
+
+// runScheduledTransfer выполняет один запуск. Ошибка перевода записывается в историю запусков
+// и возвращается вызывающей стороне только для логирования
+func (s *MerchService) runScheduledTransfer(ctx context.Context, owner string, st model.ScheduledTransfer) error {
+	if st.NextRunAt == nil {
+		return nil
+	}
+
+	scheduledFor := *st.NextRunAt
+	now := time.Now()
+
+	next, err := s.nextScheduledRun(st, now)
+	if err != nil {
+		return err
+	}
+
+	toUserID, err := s.repo.ExecuteScheduledTransfer(ctx, model.ExecuteScheduledTransferParams{
+		ID:           st.ID,
+		Owner:        owner,
+		ScheduledFor: scheduledFor,
+		NextRunAt:    next,
+		Limits:       s.transferLimits(),
+	})
+	if err == nil {
+		s.invalidateUserInfo(ctx, st.FromUserID, toUserID)
+		return nil
+	}
+
+	// аренду перехватил другой инстанс или запуск уже выполнен - тут делать нечего
+	if errors.Is(err, database.ErrLeaseLost) || errors.Is(err, database.ErrAlreadyExecuted) {
+		return err
+	}
+
+	serviceErr := MapDBErrorToServiceError(err)
+
+	failure := model.RecordScheduledTransferFailureParams{
+		ID:           st.ID,
+		Owner:        owner,
+		ScheduledFor: scheduledFor,
+		Error:        serviceErr.Error(),
+		Failures:     st.ConsecutiveFailures,
+		Status:       model.ScheduledTransferActive,
+		NextRunAt:    next,
+	}
+
+	// разовый перевод повторяем позже, регулярный - в следующий раз по расписанию
+	if next == nil {
+		retryAt := now.Add(s.transfer.ScheduledRetryInterval)
+		failure.NextRunAt = &retryAt
+	}
+
+	// засчитываем любую неудачу: замороженный отправитель или заблокированный получатель
+	// иначе вечно откладывали бы разовый перевод, каждый раз записывая неудачу
+	failure.Failures++
+	if failure.Failures >= s.transfer.ScheduledMaxFailures {
+		failure.Status = model.ScheduledTransferStopped
+		failure.NextRunAt = nil
+	}
+
+	if recordErr := s.repo.RecordScheduledTransferFailure(ctx, failure); recordErr != nil {
+		return fmt.Errorf("%w (record failure: %w)", err, recordErr)
+	}
+
+	return err
+}
+
+// nextScheduledRun считает время следующего запуска. Для разового перевода - nil.
+// Пропущенные запуски (например, сервис лежал) не догоняем, берем ближайший после now
+func (s *MerchService) nextScheduledRun(st model.ScheduledTransfer, now time.Time) (*time.Time, error) {
+	if st.Schedule == "" {
+		return nil, nil
+	}
+
+	schedule, err := cron.ParseStandard(st.Schedule)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSchedule, err)
+	}
+
+	next := schedule.Next(now.UTC())
+	return &next, nil
+}
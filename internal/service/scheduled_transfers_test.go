@@ -0,0 +1,178 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/internal/database"
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/0x0FACED/merch-shop/internal/service"
+	"github.com/0x0FACED/merch-shop/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func testClaimParams() model.ClaimScheduledTransfersParams {
+	return model.ClaimScheduledTransfersParams{Owner: "test-instance", Lease: time.Minute, Limit: 10}
+}
+
+// Тест создания регулярного перевода: время первого запуска берется из cron
+func TestCreateScheduledTransfer_Recurring(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	params := model.CreateScheduledTransferParams{FromUser: 1, ToUser: "user2", Amount: 10, Schedule: "0 12 * * 5"}
+
+	mockRepo.On("CreateScheduledTransfer", mock.Anything, mock.MatchedBy(func(p model.CreateScheduledTransferParams) bool {
+		return p.RunAt.After(time.Now()) && p.RunAt.UTC().Weekday() == time.Friday && p.RunAt.UTC().Hour() == 12
+	})).Return(&model.ScheduledTransfer{ID: 1, Status: model.ScheduledTransferActive}, nil)
+
+	st, err := userService.CreateScheduledTransfer(context.Background(), params)
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.ScheduledTransferActive, st.Status)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateScheduledTransfer_InvalidSchedule(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	params := model.CreateScheduledTransferParams{FromUser: 1, ToUser: "user2", Amount: 10, Schedule: "every friday"}
+
+	_, err := userService.CreateScheduledTransfer(context.Background(), params)
+
+	assert.ErrorIs(t, err, service.ErrInvalidSchedule)
+	mockRepo.AssertNotCalled(t, "CreateScheduledTransfer", mock.Anything, mock.Anything)
+}
+
+func TestCreateScheduledTransfer_InPast(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	params := model.CreateScheduledTransferParams{FromUser: 1, ToUser: "user2", Amount: 10, RunAt: time.Now().Add(-time.Hour)}
+
+	_, err := userService.CreateScheduledTransfer(context.Background(), params)
+
+	assert.ErrorIs(t, err, service.ErrScheduleInPast)
+	mockRepo.AssertNotCalled(t, "CreateScheduledTransfer", mock.Anything, mock.Anything)
+}
+
+// Тест успешного выполнения разового перевода: следующего запуска нет
+func TestRunScheduledTransfers_OneShotSuccess(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	runAt := time.Now().Add(-time.Minute)
+	st := model.ScheduledTransfer{ID: 7, FromUserID: 1, ToUserID: 2, Amount: 10, NextRunAt: &runAt, Status: model.ScheduledTransferActive}
+
+	mockRepo.On("ClaimScheduledTransfers", mock.Anything, testClaimParams()).Return([]model.ScheduledTransfer{st}, nil)
+	mockRepo.On("ExecuteScheduledTransfer", mock.Anything, model.ExecuteScheduledTransferParams{
+		ID:           7,
+		Owner:        "test-instance",
+		ScheduledFor: runAt,
+	}).Return(2, nil)
+
+	executed, err := userService.RunScheduledTransfers(context.Background(), testClaimParams())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, executed)
+	mockRepo.AssertExpectations(t)
+}
+
+// Тест регулярного перевода при нехватке монет: неудача засчитывается, перевод остается активным
+func TestRunScheduledTransfers_InsufficientFunds(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	runAt := time.Now().Add(-time.Minute)
+	st := model.ScheduledTransfer{ID: 7, FromUserID: 1, ToUserID: 2, Amount: 10, Schedule: "0 12 * * 5", NextRunAt: &runAt}
+
+	mockRepo.On("ClaimScheduledTransfers", mock.Anything, testClaimParams()).Return([]model.ScheduledTransfer{st}, nil)
+	mockRepo.On("ExecuteScheduledTransfer", mock.Anything, mock.Anything).Return(0, database.ErrInsufficientFunds)
+	mockRepo.On("RecordScheduledTransferFailure", mock.Anything, mock.MatchedBy(func(p model.RecordScheduledTransferFailureParams) bool {
+		return p.Failures == 1 &&
+			p.Status == model.ScheduledTransferActive &&
+			p.NextRunAt != nil && p.NextRunAt.UTC().Weekday() == time.Friday &&
+			p.Error == service.ErrInsufficientFunds.Error()
+	})).Return(nil)
+
+	executed, err := userService.RunScheduledTransfers(context.Background(), testClaimParams())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, executed)
+	mockRepo.AssertExpectations(t)
+}
+
+// Тест остановки перевода после нескольких неудач подряд
+func TestRunScheduledTransfers_StopAfterMaxFailures(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	runAt := time.Now().Add(-time.Minute)
+	st := model.ScheduledTransfer{ID: 7, FromUserID: 1, ToUserID: 2, Amount: 10, NextRunAt: &runAt, ConsecutiveFailures: 2}
+
+	mockRepo.On("ClaimScheduledTransfers", mock.Anything, testClaimParams()).Return([]model.ScheduledTransfer{st}, nil)
+	mockRepo.On("ExecuteScheduledTransfer", mock.Anything, mock.Anything).Return(0, database.ErrInsufficientFunds)
+	mockRepo.On("RecordScheduledTransferFailure", mock.Anything, mock.MatchedBy(func(p model.RecordScheduledTransferFailureParams) bool {
+		return p.Failures == 3 && p.Status == model.ScheduledTransferStopped && p.NextRunAt == nil
+	})).Return(nil)
+
+	_, err := userService.RunScheduledTransfers(context.Background(), testClaimParams())
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+// Тест остановки разового перевода после других неудач: они засчитываются так же,
+// как нехватка монет, и перевод останавливается вместо бесконечных повторов
+func TestRunScheduledTransfers_StopAfterOtherFailures(t *testing.T) {
+	tests := []struct {
+		name  string
+		dbErr error
+	}{
+		{name: "sender frozen", dbErr: database.ErrAccountFrozen},
+		{name: "sender suspended", dbErr: database.ErrAccountSuspended},
+		{name: "recipient not active", dbErr: database.ErrRecipientNotActive},
+		{name: "transfer limit", dbErr: database.ErrDailyTransferLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(mocks.MockMerchRepository)
+			userService := newTestService(mockRepo)
+
+			runAt := time.Now().Add(-time.Minute)
+			st := model.ScheduledTransfer{ID: 7, FromUserID: 1, ToUserID: 2, Amount: 10, NextRunAt: &runAt, ConsecutiveFailures: 2}
+
+			mockRepo.On("ClaimScheduledTransfers", mock.Anything, testClaimParams()).Return([]model.ScheduledTransfer{st}, nil)
+			mockRepo.On("ExecuteScheduledTransfer", mock.Anything, mock.Anything).Return(0, tt.dbErr)
+			mockRepo.On("RecordScheduledTransferFailure", mock.Anything, mock.MatchedBy(func(p model.RecordScheduledTransferFailureParams) bool {
+				return p.Failures == 3 && p.Status == model.ScheduledTransferStopped && p.NextRunAt == nil
+			})).Return(nil)
+
+			_, err := userService.RunScheduledTransfers(context.Background(), testClaimParams())
+
+			assert.NoError(t, err)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// Тест потери аренды: неудача не записывается, этим займется другой инстанс
+func TestRunScheduledTransfers_LeaseLost(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	runAt := time.Now().Add(-time.Minute)
+	st := model.ScheduledTransfer{ID: 7, FromUserID: 1, ToUserID: 2, Amount: 10, NextRunAt: &runAt}
+
+	mockRepo.On("ClaimScheduledTransfers", mock.Anything, testClaimParams()).Return([]model.ScheduledTransfer{st}, nil)
+	mockRepo.On("ExecuteScheduledTransfer", mock.Anything, mock.Anything).Return(0, database.ErrLeaseLost)
+
+	_, err := userService.RunScheduledTransfers(context.Background(), testClaimParams())
+
+	assert.NoError(t, err)
+	mockRepo.AssertNotCalled(t, "RecordScheduledTransferFailure", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"go.uber.org/zap"
+)
+
+// maxUserAgentLen - user agent приходит от клиента как есть, длиннее храним обрезанным
+const maxUserAgentLen = 512
+
+// CreateSession заводит сессию под новый токен, ее ID зашивается в токен
+func (s *MerchService) CreateSession(ctx context.Context, params model.CreateSessionParams) (*model.Session, error) {
+	s.logger.Info("CreateSession() request", zap.Uint("user_id", params.UserID), zap.String("ip", params.IP))
+
+	params.UserAgent = truncateUserAgent(params.UserAgent)
+
+	session, err := s.repo.CreateSession(ctx, params)
+	if err != nil {
+		s.logger.Error("CreateSession() -> CreateSession() request | error",
+			zap.Uint("user_id", params.UserID),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	s.logger.Info("CreateSession() response", zap.Uint("user_id", params.UserID), zap.Uint("session_id", session.ID))
+
+	return session, nil
+}
+
+// GetSessions возвращает действующие сессии юзера, currentID - сессия текущего запроса
+func (s *MerchService) GetSessions(ctx context.Context, userID, currentID uint) ([]model.Session, error) {
+	s.logger.Info("GetSessions() request", zap.Uint("user_id", userID))
+
+	sessions, err := s.repo.GetSessions(ctx, userID)
+	if err != nil {
+		s.logger.Error("GetSessions() -> GetSessions() request | error",
+			zap.Uint("user_id", userID),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession отзывает сессию юзера, в том числе текущую - это выход из аккаунта
+func (s *MerchService) RevokeSession(ctx context.Context, params model.SessionIDParams) error {
+	s.logger.Info("RevokeSession() request", zap.Any("params", params))
+
+	if err := s.repo.RevokeSession(ctx, params); err != nil {
+		s.logger.Error("RevokeSession() -> RevokeSession() request | error",
+			zap.Any("params", params),
+			zap.Error(err),
+		)
+		return MapDBErrorToServiceError(err)
+	}
+
+	s.logger.Info("RevokeSession() response", zap.Any("params", params))
+
+	return nil
+}
+
+// truncateUserAgent обрезает user agent до maxUserAgentLen байт, не разрывая символы
+func truncateUserAgent(userAgent string) string {
+	userAgent = strings.TrimSpace(userAgent)
+	if len(userAgent) <= maxUserAgentLen {
+		return userAgent
+	}
+
+	cut := maxUserAgentLen
+	for cut > 0 && !utf8.RuneStart(userAgent[cut]) {
+		cut--
+	}
+	return userAgent[:cut]
+}
@@ -0,0 +1,74 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/internal/database"
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/0x0FACED/merch-shop/internal/service"
+	"github.com/0x0FACED/merch-shop/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Слишком длинный user agent обрезается, а не ломает вход
+func TestCreateSession_TruncatesUserAgent(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	mockRepo.On("CreateSession", mock.Anything, mock.MatchedBy(func(p model.CreateSessionParams) bool {
+		return p.UserID == 1 && len(p.UserAgent) == 512 && p.IP == "10.0.0.1" && p.TTL == time.Hour
+	})).Return(&model.Session{ID: 5}, nil)
+
+	session, err := userService.CreateSession(context.Background(), model.CreateSessionParams{
+		UserID:    1,
+		UserAgent: strings.Repeat("a", 1000),
+		IP:        "10.0.0.1",
+		TTL:       time.Hour,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint(5), session.ID)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetSessions_MarksCurrent(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	mockRepo.On("GetSessions", mock.Anything, uint(1)).Return([]model.Session{{ID: 3}, {ID: 2}}, nil)
+
+	sessions, err := userService.GetSessions(context.Background(), 1, 2)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.False(t, sessions[0].Current)
+	assert.True(t, sessions[1].Current)
+}
+
+func TestRevokeSession_NotFound(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	params := model.SessionIDParams{ID: 9, UserID: 1}
+	mockRepo.On("RevokeSession", mock.Anything, params).Return(database.ErrNotFound)
+
+	err := userService.RevokeSession(context.Background(), params)
+	assert.ErrorIs(t, err, service.ErrNotFound)
+}
+
+// Токен отозванной сессии не принимается, даже если версия токенов та же
+func TestCheckUserAccess_RevokedSession(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	mockRepo.On("GetUserAccess", mock.Anything, uint(1), uint(7)).
+		Return(&model.UserAccess{Status: model.UserStatusActive, SessionRevoked: true}, nil)
+	mockRepo.On("GetUserAccess", mock.Anything, uint(1), uint(8)).
+		Return(&model.UserAccess{Status: model.UserStatusActive}, nil)
+
+	assert.ErrorIs(t, userService.CheckUserAccess(context.Background(), 1, 0, 7), service.ErrSessionRevoked)
+	assert.NoError(t, userService.CheckUserAccess(context.Background(), 1, 0, 8))
+}
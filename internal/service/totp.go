@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/internal/crypt"
+	"github.com/0x0FACED/merch-shop/internal/database"
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/0x0FACED/merch-shop/internal/totp"
+	"go.uber.org/zap"
+)
+
+// secretCipher шифрует секреты TOTP в базе, см. crypt.AESGCM
+type secretCipher interface {
+	Encrypt(plaintext, associated string) (string, error)
+	Decrypt(ciphertext, associated string) (string, error)
+}
+
+const (
+	recoveryCodeCount = 10
+	recoveryCodeBytes = 5 // 10 hex символов, юзеру показываются как xxxxx-xxxxx
+
+	// после стольких неверных кодов подряд проверка блокируется на totpLockDuration,
+	// иначе 6 цифр можно перебрать
+	maxTOTPFailures  = 5
+	totpLockDuration = 5 * time.Minute
+)
+
+// EnrollTOTP начинает подключение 2FA: генерирует секрет и сохраняет его зашифрованным.
+// 2FA включится, когда юзер подтвердит ее первым кодом из приложения (ConfirmTOTP)
+func (s *MerchService) EnrollTOTP(ctx context.Context, userID uint) (*model.TOTPEnrollment, error) {
+	s.logger.Info("EnrollTOTP() request", zap.Uint("user_id", userID))
+
+	user, err := s.repo.GetUser(ctx, userID)
+	if err != nil {
+		s.logger.Error("EnrollTOTP() -> GetUser() request | error", zap.Uint("user_id", userID), zap.Error(err))
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnknown, err)
+	}
+
+	encrypted, err := s.secrets.Encrypt(secret, totpAssociatedData(userID))
+	if err != nil {
+		s.logger.Error("EnrollTOTP() -> Encrypt() | error", zap.Uint("user_id", userID), zap.Error(err))
+		return nil, mapCipherError(err)
+	}
+
+	params := model.CreateTOTPParams{
+		UserID:          userID,
+		SecretEncrypted: encrypted,
+	}
+
+	if err := s.repo.CreateTOTP(ctx, params); err != nil {
+		s.logger.Error("EnrollTOTP() -> CreateTOTP() request | error", zap.Uint("user_id", userID), zap.Error(err))
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	s.logger.Info("EnrollTOTP() response", zap.Uint("user_id", userID))
+
+	return &model.TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.ProvisioningURI(s.auth.TOTPIssuer, user.Username, secret),
+	}, nil
+}
+
+// ConfirmTOTP включает 2FA, если код подходит к секрету из EnrollTOTP.
+// Возвращает коды восстановления, они показываются один раз
+func (s *MerchService) ConfirmTOTP(ctx context.Context, userID uint, code string) ([]string, error) {
+	s.logger.Info("ConfirmTOTP() request", zap.Uint("user_id", userID))
+
+	t, err := s.repo.GetTOTP(ctx, userID)
+	if err != nil {
+		s.logger.Error("ConfirmTOTP() -> GetTOTP() request | error", zap.Uint("user_id", userID), zap.Error(err))
+		return nil, MapDBErrorToServiceError(err)
+	}
+	if t.Confirmed {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+
+	step, ok, err := s.validateTOTP(t, code)
+	if err != nil {
+		s.logger.Error("ConfirmTOTP() -> validateTOTP() | error", zap.Uint("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidTOTP
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		raw, err := randomHex(recoveryCodeBytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrUnknown, err)
+		}
+		codes[i] = raw[:len(raw)/2] + "-" + raw[len(raw)/2:]
+		hashes[i] = hashSecret(raw)
+	}
+
+	params := model.ConfirmTOTPParams{
+		UserID:             userID,
+		Step:               step,
+		RecoveryCodeHashes: hashes,
+	}
+
+	if err := s.repo.ConfirmTOTP(ctx, params); err != nil {
+		s.logger.Error("ConfirmTOTP() -> ConfirmTOTP() request | error", zap.Uint("user_id", userID), zap.Error(err))
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	s.logger.Info("ConfirmTOTP() response", zap.Uint("user_id", userID))
+
+	return codes, nil
+}
+
+// DisableTOTP выключает 2FA, подтвердить нужно кодом TOTP или кодом восстановления
+func (s *MerchService) DisableTOTP(ctx context.Context, userID uint, code string) error {
+	s.logger.Info("DisableTOTP() request", zap.Uint("user_id", userID))
+
+	t, err := s.repo.GetTOTP(ctx, userID)
+	if err != nil && !errors.Is(err, database.ErrNotFound) {
+		s.logger.Error("DisableTOTP() -> GetTOTP() request | error", zap.Uint("user_id", userID), zap.Error(err))
+		return MapDBErrorToServiceError(err)
+	}
+	if t == nil || !t.Confirmed {
+		return ErrTOTPNotEnabled
+	}
+
+	if err := s.verifySecondFactor(ctx, t, code); err != nil {
+		s.logger.Error("DisableTOTP() -> verifySecondFactor() | error", zap.Uint("user_id", userID), zap.Error(err))
+		return err
+	}
+
+	if err := s.repo.DeleteTOTP(ctx, userID); err != nil {
+		s.logger.Error("DisableTOTP() -> DeleteTOTP() request | error", zap.Uint("user_id", userID), zap.Error(err))
+		return MapDBErrorToServiceError(err)
+	}
+
+	s.logger.Info("DisableTOTP() response", zap.Uint("user_id", userID))
+
+	return nil
+}
+
+// checkLoginTOTP - второй фактор при входе по паролю, вызывается только если 2FA включена.
+// При входе через SSO не проверяется, там второй фактор - забота IdP
+func (s *MerchService) checkLoginTOTP(ctx context.Context, userID uint, code string) error {
+	t, err := s.repo.GetTOTP(ctx, userID)
+	if err != nil {
+		return MapDBErrorToServiceError(err)
+	}
+
+	return s.verifySecondFactor(ctx, t, code)
+}
+
+// checkStepUp требует код 2FA для перевода больше AuthConfig.TOTPStepUpAmount.
+// 2FA опциональна: юзеров, которые ее не включили, проверка не касается
+func (s *MerchService) checkStepUp(ctx context.Context, userID uint, amount int, code string) error {
+	if s.auth.TOTPStepUpAmount <= 0 || amount <= s.auth.TOTPStepUpAmount {
+		return nil
+	}
+
+	t, err := s.repo.GetTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return nil
+		}
+		return MapDBErrorToServiceError(err)
+	}
+	if !t.Confirmed {
+		return nil
+	}
+
+	return s.verifySecondFactor(ctx, t, code)
+}
+
+// verifySecondFactor проверяет код TOTP (6 цифр) или код восстановления. Принятый код
+// второй раз не пройдет, неверные коды считаются и после maxTOTPFailures блокируют проверку
+func (s *MerchService) verifySecondFactor(ctx context.Context, t *model.TOTP, code string) error {
+	if t.LockedUntil != nil {
+		return ErrTOTPLocked
+	}
+	if code == "" {
+		return ErrTOTPRequired
+	}
+
+	var err error
+	if totp.IsCode(code) {
+		step, ok, validateErr := s.validateTOTP(t, code)
+		switch {
+		case validateErr != nil:
+			return validateErr
+		case ok:
+			err = s.repo.UseTOTPStep(ctx, t.UserID, step)
+		default:
+			err = ErrInvalidTOTP
+		}
+	} else {
+		err = s.repo.UseRecoveryCode(ctx, t.UserID, hashSecret(normalizeRecoveryCode(code)))
+	}
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrInvalidTOTP),
+		errors.Is(err, database.ErrTOTPCodeUsed),
+		errors.Is(err, database.ErrInvalidRecoveryCode):
+		failure := model.TOTPFailureParams{
+			UserID:      t.UserID,
+			MaxFailures: maxTOTPFailures,
+			LockFor:     totpLockDuration,
+		}
+		if err := s.repo.RecordTOTPFailure(ctx, failure); err != nil {
+			s.logger.Error("verifySecondFactor() -> RecordTOTPFailure() request | error",
+				zap.Uint("user_id", t.UserID),
+				zap.Error(err),
+			)
+		}
+		return ErrInvalidTOTP
+	default:
+		return MapDBErrorToServiceError(err)
+	}
+}
+
+func (s *MerchService) validateTOTP(t *model.TOTP, code string) (int64, bool, error) {
+	secret, err := s.secrets.Decrypt(t.SecretEncrypted, totpAssociatedData(t.UserID))
+	if err != nil {
+		return 0, false, mapCipherError(err)
+	}
+
+	step, ok, err := totp.Validate(secret, code, time.Now())
+	if err != nil {
+		return 0, false, fmt.Errorf("%w: %w", ErrUnknown, err)
+	}
+
+	return step, ok, nil
+}
+
+// totpAssociatedData привязывает шифротекст секрета к юзеру
+func totpAssociatedData(userID uint) string {
+	return fmt.Sprintf("totp:%d", userID)
+}
+
+// normalizeRecoveryCode убирает дефис и регистр, чтобы код можно было ввести как угодно
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
+
+func mapCipherError(err error) error {
+	if errors.Is(err, crypt.ErrDisabled) {
+		return ErrTOTPDisabled
+	}
+	return fmt.Errorf("%w: %w", ErrUnknown, err)
+}
@@ -0,0 +1,305 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/internal/crypt"
+	"github.com/0x0FACED/merch-shop/internal/database"
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/0x0FACED/merch-shop/internal/service"
+	"github.com/0x0FACED/merch-shop/internal/service/mocks"
+	"github.com/0x0FACED/merch-shop/internal/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testTOTPKey = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+
+// newTOTPService - сервис с настоящим шифрованием секретов и step-up для переводов больше 500
+func newTOTPService(t *testing.T, mockRepo *mocks.MockMerchRepository) (*service.MerchService, crypt.Cipher) {
+	t.Helper()
+
+	cipher, err := crypt.New(testTOTPKey)
+	require.NoError(t, err)
+
+	deps := testDeps()
+	deps.Secrets = cipher
+	deps.Auth.TOTPIssuer = "Merch Shop"
+	deps.Auth.TOTPStepUpAmount = 500
+
+	return service.NewUserService(mockRepo, testLogger(), deps), cipher
+}
+
+// confirmedTOTP - включенная 2FA юзера с зашифрованным секретом
+func confirmedTOTP(t *testing.T, cipher crypt.Cipher, userID uint, secret string) *model.TOTP {
+	t.Helper()
+
+	encrypted, err := cipher.Encrypt(secret, fmt.Sprintf("totp:%d", userID))
+	require.NoError(t, err)
+
+	return &model.TOTP{UserID: userID, SecretEncrypted: encrypted, Confirmed: true}
+}
+
+func currentCode(t *testing.T, secret string) string {
+	t.Helper()
+
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	return code
+}
+
+func TestEnrollAndConfirmTOTP(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService, cipher := newTOTPService(t, mockRepo)
+
+	var saved model.CreateTOTPParams
+	mockRepo.On("GetUser", mock.Anything, uint(1)).Return(&model.User{ID: 1, Username: "alice"}, nil)
+	mockRepo.On("CreateTOTP", mock.Anything, mock.MatchedBy(func(p model.CreateTOTPParams) bool {
+		saved = p
+		return p.UserID == 1
+	})).Return(nil)
+
+	enrollment, err := userService.EnrollTOTP(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Contains(t, enrollment.URI, "otpauth://totp/Merch%20Shop:alice?")
+	assert.Contains(t, enrollment.URI, "secret="+enrollment.Secret)
+
+	// в базу секрет попадает только зашифрованным
+	assert.NotContains(t, saved.SecretEncrypted, enrollment.Secret)
+	decrypted, err := cipher.Decrypt(saved.SecretEncrypted, "totp:1")
+	require.NoError(t, err)
+	assert.Equal(t, enrollment.Secret, decrypted)
+
+	var confirmed model.ConfirmTOTPParams
+	mockRepo.On("GetTOTP", mock.Anything, uint(1)).
+		Return(&model.TOTP{UserID: 1, SecretEncrypted: saved.SecretEncrypted}, nil)
+	mockRepo.On("ConfirmTOTP", mock.Anything, mock.MatchedBy(func(p model.ConfirmTOTPParams) bool {
+		confirmed = p
+		return p.UserID == 1
+	})).Return(nil)
+
+	codes, err := userService.ConfirmTOTP(context.Background(), 1, currentCode(t, enrollment.Secret))
+	require.NoError(t, err)
+	assert.Len(t, codes, 10)
+	assert.Len(t, confirmed.RecoveryCodeHashes, 10)
+	assert.Regexp(t, `^[0-9a-f]{5}-[0-9a-f]{5}$`, codes[0])
+	assert.Equal(t, sha256Hex(codes[0][:5]+codes[0][6:]), confirmed.RecoveryCodeHashes[0])
+	assert.Equal(t, totp.Step(time.Now()), confirmed.Step)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestConfirmTOTP_InvalidCode(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService, cipher := newTOTPService(t, mockRepo)
+
+	pending := confirmedTOTP(t, cipher, 1, "JBSWY3DPEHPK3PXP")
+	pending.Confirmed = false
+	mockRepo.On("GetTOTP", mock.Anything, uint(1)).Return(pending, nil)
+
+	_, err := userService.ConfirmTOTP(context.Background(), 1, "000000")
+	assert.ErrorIs(t, err, service.ErrInvalidTOTP)
+	mockRepo.AssertNotCalled(t, "ConfirmTOTP", mock.Anything, mock.Anything)
+}
+
+func TestEnrollTOTP_NoKey(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	mockRepo.On("GetUser", mock.Anything, uint(1)).Return(&model.User{ID: 1, Username: "alice"}, nil)
+
+	_, err := userService.EnrollTOTP(context.Background(), 1)
+	assert.ErrorIs(t, err, service.ErrTOTPDisabled)
+}
+
+func TestAuthUser_TOTP(t *testing.T) {
+	const secret = "JBSWY3DPEHPK3PXP"
+	user := &model.User{ID: 1, Username: "alice", Password: testPasswordHash, TOTPEnabled: true}
+
+	tests := []struct {
+		name    string
+		otp     string
+		locked  bool
+		useErr  error
+		wantErr error
+	}{
+		{name: "valid code", otp: "current"},
+		{name: "no code", otp: "", wantErr: service.ErrTOTPRequired},
+		{name: "wrong code", otp: "000000", wantErr: service.ErrInvalidTOTP},
+		{name: "reused code", otp: "current", useErr: database.ErrTOTPCodeUsed, wantErr: service.ErrInvalidTOTP},
+		{name: "locked", otp: "current", locked: true, wantErr: service.ErrTOTPLocked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(mocks.MockMerchRepository)
+			userService, cipher := newTOTPService(t, mockRepo)
+
+			otp := tt.otp
+			if otp == "current" {
+				otp = currentCode(t, secret)
+			}
+
+			stored := confirmedTOTP(t, cipher, 1, secret)
+			if tt.locked {
+				lockedUntil := time.Now().Add(time.Minute)
+				stored.LockedUntil = &lockedUntil
+			}
+
+			params := model.AuthUserParams{Username: "alice", Password: "test", OTP: otp}
+			mockRepo.On("AuthUser", mock.Anything, params).Return(user, nil)
+			mockRepo.On("GetTOTP", mock.Anything, uint(1)).Return(stored, nil)
+			mockRepo.On("UseTOTPStep", mock.Anything, uint(1), mock.Anything).Return(tt.useErr)
+			mockRepo.On("RecordTOTPFailure", mock.Anything, model.TOTPFailureParams{
+				UserID:      1,
+				MaxFailures: 5,
+				LockFor:     5 * time.Minute,
+			}).Return(nil)
+
+			got, err := userService.AuthUser(context.Background(), params)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, got)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, uint(1), got.ID)
+			}
+
+			// неверный код считается как неудачная попытка, пустой и блокировка - нет
+			if tt.wantErr == service.ErrInvalidTOTP {
+				mockRepo.AssertCalled(t, "RecordTOTPFailure", mock.Anything, mock.Anything)
+			} else {
+				mockRepo.AssertNotCalled(t, "RecordTOTPFailure", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestSendCoin_StepUp(t *testing.T) {
+	const secret = "JBSWY3DPEHPK3PXP"
+
+	t.Run("below threshold", func(t *testing.T) {
+		mockRepo := new(mocks.MockMerchRepository)
+		userService, _ := newTOTPService(t, mockRepo)
+
+		params := model.SendCoinParams{FromUser: 1, ToUser: "bob", Amount: 500}
+		mockRepo.On("SendCoin", mock.Anything, params).Return(2, nil)
+
+		require.NoError(t, userService.SendCoin(context.Background(), params))
+		mockRepo.AssertNotCalled(t, "GetTOTP", mock.Anything, mock.Anything)
+	})
+
+	t.Run("2fa not enabled", func(t *testing.T) {
+		mockRepo := new(mocks.MockMerchRepository)
+		userService, _ := newTOTPService(t, mockRepo)
+
+		params := model.SendCoinParams{FromUser: 1, ToUser: "bob", Amount: 600}
+		mockRepo.On("GetTOTP", mock.Anything, uint(1)).Return(nil, database.ErrNotFound)
+		mockRepo.On("SendCoin", mock.Anything, params).Return(2, nil)
+
+		require.NoError(t, userService.SendCoin(context.Background(), params))
+	})
+
+	t.Run("code required", func(t *testing.T) {
+		mockRepo := new(mocks.MockMerchRepository)
+		userService, cipher := newTOTPService(t, mockRepo)
+
+		params := model.SendCoinParams{FromUser: 1, ToUser: "bob", Amount: 600}
+		mockRepo.On("GetTOTP", mock.Anything, uint(1)).Return(confirmedTOTP(t, cipher, 1, secret), nil)
+
+		err := userService.SendCoin(context.Background(), params)
+		assert.ErrorIs(t, err, service.ErrTOTPRequired)
+		mockRepo.AssertNotCalled(t, "SendCoin", mock.Anything, mock.Anything)
+	})
+
+	t.Run("recovery code", func(t *testing.T) {
+		mockRepo := new(mocks.MockMerchRepository)
+		userService, cipher := newTOTPService(t, mockRepo)
+
+		params := model.SendCoinParams{FromUser: 1, ToUser: "bob", Amount: 600, OTP: "ABCDE-12345"}
+		mockRepo.On("GetTOTP", mock.Anything, uint(1)).Return(confirmedTOTP(t, cipher, 1, secret), nil)
+		mockRepo.On("UseRecoveryCode", mock.Anything, uint(1), sha256Hex("abcde12345")).Return(nil)
+		mockRepo.On("SendCoin", mock.Anything, params).Return(2, nil)
+
+		require.NoError(t, userService.SendCoin(context.Background(), params))
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestSendCoinBatch_StepUpOnTotal(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService, cipher := newTOTPService(t, mockRepo)
+
+	// каждый перевод меньше порога, но вместе больше
+	params := model.SendCoinBatchParams{
+		FromUser: 1,
+		Transfers: []model.BatchTransfer{
+			{ToUser: "bob", Amount: 300},
+			{ToUser: "carol", Amount: 300},
+		},
+	}
+	mockRepo.On("GetTOTP", mock.Anything, uint(1)).Return(confirmedTOTP(t, cipher, 1, "JBSWY3DPEHPK3PXP"), nil)
+
+	_, err := userService.SendCoinBatch(context.Background(), params)
+	assert.ErrorIs(t, err, service.ErrTOTPRequired)
+	mockRepo.AssertNotCalled(t, "SendCoinBatch", mock.Anything, mock.Anything)
+}
+
+// Принять крупный запрос монет без кода нельзя: иначе step-up обходится через запрос от сообщника
+func TestAcceptPaymentRequest_StepUp(t *testing.T) {
+	const secret = "JBSWY3DPEHPK3PXP"
+	pr := &model.PaymentRequest{ID: 7, FromUserID: 2, ToUserID: 1, Amount: 600, Status: model.PaymentRequestPending}
+
+	t.Run("code required", func(t *testing.T) {
+		mockRepo := new(mocks.MockMerchRepository)
+		userService, cipher := newTOTPService(t, mockRepo)
+
+		mockRepo.On("GetPaymentRequest", mock.Anything, uint(7)).Return(pr, nil)
+		mockRepo.On("GetTOTP", mock.Anything, uint(1)).Return(confirmedTOTP(t, cipher, 1, secret), nil)
+
+		_, err := userService.AcceptPaymentRequest(context.Background(), model.ResolvePaymentRequestParams{ID: 7, UserID: 1})
+		assert.ErrorIs(t, err, service.ErrTOTPRequired)
+		mockRepo.AssertNotCalled(t, "AcceptPaymentRequest", mock.Anything, mock.Anything)
+	})
+
+	t.Run("recovery code", func(t *testing.T) {
+		mockRepo := new(mocks.MockMerchRepository)
+		userService, cipher := newTOTPService(t, mockRepo)
+
+		params := model.ResolvePaymentRequestParams{ID: 7, UserID: 1, OTP: "ABCDE-12345"}
+		mockRepo.On("GetPaymentRequest", mock.Anything, uint(7)).Return(pr, nil)
+		mockRepo.On("GetTOTP", mock.Anything, uint(1)).Return(confirmedTOTP(t, cipher, 1, secret), nil)
+		mockRepo.On("UseRecoveryCode", mock.Anything, uint(1), sha256Hex("abcde12345")).Return(nil)
+		mockRepo.On("AcceptPaymentRequest", mock.Anything, mock.Anything).Return(pr, nil)
+
+		_, err := userService.AcceptPaymentRequest(context.Background(), params)
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+// Код для перевода по расписанию спрашивается при создании, по сумме одного запуска
+func TestCreateScheduledTransfer_StepUp(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService, cipher := newTOTPService(t, mockRepo)
+
+	params := model.CreateScheduledTransferParams{FromUser: 1, ToUser: "bob", Amount: 600, Schedule: "0 9 * * 1"}
+	mockRepo.On("GetTOTP", mock.Anything, uint(1)).Return(confirmedTOTP(t, cipher, 1, "JBSWY3DPEHPK3PXP"), nil)
+
+	_, err := userService.CreateScheduledTransfer(context.Background(), params)
+	assert.ErrorIs(t, err, service.ErrTOTPRequired)
+	mockRepo.AssertNotCalled(t, "CreateScheduledTransfer", mock.Anything, mock.Anything)
+}
+
+func TestDisableTOTP_NotEnabled(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService, _ := newTOTPService(t, mockRepo)
+
+	mockRepo.On("GetTOTP", mock.Anything, uint(1)).Return(nil, database.ErrNotFound)
+
+	err := userService.DisableTOTP(context.Background(), 1, "123456")
+	assert.ErrorIs(t, err, service.ErrTOTPNotEnabled)
+}
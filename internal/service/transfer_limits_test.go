@@ -0,0 +1,78 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/0x0FACED/merch-shop/internal/database"
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/0x0FACED/merch-shop/internal/service"
+	"github.com/0x0FACED/merch-shop/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Лимиты из конфига передаются в репозиторий, проверяет их база внутри транзакции перевода
+func TestSendCoin_PassesLimits(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	cfg := testTransferConfig()
+	cfg.MaxSingle = 500
+	cfg.DailyTotal = 1000
+	cfg.WeeklyTotal = 3000
+	cfg.DailyRecipients = 10
+	deps := testDeps()
+	deps.Transfer = cfg
+	userService := service.NewUserService(mockRepo, testLogger(), deps)
+
+	params := model.SendCoinParams{FromUser: 1, ToUser: "receiver", Amount: 100}
+	expected := params
+	expected.Limits = model.TransferLimits{MaxSingle: 500, DailyTotal: 1000, WeeklyTotal: 3000, DailyRecipients: 10}
+
+	mockRepo.On("SendCoin", mock.Anything, expected).Return(2, nil)
+
+	err := userService.SendCoin(context.Background(), params)
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSendCoin_LimitErrors(t *testing.T) {
+	cases := []struct {
+		dbErr      error
+		serviceErr error
+	}{
+		{database.ErrSingleTransferLimit, service.ErrSingleTransferLimit},
+		{database.ErrDailyTransferLimit, service.ErrDailyTransferLimit},
+		{database.ErrWeeklyTransferLimit, service.ErrWeeklyTransferLimit},
+		{database.ErrDailyRecipientsLimit, service.ErrDailyRecipientsLimit},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.serviceErr.Error(), func(t *testing.T) {
+			mockRepo := new(mocks.MockMerchRepository)
+			userService := newTestService(mockRepo)
+
+			params := model.SendCoinParams{FromUser: 1, ToUser: "receiver", Amount: 100}
+			mockRepo.On("SendCoin", mock.Anything, params).Return(0, fmt.Errorf("%w (100)", tc.dbErr))
+
+			err := userService.SendCoin(context.Background(), params)
+			assert.ErrorIs(t, err, tc.serviceErr)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// Превышение лимита на одном переводе пачки указывает на этот перевод
+func TestSendCoinBatch_LimitExceeded(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	params := testBatchParams()
+	mockRepo.On("SendCoinBatch", mock.Anything, params).
+		Return(nil, &database.BatchTransferError{Index: 1, Err: database.ErrDailyRecipientsLimit})
+
+	results, err := userService.SendCoinBatch(context.Background(), params)
+	assert.ErrorIs(t, err, service.ErrDailyRecipientsLimit)
+	assert.Equal(t, model.BatchTransferStatusFailed, results[1].Status)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"go.uber.org/zap"
+)
+
+// ExportUserData собирает все данные юзера: профиль, кошелек, инвентарь, переводы, покупки,
+// запросы монет в обе стороны и отложенные переводы
+func (s *MerchService) ExportUserData(ctx context.Context, userID uint) (*model.UserExport, error) {
+	s.logger.Info("ExportUserData() request", zap.Uint("user_id", userID))
+
+	export, err := s.repo.ExportUserData(ctx, userID)
+	if err != nil {
+		s.logger.Error("ExportUserData() -> ExportUserData() request | error",
+			zap.Uint("user_id", userID),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	for _, direction := range []string{model.PaymentRequestsIncoming, model.PaymentRequestsOutgoing} {
+		params := model.GetPaymentRequestsParams{UserID: userID, Direction: direction}
+
+		requests, err := s.repo.GetPaymentRequests(ctx, params)
+		if err != nil {
+			s.logger.Error("ExportUserData() -> GetPaymentRequests() request | error",
+				zap.Any("params", params),
+				zap.Error(err),
+			)
+			return nil, MapDBErrorToServiceError(err)
+		}
+
+		export.PaymentRequests = append(export.PaymentRequests, requests...)
+	}
+
+	transfers, err := s.repo.GetScheduledTransfers(ctx, model.GetScheduledTransfersParams{UserID: userID})
+	if err != nil {
+		s.logger.Error("ExportUserData() -> GetScheduledTransfers() request | error",
+			zap.Uint("user_id", userID),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	export.ScheduledTransfers = transfers
+	export.ExportedAt = time.Now().UTC()
+
+	s.logger.Info("ExportUserData() response", zap.Uint("user_id", userID))
+
+	return export, nil
+}
+
+// DeleteUser удаляет аккаунт по запросу самого юзера после проверки пароля.
+// Юзер анонимизируется, а не удаляется (см. database.DeleteUser): у контрагентов
+// его переводы остаются в истории под именем model.DeletedUser
+func (s *MerchService) DeleteUser(ctx context.Context, params model.DeleteUserParams) error {
+	s.logger.Info("DeleteUser() request", zap.Uint("user_id", params.UserID))
+
+	user, err := s.repo.GetUser(ctx, params.UserID)
+	if err != nil {
+		s.logger.Error("DeleteUser() -> GetUser() request | error",
+			zap.Uint("user_id", params.UserID),
+			zap.Error(err),
+		)
+		return MapDBErrorToServiceError(err)
+	}
+
+	if err := compareHashAndPassword(user.Password, params.Password); err != nil {
+		s.logger.Error("DeleteUser() -> compareHashAndPassword() request | error",
+			zap.Uint("user_id", params.UserID),
+			zap.Error(err),
+		)
+		return ErrInvalidPassword
+	}
+
+	counterparties, err := s.repo.DeleteUser(ctx, params.UserID)
+	if err != nil {
+		s.logger.Error("DeleteUser() -> DeleteUser() request | error",
+			zap.Uint("user_id", params.UserID),
+			zap.Error(err),
+		)
+		return MapDBErrorToServiceError(err)
+	}
+
+	// у контрагентов в кэше лежит история со старым именем
+	s.invalidateUserInfo(ctx, append(counterparties, params.UserID)...)
+
+	s.logger.Info("DeleteUser() response",
+		zap.Uint("user_id", params.UserID),
+		zap.Int("counterparties", len(counterparties)),
+	)
+
+	return nil
+}
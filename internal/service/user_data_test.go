@@ -0,0 +1,113 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/internal/cache"
+	"github.com/0x0FACED/merch-shop/internal/database"
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/0x0FACED/merch-shop/internal/service"
+	"github.com/0x0FACED/merch-shop/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// хэш пароля "test"
+const testPasswordHash = "$2a$04$sjoS1Bf2A30VG0Vt0LkQf..KmKQCHuS5wvDG5RFTCQ2F1EtVVmTcm"
+
+// В выгрузку попадают запросы монет в обе стороны и отложенные переводы
+func TestExportUserData_Success(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	mockRepo.On("ExportUserData", mock.Anything, uint(1)).Return(&model.UserExport{
+		Profile: model.UserProfile{ID: 1, Username: "user1"},
+		Transfers: []model.TransferRecord{
+			{Direction: model.TransferDirectionOut, Counterparty: model.DeletedUser, Amount: 10, Kind: model.TransactionKindTransfer},
+		},
+	}, nil)
+	mockRepo.On("GetPaymentRequests", mock.Anything, model.GetPaymentRequestsParams{
+		UserID:    1,
+		Direction: model.PaymentRequestsIncoming,
+	}).Return([]model.PaymentRequest{{ID: 1}}, nil)
+	mockRepo.On("GetPaymentRequests", mock.Anything, model.GetPaymentRequestsParams{
+		UserID:    1,
+		Direction: model.PaymentRequestsOutgoing,
+	}).Return([]model.PaymentRequest{{ID: 2}}, nil)
+	mockRepo.On("GetScheduledTransfers", mock.Anything, model.GetScheduledTransfersParams{UserID: 1}).
+		Return([]model.ScheduledTransfer{{ID: 3}}, nil)
+
+	export, err := userService.ExportUserData(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "user1", export.Profile.Username)
+	assert.Len(t, export.Transfers, 1)
+	assert.Len(t, export.PaymentRequests, 2)
+	assert.Len(t, export.ScheduledTransfers, 1)
+	assert.False(t, export.ExportedAt.IsZero())
+	mockRepo.AssertExpectations(t)
+}
+
+func TestExportUserData_DBError(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	mockRepo.On("ExportUserData", mock.Anything, uint(1)).Return(&model.UserExport{}, nil)
+	mockRepo.On("GetPaymentRequests", mock.Anything, mock.Anything).Return(nil, database.ErrQueryFailed)
+
+	_, err := userService.ExportUserData(context.Background(), 1)
+	assert.ErrorIs(t, err, service.ErrQueryFailed)
+	mockRepo.AssertNotCalled(t, "GetScheduledTransfers", mock.Anything, mock.Anything)
+}
+
+// После удаления кэш сбрасывается и у самого юзера, и у его контрагентов
+func TestDeleteUser_Success(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	deps := testDeps()
+	deps.Cache = cache.NewLRU(10, time.Minute)
+	userService := service.NewUserService(mockRepo, testLogger(), deps)
+
+	infoParams := model.GetUserInfoParams{ID: 2}
+	mockRepo.On("GetUserInfo", mock.Anything, infoParams).Return(&model.UserInfo{
+		CoinHistory: model.CoinHistory{Received: []model.ReceivedTransaction{{User: "user1", Amount: 10}}},
+	}, nil).Once()
+	mockRepo.On("GetUser", mock.Anything, uint(1)).Return(&model.User{ID: 1, Password: testPasswordHash}, nil)
+	mockRepo.On("DeleteUser", mock.Anything, uint(1)).Return([]uint{2}, nil)
+	mockRepo.On("GetUserInfo", mock.Anything, infoParams).Return(&model.UserInfo{
+		CoinHistory: model.CoinHistory{Received: []model.ReceivedTransaction{{User: model.DeletedUser, Amount: 10}}},
+	}, nil).Once()
+
+	_, _ = userService.GetUserInfo(context.Background(), infoParams)
+
+	err := userService.DeleteUser(context.Background(), model.DeleteUserParams{UserID: 1, Password: "test"})
+	assert.NoError(t, err)
+
+	info, err := userService.GetUserInfo(context.Background(), infoParams)
+	assert.NoError(t, err)
+	assert.Equal(t, model.DeletedUser, info.CoinHistory.Received[0].User)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestDeleteUser_InvalidPassword(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	mockRepo.On("GetUser", mock.Anything, uint(1)).Return(&model.User{ID: 1, Password: testPasswordHash}, nil)
+
+	err := userService.DeleteUser(context.Background(), model.DeleteUserParams{UserID: 1, Password: "wrong"})
+	assert.ErrorIs(t, err, service.ErrInvalidPassword)
+	mockRepo.AssertNotCalled(t, "DeleteUser", mock.Anything, mock.Anything)
+}
+
+func TestDeleteUser_NotFound(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	mockRepo.On("GetUser", mock.Anything, uint(1)).Return(&model.User{ID: 1, Password: testPasswordHash}, nil)
+	mockRepo.On("DeleteUser", mock.Anything, uint(1)).Return(nil, database.ErrNotFound)
+
+	err := userService.DeleteUser(context.Background(), model.DeleteUserParams{UserID: 1, Password: "test"})
+	assert.ErrorIs(t, err, service.ErrNotFound)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"go.uber.org/zap"
+)
+
+// userStatuses - статусы, которые ставит админ. deleted ставит только DeleteUser:
+// вместе со статусом он стирает имя, пароль, привязки и отменяет незакрытые переводы
+var userStatuses = []string{
+	model.UserStatusActive,
+	model.UserStatusFrozen,
+	model.UserStatusSuspended,
+}
+
+// CheckUserAccess возвращает ErrAccountSuspended, если юзеру нельзя пользоваться API,
+// и ErrSessionRevoked, если токен выдан до смены пароля (версия в токене устарела)
+// или его сессию отозвали. sessionID 0 - токен выдан до появления сессий.
+// Вызывается из AuthMiddleware на каждый запрос, поэтому без логирования успешного случая
+func (s *MerchService) CheckUserAccess(ctx context.Context, userID uint, tokenVersion int, sessionID uint) error {
+	access, err := s.repo.GetUserAccess(ctx, userID, sessionID)
+	if err != nil {
+		s.logger.Error("CheckUserAccess() -> GetUserAccess() request | error",
+			zap.Uint("user_id", userID),
+			zap.Uint("session_id", sessionID),
+			zap.Error(err),
+		)
+		return MapDBErrorToServiceError(err)
+	}
+
+	if !model.CanLogin(access.Status) {
+		return ErrAccountSuspended
+	}
+
+	if tokenVersion != access.TokenVersion || access.SessionRevoked {
+		return ErrSessionRevoked
+	}
+
+	return nil
+}
+
+// SetUserStatus меняет статус юзера от имени админа. Смена пишется в журнал вместе с причиной
+func (s *MerchService) SetUserStatus(ctx context.Context, params model.SetUserStatusParams) error {
+	s.logger.Info("SetUserStatus() request", zap.Any("params", params))
+
+	if !slices.Contains(userStatuses, params.Status) {
+		return fmt.Errorf("%w: %q", ErrInvalidUserStatus, params.Status)
+	}
+
+	if strings.TrimSpace(params.Reason) == "" {
+		return fmt.Errorf("%w: reason is required", ErrInvalidUserStatus)
+	}
+
+	// иначе админ может случайно отрезать доступ самому себе
+	if params.UserID == params.AdminID && params.Status != model.UserStatusActive {
+		return fmt.Errorf("%w: cannot change own status", ErrForbidden)
+	}
+
+	if err := s.repo.SetUserStatus(ctx, params); err != nil {
+		s.logger.Error("SetUserStatus() -> SetUserStatus() request | error",
+			zap.Any("params", params),
+			zap.Error(err),
+		)
+		return MapDBErrorToServiceError(err)
+	}
+
+	s.logger.Info("SetUserStatus() response", zap.Any("params", params))
+
+	return nil
+}
+
+func (s *MerchService) GetUserStatusHistory(ctx context.Context, userID uint) ([]model.UserStatusChange, error) {
+	s.logger.Info("GetUserStatusHistory() request", zap.Uint("user_id", userID))
+
+	history, err := s.repo.GetUserStatusHistory(ctx, userID)
+	if err != nil {
+		s.logger.Error("GetUserStatusHistory() -> GetUserStatusHistory() request | error",
+			zap.Uint("user_id", userID),
+			zap.Error(err),
+		)
+		return nil, MapDBErrorToServiceError(err)
+	}
+
+	return history, nil
+}
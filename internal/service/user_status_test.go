@@ -0,0 +1,127 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/0x0FACED/merch-shop/internal/database"
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/0x0FACED/merch-shop/internal/service"
+	"github.com/0x0FACED/merch-shop/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSetUserStatus_Success(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	params := model.SetUserStatusParams{UserID: 2, AdminID: 1, Status: model.UserStatusSuspended, Reason: "abuse"}
+	mockRepo.On("SetUserStatus", mock.Anything, params).Return(nil)
+
+	err := userService.SetUserStatus(context.Background(), params)
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSetUserStatus_Invalid(t *testing.T) {
+	cases := map[string]struct {
+		params model.SetUserStatusParams
+		err    error
+	}{
+		"unknown status": {
+			params: model.SetUserStatusParams{UserID: 2, AdminID: 1, Status: "banned", Reason: "abuse"},
+			err:    service.ErrInvalidUserStatus,
+		},
+		"deleted is only set by account deletion": {
+			params: model.SetUserStatusParams{UserID: 2, AdminID: 1, Status: model.UserStatusDeleted, Reason: "left the company"},
+			err:    service.ErrInvalidUserStatus,
+		},
+		"blank reason": {
+			params: model.SetUserStatusParams{UserID: 2, AdminID: 1, Status: model.UserStatusFrozen, Reason: "  "},
+			err:    service.ErrInvalidUserStatus,
+		},
+		"own status": {
+			params: model.SetUserStatusParams{UserID: 1, AdminID: 1, Status: model.UserStatusSuspended, Reason: "oops"},
+			err:    service.ErrForbidden,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			mockRepo := new(mocks.MockMerchRepository)
+			userService := newTestService(mockRepo)
+
+			err := userService.SetUserStatus(context.Background(), tc.params)
+			assert.ErrorIs(t, err, tc.err)
+			mockRepo.AssertNotCalled(t, "SetUserStatus", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestSetUserStatus_NotFound(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	params := model.SetUserStatusParams{UserID: 42, AdminID: 1, Status: model.UserStatusFrozen, Reason: "review"}
+	mockRepo.On("SetUserStatus", mock.Anything, params).Return(database.ErrNotFound)
+
+	err := userService.SetUserStatus(context.Background(), params)
+	assert.ErrorIs(t, err, service.ErrNotFound)
+}
+
+func TestCheckUserAccess(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	mockRepo.On("GetUserAccess", mock.Anything, uint(1), uint(0)).Return(&model.UserAccess{Status: model.UserStatusActive}, nil)
+	mockRepo.On("GetUserAccess", mock.Anything, uint(2), uint(0)).Return(&model.UserAccess{Status: model.UserStatusFrozen}, nil)
+	mockRepo.On("GetUserAccess", mock.Anything, uint(3), uint(0)).Return(&model.UserAccess{Status: model.UserStatusSuspended}, nil)
+	mockRepo.On("GetUserAccess", mock.Anything, uint(4), uint(0)).Return(&model.UserAccess{Status: model.UserStatusDeleted}, nil)
+
+	assert.NoError(t, userService.CheckUserAccess(context.Background(), 1, 0, 0))
+	// замороженный юзер API пользоваться может, запрещены только траты
+	assert.NoError(t, userService.CheckUserAccess(context.Background(), 2, 0, 0))
+	assert.ErrorIs(t, userService.CheckUserAccess(context.Background(), 3, 0, 0), service.ErrAccountSuspended)
+	assert.ErrorIs(t, userService.CheckUserAccess(context.Background(), 4, 0, 0), service.ErrAccountSuspended)
+}
+
+// Токен, выданный до смены пароля, больше не принимается
+func TestCheckUserAccess_RevokedToken(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	mockRepo.On("GetUserAccess", mock.Anything, uint(1), uint(0)).Return(&model.UserAccess{Status: model.UserStatusActive, TokenVersion: 2}, nil)
+
+	assert.ErrorIs(t, userService.CheckUserAccess(context.Background(), 1, 1, 0), service.ErrSessionRevoked)
+	assert.NoError(t, userService.CheckUserAccess(context.Background(), 1, 2, 0))
+}
+
+// Заблокированный юзер не может войти даже с верным паролем
+func TestAuthUser_Suspended(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	// хэш пароля "test", как в TestAuthUser_Success
+	params := model.AuthUserParams{Username: "testuser", Password: "test"}
+	mockRepo.On("AuthUser", mock.Anything, params).Return(&model.User{
+		ID:       1,
+		Username: "testuser",
+		Password: "$2a$04$sjoS1Bf2A30VG0Vt0LkQf..KmKQCHuS5wvDG5RFTCQ2F1EtVVmTcm",
+		Status:   model.UserStatusSuspended,
+	}, nil)
+
+	_, err := userService.AuthUser(context.Background(), params)
+	assert.ErrorIs(t, err, service.ErrAccountSuspended)
+}
+
+func TestSendCoin_RecipientNotActive(t *testing.T) {
+	mockRepo := new(mocks.MockMerchRepository)
+	userService := newTestService(mockRepo)
+
+	params := model.SendCoinParams{FromUser: 1, ToUser: "frozenuser", Amount: 100}
+	mockRepo.On("SendCoin", mock.Anything, params).Return(0, database.ErrRecipientNotActive)
+
+	err := userService.SendCoin(context.Background(), params)
+	assert.ErrorIs(t, err, service.ErrRecipientNotActive)
+}
@@ -0,0 +1,66 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/internal/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Тестовые векторы из приложения B RFC 6238 (SHA1), там 8 цифр - сравниваем последние 6
+func TestCode_RFC6238(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, tt := range tests {
+		code, err := totp.Code(secret, totp.Step(time.Unix(tt.unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, tt.code[2:], code, "time %d", tt.unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	now := time.Unix(1_700_000_000, 0)
+	code, err := totp.Code(secret, totp.Step(now))
+	require.NoError(t, err)
+
+	step, ok, err := totp.Validate(secret, code, now)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, totp.Step(now), step)
+
+	// соседний шаг принимается, через два - уже нет
+	_, ok, _ = totp.Validate(secret, code, now.Add(totp.Period))
+	assert.True(t, ok)
+	_, ok, _ = totp.Validate(secret, code, now.Add(2*totp.Period))
+	assert.False(t, ok)
+
+	_, ok, _ = totp.Validate(secret, "12345", now)
+	assert.False(t, ok)
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri := totp.ProvisioningURI("Merch Shop", "alice", "JBSWY3DPEHPK3PXP")
+
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Merch%20Shop:alice?"))
+	assert.Contains(t, uri, "secret=JBSWY3DPEHPK3PXP")
+	assert.Contains(t, uri, "issuer=Merch+Shop")
+}
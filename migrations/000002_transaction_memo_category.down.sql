@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS shop.idx_transactions_category;
+
+ALTER TABLE shop.transactions
+    DROP COLUMN IF EXISTS category,
+    DROP COLUMN IF EXISTS memo;
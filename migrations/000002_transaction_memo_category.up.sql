@@ -0,0 +1,8 @@
+-- Комментарий к переводу ("спасибо за ревью") и категория из настраиваемого набора (kudos, reimbursement, gift).
+-- Оба поля опциональные, старые транзакции остаются без них.
+-- Набор категорий проверяется на уровне сервиса, потому что он задается в конфиге, а не в базе
+ALTER TABLE shop.transactions
+    ADD COLUMN IF NOT EXISTS memo VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS category VARCHAR(32);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_category ON shop.transactions(category);
@@ -0,0 +1,20 @@
+-- Запросы монет: from_user_id просит у to_user_id перевести ему amount монет.
+-- Пока запрос в статусе pending, получатель может его принять (тогда выполняется обычный перевод)
+-- или отклонить, а автор - отменить. После expires_at запрос считается протухшим (expired),
+-- даже если статус в таблице еще pending
+CREATE TABLE IF NOT EXISTS shop.payment_requests (
+    id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    from_user_id INTEGER NOT NULL REFERENCES shop.users(id) ON DELETE CASCADE,
+    to_user_id INTEGER NOT NULL REFERENCES shop.users(id) ON DELETE CASCADE,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    memo VARCHAR(255),
+    status VARCHAR(16) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'accepted', 'declined', 'cancelled', 'expired')),
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    resolved_at TIMESTAMP,
+    CHECK (from_user_id <> to_user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_requests_from_user ON shop.payment_requests(from_user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_payment_requests_to_user ON shop.payment_requests(to_user_id, created_at DESC);
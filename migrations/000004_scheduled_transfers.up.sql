@@ -0,0 +1,37 @@
+-- Отложенные и регулярные переводы. Если schedule NULL, то перевод разовый и выполняется в next_run_at,
+-- иначе schedule - cron выражение (5 полей, UTC), а next_run_at - время следующего запуска.
+-- lease_owner/lease_until - аренда: инстанс сервиса, который взял перевод на выполнение.
+-- Пока аренда не истекла, другие инстансы этот перевод не трогают.
+-- Здесь используем TIMESTAMPTZ, чтобы время аренды не зависело от часового пояса инстансов
+CREATE TABLE IF NOT EXISTS shop.scheduled_transfers (
+    id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    from_user_id INTEGER NOT NULL REFERENCES shop.users(id) ON DELETE CASCADE,
+    to_user_id INTEGER NOT NULL REFERENCES shop.users(id) ON DELETE CASCADE,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    memo VARCHAR(255),
+    category VARCHAR(32),
+    schedule VARCHAR(255),
+    next_run_at TIMESTAMPTZ,
+    status VARCHAR(16) NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'completed', 'cancelled', 'stopped')),
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    lease_owner VARCHAR(255),
+    lease_until TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Частичный индекс под выборку планировщика
+CREATE INDEX IF NOT EXISTS idx_scheduled_transfers_due ON shop.scheduled_transfers(next_run_at) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_scheduled_transfers_from_user ON shop.scheduled_transfers(from_user_id);
+
+-- История запусков. Уникальность (scheduled_transfer_id, scheduled_for) гарантирует,
+-- что один и тот же запуск не выполнится дважды, даже если аренда истекла посреди выполнения
+CREATE TABLE IF NOT EXISTS shop.scheduled_transfer_runs (
+    id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    scheduled_transfer_id INTEGER NOT NULL REFERENCES shop.scheduled_transfers(id) ON DELETE CASCADE,
+    scheduled_for TIMESTAMPTZ NOT NULL,
+    executed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    status VARCHAR(16) NOT NULL CHECK (status IN ('success', 'failed')),
+    error TEXT,
+    UNIQUE (scheduled_transfer_id, scheduled_for)
+);
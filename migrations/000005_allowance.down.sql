@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS shop.allowance_grants;
+
+ALTER TABLE shop.transactions
+    DROP COLUMN IF EXISTS kind;
@@ -0,0 +1,16 @@
+-- Тип записи в истории: обычный перевод между юзерами или системная операция.
+-- У системных операций from_user_id (или to_user_id) NULL, в истории они показываются от имени @system
+ALTER TABLE shop.transactions
+    ADD COLUMN IF NOT EXISTS kind VARCHAR(16) NOT NULL DEFAULT 'transfer';
+
+-- Начисления ежемесячной (или еженедельной) нормы монет.
+-- Первичный ключ (user_id, period) делает начисление идемпотентным: за один период юзер
+-- получит монеты только один раз, сколько бы раз ни запускалась задача.
+-- amount может быть 0, если баланс уже упирается в максимум - период все равно отмечается
+CREATE TABLE IF NOT EXISTS shop.allowance_grants (
+    user_id INTEGER REFERENCES shop.users(id) ON DELETE CASCADE,
+    period VARCHAR(16) NOT NULL,
+    amount INTEGER NOT NULL CHECK (amount >= 0),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, period)
+);
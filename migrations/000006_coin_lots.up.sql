@@ -0,0 +1,22 @@
+-- Монеты хранятся партиями (lots): сколько монет и когда юзер их получил.
+-- Сумма remaining по юзеру всегда равна shop.wallets.balance, баланс остается для быстрых проверок.
+-- Тратятся сначала самые старые партии (FIFO), а партии старше срока жизни монет сгорают.
+-- Срок жизни задается в конфиге, поэтому храним только дату получения, а не дату сгорания.
+-- Все изменения партий юзера идут после UPDATE его кошелька в той же транзакции,
+-- поэтому блокировка строки shop.wallets служит мьютексом и для партий
+CREATE TABLE IF NOT EXISTS shop.coin_lots (
+    id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES shop.users(id) ON DELETE CASCADE,
+    remaining INTEGER NOT NULL CHECK (remaining >= 0),
+    acquired_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_coin_lots_user_acquired ON shop.coin_lots(user_id, acquired_at, id);
+CREATE INDEX IF NOT EXISTS idx_coin_lots_acquired ON shop.coin_lots(acquired_at);
+
+-- Историю получения монет до этой миграции восстановить нельзя,
+-- поэтому текущие балансы считаем полученными в момент миграции
+INSERT INTO shop.coin_lots (user_id, remaining, acquired_at)
+SELECT user_id, balance, NOW()
+FROM shop.wallets
+WHERE balance > 0;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS shop.idx_transactions_from_created;
+
+DROP TABLE IF EXISTS shop.role_transfer_limits;
+
+ALTER TABLE shop.users DROP COLUMN IF EXISTS role;
@@ -0,0 +1,15 @@
+-- Роль юзера. Пока используется только для переопределения лимитов переводов
+ALTER TABLE shop.users ADD COLUMN IF NOT EXISTS role VARCHAR(32) NOT NULL DEFAULT 'user';
+
+-- Переопределение лимитов переводов для роли. Лимиты по умолчанию задаются в конфиге,
+-- NULL в колонке значит "как в конфиге", 0 - без ограничения
+CREATE TABLE IF NOT EXISTS shop.role_transfer_limits (
+    role VARCHAR(32) PRIMARY KEY,
+    max_single_transfer INTEGER CHECK (max_single_transfer >= 0),
+    daily_total INTEGER CHECK (daily_total >= 0),
+    weekly_total INTEGER CHECK (weekly_total >= 0),
+    daily_recipients INTEGER CHECK (daily_recipients >= 0)
+);
+
+-- для подсчета отправленного за последние сутки/неделю внутри транзакции перевода
+CREATE INDEX IF NOT EXISTS idx_transactions_from_created ON shop.transactions(from_user_id, created_at);
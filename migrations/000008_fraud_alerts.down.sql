@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS shop.idx_transactions_created;
+
+DROP TABLE IF EXISTS shop.fraud_alerts;
+
+ALTER TABLE shop.users DROP COLUMN IF EXISTS status;
@@ -0,0 +1,28 @@
+-- Статус юзера. frozen - юзер может входить и получать монеты, но не может их тратить
+-- (переводы, покупки), пока админ не разберется
+ALTER TABLE shop.users ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'active'
+    CHECK (status IN ('active', 'frozen'));
+
+-- Алерты антифрода. user_ids - юзеры, к которым относится находка (их же замораживаем
+-- при автозаморозке), details - подробности правила (путь кольца, отправители и т.д.).
+-- fingerprint определяет "ту же самую" находку: пока по ней есть открытый алерт, новый не создается
+CREATE TABLE IF NOT EXISTS shop.fraud_alerts (
+    id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    rule VARCHAR(32) NOT NULL,
+    fingerprint VARCHAR(255) NOT NULL,
+    user_ids INTEGER[] NOT NULL,
+    details JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(16) NOT NULL DEFAULT 'open'
+        CHECK (status IN ('open', 'confirmed', 'dismissed')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    reviewed_by INTEGER REFERENCES shop.users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMP,
+    review_note VARCHAR(1024)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_fraud_alerts_open_fingerprint ON shop.fraud_alerts(fingerprint) WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS idx_fraud_alerts_fingerprint ON shop.fraud_alerts(fingerprint, reviewed_at);
+CREATE INDEX IF NOT EXISTS idx_fraud_alerts_status ON shop.fraud_alerts(status, created_at DESC);
+
+-- правила антифрода смотрят на переводы за последнее окно
+CREATE INDEX IF NOT EXISTS idx_transactions_created ON shop.transactions(created_at);
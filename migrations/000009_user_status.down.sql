@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS shop.user_status_audit;
+
+ALTER TABLE shop.users DROP COLUMN IF EXISTS status_changed_at;
+ALTER TABLE shop.users DROP COLUMN IF EXISTS status_reason;
+
+UPDATE shop.users SET status = 'frozen' WHERE status IN ('suspended', 'deleted');
+
+ALTER TABLE shop.users DROP CONSTRAINT IF EXISTS users_status_check;
+ALTER TABLE shop.users ADD CONSTRAINT users_status_check
+    CHECK (status IN ('active', 'frozen'));
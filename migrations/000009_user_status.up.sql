@@ -0,0 +1,24 @@
+-- Полная модель статусов юзера:
+--   active    - все можно
+--   frozen    - входить можно, тратить и получать монеты нельзя
+--   suspended - входить нельзя, все токены перестают работать
+--   deleted   - мягкое удаление, вместо DELETE строки, чтобы не терять кошелек, инвентарь и историю
+ALTER TABLE shop.users DROP CONSTRAINT IF EXISTS users_status_check;
+ALTER TABLE shop.users ADD CONSTRAINT users_status_check
+    CHECK (status IN ('active', 'frozen', 'suspended', 'deleted'));
+
+ALTER TABLE shop.users ADD COLUMN IF NOT EXISTS status_reason VARCHAR(1024);
+ALTER TABLE shop.users ADD COLUMN IF NOT EXISTS status_changed_at TIMESTAMP;
+
+-- Журнал смены статусов. changed_by NULL - статус сменила система (например, автозаморозка антифродом)
+CREATE TABLE IF NOT EXISTS shop.user_status_audit (
+    id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES shop.users(id) ON DELETE CASCADE,
+    old_status VARCHAR(16) NOT NULL,
+    new_status VARCHAR(16) NOT NULL,
+    reason VARCHAR(1024),
+    changed_by INTEGER REFERENCES shop.users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_status_audit_user ON shop.user_status_audit(user_id, created_at DESC);
@@ -0,0 +1,3 @@
+DROP FUNCTION IF EXISTS shop.display_username(TEXT, TEXT);
+
+DROP TABLE IF EXISTS shop.purchases;
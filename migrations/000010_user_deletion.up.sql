@@ -0,0 +1,19 @@
+-- История покупок. Раньше покупка только увеличивала количество в shop.inventory,
+-- поэтому выгрузить, что и когда юзер покупал, было нельзя. Цена сохраняется на момент покупки
+CREATE TABLE IF NOT EXISTS shop.purchases (
+    id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    user_id INTEGER REFERENCES shop.users(id) ON DELETE SET NULL,
+    item_id INTEGER REFERENCES shop.items(id) ON DELETE SET NULL,
+    price INTEGER NOT NULL CHECK (price >= 0),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_purchases_user ON shop.purchases(user_id, created_at);
+
+-- Удаление аккаунта юзером не удаляет строку из shop.users (иначе рвется история переводов),
+-- а ставит статус deleted и затирает личные данные: username становится '@deleted:<id>'
+-- (не alphanum, поэтому не пересечется с реальными юзерами и освобождает старое имя),
+-- пароль обнуляется. Другим юзерам такой контрагент показывается как '@deleted' (model.DeletedUser)
+CREATE OR REPLACE FUNCTION shop.display_username(username TEXT, status TEXT) RETURNS TEXT AS $$
+    SELECT CASE WHEN status = 'deleted' THEN '@deleted' ELSE username END
+$$ LANGUAGE SQL IMMUTABLE;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS shop.password_reset_tokens;
+
+ALTER TABLE shop.users DROP COLUMN IF EXISTS token_version;
@@ -0,0 +1,19 @@
+-- Версия токенов юзера зашита в JWT (claim ver), AuthMiddleware сверяет ее с базой на каждый запрос.
+-- Смена пароля увеличивает версию, поэтому все выданные до этого токены сразу перестают работать.
+-- В токенах, выданных до этой миграции, claim нет - они считаются версией 0
+ALTER TABLE shop.users ADD COLUMN IF NOT EXISTS token_version INTEGER NOT NULL DEFAULT 0;
+
+-- Одноразовые токены сброса пароля, которые выдает админ. Сам токен уходит юзеру письмом
+-- (пока через outbox), в базе хранится только его sha256. У юзера одновременно действует
+-- не больше одного токена: при выдаче нового и при смене пароля неиспользованные удаляются
+CREATE TABLE IF NOT EXISTS shop.password_reset_tokens (
+    id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES shop.users(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_by INTEGER REFERENCES shop.users(id) ON DELETE SET NULL, -- админ, выдавший токен
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_user ON shop.password_reset_tokens(user_id) WHERE used_at IS NULL;
@@ -0,0 +1,2 @@
+-- Сервисные аккаунты остаются: у них кошельки и история переводов. Без ключей войти под ними нельзя
+DROP TABLE IF EXISTS shop.api_keys;
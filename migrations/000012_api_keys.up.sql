@@ -0,0 +1,22 @@
+-- Сервисные аккаунты (боты, интеграции) - обычные юзеры с ролью service и пустым хэшем пароля,
+-- поэтому войти по паролю под ними нельзя. Ходят в API только по ключам. Свой кошелек у них есть,
+-- лимиты переводов для них можно задать в shop.role_transfer_limits через роль service.
+--
+-- Ключ выглядит как msk_<48 hex>. В базе хранится sha256 всего ключа и его начало (prefix),
+-- чтобы админ мог отличить ключи в списке. Сам ключ показывается один раз при создании.
+-- scopes - что можно делать ключом (см. model.APIKeyScopes), NULL в expires_at - бессрочный ключ
+CREATE TABLE IF NOT EXISTS shop.api_keys (
+    id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES shop.users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    prefix VARCHAR(16) NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL,
+    created_by INTEGER REFERENCES shop.users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user ON shop.api_keys(user_id, created_at DESC);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS shop.oidc_login_states;
+
+DROP TABLE IF EXISTS shop.user_identities;
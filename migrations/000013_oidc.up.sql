@@ -0,0 +1,26 @@
+-- Вход через корпоративный SSO (OpenID Connect). Юзер IdP привязывается к юзеру магазина
+-- по паре issuer + subject, email хранится для справки и обновляется при каждом входе.
+-- Юзер, созданный при первом входе через SSO, получает пустой хэш пароля, поэтому войти
+-- по паролю под ним нельзя, пока админ не выдаст токен сброса
+CREATE TABLE IF NOT EXISTS shop.user_identities (
+    id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES shop.users(id) ON DELETE CASCADE,
+    issuer TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    email TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_login_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (issuer, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user ON shop.user_identities(user_id);
+
+-- Начатые входы через SSO: между редиректом на IdP и возвратом юзера храним PKCE verifier
+-- и nonce. state из ссылки хранится только хэшем и используется один раз
+CREATE TABLE IF NOT EXISTS shop.oidc_login_states (
+    state_hash TEXT PRIMARY KEY,
+    code_verifier TEXT NOT NULL,
+    nonce TEXT NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
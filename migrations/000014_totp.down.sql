@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS shop.totp_recovery_codes;
+
+DROP TABLE IF EXISTS shop.user_totp;
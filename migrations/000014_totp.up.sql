@@ -0,0 +1,25 @@
+-- Двухфакторная аутентификация по TOTP. Секрет хранится зашифрованным (AES-GCM, ключ
+-- AUTH_TOTP_KEY из конфига). Пока confirmed_at пустой, 2FA не включена: юзер еще не ввел
+-- первый код из приложения. last_step - шаг последнего принятого кода, чтобы тот же код
+-- нельзя было использовать повторно. После нескольких неверных кодов подряд проверка
+-- блокируется до locked_until
+CREATE TABLE IF NOT EXISTS shop.user_totp (
+    user_id INTEGER PRIMARY KEY REFERENCES shop.users(id) ON DELETE CASCADE,
+    secret_encrypted TEXT NOT NULL,
+    confirmed_at TIMESTAMP,
+    last_step BIGINT NOT NULL DEFAULT 0,
+    failed_attempts INTEGER NOT NULL DEFAULT 0,
+    locked_until TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Одноразовые коды восстановления на случай потери телефона, хранится только sha256.
+-- Выдаются при включении 2FA, старые при этом удаляются
+CREATE TABLE IF NOT EXISTS shop.totp_recovery_codes (
+    id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES shop.users(id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (user_id, code_hash)
+);
@@ -0,0 +1,16 @@
+-- Сессии: каждый выданный JWT привязан к записи здесь (claim sid), так юзер видит,
+-- где он залогинен, и может выйти на отдельном устройстве. expires_at совпадает с exp токена,
+-- после него запись больше не нужна. last_seen_at обновляется не чаще раза в минуту.
+-- Отозванная сессия хранится до expires_at, чтобы AuthMiddleware отклонял ее токен
+CREATE TABLE IF NOT EXISTS shop.sessions (
+    id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES shop.users(id) ON DELETE CASCADE,
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user ON shop.sessions(user_id, created_at DESC);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS shop.order_items;
+
+DROP TABLE IF EXISTS shop.orders;
+
+DROP TABLE IF EXISTS shop.cart_items;
@@ -0,0 +1,30 @@
+-- Корзина на сервере: строка - предмет и количество. Цена в корзине не хранится,
+-- она берется из shop.items в момент оформления заказа
+CREATE TABLE IF NOT EXISTS shop.cart_items (
+    user_id INTEGER NOT NULL REFERENCES shop.users(id) ON DELETE CASCADE,
+    item_id INTEGER NOT NULL REFERENCES shop.items(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    added_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, item_id)
+);
+
+-- Заказ - оформленная корзина, оплачивается одним списанием. total - сумма всех строк
+CREATE TABLE IF NOT EXISTS shop.orders (
+    id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    user_id INTEGER REFERENCES shop.users(id) ON DELETE SET NULL,
+    total INTEGER NOT NULL CHECK (total >= 0),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_orders_user ON shop.orders(user_id, created_at);
+
+-- Строки заказа, price - цена одной штуки на момент заказа
+CREATE TABLE IF NOT EXISTS shop.order_items (
+    id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    order_id INTEGER NOT NULL REFERENCES shop.orders(id) ON DELETE CASCADE,
+    item_id INTEGER REFERENCES shop.items(id) ON DELETE SET NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    price INTEGER NOT NULL CHECK (price >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_items_order ON shop.order_items(order_id);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS shop.idx_order_items_item;
+
+ALTER TABLE shop.items
+    DROP CONSTRAINT IF EXISTS items_sale_window_check,
+    DROP COLUMN IF EXISTS sale_ends_at,
+    DROP COLUMN IF EXISTS sale_starts_at,
+    DROP COLUMN IF EXISTS per_user_limit,
+    DROP COLUMN IF EXISTS stock;
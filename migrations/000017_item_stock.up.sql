@@ -0,0 +1,17 @@
+-- Ограничения продажи предмета, NULL в любой колонке - ограничения нет.
+-- stock - сколько штук осталось на складе, уменьшается при каждой покупке.
+-- per_user_limit - сколько штук один юзер может купить за все время.
+-- sale_starts_at/sale_ends_at - окно продажи лимитированного дропа, конец не включается.
+-- Границы - моменты времени, а не время на часах, иначе окно сдвигается с часовым поясом сессии
+ALTER TABLE shop.items
+    ADD COLUMN IF NOT EXISTS stock INTEGER CHECK (stock >= 0),
+    ADD COLUMN IF NOT EXISTS per_user_limit INTEGER CHECK (per_user_limit > 0),
+    ADD COLUMN IF NOT EXISTS sale_starts_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS sale_ends_at TIMESTAMPTZ;
+
+ALTER TABLE shop.items
+    DROP CONSTRAINT IF EXISTS items_sale_window_check,
+    ADD CONSTRAINT items_sale_window_check CHECK (sale_starts_at < sale_ends_at);
+
+-- для лимита на юзера считаем, сколько штук предмета он уже купил
+CREATE INDEX IF NOT EXISTS idx_order_items_item ON shop.order_items(item_id);
@@ -0,0 +1,28 @@
+ALTER TABLE shop.purchases DROP COLUMN IF EXISTS variant_id;
+
+ALTER TABLE shop.order_items DROP COLUMN IF EXISTS variant_id;
+
+-- количества разных вариантов одного предмета складываются обратно в одну строку
+ALTER TABLE shop.cart_items DROP CONSTRAINT IF EXISTS cart_items_pkey;
+ALTER TABLE shop.cart_items DROP COLUMN IF EXISTS variant_id;
+WITH merged AS (
+    DELETE FROM shop.cart_items RETURNING user_id, item_id, quantity, added_at
+)
+INSERT INTO shop.cart_items (user_id, item_id, quantity, added_at)
+SELECT user_id, item_id, SUM(quantity), MIN(added_at) FROM merged GROUP BY user_id, item_id;
+ALTER TABLE shop.cart_items ADD PRIMARY KEY (user_id, item_id);
+
+ALTER TABLE shop.inventory DROP CONSTRAINT IF EXISTS inventory_pkey;
+ALTER TABLE shop.inventory DROP COLUMN IF EXISTS variant_id;
+WITH merged AS (
+    DELETE FROM shop.inventory RETURNING user_id, item_id, quantity
+)
+INSERT INTO shop.inventory (user_id, item_id, quantity)
+SELECT user_id, item_id, SUM(quantity) FROM merged GROUP BY user_id, item_id;
+ALTER TABLE shop.inventory ADD PRIMARY KEY (user_id, item_id);
+
+DROP TRIGGER IF EXISTS items_default_variant ON shop.items;
+
+DROP FUNCTION IF EXISTS shop.create_default_item_variant();
+
+DROP TABLE IF EXISTS shop.item_variants;
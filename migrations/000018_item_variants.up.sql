@@ -0,0 +1,65 @@
+-- Варианты предмета (размер, цвет) со своим SKU, надбавкой к цене и складом.
+-- У каждого предмета есть вариант по умолчанию без размера и цвета, его SKU - имя предмета.
+-- Он нужен предметам без вариантов и покупкам, сделанным до появления вариантов.
+-- price_delta прибавляется к цене предмета, может быть отрицательной.
+-- stock - склад варианта, NULL - без ограничения. Склад предмета из shop.items действует отдельно.
+-- SKU уникален в пределах предмета: SKU по умолчанию совпадает с именем предмета
+-- и не должен мешать другим предметам
+CREATE TABLE IF NOT EXISTS shop.item_variants (
+    id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    item_id INTEGER NOT NULL REFERENCES shop.items(id) ON DELETE CASCADE,
+    sku VARCHAR(255) NOT NULL,
+    size VARCHAR(32) NOT NULL DEFAULT '',
+    color VARCHAR(32) NOT NULL DEFAULT '',
+    price_delta INTEGER NOT NULL DEFAULT 0,
+    stock INTEGER CHECK (stock >= 0),
+    is_default BOOLEAN NOT NULL DEFAULT FALSE,
+    UNIQUE (item_id, sku)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_item_variants_default ON shop.item_variants(item_id) WHERE is_default;
+
+INSERT INTO shop.item_variants (item_id, sku, is_default)
+SELECT id, name, TRUE FROM shop.items
+ON CONFLICT DO NOTHING;
+
+-- новые предметы сразу получают вариант по умолчанию
+CREATE OR REPLACE FUNCTION shop.create_default_item_variant() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO shop.item_variants (item_id, sku, is_default) VALUES (NEW.id, NEW.name, TRUE);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER items_default_variant
+    AFTER INSERT ON shop.items
+    FOR EACH ROW EXECUTE FUNCTION shop.create_default_item_variant();
+
+-- инвентарь и корзина теперь по вариантам, старые строки переходят на вариант по умолчанию.
+-- item_id остается, чтобы не джойнить варианты ради предмета
+ALTER TABLE shop.inventory ADD COLUMN IF NOT EXISTS variant_id INTEGER REFERENCES shop.item_variants(id) ON DELETE CASCADE;
+UPDATE shop.inventory inv SET variant_id = v.id
+FROM shop.item_variants v
+WHERE v.item_id = inv.item_id AND v.is_default;
+ALTER TABLE shop.inventory ALTER COLUMN variant_id SET NOT NULL;
+ALTER TABLE shop.inventory DROP CONSTRAINT IF EXISTS inventory_pkey;
+ALTER TABLE shop.inventory ADD PRIMARY KEY (user_id, variant_id);
+
+ALTER TABLE shop.cart_items ADD COLUMN IF NOT EXISTS variant_id INTEGER REFERENCES shop.item_variants(id) ON DELETE CASCADE;
+UPDATE shop.cart_items c SET variant_id = v.id
+FROM shop.item_variants v
+WHERE v.item_id = c.item_id AND v.is_default;
+ALTER TABLE shop.cart_items ALTER COLUMN variant_id SET NOT NULL;
+ALTER TABLE shop.cart_items DROP CONSTRAINT IF EXISTS cart_items_pkey;
+ALTER TABLE shop.cart_items ADD PRIMARY KEY (user_id, variant_id);
+
+-- в истории покупок и заказов вариант может пропасть вместе с предметом, как и item_id
+ALTER TABLE shop.order_items ADD COLUMN IF NOT EXISTS variant_id INTEGER REFERENCES shop.item_variants(id) ON DELETE SET NULL;
+UPDATE shop.order_items oi SET variant_id = v.id
+FROM shop.item_variants v
+WHERE v.item_id = oi.item_id AND v.is_default;
+
+ALTER TABLE shop.purchases ADD COLUMN IF NOT EXISTS variant_id INTEGER REFERENCES shop.item_variants(id) ON DELETE SET NULL;
+UPDATE shop.purchases p SET variant_id = v.id
+FROM shop.item_variants v
+WHERE v.item_id = p.item_id AND v.is_default;
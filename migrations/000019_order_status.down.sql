@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS shop.order_status_audit;
+
+DROP INDEX IF EXISTS shop.idx_orders_status;
+
+ALTER TABLE shop.orders
+    DROP CONSTRAINT IF EXISTS orders_status_check,
+    DROP COLUMN IF EXISTS status_changed_at,
+    DROP COLUMN IF EXISTS status;
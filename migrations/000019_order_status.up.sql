@@ -0,0 +1,28 @@
+-- Выдача заказов офис-менеджером:
+--   placed           - оплачен, ждет сборки
+--   ready_for_pickup - собран, можно забирать
+--   delivered        - выдан, статус окончательный
+--   cancelled        - отменен, монеты вернулись, предметы ушли из инвентаря и вернулись на склад
+-- Заказы, оформленные до этой миграции, считаются placed
+ALTER TABLE shop.orders
+    ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'placed',
+    ADD COLUMN IF NOT EXISTS status_changed_at TIMESTAMP;
+
+ALTER TABLE shop.orders DROP CONSTRAINT IF EXISTS orders_status_check;
+ALTER TABLE shop.orders ADD CONSTRAINT orders_status_check
+    CHECK (status IN ('placed', 'ready_for_pickup', 'delivered', 'cancelled'));
+
+CREATE INDEX IF NOT EXISTS idx_orders_status ON shop.orders(status, created_at);
+
+-- Журнал смены статусов заказа, как shop.user_status_audit
+CREATE TABLE IF NOT EXISTS shop.order_status_audit (
+    id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    order_id INTEGER NOT NULL REFERENCES shop.orders(id) ON DELETE CASCADE,
+    old_status VARCHAR(16) NOT NULL,
+    new_status VARCHAR(16) NOT NULL,
+    reason VARCHAR(1024),
+    changed_by INTEGER REFERENCES shop.users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_status_audit_order ON shop.order_status_audit(order_id, created_at DESC);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS shop.return_request_items;
+DROP TABLE IF EXISTS shop.return_requests;
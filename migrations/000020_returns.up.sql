@@ -0,0 +1,33 @@
+-- Возвраты: юзер просит вернуть штуки варианта из выданных заказов, админ одобряет или отклоняет.
+-- amount - сколько вернется по ценам покупки, refund - сколько вернули на самом деле
+-- (админ может вернуть меньше, например за испорченный предмет)
+CREATE TABLE IF NOT EXISTS shop.return_requests (
+    id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES shop.users(id) ON DELETE CASCADE,
+    item_id INTEGER REFERENCES shop.items(id) ON DELETE SET NULL,
+    variant_id INTEGER REFERENCES shop.item_variants(id) ON DELETE SET NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    amount INTEGER NOT NULL CHECK (amount >= 0),
+    refund INTEGER CHECK (refund >= 0 AND refund <= amount),
+    reason VARCHAR(1024) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    review_note VARCHAR(1024),
+    reviewed_by INTEGER REFERENCES shop.users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_return_requests_user ON shop.return_requests(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_return_requests_status ON shop.return_requests(status, created_at);
+
+-- Из каких строк заказов возвращаются штуки. Штуки в pending и approved возвратах
+-- второй раз вернуть нельзя, отклоненный возврат их освобождает
+CREATE TABLE IF NOT EXISTS shop.return_request_items (
+    return_id INTEGER NOT NULL REFERENCES shop.return_requests(id) ON DELETE CASCADE,
+    order_item_id INTEGER NOT NULL REFERENCES shop.order_items(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    price INTEGER NOT NULL CHECK (price >= 0),
+    PRIMARY KEY (return_id, order_item_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_return_request_items_order_item ON shop.return_request_items(order_item_id);
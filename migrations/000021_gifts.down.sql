@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS shop.gifts;
+
+ALTER TABLE shop.orders DROP COLUMN IF EXISTS recipient_id;
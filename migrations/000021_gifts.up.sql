@@ -0,0 +1,19 @@
+-- Заказ в подарок: платит user_id, предметы получает recipient_id. NULL - покупатель сам себе
+ALTER TABLE shop.orders
+    ADD COLUMN IF NOT EXISTS recipient_id INTEGER REFERENCES shop.users(id) ON DELETE SET NULL;
+
+-- Подарки предметов. order_id - заказ, которым подарок куплен, NULL - отдан из инвентаря
+CREATE TABLE IF NOT EXISTS shop.gifts (
+    id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    from_user_id INTEGER REFERENCES shop.users(id) ON DELETE SET NULL,
+    to_user_id INTEGER REFERENCES shop.users(id) ON DELETE SET NULL,
+    item_id INTEGER REFERENCES shop.items(id) ON DELETE SET NULL,
+    variant_id INTEGER REFERENCES shop.item_variants(id) ON DELETE SET NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    order_id INTEGER REFERENCES shop.orders(id) ON DELETE SET NULL,
+    message VARCHAR(1024),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_gifts_from_user ON shop.gifts(from_user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_gifts_to_user ON shop.gifts(to_user_id, created_at);
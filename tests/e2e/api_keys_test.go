@@ -0,0 +1,87 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAPIKeys проверяет ключ сервисного аккаунта: доступ только по скоупам и отзыв
+func TestAPIKeys(t *testing.T) {
+	ctx := context.Background()
+
+	adminToken := authUser(t, "keysadmin", "password", testServer)
+	token := authUser(t, "keysuser", "password", testServer)
+
+	_, err := testDB.Pool().Exec(ctx, `UPDATE shop.users SET role = 'admin' WHERE username = 'keysadmin'`)
+	assert.NoError(t, err)
+
+	// не админ сервисный аккаунт создать не может
+	rec := postJSON("/api/admin/serviceAccounts", token, map[string]string{"name": "keysbot"})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = postJSON("/api/admin/serviceAccounts", adminToken, map[string]string{"name": "keysbot"})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	var account model.ServiceAccount
+	_ = json.Unmarshal(rec.Body.Bytes(), &account)
+
+	rec = postJSON("/api/admin/serviceAccounts", adminToken, map[string]string{"name": "keysbot"})
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	keysPath := fmt.Sprintf("/api/admin/serviceAccounts/%d/apiKeys", account.ID)
+
+	rec = postJSON(keysPath, adminToken, map[string]any{"name": "deploy", "scopes": []string{"admin:write"}})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = postJSON(keysPath, adminToken, map[string]any{"name": "deploy", "scopes": []string{model.ScopeTransfersWrite}})
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	var key model.CreatedAPIKey
+	_ = json.Unmarshal(rec.Body.Bytes(), &key)
+	assert.Contains(t, key.Key, model.APIKeyPrefix)
+
+	// ключ у сервисного аккаунта нельзя выдать обычному юзеру
+	var userID uint
+	err = testDB.Pool().QueryRow(ctx, `SELECT id FROM shop.users WHERE username = 'keysuser'`).Scan(&userID)
+	assert.NoError(t, err)
+
+	rec = postJSON(fmt.Sprintf("/api/admin/serviceAccounts/%d/apiKeys", userID), adminToken, map[string]any{"scopes": []string{model.ScopeUsersRead}})
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	// бесплатных монет сервисный аккаунт не получает
+	sendCoins(t, token, "keysbot", 100)
+
+	rec = sendCoinRaw(key.Key, "keysuser", 30)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, uint(930), getCoins(t, token))
+
+	// скоупа users:read нет
+	req := httptest.NewRequest(http.MethodGet, "/api/info", nil)
+	req.Header.Set("Authorization", "Bearer "+key.Key)
+	rec = httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	// админские ручки ключам недоступны
+	rec = postJSON("/api/admin/serviceAccounts", key.Key, map[string]string{"name": "otherbot"})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = sendCoinRaw(model.APIKeyPrefix+"0000", "keysuser", 1)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req = httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/admin/apiKeys/%d", key.ID), nil)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	rec = httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = sendCoinRaw(key.Key, "keysuser", 1)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
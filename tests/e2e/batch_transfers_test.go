@@ -0,0 +1,111 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sendCoinBatch(token string, transfers []map[string]any) *httptest.ResponseRecorder {
+	reqBody, _ := json.Marshal(map[string]any{"transfers": transfers})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/sendCoin/batch", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rec := httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	return rec
+}
+
+func getCoins(t *testing.T, token string) uint {
+	req := httptest.NewRequest(http.MethodGet, "/api/info", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rec := httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var info struct {
+		Coins uint `json:"coins"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &info)
+	return info.Coins
+}
+
+type batchResponse struct {
+	Results []struct {
+		ToUser string `json:"toUser"`
+		Status string `json:"status"`
+	} `json:"results"`
+	Errors string `json:"errors"`
+}
+
+// TestSendCoinBatch_Success проверяет, что проходят все переводы пачки
+func TestSendCoinBatch_Success(t *testing.T) {
+	authUser(t, "batchalice", "password", testServer)
+	authUser(t, "batchbob", "password", testServer)
+	token := authUser(t, "batchlead", "password", testServer)
+
+	rec := sendCoinBatch(token, []map[string]any{
+		{"toUser": "batchalice", "amount": 100, "memo": "bonus"},
+		{"toUser": "batchbob", "amount": 150},
+	})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var resp batchResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	if assert.Len(t, resp.Results, 2) {
+		assert.Equal(t, "sent", resp.Results[0].Status)
+		assert.Equal(t, "sent", resp.Results[1].Status)
+	}
+
+	assert.Equal(t, uint(750), getCoins(t, token))
+}
+
+// TestSendCoinBatch_AllOrNothing проверяет, что при ошибке в одном переводе не проходит ни один
+func TestSendCoinBatch_AllOrNothing(t *testing.T) {
+	authUser(t, "batchcarol", "password", testServer)
+	token := authUser(t, "batchlead2", "password", testServer)
+
+	rec := sendCoinBatch(token, []map[string]any{
+		{"toUser": "batchcarol", "amount": 100},
+		{"toUser": "batchnobody", "amount": 100},
+	})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var resp batchResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	if assert.Len(t, resp.Results, 2) {
+		assert.Equal(t, "rolled_back", resp.Results[0].Status)
+		assert.Equal(t, "failed", resp.Results[1].Status)
+	}
+
+	assert.Equal(t, uint(1000), getCoins(t, token))
+}
+
+// TestSendCoinBatch_ValidationIndex проверяет, что ошибка валидации указывает на перевод
+func TestSendCoinBatch_ValidationIndex(t *testing.T) {
+	token := authUser(t, "batchlead3", "password", testServer)
+
+	rec := sendCoinBatch(token, []map[string]any{
+		{"toUser": "batchalice", "amount": 100},
+		{"toUser": "batchbob", "amount": -5},
+	})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var resp struct {
+		Errors []struct {
+			Index *int   `json:"index"`
+			Field string `json:"field"`
+		} `json:"errors"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	if assert.Len(t, resp.Errors, 1) && assert.NotNil(t, resp.Errors[0].Index) {
+		assert.Equal(t, 1, *resp.Errors[0].Index)
+	}
+}
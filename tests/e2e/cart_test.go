@@ -0,0 +1,122 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func requestJSON(method, path, token string, body any) *httptest.ResponseRecorder {
+	var reqBody []byte
+	if body != nil {
+		reqBody, _ = json.Marshal(body)
+	}
+
+	req := httptest.NewRequest(method, path, bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rec := httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	return rec
+}
+
+func addToCart(t *testing.T, token, item string, quantity int) model.Cart {
+	rec := requestJSON(http.MethodPost, "/api/cart/items", token, map[string]any{"item": item, "quantity": quantity})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var cart model.Cart
+	_ = json.Unmarshal(rec.Body.Bytes(), &cart)
+	return cart
+}
+
+func getInventory(t *testing.T, token string) []model.Item {
+	rec := requestJSON(http.MethodGet, "/api/info", token, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp struct {
+		Inventory []model.Item `json:"inventory"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	return resp.Inventory
+}
+
+// TestCart_Checkout проверяет корзину и оформление всех строк одним заказом
+func TestCart_Checkout(t *testing.T) {
+	token := authUser(t, "cartuser", "password", testServer)
+
+	addToCart(t, token, "socks", 3)
+	cart := addToCart(t, token, "socks", 2)
+	require.Len(t, cart.Lines, 1)
+	assert.Equal(t, 5, cart.Lines[0].Quantity)
+
+	addToCart(t, token, "book", 2)
+
+	rec := requestJSON(http.MethodPut, "/api/cart/items/book", token, map[string]any{"quantity": 1})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	_ = json.Unmarshal(rec.Body.Bytes(), &cart)
+	assert.Equal(t, model.Cart{
+		Lines: []model.CartLine{
+			{Item: "socks", Quantity: 5, Price: 10, Total: 50},
+			{Item: "book", Quantity: 1, Price: 50, Total: 50},
+		},
+		Total: 100,
+	}, cart)
+
+	assert.Equal(t, http.StatusBadRequest, requestJSON(http.MethodPost, "/api/cart/items", token, map[string]any{"item": "yacht", "quantity": 1}).Code)
+	assert.Equal(t, http.StatusBadRequest, requestJSON(http.MethodPut, "/api/cart/items/pen", token, map[string]any{"quantity": 1}).Code)
+	assert.Equal(t, http.StatusBadRequest, requestJSON(http.MethodPost, "/api/cart/items", token, map[string]any{"item": "socks", "quantity": 96}).Code,
+		"5 socks are already in the cart, 101 is over the limit")
+
+	rec = requestJSON(http.MethodPost, "/api/checkout", token, nil)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var order model.Order
+	_ = json.Unmarshal(rec.Body.Bytes(), &order)
+	assert.NotZero(t, order.ID)
+	assert.Equal(t, 100, order.Total)
+	assert.Equal(t, []model.OrderLine{{Item: "socks", Quantity: 5, Price: 10}, {Item: "book", Quantity: 1, Price: 50}}, order.Lines)
+
+	assert.Equal(t, uint(900), getCoins(t, token))
+	assert.ElementsMatch(t, []model.Item{{Type: "socks", Quantity: 5}, {Type: "book", Quantity: 1}}, getInventory(t, token))
+
+	// корзина очищена
+	rec = requestJSON(http.MethodPost, "/api/checkout", token, nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+// TestCart_CheckoutInsufficientFunds проверяет, что при нехватке монет ничего не списывается
+// и корзина остается
+func TestCart_CheckoutInsufficientFunds(t *testing.T) {
+	token := authUser(t, "cartpoor", "password", testServer)
+
+	addToCart(t, token, "pen", 1)
+	addToCart(t, token, "pink-hoody", 2)
+
+	rec := requestJSON(http.MethodPost, "/api/checkout", token, nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var resp struct {
+		Errors string `json:"errors"`
+		Item   string `json:"item"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	assert.Contains(t, resp.Errors, "insufficient funds")
+	assert.Empty(t, resp.Item)
+
+	assert.Equal(t, uint(1000), getCoins(t, token))
+	assert.Empty(t, getInventory(t, token))
+
+	rec = requestJSON(http.MethodDelete, "/api/cart/items/pink-hoody", token, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = requestJSON(http.MethodPost, "/api/checkout", token, nil)
+	assert.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Equal(t, uint(990), getCoins(t, token))
+}
@@ -0,0 +1,75 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCoinExpiry_FIFO проверяет, что тратятся самые старые монеты, а сгорают только просроченные
+func TestCoinExpiry_FIFO(t *testing.T) {
+	ctx := context.Background()
+
+	donorToken := authUser(t, "expirydonor", "password", testServer)
+	token := authUser(t, "expiryholder", "password", testServer)
+
+	// у holder две партии: стартовые 1000 и 300 от donor
+	sendCoins(t, donorToken, "expiryholder", 300)
+
+	// тратим 200, они должны списаться со стартовой партии
+	sendCoins(t, token, "expirydonor", 200)
+
+	// делаем стартовую партию (теперь 800) просроченной
+	_, err := testDB.Pool().Exec(ctx, `
+		UPDATE shop.coin_lots
+		SET acquired_at = NOW() - INTERVAL '2 years'
+		WHERE remaining = 800
+			AND user_id = (SELECT id FROM shop.users WHERE username = 'expiryholder')
+	`)
+	assert.NoError(t, err)
+
+	result, err := testDB.ExpireCoins(ctx, model.ExpireCoinsParams{Lifetime: 365 * 24 * time.Hour, Limit: 100})
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, result.TotalAmount, 800)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/info", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rec := httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var info struct {
+		Coins       uint `json:"coins"`
+		CoinHistory struct {
+			Sent []struct {
+				ToUser string `json:"toUser"`
+				Amount int    `json:"amount"`
+			} `json:"sent"`
+		} `json:"coinHistory"`
+		ExpiringCoins []struct {
+			Amount int `json:"amount"`
+		} `json:"expiringCoins"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &info)
+
+	assert.Equal(t, uint(300), info.Coins)
+
+	expired := 0
+	for _, tr := range info.CoinHistory.Sent {
+		if tr.ToUser == model.SystemUser {
+			expired += tr.Amount
+		}
+	}
+	assert.Equal(t, 800, expired)
+
+	if assert.Len(t, info.ExpiringCoins, 1) {
+		assert.Equal(t, 300, info.ExpiringCoins[0].Amount)
+	}
+}
@@ -0,0 +1,112 @@
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFraud_CycleFreezeAndReview проверяет полный цикл: кольцо переводов находится,
+// участники замораживаются, админ разбирает алерт и размораживает их
+func TestFraud_CycleFreezeAndReview(t *testing.T) {
+	ctx := context.Background()
+
+	tokenA := authUser(t, "ringa", "password", testServer)
+	tokenB := authUser(t, "ringb", "password", testServer)
+	tokenC := authUser(t, "ringc", "password", testServer)
+	adminToken := authUser(t, "fraudadmin", "password", testServer)
+
+	_, err := testDB.Pool().Exec(ctx, `UPDATE shop.users SET role = 'admin' WHERE username = 'fraudadmin'`)
+	assert.NoError(t, err)
+
+	sendCoins(t, tokenA, "ringb", 100)
+	sendCoins(t, tokenB, "ringc", 100)
+	sendCoins(t, tokenC, "ringa", 100)
+
+	findings, err := testDB.FindTransferCycles(ctx, model.FraudDetectionParams{
+		Window:         time.Hour,
+		CycleMaxLength: 3,
+		CycleMinAmount: 50,
+	})
+	assert.NoError(t, err)
+
+	// в базе могут быть кольца из других тестов, берем только наше
+	var ring []uint
+	err = testDB.Pool().QueryRow(ctx, `
+		SELECT array_agg(id ORDER BY id) FROM shop.users WHERE username IN ('ringa', 'ringb', 'ringc')
+	`).Scan(&ring)
+	assert.NoError(t, err)
+
+	findings = slices.DeleteFunc(findings, func(f model.FraudFinding) bool {
+		return !slices.Equal(f.UserIDs, ring)
+	})
+	if !assert.Len(t, findings, 1) {
+		return
+	}
+
+	result, err := testDB.SaveFraudAlerts(ctx, model.SaveFraudAlertsParams{
+		Findings:   findings,
+		Cooldown:   time.Hour,
+		AutoFreeze: true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Alerts)
+	assert.Len(t, result.Frozen, 3)
+
+	// повторный проход не создает дубль, пока алерт открыт
+	result, err = testDB.SaveFraudAlerts(ctx, model.SaveFraudAlertsParams{Findings: findings, Cooldown: time.Hour})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.Alerts)
+
+	rec := sendCoinRaw(tokenA, "ringb", 10)
+	assert.Equal(t, http.StatusForbidden, rec.Code, "frozen account must not send coins")
+
+	// обычному юзеру админские ручки недоступны
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/fraudAlerts?status=open", nil)
+	req.Header.Set("Authorization", "Bearer "+tokenA)
+	rec = httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/admin/fraudAlerts?status=open", nil)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	rec = httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var list struct {
+		Alerts []struct {
+			ID   uint   `json:"id"`
+			Rule string `json:"rule"`
+		} `json:"alerts"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &list)
+	// алерт только что создан, поэтому он первый
+	if !assert.NotEmpty(t, list.Alerts) {
+		return
+	}
+	assert.Equal(t, model.FraudRuleCycle, list.Alerts[0].Rule)
+
+	reqBody, _ := json.Marshal(map[string]any{
+		"status":   "dismissed",
+		"note":     "team lunch split",
+		"unfreeze": true,
+	})
+	req = httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/admin/fraudAlerts/%d/review", list.Alerts[0].ID), bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	rec = httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	sendCoins(t, tokenA, "ringb", 10)
+}
@@ -0,0 +1,117 @@
+package e2e
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/0x0FACED/merch-shop/internal/server/handler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sendGift(path, token, toUser, item string, quantity int) *httptest.ResponseRecorder {
+	return requestJSON(http.MethodPost, path, token, map[string]any{
+		"toUser":   toUser,
+		"item":     item,
+		"quantity": quantity,
+		"message":  "for you",
+	})
+}
+
+func getGiftHistory(t *testing.T, token string) model.GiftHistory {
+	rec := requestJSON(http.MethodGet, "/api/info", token, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp handler.InfoResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	return resp.GiftHistory
+}
+
+// TestGiftItem проверяет подарок из своего инвентаря: монеты не двигаются,
+// подарок виден в истории обеих сторон
+func TestGiftItem(t *testing.T) {
+	admin := adminToken(t, "giftitemadmin")
+	sender := authUser(t, "giftsender", "password", testServer)
+	recipient := authUser(t, "giftrecipient", "password", testServer)
+
+	rec := requestJSON(http.MethodPost, "/api/buy", sender, map[string]any{"item": "cup", "quantity": 3})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var order model.Order
+	_ = json.Unmarshal(rec.Body.Bytes(), &order)
+
+	// до выдачи заказа его штуки может забрать отмена, дарить их нельзя
+	assert.Equal(t, http.StatusConflict, sendGift("/api/gifts", sender, "giftrecipient", "cup", 1).Code, "order is not delivered yet")
+	deliverOrder(t, admin, order.ID)
+
+	assert.Equal(t, http.StatusBadRequest, sendGift("/api/gifts", sender, "giftsender", "cup", 1).Code)
+	assert.Equal(t, http.StatusBadRequest, sendGift("/api/gifts", sender, "nosuchuser", "cup", 1).Code)
+	assert.Equal(t, http.StatusConflict, sendGift("/api/gifts", sender, "giftrecipient", "cup", 4).Code, "holds only 3")
+	assert.Equal(t, http.StatusConflict, sendGift("/api/gifts", sender, "giftrecipient", "pen", 1).Code)
+
+	rec = sendGift("/api/gifts", sender, "giftrecipient", "cup", 2)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var gift model.SentGift
+	_ = json.Unmarshal(rec.Body.Bytes(), &gift)
+	assert.Equal(t, "giftrecipient", gift.User)
+	assert.Nil(t, gift.OrderID)
+
+	assert.Equal(t, uint(940), getCoins(t, sender))
+	assert.Equal(t, uint(1000), getCoins(t, recipient))
+	assert.Equal(t, []model.Item{{Type: "cup", Quantity: 1}}, getInventory(t, sender))
+	assert.Equal(t, []model.Item{{Type: "cup", Quantity: 2}}, getInventory(t, recipient))
+
+	sent := getGiftHistory(t, sender).Sent
+	if assert.Len(t, sent, 1) {
+		assert.Equal(t, "giftrecipient", sent[0].User)
+		assert.Equal(t, "for you", sent[0].Message)
+	}
+	received := getGiftHistory(t, recipient).Received
+	if assert.Len(t, received, 1) {
+		assert.Equal(t, "giftsender", received[0].User)
+		assert.Equal(t, 2, received[0].Quantity)
+		assert.Equal(t, "for you", received[0].Message)
+	}
+}
+
+// TestBuyGift проверяет покупку в подарок: платит отправитель, предметы у получателя,
+// а отмена заказа забирает их у получателя и возвращает монеты отправителю
+func TestBuyGift(t *testing.T) {
+	admin := adminToken(t, "giftadmin")
+	sender := authUser(t, "giftbuyer", "password", testServer)
+	recipient := authUser(t, "giftfriend", "password", testServer)
+
+	rec := sendGift("/api/gifts/buy", sender, "giftfriend", "t-shirt", 2)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var order model.Order
+	_ = json.Unmarshal(rec.Body.Bytes(), &order)
+	assert.Equal(t, 160, order.Total)
+	assert.Equal(t, "giftfriend", order.Recipient)
+	assert.Equal(t, http.StatusConflict, sendGift("/api/gifts", recipient, "giftbuyer", "t-shirt", 1).Code,
+		"gift order is not delivered yet")
+
+	assert.Equal(t, uint(840), getCoins(t, sender))
+	assert.Equal(t, uint(1000), getCoins(t, recipient))
+	assert.Empty(t, getInventory(t, sender))
+	assert.Equal(t, []model.Item{{Type: "t-shirt", Quantity: 2}}, getInventory(t, recipient))
+
+	received := getGiftHistory(t, recipient).Received
+	if assert.Len(t, received, 1) && assert.NotNil(t, received[0].OrderID) {
+		assert.Equal(t, order.ID, *received[0].OrderID)
+		assert.Equal(t, "for you", received[0].Message)
+	}
+
+	// подарок виден в заказах отправителя вместе с получателем
+	orders := getOrders(t, sender, "")
+	if assert.Len(t, orders, 1) {
+		assert.Equal(t, "giftfriend", orders[0].Recipient)
+	}
+
+	rec = setOrderStatus(admin, order.ID, model.OrderStatusCancelled, "out of stock")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	assert.Equal(t, uint(1000), getCoins(t, sender))
+	assert.Empty(t, getInventory(t, recipient))
+}
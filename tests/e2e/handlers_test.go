@@ -117,3 +117,130 @@ func TestUserInfo_Success(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, rec.Code, "Retrieving user information failed")
 }
+
+// TestUserInfo_AggregatedHistory проверяет группировку истории по контрагентам
+func TestUserInfo_AggregatedHistory(t *testing.T) {
+	authUser(t, "aggrecipient", "password", testServer)
+	token := authUser(t, "aggsender", "password", testServer)
+
+	for range 3 {
+		reqBody, _ := json.Marshal(map[string]any{
+			"toUser": "aggrecipient",
+			"amount": 5,
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/api/sendCoin", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		rec := httptest.NewRecorder()
+		testServer.Echo().ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/info?history=aggregated", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rec := httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code, "Retrieving aggregated info failed")
+
+	var resp struct {
+		CoinHistory struct {
+			Sent []struct {
+				ToUser string `json:"toUser"`
+				Amount int    `json:"amount"`
+				Count  int    `json:"count"`
+			} `json:"sent"`
+		} `json:"coinHistory"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+
+	assert.Len(t, resp.CoinHistory.Sent, 1)
+	if len(resp.CoinHistory.Sent) == 1 {
+		assert.Equal(t, 15, resp.CoinHistory.Sent[0].Amount)
+		assert.Equal(t, 3, resp.CoinHistory.Sent[0].Count)
+	}
+}
+
+// TestUserInfo_InvalidHistoryMode проверяет отказ при неизвестном режиме истории
+func TestUserInfo_InvalidHistoryMode(t *testing.T) {
+	token := authUser(t, "infouser", "password", testServer)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/info?history=weird", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rec := httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "Unknown history mode must be rejected")
+}
+
+// TestSendCoin_MemoAndCategory проверяет, что комментарий и категория попадают в историю
+// и по категории можно фильтровать
+func TestSendCoin_MemoAndCategory(t *testing.T) {
+	authUser(t, "memorecipient", "password", testServer)
+	token := authUser(t, "memosender", "password", testServer)
+
+	for _, category := range []string{"kudos", "gift"} {
+		reqBody, _ := json.Marshal(map[string]any{
+			"toUser":   "memorecipient",
+			"amount":   10,
+			"memo":     "thanks for the code review",
+			"category": category,
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/api/sendCoin", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		rec := httptest.NewRecorder()
+		testServer.Echo().ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/info?category=kudos", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rec := httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var resp struct {
+		CoinHistory struct {
+			Sent []struct {
+				Memo     string `json:"memo"`
+				Category string `json:"category"`
+			} `json:"sent"`
+		} `json:"coinHistory"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+
+	assert.Len(t, resp.CoinHistory.Sent, 1)
+	if len(resp.CoinHistory.Sent) == 1 {
+		assert.Equal(t, "thanks for the code review", resp.CoinHistory.Sent[0].Memo)
+		assert.Equal(t, "kudos", resp.CoinHistory.Sent[0].Category)
+	}
+}
+
+// TestSendCoin_InvalidMemo проверяет отказ при управляющих символах в комментарии
+func TestSendCoin_InvalidMemo(t *testing.T) {
+	token := authUser(t, "memosender", "password", testServer)
+
+	reqBody, _ := json.Marshal(map[string]any{
+		"toUser": "testuser",
+		"amount": 10,
+		"memo":   "line1\nline2",
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/sendCoin", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rec := httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "Memo with control characters must be rejected")
+}
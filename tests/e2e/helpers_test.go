@@ -0,0 +1,33 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// sendCoins отправляет монеты и проверяет, что перевод прошел
+func sendCoins(t *testing.T, token, toUser string, amount int) {
+	rec := sendCoinRaw(token, toUser, amount)
+	assert.Equal(t, http.StatusOK, rec.Code, "The coin sending failed")
+}
+
+// sendCoinRaw отправляет монеты и возвращает ответ как есть
+func sendCoinRaw(token, toUser string, amount int) *httptest.ResponseRecorder {
+	reqBody, _ := json.Marshal(map[string]any{
+		"toUser": toUser,
+		"amount": amount,
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/sendCoin", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rec := httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	return rec
+}
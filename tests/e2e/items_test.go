@@ -0,0 +1,219 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/config"
+	"github.com/0x0FACED/merch-shop/internal/database"
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/0x0FACED/merch-shop/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func adminToken(t *testing.T, username string) string {
+	token := authUser(t, username, "password", testServer)
+
+	_, err := testDB.Pool().Exec(context.Background(), `UPDATE shop.users SET role = 'admin' WHERE username = $1`, username)
+	require.NoError(t, err)
+
+	return token
+}
+
+// setItemRules задает ограничения продажи и снимает их в конце теста
+func setItemRules(t *testing.T, adminToken, item string, rules map[string]any) {
+	rec := requestJSON(http.MethodPut, "/api/admin/items/"+item, adminToken, rules)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	t.Cleanup(func() {
+		requestJSON(http.MethodPut, "/api/admin/items/"+item, adminToken, map[string]any{})
+	})
+}
+
+func getCatalogItem(t *testing.T, token, name string) model.CatalogItem {
+	rec := requestJSON(http.MethodGet, "/api/items", token, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp struct {
+		Items []model.CatalogItem `json:"items"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+
+	for _, item := range resp.Items {
+		if item.Name == name {
+			return item
+		}
+	}
+	t.Fatalf("item %q not in catalog", name)
+	return model.CatalogItem{}
+}
+
+// TestItems_StockAndPerUserLimit проверяет склад и лимит на юзера для обеих покупок
+func TestItems_StockAndPerUserLimit(t *testing.T) {
+	admin := adminToken(t, "stockadmin")
+	token := authUser(t, "stockbuyer", "password", testServer)
+	otherToken := authUser(t, "stockother", "password", testServer)
+
+	setItemRules(t, admin, "pen", map[string]any{"stock": 3, "perUserLimit": 2})
+
+	item := getCatalogItem(t, token, "pen")
+	require.NotNil(t, item.Stock)
+	assert.Equal(t, 3, *item.Stock)
+	require.NotNil(t, item.PerUserLimit)
+	assert.Equal(t, 2, *item.PerUserLimit)
+	assert.True(t, item.Available)
+
+	rec := requestJSON(http.MethodPost, "/api/buy", token, map[string]any{"item": "pen", "quantity": 2})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	rec = requestJSON(http.MethodGet, "/api/buy/pen", token, nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, "per-user limit counts units from orders")
+
+	rec = requestJSON(http.MethodPost, "/api/buy", otherToken, map[string]any{"item": "pen", "quantity": 2})
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), "1 left")
+
+	rec = requestJSON(http.MethodGet, "/api/buy/pen", otherToken, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	item = getCatalogItem(t, token, "pen")
+	assert.Equal(t, 0, *item.Stock)
+	assert.False(t, item.Available)
+
+	// распроданный предмет в корзине: заказ не проходит, в ответе - его строка
+	addToCart(t, otherToken, "cup", 1)
+	addToCart(t, otherToken, "pen", 1)
+
+	rec = requestJSON(http.MethodPost, "/api/checkout", otherToken, nil)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	var resp struct {
+		Item string `json:"item"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	assert.Equal(t, "pen", resp.Item)
+
+	assert.Equal(t, uint(990), getCoins(t, otherToken))
+}
+
+// TestItems_ConcurrentStock проверяет, что параллельные покупки не продают больше склада
+func TestItems_ConcurrentStock(t *testing.T) {
+	admin := adminToken(t, "raceadmin")
+
+	const buyers, stock = 10, 4
+	setItemRules(t, admin, "socks", map[string]any{"stock": stock})
+
+	tokens := make([]string, buyers)
+	for i := range tokens {
+		tokens[i] = authUser(t, fmt.Sprintf("racebuyer%d", i), "password", testServer)
+	}
+
+	codes := make([]int, buyers)
+	var wg sync.WaitGroup
+	for i, token := range tokens {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes[i] = requestJSON(http.MethodGet, "/api/buy/socks", token, nil).Code
+		}()
+	}
+	wg.Wait()
+
+	var sold int
+	for _, code := range codes {
+		if code == http.StatusOK {
+			sold++
+			continue
+		}
+		assert.Equal(t, http.StatusConflict, code)
+	}
+	assert.Equal(t, stock, sold)
+	assert.Equal(t, 0, *getCatalogItem(t, admin, "socks").Stock)
+}
+
+// TestItems_SaleWindow проверяет окно продажи лимитированного дропа
+func TestItems_SaleWindow(t *testing.T) {
+	admin := adminToken(t, "dropadmin")
+	token := authUser(t, "dropbuyer", "password", testServer)
+
+	now := time.Now().UTC()
+
+	setItemRules(t, admin, "umbrella", map[string]any{"saleStartsAt": now.Add(time.Hour)})
+	rec := requestJSON(http.MethodGet, "/api/buy/umbrella", token, nil)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), "has not started")
+	assert.False(t, getCatalogItem(t, token, "umbrella").Available)
+
+	setItemRules(t, admin, "umbrella", map[string]any{"saleStartsAt": now.Add(-2 * time.Hour), "saleEndsAt": now.Add(-time.Hour)})
+	rec = requestJSON(http.MethodPost, "/api/buy", token, map[string]any{"item": "umbrella", "quantity": 1})
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), "has ended")
+
+	setItemRules(t, admin, "umbrella", map[string]any{"saleStartsAt": now.Add(-time.Hour), "saleEndsAt": now.Add(time.Hour)})
+	rec = requestJSON(http.MethodGet, "/api/buy/umbrella", token, nil)
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = requestJSON(http.MethodPut, "/api/admin/items/umbrella", admin, map[string]any{"saleStartsAt": now, "saleEndsAt": now})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = requestJSON(http.MethodPut, "/api/admin/items/umbrella", token, map[string]any{"stock": 1})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+// TestItems_SaleWindowSessionTimeZone проверяет, что окно продажи не сдвигается,
+// когда часовой пояс сессии базы не UTC
+func TestItems_SaleWindowSessionTimeZone(t *testing.T) {
+	ctx := context.Background()
+
+	// неизвестные параметры DSN pgx отправляет как параметры сессии каждого соединения пула
+	cfg := config.MustLoadTestConfig()
+	dsn, err := url.Parse(cfg.Database.DSN)
+	require.NoError(t, err)
+	query := dsn.Query()
+	query.Set("timezone", "Asia/Tokyo")
+	dsn.RawQuery = query.Encode()
+	cfg.Database.DSN = dsn.String()
+
+	db, err := database.New(cfg.Database, logger.New(cfg.Logger))
+	require.NoError(t, err)
+	db.MustConnect(ctx)
+	defer db.Close()
+
+	var tz string
+	require.NoError(t, db.Pool().QueryRow(ctx, `SHOW TIME ZONE`).Scan(&tz))
+	require.Equal(t, "Asia/Tokyo", tz)
+
+	t.Cleanup(func() {
+		_, _ = db.SetItemRules(ctx, model.SetItemRulesParams{Item: "umbrella"})
+	})
+
+	available := func() bool {
+		items, err := db.GetCatalog(ctx)
+		require.NoError(t, err)
+		for _, item := range items {
+			if item.Name == "umbrella" {
+				return item.Available
+			}
+		}
+		t.Fatal("umbrella not in catalog")
+		return false
+	}
+
+	now := time.Now().UTC()
+	start, end := now.Add(-time.Hour), now.Add(time.Hour)
+	_, err = db.SetItemRules(ctx, model.SetItemRulesParams{Item: "umbrella", SaleStartsAt: &start, SaleEndsAt: &end})
+	require.NoError(t, err)
+	assert.True(t, available(), "sale is open right now")
+
+	start, end = now.Add(time.Hour), now.Add(2*time.Hour)
+	_, err = db.SetItemRules(ctx, model.SetItemRulesParams{Item: "umbrella", SaleStartsAt: &start, SaleEndsAt: &end})
+	require.NoError(t, err)
+	assert.False(t, available(), "sale starts in an hour")
+}
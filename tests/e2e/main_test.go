@@ -2,6 +2,7 @@ package e2e
 
 import (
 	"context"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -9,7 +10,10 @@ import (
 	"time"
 
 	"github.com/0x0FACED/merch-shop/config"
+	"github.com/0x0FACED/merch-shop/internal/crypt"
 	"github.com/0x0FACED/merch-shop/internal/database"
+	"github.com/0x0FACED/merch-shop/internal/oidc"
+	"github.com/0x0FACED/merch-shop/internal/oidc/oidctest"
 	"github.com/0x0FACED/merch-shop/internal/server"
 	"github.com/0x0FACED/merch-shop/internal/server/handler"
 	"github.com/0x0FACED/merch-shop/internal/service"
@@ -21,6 +25,8 @@ import (
 var (
 	testServer *server.Server
 	testDB     *database.Postgres
+	testMailer *mailRecorder
+	testIdP    *oidctest.Server
 )
 
 func TestMain(m *testing.M) {
@@ -38,7 +44,31 @@ func TestMain(m *testing.M) {
 	testDB.MustConnect(ctx)
 	defer testDB.Close()
 
-	merchService := service.NewUserService(testDB, log)
+	testMailer = &mailRecorder{}
+
+	testIdP = oidctest.NewServer(testOIDCClientID)
+	defer testIdP.Close()
+	idp := oidc.NewClient(config.OIDCConfig{
+		Issuer:      testIdP.URL,
+		ClientID:    testOIDCClientID,
+		RedirectURL: "http://localhost/api/auth/oidc/callback",
+		Scopes:      []string{"openid", "email"},
+	}, http.DefaultClient)
+
+	totpCipher, err := crypt.New(testTOTPKey)
+	if err != nil {
+		log.Fatal("Failed to create TOTP cipher", zap.Error(err))
+	}
+	cfg.Auth.TOTPStepUpAmount = testStepUpAmount
+
+	merchService := service.NewUserService(testDB, log, service.Deps{
+		Mailer:   testMailer,
+		IdP:      idp,
+		Secrets:  totpCipher,
+		Transfer: cfg.Transfer,
+		Auth:     cfg.Auth,
+		Shop:     cfg.Shop,
+	})
 	h := handler.NewHandler(merchService, log, &cfg.Server)
 
 	testServer, err = server.NewServer(cfg, h)
@@ -70,4 +100,24 @@ func clearDB(ctx context.Context, db *pgxpool.Pool) {
 	_, _ = db.Exec(ctx, "DELETE FROM shop.wallets")
 	_, _ = db.Exec(ctx, "DELETE FROM shop.inventory")
 	_, _ = db.Exec(ctx, "DELETE FROM shop.transactions")
+	_, _ = db.Exec(ctx, "DELETE FROM shop.payment_requests")
+	_, _ = db.Exec(ctx, "DELETE FROM shop.coin_lots")
+	_, _ = db.Exec(ctx, "DELETE FROM shop.role_transfer_limits")
+	_, _ = db.Exec(ctx, "DELETE FROM shop.fraud_alerts")
+	_, _ = db.Exec(ctx, "DELETE FROM shop.user_status_audit")
+	_, _ = db.Exec(ctx, "DELETE FROM shop.purchases")
+	_, _ = db.Exec(ctx, "DELETE FROM shop.password_reset_tokens")
+	_, _ = db.Exec(ctx, "DELETE FROM shop.api_keys")
+	_, _ = db.Exec(ctx, "DELETE FROM shop.user_identities")
+	_, _ = db.Exec(ctx, "DELETE FROM shop.oidc_login_states")
+	_, _ = db.Exec(ctx, "DELETE FROM shop.totp_recovery_codes")
+	_, _ = db.Exec(ctx, "DELETE FROM shop.user_totp")
+	_, _ = db.Exec(ctx, "DELETE FROM shop.sessions")
+	_, _ = db.Exec(ctx, "DELETE FROM shop.cart_items")
+	_, _ = db.Exec(ctx, "DELETE FROM shop.gifts")
+	_, _ = db.Exec(ctx, "DELETE FROM shop.return_requests")
+	_, _ = db.Exec(ctx, "DELETE FROM shop.order_items")
+	_, _ = db.Exec(ctx, "DELETE FROM shop.orders")
+	_, _ = db.Exec(ctx, "UPDATE shop.items SET stock = NULL, per_user_limit = NULL, sale_starts_at = NULL, sale_ends_at = NULL")
+	_, _ = db.Exec(ctx, "DELETE FROM shop.item_variants WHERE NOT is_default")
 }
@@ -0,0 +1,100 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testOIDCClientID = "merch-shop-test"
+
+// oidcLogin проходит вход через SSO целиком: наш редирект на IdP, "логин" в IdP
+// и возврат на callback. Возвращает ответ callback
+func oidcLogin(t *testing.T) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api/auth/oidc/login", nil)
+	rec := httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusFound, rec.Code)
+
+	callback, err := testIdP.Login(rec.Header().Get("Location"))
+	require.NoError(t, err)
+
+	req = httptest.NewRequest(http.MethodGet, callback.RequestURI(), nil)
+	rec = httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	return rec
+}
+
+func oidcToken(t *testing.T, rec *httptest.ResponseRecorder) string {
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp struct {
+		Token string `json:"token"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	require.NotEmpty(t, resp.Token)
+	return resp.Token
+}
+
+// TestOIDCLogin проверяет вход через SSO: при первом входе создается юзер с кошельком,
+// при повторном находится тот же, а по паролю под ним войти нельзя
+func TestOIDCLogin(t *testing.T) {
+	ctx := context.Background()
+
+	testIdP.SetUser("sso-alice", "sso.alice@example.com")
+
+	token := oidcToken(t, oidcLogin(t))
+	assert.Equal(t, uint(1000), getCoins(t, token))
+
+	// повторный вход - тот же юзер, монеты не начисляются заново
+	token = oidcToken(t, oidcLogin(t))
+	assert.Equal(t, uint(1000), getCoins(t, token))
+
+	var users int
+	err := testDB.Pool().QueryRow(ctx, `SELECT COUNT(*) FROM shop.users WHERE username LIKE 'ssoalice%'`).Scan(&users)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, users)
+
+	// другой юзер IdP с тем же началом email получает имя с номером
+	testIdP.SetUser("sso-alice-2", "sso.alice@other.example.com")
+	otherToken := oidcToken(t, oidcLogin(t))
+
+	sendCoins(t, otherToken, "ssoalice", 10)
+	assert.Equal(t, uint(990), getCoins(t, otherToken))
+	assert.Equal(t, uint(1010), getCoins(t, token))
+
+	err = testDB.Pool().QueryRow(ctx, `SELECT COUNT(*) FROM shop.users WHERE username = 'ssoalice2'`).Scan(&users)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, users)
+
+	// пароля у юзера из SSO нет
+	assert.Equal(t, http.StatusUnauthorized, login("ssoalice", "password").Code)
+}
+
+// TestOIDCLogin_StateReuse проверяет, что ответ IdP нельзя использовать второй раз
+func TestOIDCLogin_StateReuse(t *testing.T) {
+	testIdP.SetUser("sso-carol", "carol@example.com")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/auth/oidc/login", nil)
+	rec := httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusFound, rec.Code)
+
+	callback, err := testIdP.Login(rec.Header().Get("Location"))
+	require.NoError(t, err)
+
+	req = httptest.NewRequest(http.MethodGet, callback.RequestURI(), nil)
+	rec = httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, callback.RequestURI(), nil)
+	rec = httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,155 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/0x0FACED/merch-shop/internal/server/handler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPurchaseItem_Quantity проверяет покупку нескольких штук одним заказом
+// и то, что старая покупка по одной штуке работает как раньше
+func TestPurchaseItem_Quantity(t *testing.T) {
+	token := authUser(t, "qtybuyer", "password", testServer)
+
+	rec := requestJSON(http.MethodPost, "/api/buy", token, map[string]any{"item": "cup", "quantity": 3})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var order model.Order
+	_ = json.Unmarshal(rec.Body.Bytes(), &order)
+	assert.NotZero(t, order.ID)
+	assert.Equal(t, 60, order.Total)
+	assert.Equal(t, []model.OrderLine{{Item: "cup", Quantity: 3, Price: 20}}, order.Lines)
+
+	rec = requestJSON(http.MethodGet, "/api/buy/cup", token, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	assert.Equal(t, uint(920), getCoins(t, token))
+	assert.Equal(t, []model.Item{{Type: "cup", Quantity: 4}}, getInventory(t, token))
+
+	assert.Equal(t, http.StatusBadRequest, requestJSON(http.MethodPost, "/api/buy", token, map[string]any{"item": "cup", "quantity": 0}).Code)
+	assert.Equal(t, http.StatusBadRequest, requestJSON(http.MethodPost, "/api/buy", token, map[string]any{"item": "yacht", "quantity": 1}).Code)
+	assert.Equal(t, http.StatusBadRequest, requestJSON(http.MethodPost, "/api/buy", token, map[string]any{"item": "pen", "quantity": 101}).Code,
+		"over the per-order limit")
+
+	// 5 * 200 = 1000 при балансе 920: не списывается ничего
+	rec = requestJSON(http.MethodPost, "/api/buy", token, map[string]any{"item": "powerbank", "quantity": 5})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "insufficient funds")
+
+	assert.Equal(t, uint(920), getCoins(t, token))
+	assert.Equal(t, []model.Item{{Type: "cup", Quantity: 4}}, getInventory(t, token))
+}
+
+func setOrderStatus(adminToken string, orderID uint, status, reason string) *httptest.ResponseRecorder {
+	path := fmt.Sprintf("/api/admin/orders/%d/status", orderID)
+	return requestJSON(http.MethodPost, path, adminToken, map[string]any{"status": status, "reason": reason})
+}
+
+func getOrders(t *testing.T, token, query string) []model.Order {
+	rec := requestJSON(http.MethodGet, "/api/orders"+query, token, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp handler.OrdersResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	return resp.Orders
+}
+
+// TestOrderFulfillment проверяет выдачу заказа: переходы только вперед, каждый пишется в журнал
+func TestOrderFulfillment(t *testing.T) {
+	admin := adminToken(t, "fulfilladmin")
+	token := authUser(t, "fulfillbuyer", "password", testServer)
+
+	rec := requestJSON(http.MethodPost, "/api/buy", token, map[string]any{"item": "cup", "quantity": 2})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var order model.Order
+	_ = json.Unmarshal(rec.Body.Bytes(), &order)
+	assert.Equal(t, model.OrderStatusPlaced, order.Status)
+
+	assert.Equal(t, http.StatusForbidden, setOrderStatus(token, order.ID, model.OrderStatusReadyForPickup, "").Code)
+	assert.Equal(t, http.StatusConflict, setOrderStatus(admin, order.ID, model.OrderStatusDelivered, "").Code,
+		"placed order must be ready for pickup first")
+	assert.Equal(t, http.StatusBadRequest, setOrderStatus(admin, order.ID+1000, model.OrderStatusReadyForPickup, "").Code)
+
+	rec = setOrderStatus(admin, order.ID, model.OrderStatusReadyForPickup, "packed")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = requestJSON(http.MethodGet, "/api/admin/orders?status=ready_for_pickup", admin, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var ready handler.OrdersResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &ready)
+	if assert.Len(t, ready.Orders, 1) {
+		assert.Equal(t, "fulfillbuyer", ready.Orders[0].Username)
+	}
+
+	rec = setOrderStatus(admin, order.ID, model.OrderStatusDelivered, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	_ = json.Unmarshal(rec.Body.Bytes(), &order)
+	assert.Equal(t, model.OrderStatusDelivered, order.Status)
+	assert.NotNil(t, order.StatusChangedAt)
+
+	assert.Equal(t, http.StatusConflict, setOrderStatus(admin, order.ID, model.OrderStatusCancelled, "too late").Code)
+
+	orders := getOrders(t, token, "")
+	if assert.Len(t, orders, 1) {
+		assert.Equal(t, model.OrderStatusDelivered, orders[0].Status)
+	}
+	assert.Empty(t, getOrders(t, token, "?status=placed"))
+
+	rec = requestJSON(http.MethodGet, fmt.Sprintf("/api/admin/orders/%d/status", order.ID), admin, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var history handler.OrderStatusHistoryResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &history)
+	if assert.Len(t, history.History, 2) {
+		assert.Equal(t, model.OrderStatusDelivered, history.History[0].NewStatus)
+		assert.Equal(t, model.OrderStatusReadyForPickup, history.History[1].NewStatus)
+		assert.Equal(t, "packed", history.History[1].Reason)
+	}
+}
+
+// TestOrderCancel проверяет, что отмена разом возвращает монеты, забирает предметы и возвращает их на склад,
+// а отмененный заказ не идет в лимит на юзера
+func TestOrderCancel(t *testing.T) {
+	admin := adminToken(t, "canceladmin")
+	token := authUser(t, "cancelbuyer", "password", testServer)
+
+	setItemRules(t, admin, "cup", map[string]any{"stock": 5, "perUserLimit": 2})
+
+	rec := requestJSON(http.MethodPost, "/api/buy", token, map[string]any{"item": "cup", "quantity": 2})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var order model.Order
+	_ = json.Unmarshal(rec.Body.Bytes(), &order)
+
+	// покупка по одной штуке тоже заказ
+	require.Equal(t, http.StatusOK, requestJSON(http.MethodGet, "/api/buy/pen", token, nil).Code)
+	assert.Len(t, getOrders(t, token, "?status=placed"), 2)
+
+	assert.Equal(t, uint(950), getCoins(t, token))
+	assert.Equal(t, 3, *getCatalogItem(t, token, "cup").Stock)
+
+	assert.Equal(t, http.StatusBadRequest, setOrderStatus(admin, order.ID, model.OrderStatusCancelled, "").Code,
+		"reason is required to cancel")
+
+	rec = setOrderStatus(admin, order.ID, model.OrderStatusCancelled, "customer changed mind")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	assert.Equal(t, uint(990), getCoins(t, token))
+	assert.Equal(t, []model.Item{{Type: "pen", Quantity: 1}}, getInventory(t, token))
+	assert.Equal(t, 5, *getCatalogItem(t, token, "cup").Stock)
+
+	orders := getOrders(t, token, "?status=cancelled")
+	if assert.Len(t, orders, 1) {
+		assert.Equal(t, order.ID, orders[0].ID)
+	}
+
+	// отмененные штуки не считаются купленными
+	rec = requestJSON(http.MethodPost, "/api/buy", token, map[string]any{"item": "cup", "quantity": 2})
+	assert.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+}
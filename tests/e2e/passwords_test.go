@@ -0,0 +1,133 @@
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// mailRecorder запоминает письма вместо outbox файла
+type mailRecorder struct {
+	mu       sync.Mutex
+	messages []model.Message
+}
+
+func (r *mailRecorder) Send(_ context.Context, msg model.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, msg)
+	return nil
+}
+
+var resetTokenRe = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// lastResetToken достает токен сброса из последнего письма юзеру
+func (r *mailRecorder) lastResetToken(to string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.messages) - 1; i >= 0; i-- {
+		if r.messages[i].To == to {
+			return resetTokenRe.FindString(r.messages[i].Body)
+		}
+	}
+	return ""
+}
+
+func postJSON(path, token string, body any) *httptest.ResponseRecorder {
+	reqBody, _ := json.Marshal(body)
+
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	rec := httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	return rec
+}
+
+func login(username, password string) *httptest.ResponseRecorder {
+	return postJSON("/api/auth", "", map[string]string{"username": username, "password": password})
+}
+
+// TestChangePassword проверяет, что смена пароля отзывает старый токен и выдает новый
+func TestChangePassword(t *testing.T) {
+	token := authUser(t, "pwduser", "password", testServer)
+
+	rec := postJSON("/api/me/password", token, map[string]string{"oldPassword": "wrong", "newPassword": "newpassword"})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = postJSON("/api/me/password", token, map[string]string{"oldPassword": "password", "newPassword": "newpassword"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var resp struct {
+		Token string `json:"token"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	assert.NotEmpty(t, resp.Token)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/info", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec = httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	assert.Equal(t, uint(1000), getCoins(t, resp.Token))
+
+	assert.Equal(t, http.StatusUnauthorized, login("pwduser", "password").Code)
+	assert.Equal(t, http.StatusOK, login("pwduser", "newpassword").Code)
+}
+
+// TestPasswordReset проверяет сброс пароля админом: токен приходит письмом, одноразовый
+// и отзывает все токены юзера
+func TestPasswordReset(t *testing.T) {
+	ctx := context.Background()
+
+	adminToken := authUser(t, "resetadmin", "password", testServer)
+	token := authUser(t, "resetuser", "password", testServer)
+
+	_, err := testDB.Pool().Exec(ctx, `UPDATE shop.users SET role = 'admin' WHERE username = 'resetadmin'`)
+	assert.NoError(t, err)
+
+	var userID uint
+	err = testDB.Pool().QueryRow(ctx, `SELECT id FROM shop.users WHERE username = 'resetuser'`).Scan(&userID)
+	assert.NoError(t, err)
+
+	path := fmt.Sprintf("/api/admin/users/%d/passwordReset", userID)
+
+	// не админ выдать токен не может
+	rec := postJSON(path, token, nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = postJSON(path, adminToken, nil)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "token")
+
+	resetToken := testMailer.lastResetToken("resetuser")
+	assert.Len(t, resetToken, 64)
+
+	rec = postJSON("/api/auth/reset", "", map[string]string{"token": resetToken, "newPassword": "resetpassword"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// токен одноразовый
+	rec = postJSON("/api/auth/reset", "", map[string]string{"token": resetToken, "newPassword": "otherpassword"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/info", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec = httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	assert.Equal(t, http.StatusOK, login("resetuser", "resetpassword").Code)
+}
@@ -0,0 +1,96 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// createPaymentRequest создает запрос монет и возвращает его ID
+func createPaymentRequest(t *testing.T, token, toUser string, amount int) uint {
+	reqBody, _ := json.Marshal(map[string]any{
+		"toUser": toUser,
+		"amount": amount,
+		"memo":   "pizza",
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/paymentRequests", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rec := httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	var resp struct {
+		ID uint `json:"id"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	return resp.ID
+}
+
+// TestPaymentRequest_Accept проверяет полный цикл: создание, список входящих и принятие
+func TestPaymentRequest_Accept(t *testing.T) {
+	requesterToken := authUser(t, "prrequester", "password", testServer)
+	payerToken := authUser(t, "prpayer", "password", testServer)
+
+	id := createPaymentRequest(t, requesterToken, "prpayer", 40)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/paymentRequests?direction=incoming", nil)
+	req.Header.Set("Authorization", "Bearer "+payerToken)
+
+	rec := httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var list struct {
+		Requests []struct {
+			ID     uint   `json:"id"`
+			Status string `json:"status"`
+		} `json:"requests"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &list)
+	assert.NotEmpty(t, list.Requests)
+
+	req = httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/paymentRequests/%d/accept", id), nil)
+	req.Header.Set("Authorization", "Bearer "+payerToken)
+
+	rec = httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code, "Accepting payment request failed")
+
+	// второй раз принять нельзя
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/paymentRequests/%d/accept", id), nil)
+	req.Header.Set("Authorization", "Bearer "+payerToken)
+	testServer.Echo().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
+
+// TestPaymentRequest_CancelByPayer проверяет, что отменить запрос может только автор
+func TestPaymentRequest_CancelByPayer(t *testing.T) {
+	requesterToken := authUser(t, "prrequester", "password", testServer)
+	payerToken := authUser(t, "prpayer", "password", testServer)
+
+	id := createPaymentRequest(t, requesterToken, "prpayer", 10)
+
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/paymentRequests/%d/cancel", id), nil)
+	req.Header.Set("Authorization", "Bearer "+payerToken)
+
+	rec := httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/paymentRequests/%d/decline", id), nil)
+	req.Header.Set("Authorization", "Bearer "+payerToken)
+
+	rec = httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,158 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/0x0FACED/merch-shop/internal/server/handler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createReturn(token, item string, quantity int) *httptest.ResponseRecorder {
+	return requestJSON(http.MethodPost, "/api/returns", token, map[string]any{
+		"item":     item,
+		"quantity": quantity,
+		"reason":   "wrong size",
+	})
+}
+
+func reviewReturn(adminToken string, id uint, action string, body map[string]any) *httptest.ResponseRecorder {
+	return requestJSON(http.MethodPost, fmt.Sprintf("/api/admin/returns/%d/%s", id, action), adminToken, body)
+}
+
+// deliverOrder проводит заказ через выдачу
+func deliverOrder(t *testing.T, adminToken string, orderID uint) {
+	for _, status := range []string{model.OrderStatusReadyForPickup, model.OrderStatusDelivered} {
+		rec := setOrderStatus(adminToken, orderID, status, "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	}
+}
+
+// TestReturn проверяет возврат части выданного заказа с частичным возвратом монет
+func TestReturn(t *testing.T) {
+	admin := adminToken(t, "returnadmin")
+	token := authUser(t, "returnbuyer", "password", testServer)
+
+	rec := requestJSON(http.MethodPost, "/api/buy", token, map[string]any{"item": "t-shirt", "quantity": 3})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var order model.Order
+	_ = json.Unmarshal(rec.Body.Bytes(), &order)
+
+	assert.Equal(t, http.StatusConflict, createReturn(token, "t-shirt", 1).Code, "order is not delivered yet")
+
+	deliverOrder(t, admin, order.ID)
+
+	assert.Equal(t, http.StatusConflict, createReturn(token, "t-shirt", 5).Code, "holds only 3")
+
+	rec = createReturn(token, "t-shirt", 2)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var ret model.ReturnRequest
+	_ = json.Unmarshal(rec.Body.Bytes(), &ret)
+	assert.Equal(t, model.ReturnPending, ret.Status)
+	assert.Equal(t, 160, ret.Amount)
+
+	assert.Equal(t, http.StatusConflict, createReturn(token, "t-shirt", 2).Code, "2 of 3 are already pending")
+
+	assert.Equal(t, http.StatusForbidden, reviewReturn(token, ret.ID, "approve", nil).Code)
+	assert.Equal(t, http.StatusBadRequest, reviewReturn(admin, ret.ID, "reject", map[string]any{"refund": 10}).Code)
+	assert.Equal(t, http.StatusBadRequest, reviewReturn(admin, ret.ID, "approve", map[string]any{"refund": 200}).Code,
+		"refund over the purchase price")
+
+	rec = reviewReturn(admin, ret.ID, "approve", map[string]any{"refund": 100, "note": "worn"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	_ = json.Unmarshal(rec.Body.Bytes(), &ret)
+	assert.Equal(t, model.ReturnApproved, ret.Status)
+	if assert.NotNil(t, ret.Refund) {
+		assert.Equal(t, 100, *ret.Refund)
+	}
+
+	assert.Equal(t, uint(860), getCoins(t, token))
+	assert.Equal(t, []model.Item{{Type: "t-shirt", Quantity: 1}}, getInventory(t, token))
+
+	assert.Equal(t, http.StatusConflict, reviewReturn(admin, ret.ID, "approve", nil).Code)
+
+	rec = requestJSON(http.MethodGet, "/api/returns", token, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var resp handler.ReturnsResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	if assert.Len(t, resp.Returns, 1) {
+		assert.Equal(t, "worn", resp.Returns[0].ReviewNote)
+	}
+}
+
+// TestReturn_Window проверяет, что после срока возврата выданный заказ вернуть нельзя,
+// а отклоненный возврат освобождает штуки
+func TestReturn_Window(t *testing.T) {
+	admin := adminToken(t, "windowadmin")
+	token := authUser(t, "windowbuyer", "password", testServer)
+
+	rec := requestJSON(http.MethodPost, "/api/buy", token, map[string]any{"item": "cup", "quantity": 1})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var order model.Order
+	_ = json.Unmarshal(rec.Body.Bytes(), &order)
+
+	deliverOrder(t, admin, order.ID)
+
+	rec = createReturn(token, "cup", 1)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var ret model.ReturnRequest
+	_ = json.Unmarshal(rec.Body.Bytes(), &ret)
+
+	rec = reviewReturn(admin, ret.ID, "reject", map[string]any{"note": "used"})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, []model.Item{{Type: "cup", Quantity: 1}}, getInventory(t, token))
+
+	_, err := testDB.Pool().Exec(context.Background(),
+		`UPDATE shop.orders SET status_changed_at = NOW() - INTERVAL '30 days' WHERE id = $1`, order.ID)
+	require.NoError(t, err)
+
+	rec = createReturn(token, "cup", 1)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), "return window")
+}
+
+// TestReturn_ItemsGone проверяет, что одобрение возврата без штук в инвентаре ничего не меняет:
+// монеты не зачисляются, а заявка остается на рассмотрении
+func TestReturn_ItemsGone(t *testing.T) {
+	admin := adminToken(t, "goneadmin")
+	token := authUser(t, "gonebuyer", "password", testServer)
+
+	rec := requestJSON(http.MethodPost, "/api/buy", token, map[string]any{"item": "cup", "quantity": 2})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var order model.Order
+	_ = json.Unmarshal(rec.Body.Bytes(), &order)
+
+	deliverOrder(t, admin, order.ID)
+
+	rec = createReturn(token, "cup", 2)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var ret model.ReturnRequest
+	_ = json.Unmarshal(rec.Body.Bytes(), &ret)
+
+	// API не дает распорядиться штуками под возвратом, так что одну убираем прямо в базе
+	_, err := testDB.Pool().Exec(context.Background(), `
+		UPDATE shop.inventory SET quantity = quantity - 1
+		WHERE user_id = (SELECT id FROM shop.users WHERE username = $1)
+	`, "gonebuyer")
+	require.NoError(t, err)
+	coins := getCoins(t, token)
+
+	rec = reviewReturn(admin, ret.ID, "approve", nil)
+	assert.Equal(t, http.StatusConflict, rec.Code, rec.Body.String())
+	assert.Equal(t, coins, getCoins(t, token))
+	assert.Equal(t, []model.Item{{Type: "cup", Quantity: 1}}, getInventory(t, token))
+
+	rec = requestJSON(http.MethodGet, "/api/returns", token, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var resp handler.ReturnsResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	if assert.Len(t, resp.Returns, 1) {
+		assert.Equal(t, model.ReturnPending, resp.Returns[0].Status)
+	}
+}
@@ -0,0 +1,29 @@
+package e2e
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestScheduledTransfer_Self проверяет, что перевод самому себе отклоняется при создании расписания
+func TestScheduledTransfer_Self(t *testing.T) {
+	token := authUser(t, "scheduleself", "password", testServer)
+	authUser(t, "schedulepeer", "password", testServer)
+
+	rec := requestJSON(http.MethodPost, "/api/scheduledTransfers", token, map[string]any{
+		"toUser":   "scheduleself",
+		"amount":   10,
+		"schedule": "0 9 * * 1",
+	})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "yourself")
+
+	rec = requestJSON(http.MethodPost, "/api/scheduledTransfers", token, map[string]any{
+		"toUser":   "schedulepeer",
+		"amount":   10,
+		"schedule": "0 9 * * 1",
+	})
+	assert.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+}
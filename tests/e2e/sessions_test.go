@@ -0,0 +1,102 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loginFrom входит с заданным user agent и возвращает токен
+func loginFrom(t *testing.T, username, password, userAgent string) string {
+	reqBody, _ := json.Marshal(map[string]string{"username": username, "password": password})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/auth", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+
+	rec := httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp struct {
+		Token string `json:"token"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	return resp.Token
+}
+
+func getSessions(t *testing.T, token string) []model.Session {
+	req := httptest.NewRequest(http.MethodGet, "/api/me/sessions", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rec := httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp struct {
+		Sessions []model.Session `json:"sessions"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	return resp.Sessions
+}
+
+func revokeSession(token string, id uint) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/me/sessions/%d", id), nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rec := httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	return rec
+}
+
+// TestSessions проверяет список сессий и отзыв сессии на другом устройстве
+func TestSessions(t *testing.T) {
+	laptop := loginFrom(t, "sessionuser", "password", "laptop")
+	phone := loginFrom(t, "sessionuser", "password", "phone")
+	other := loginFrom(t, "sessionpeer", "password", "laptop")
+
+	sessions := getSessions(t, laptop)
+	require.Len(t, sessions, 2)
+	// новые первыми
+	assert.Equal(t, "phone", sessions[0].UserAgent)
+	assert.False(t, sessions[0].Current)
+	assert.Equal(t, "laptop", sessions[1].UserAgent)
+	assert.True(t, sessions[1].Current)
+	phoneSession := sessions[0].ID
+
+	// чужую сессию отозвать нельзя
+	assert.Equal(t, http.StatusNotFound, revokeSession(other, phoneSession).Code)
+
+	assert.Equal(t, http.StatusOK, revokeSession(laptop, phoneSession).Code)
+	assert.Equal(t, http.StatusNotFound, revokeSession(laptop, phoneSession).Code)
+
+	// токен отозванной сессии больше не принимается, остальные работают
+	req := httptest.NewRequest(http.MethodGet, "/api/info", nil)
+	req.Header.Set("Authorization", "Bearer "+phone)
+	rec := httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	assert.Equal(t, uint(1000), getCoins(t, laptop))
+	assert.Len(t, getSessions(t, laptop), 1)
+
+	// смена пароля отзывает все сессии, в списке остается только новая
+	rec = postJSON("/api/me/password", laptop, map[string]string{"oldPassword": "password", "newPassword": "newpassword"})
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp struct {
+		Token string `json:"token"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+
+	sessions = getSessions(t, resp.Token)
+	require.Len(t, sessions, 1)
+	assert.True(t, sessions[0].Current)
+}
@@ -0,0 +1,107 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/0x0FACED/merch-shop/internal/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testTOTPKey      = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+	testStepUpAmount = 500
+)
+
+// totpCode - код на шаг now+offset. Принятый шаг второй раз не пройдет,
+// поэтому следующие коды в тесте берутся с offset 1 (допускается сдвиг на шаг)
+func totpCode(t *testing.T, secret string, offset int64) string {
+	code, err := totp.Code(secret, totp.Step(time.Now())+offset)
+	require.NoError(t, err)
+	return code
+}
+
+// TestTOTP проверяет подключение 2FA, второй фактор при входе и для крупного перевода
+func TestTOTP(t *testing.T) {
+	token := authUser(t, "totpuser", "password", testServer)
+	_ = authUser(t, "totppeer", "password", testServer)
+
+	rec := postJSON("/api/me/2fa", token, nil)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var enrollment struct {
+		Secret string `json:"secret"`
+		URI    string `json:"uri"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &enrollment)
+	assert.Contains(t, enrollment.URI, "otpauth://totp/")
+
+	// до подтверждения 2FA не включена
+	assert.Equal(t, http.StatusOK, login("totpuser", "password").Code)
+
+	rec = postJSON("/api/me/2fa/confirm", token, map[string]string{"code": "000000"})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = postJSON("/api/me/2fa/confirm", token, map[string]string{"code": totpCode(t, enrollment.Secret, 0)})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var recovery struct {
+		RecoveryCodes []string `json:"recoveryCodes"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &recovery)
+	require.Len(t, recovery.RecoveryCodes, 10)
+
+	rec = postJSON("/api/me/2fa", token, nil)
+	assert.Equal(t, http.StatusConflict, rec.Code, "2FA is already enabled")
+
+	// вход без кода и с уже использованным кодом не проходит
+	assert.Equal(t, http.StatusUnauthorized, login("totpuser", "password").Code)
+	rec = postJSON("/api/auth", "", map[string]string{
+		"username": "totpuser",
+		"password": "password",
+		"otp":      totpCode(t, enrollment.Secret, 0),
+	})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = postJSON("/api/auth", "", map[string]string{
+		"username": "totpuser",
+		"password": "password",
+		"otp":      totpCode(t, enrollment.Secret, 1),
+	})
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// мелкий перевод без кода, крупный только с кодом
+	sendCoins(t, token, "totppeer", 100)
+	assert.Equal(t, http.StatusUnauthorized, sendCoinRaw(token, "totppeer", testStepUpAmount+1).Code)
+
+	rec = postJSON("/api/sendCoin", token, map[string]any{
+		"toUser": "totppeer",
+		"amount": testStepUpAmount + 1,
+		"otp":    recovery.RecoveryCodes[0],
+	})
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// код восстановления одноразовый
+	rec = postJSON("/api/sendCoin", token, map[string]any{
+		"toUser": "totppeer",
+		"amount": testStepUpAmount + 1,
+		"otp":    recovery.RecoveryCodes[0],
+	})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, uint(1000-100-testStepUpAmount-1), getCoins(t, token))
+
+	reqBody, _ := json.Marshal(map[string]string{"code": recovery.RecoveryCodes[1]})
+	req := httptest.NewRequest(http.MethodDelete, "/api/me/2fa", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec = httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	assert.Equal(t, http.StatusOK, login("totpuser", "password").Code)
+}
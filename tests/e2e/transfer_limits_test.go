@@ -0,0 +1,43 @@
+package e2e
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSendCoin_RoleLimits проверяет лимиты, переопределенные для роли
+func TestSendCoin_RoleLimits(t *testing.T) {
+	ctx := context.Background()
+
+	authUser(t, "limitreceiver", "password", testServer)
+	authUser(t, "limitreceiver2", "password", testServer)
+	token := authUser(t, "limitsender", "password", testServer)
+
+	_, err := testDB.Pool().Exec(ctx, `
+		INSERT INTO shop.role_transfer_limits (role, max_single_transfer, daily_total, daily_recipients)
+		VALUES ('intern', 100, 150, 1)
+		ON CONFLICT (role) DO NOTHING
+	`)
+	assert.NoError(t, err)
+
+	_, err = testDB.Pool().Exec(ctx, `UPDATE shop.users SET role = 'intern' WHERE username = 'limitsender'`)
+	assert.NoError(t, err)
+
+	rec := sendCoinRaw(token, "limitreceiver", 101)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, "max single transfer")
+
+	rec = sendCoinRaw(token, "limitreceiver", 100)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = sendCoinRaw(token, "limitreceiver2", 10)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, "daily recipients")
+
+	rec = sendCoinRaw(token, "limitreceiver", 60)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, "daily total")
+
+	rec = sendCoinRaw(token, "limitreceiver", 50)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
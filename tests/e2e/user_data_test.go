@@ -0,0 +1,120 @@
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func deleteMe(token, password string) *httptest.ResponseRecorder {
+	reqBody, _ := json.Marshal(map[string]string{"password": password})
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/me", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	rec := httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	return rec
+}
+
+// TestExportUserData проверяет, что в выгрузку попадают переводы, покупки и запросы монет
+func TestExportUserData(t *testing.T) {
+	token := authUser(t, "exportuser", "password", testServer)
+	_ = authUser(t, "exportpeer", "password", testServer)
+
+	sendCoins(t, token, "exportpeer", 50)
+	createPaymentRequest(t, token, "exportpeer", 20)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/buy/pen", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/me/export", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec = httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Disposition"), "attachment")
+
+	var export model.UserExport
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &export))
+
+	assert.Equal(t, "exportuser", export.Profile.Username)
+	assert.Equal(t, uint(940), export.Wallet.Balance)
+	assert.Equal(t, []model.Item{{Type: "pen", Quantity: 1}}, export.Inventory)
+	if assert.Len(t, export.Transfers, 1) {
+		assert.Equal(t, model.TransferDirectionOut, export.Transfers[0].Direction)
+		assert.Equal(t, "exportpeer", export.Transfers[0].Counterparty)
+		assert.Equal(t, 50, export.Transfers[0].Amount)
+	}
+	// покупка по одной штуке тоже оформляется заказом
+	assert.Empty(t, export.Purchases)
+	if assert.Len(t, export.Orders, 1) {
+		assert.Equal(t, model.OrderStatusPlaced, export.Orders[0].Status)
+		assert.Equal(t, []model.OrderLine{{Item: "pen", Quantity: 1, Price: 10}}, export.Orders[0].Lines)
+	}
+	assert.Len(t, export.PaymentRequests, 1)
+}
+
+// TestDeleteUser проверяет анонимизацию: переводы у контрагента остаются под именем @deleted,
+// незакрытые запросы отменяются, токен больше не принимается, а имя освобождается
+func TestDeleteUser(t *testing.T) {
+	token := authUser(t, "deleteuser", "password", testServer)
+	peerToken := authUser(t, "deletepeer", "password", testServer)
+
+	sendCoins(t, token, "deletepeer", 30)
+	createPaymentRequest(t, token, "deletepeer", 20)
+
+	rec := deleteMe(token, "wrongpassword")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = deleteMe(token, "password")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/info", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec = httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/info", nil)
+	req.Header.Set("Authorization", "Bearer "+peerToken)
+	rec = httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var info struct {
+		Coins       uint              `json:"coins"`
+		CoinHistory model.CoinHistory `json:"coinHistory"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &info)
+	assert.Equal(t, uint(1030), info.Coins)
+	assert.Equal(t, []model.ReceivedTransaction{{User: model.DeletedUser, Amount: 30}}, info.CoinHistory.Received)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/paymentRequests?direction=incoming", nil)
+	req.Header.Set("Authorization", "Bearer "+peerToken)
+	rec = httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var requests struct {
+		Requests []model.PaymentRequest `json:"requests"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &requests)
+	if assert.Len(t, requests.Requests, 1) {
+		assert.Equal(t, model.DeletedUser, requests.Requests[0].FromUser)
+		assert.Equal(t, model.PaymentRequestCancelled, requests.Requests[0].Status)
+	}
+
+	// имя свободно, под ним регистрируется новый юзер со стартовым балансом
+	newToken := authUser(t, "deleteuser", "newpassword", testServer)
+	assert.Equal(t, uint(1000), getCoins(t, newToken))
+}
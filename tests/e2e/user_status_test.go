@@ -0,0 +1,109 @@
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setUserStatus(adminToken string, userID uint, status, reason string) *httptest.ResponseRecorder {
+	reqBody, _ := json.Marshal(map[string]any{
+		"status": status,
+		"reason": reason,
+	})
+
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/admin/users/%d/status", userID), bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+
+	rec := httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	return rec
+}
+
+// TestUserStatus_FreezeAndSuspend проверяет, как статусы влияют на переводы, покупки и вход
+func TestUserStatus_FreezeAndSuspend(t *testing.T) {
+	ctx := context.Background()
+
+	adminToken := authUser(t, "statusadmin", "password", testServer)
+	token := authUser(t, "statususer", "password", testServer)
+	otherToken := authUser(t, "statusother", "password", testServer)
+
+	_, err := testDB.Pool().Exec(ctx, `UPDATE shop.users SET role = 'admin' WHERE username = 'statusadmin'`)
+	assert.NoError(t, err)
+
+	var userID uint
+	err = testDB.Pool().QueryRow(ctx, `SELECT id FROM shop.users WHERE username = 'statususer'`).Scan(&userID)
+	assert.NoError(t, err)
+
+	rec := setUserStatus(adminToken, userID, "frozen", "pending review")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// замороженный не может ни тратить, ни получать, но API ему доступно
+	rec = sendCoinRaw(token, "statusother", 10)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = sendCoinRaw(otherToken, "statususer", 10)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/buy/pen", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec = httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	assert.Equal(t, uint(1000), getCoins(t, token))
+
+	// заблокированного не пускает уже выданный токен и повторный вход
+	rec = setUserStatus(adminToken, userID, "suspended", "abuse")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/info", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec = httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	reqBody, _ := json.Marshal(map[string]string{"username": "statususer", "password": "password"})
+	req = httptest.NewRequest(http.MethodPost, "/api/auth", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = setUserStatus(adminToken, userID, "active", "appeal accepted")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	sendCoins(t, token, "statusother", 10)
+
+	req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/admin/users/%d/status", userID), nil)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	rec = httptest.NewRecorder()
+	testServer.Echo().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var resp struct {
+		History []struct {
+			NewStatus string `json:"newStatus"`
+			Reason    string `json:"reason"`
+		} `json:"history"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	if assert.Len(t, resp.History, 3) {
+		assert.Equal(t, "active", resp.History[0].NewStatus)
+		assert.Equal(t, "appeal accepted", resp.History[0].Reason)
+	}
+}
+
+// TestUserStatus_NotAdmin проверяет, что обычный юзер не может менять статусы
+func TestUserStatus_NotAdmin(t *testing.T) {
+	token := authUser(t, "statusnotadmin", "password", testServer)
+
+	rec := setUserStatus(token, 1, "suspended", "nope")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
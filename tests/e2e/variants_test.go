@@ -0,0 +1,151 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/0x0FACED/merch-shop/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setItemVariant создает или заменяет вариант и удаляет его в конце теста,
+// чтобы покупки предмета без варианта в других тестах проходили
+func setItemVariant(t *testing.T, adminToken, item, sku string, body map[string]any) model.ItemVariant {
+	rec := requestJSON(http.MethodPut, "/api/admin/items/"+item+"/variants/"+sku, adminToken, body)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	t.Cleanup(func() {
+		_, _ = testDB.Pool().Exec(context.Background(), `DELETE FROM shop.item_variants WHERE sku = $1 AND NOT is_default`, sku)
+	})
+
+	var variant model.ItemVariant
+	_ = json.Unmarshal(rec.Body.Bytes(), &variant)
+	return variant
+}
+
+// TestVariants_Purchase проверяет покупку вариантов и инвентарь по вариантам
+func TestVariants_Purchase(t *testing.T) {
+	admin := adminToken(t, "variantadmin")
+	token := authUser(t, "variantbuyer", "password", testServer)
+
+	small := setItemVariant(t, admin, "hoody", "hoody-s-black", map[string]any{"size": "S", "color": "black", "stock": 1})
+	assert.Equal(t, 300, small.Price)
+	assert.Equal(t, 1, *small.Stock)
+	xl := setItemVariant(t, admin, "hoody", "hoody-xl", map[string]any{"size": "XL", "priceDelta": 20})
+	assert.Equal(t, 320, xl.Price)
+	assert.Nil(t, xl.Stock)
+
+	hoody := getCatalogItem(t, token, "hoody")
+	require.Len(t, hoody.Variants, 2)
+	assert.Equal(t, "hoody-s-black", hoody.Variants[0].SKU)
+	assert.True(t, hoody.Available)
+
+	// у предмета есть варианты: без варианта не купить
+	rec := requestJSON(http.MethodGet, "/api/buy/hoody", token, nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "specify one")
+
+	rec = requestJSON(http.MethodGet, "/api/buy/hoody?variant=hoody-s-black", token, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = requestJSON(http.MethodGet, "/api/buy/hoody?variant=hoody-s-black", token, nil)
+	assert.Equal(t, http.StatusConflict, rec.Code, "variant stock is separate")
+
+	rec = requestJSON(http.MethodGet, "/api/buy/hoody?variant=cup", token, nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "other item's sku")
+
+	rec = requestJSON(http.MethodPost, "/api/buy", token, map[string]any{"item": "hoody", "variant": "hoody-xl", "quantity": 2})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var order model.Order
+	_ = json.Unmarshal(rec.Body.Bytes(), &order)
+	assert.Equal(t, 640, order.Total)
+	assert.Equal(t, []model.OrderLine{{Item: "hoody", Variant: "hoody-xl", Quantity: 2, Price: 320}}, order.Lines)
+
+	rec = requestJSON(http.MethodGet, "/api/buy/cup", token, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	assert.Equal(t, uint(40), getCoins(t, token))
+	assert.ElementsMatch(t, []model.Item{
+		{Type: "hoody", Variant: "hoody-s-black", Size: "S", Color: "black", Quantity: 1},
+		{Type: "hoody", Variant: "hoody-xl", Size: "XL", Quantity: 2},
+		{Type: "cup", Quantity: 1},
+	}, getInventory(t, token))
+}
+
+// TestVariants_Cart проверяет варианты в корзине: каждый вариант - своя строка
+func TestVariants_Cart(t *testing.T) {
+	admin := adminToken(t, "cartvariantadmin")
+	token := authUser(t, "cartvariantbuyer", "password", testServer)
+
+	setItemVariant(t, admin, "t-shirt", "t-shirt-m", map[string]any{"size": "M"})
+	setItemVariant(t, admin, "t-shirt", "t-shirt-l", map[string]any{"size": "L", "priceDelta": 10, "stock": 1})
+
+	rec := requestJSON(http.MethodPost, "/api/cart/items", token, map[string]any{"item": "t-shirt", "quantity": 1})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = requestJSON(http.MethodPost, "/api/cart/items", token, map[string]any{"item": "t-shirt", "variant": "t-shirt-m", "quantity": 1})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	rec = requestJSON(http.MethodPost, "/api/cart/items", token, map[string]any{"item": "t-shirt", "variant": "t-shirt-l", "quantity": 1})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = requestJSON(http.MethodPut, "/api/cart/items/t-shirt", token, map[string]any{"variant": "t-shirt-l", "quantity": 2})
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var cart model.Cart
+	_ = json.Unmarshal(rec.Body.Bytes(), &cart)
+	assert.Equal(t, model.Cart{
+		Lines: []model.CartLine{
+			{Item: "t-shirt", Variant: "t-shirt-m", Quantity: 1, Price: 80, Total: 80},
+			{Item: "t-shirt", Variant: "t-shirt-l", Quantity: 2, Price: 90, Total: 180},
+		},
+		Total: 260,
+	}, cart)
+
+	// у L на складе одна штука
+	rec = requestJSON(http.MethodPost, "/api/checkout", token, nil)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	var resp struct {
+		Item    string `json:"item"`
+		Variant string `json:"variant"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	assert.Equal(t, "t-shirt", resp.Item)
+	assert.Equal(t, "t-shirt-l", resp.Variant)
+
+	rec = requestJSON(http.MethodDelete, "/api/cart/items/t-shirt?variant=t-shirt-l", token, nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = requestJSON(http.MethodPost, "/api/checkout", token, nil)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Equal(t, []model.Item{{Type: "t-shirt", Variant: "t-shirt-m", Size: "M", Quantity: 1}}, getInventory(t, token))
+}
+
+// TestVariants_Admin проверяет ограничения на SKU и цену варианта
+func TestVariants_Admin(t *testing.T) {
+	admin := adminToken(t, "skuadmin")
+
+	setItemVariant(t, admin, "umbrella", "umbrella-red", map[string]any{"color": "red"})
+	updated := setItemVariant(t, admin, "umbrella", "umbrella-red", map[string]any{"color": "dark red", "stock": 5})
+	assert.Equal(t, "dark red", updated.Color)
+	assert.Equal(t, 5, *updated.Stock)
+
+	// SKU уникален только в пределах предмета, в том числе SKU по умолчанию - имя другого предмета
+	other := setItemVariant(t, admin, "wallet", "umbrella-red", map[string]any{"color": "red"})
+	assert.Nil(t, other.Stock)
+	setItemVariant(t, admin, "wallet", "cup", map[string]any{"color": "brown"})
+	assert.Equal(t, "dark red", getCatalogItem(t, admin, "umbrella").Variants[0].Color)
+
+	rec := requestJSON(http.MethodPut, "/api/admin/items/wallet/variants/wallet", admin, map[string]any{})
+	assert.Equal(t, http.StatusConflict, rec.Code, "default variant sku")
+
+	rec = requestJSON(http.MethodPut, "/api/admin/items/pen/variants/pen-free", admin, map[string]any{"priceDelta": -11})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = requestJSON(http.MethodPut, "/api/admin/items/yacht/variants/yacht-xl", admin, map[string]any{})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}